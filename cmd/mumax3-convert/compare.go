@@ -0,0 +1,96 @@
+package main
+
+// Compare mode: instead of converting, compare each input file to a reference
+// file and fail if they differ by more than a tolerance. E.g.:
+// 	mumax3-convert -compare ref/m000100.ovf -tol 1e-4 m000100.ovf
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/dump"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/oommf"
+)
+
+var (
+	flag_compare = flag.String("compare", "", "Compare input files to this reference file instead of converting. Exits with status 1 if a difference exceeds -tol")
+	flag_tol     = flag.Float64("tol", 0, "Tolerance on the maximum absolute difference for -compare")
+	flag_mask    = flag.String("mask", "", "For -compare: only compare cells where this scalar file (e.g. saved regions) is nonzero")
+	flag_region  = flag.Int("region", -1, "For -compare with -mask: only compare cells where the mask equals this value")
+)
+
+// compares all files to the -compare reference and exits.
+func compareFiles(fnames []string) {
+	ref := readFile(*flag_compare)
+	preprocess(ref)
+
+	var mask *data.Slice
+	if *flag_mask != "" {
+		mask = readFile(*flag_mask)
+		crop(mask)
+		if *flag_resize != "" { // nearest neighbor, keeps region numbers
+			resize(mask, *flag_resize)
+		}
+		if *flag_region >= 0 {
+			m := mask.Host()[0]
+			for i := range m {
+				if m[i] == float32(*flag_region) {
+					m[i] = 1
+				} else {
+					m[i] = 0
+				}
+			}
+		}
+	}
+
+	status := 0
+	for _, fname := range fnames {
+		f := readFile(fname)
+		preprocess(f)
+		d, err := data.Compare(f, ref, mask)
+		if err != nil {
+			fmt.Println("[fail]", fname, err)
+			status = 1
+			continue
+		}
+		if d.Within(*flag_tol) {
+			fmt.Println("[ ok ]", fname, d)
+		} else {
+			fmt.Println("[fail]", fname, d)
+			status = 1
+		}
+	}
+	os.Exit(status)
+}
+
+// reads an ovf or dump file (written by mumax2 with -mumax2), exits on error.
+func readFile(fname string) *data.Slice {
+	in, err := httpfs.Open(fname)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer in.Close()
+
+	var slice *data.Slice
+	switch path.Ext(fname) {
+	default:
+		log.Fatal(fname, ": unsupported type: ", path.Ext(fname))
+	case ".ovf", ".omf", ".ovf2":
+		slice, _, err = oommf.Read(in)
+	case ".dump":
+		if *flag_mumax2 {
+			slice, _, err = dump.ReadMumax2(in)
+		} else {
+			slice, _, err = dump.Read(in)
+		}
+	}
+	if err != nil {
+		log.Fatal(fname, ": ", err)
+	}
+	return slice
+}
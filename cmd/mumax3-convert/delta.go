@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mumax/3/delta"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+// Converts each frame of a delta-encoded time series to a file named after outfname,
+// numbered like mumax3 output: m.delta -> m000000.ovf, m000001.ovf, ...
+// Returns the number of frames.
+func doDelta(in io.Reader, outfname string, outp output) (int, error) {
+	r, err := delta.NewReader(in)
+	if err != nil {
+		return 0, err
+	}
+	for n := 0; ; n++ {
+		slice, info, err := r.Next()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		out, err := httpfs.Create(fmt.Sprintf("%s%06d%s", util.NoExt(outfname), n, outp.Ext))
+		if err != nil {
+			return n, err
+		}
+		shiftOrigin(&info, preprocess(slice))
+		outp.Convert(slice, info, panicWriter{out})
+		out.Close()
+	}
+}
@@ -22,14 +22,26 @@ func dumpGnuplot(f *data.Slice, m data.Meta, out io.Writer) {
 	if cellsize == [3]float64{0, 0, 0} {
 		cellsize = [3]float64{1, 1, 1}
 	}
+	origin := m.Origin
 	ncomp := f.NComp()
 
+	unit := m.MeshUnit
+	if unit == "" {
+		unit = "m"
+	}
+	fmt.Fprint(buf, "# t = ", m.Time, " s\n")
+	fmt.Fprint(buf, "# x (", unit, ")", DELIM, "y (", unit, ")", DELIM, "z (", unit, ")")
+	for _, l := range m.Labels(ncomp) {
+		fmt.Fprint(buf, DELIM, l, " (", m.Unit, ")")
+	}
+	fmt.Fprint(buf, "\n")
+
 	for iz := range data[0] {
-		z := float64(iz) * cellsize[Z]
+		z := origin[Z] + float64(iz)*cellsize[Z]
 		for iy := range data[0][iz] {
-			y := float64(iy) * cellsize[Y]
+			y := origin[Y] + float64(iy)*cellsize[Y]
 			for ix := range data[0][iz][iy] {
-				x := float64(ix) * cellsize[X]
+				x := origin[X] + float64(ix)*cellsize[X]
 				fmt.Fprint(buf, x, DELIM, y, DELIM, z, DELIM)
 				for c := 0; c < ncomp-1; c++ {
 					fmt.Fprint(buf, data[c][iz][iy][ix], DELIM)
@@ -21,10 +21,16 @@ Example: convert all .ovf files to VTK binary saving only the X component. Also
 	mumax3-convert -comp 0 -vtk binary -jpg *.ovf
 Example: convert legacy .dump files to .ovf:
 	mumax3-convert -ovf2 *.dump
+Example: convert .dump files written by mumax2, which have their vector components in ZYX order:
+	mumax3-convert -mumax2 -ovf2 *.dump
+Example: expand a delta-encoded time series (OutputFormat = DELTA) to one .ovf file per frame, m000000.ovf, ...:
+	mumax3-convert -ovf2 binary m.delta
 Example: cut out a piece of the data between min:max. max is exclusive bound. bounds can be omitted, default to 0 lower bound or maximum upper bound
 	mumax3-convert -xrange 50:100 -yrange :100 file.ovf
 Example: select the bottom layer
 	mumax3-convert -zrange :1 file.ovf
+Example: compare output to a reference file, fail if any value differs by more than 1e-4 (only cells of region 1 in regions.ovf)
+	mumax3-convert -compare ref/m000100.ovf -tol 1e-4 -mask regions.ovf -region 1 m000100.ovf
 
 Output file names are automatically assigned.
 */
@@ -79,6 +85,7 @@ var (
 	flag_dir       = flag.String("o", "", "Save all output in this directory")
 	flag_arrows    = flag.Int("arrows", 0, "Arrow size for vector bitmap image output")
 	flag_color     = flag.String("color", "black,gray,white", "Colormap for scalar image output.")
+	flag_mumax2    = flag.Bool("mumax2", false, "Input dump files were written by mumax2 (ZYX vector components)")
 )
 
 var (
@@ -106,6 +113,11 @@ func main() {
 		_ = os.Mkdir(*flag_dir, 0777)
 	}
 
+	if *flag_compare != "" {
+		compareFiles(expandGlobs(flag.Args()))
+		return
+	}
+
 	// determine which outputs we want
 	var wantOut []output
 	for flag, out := range outputs {
@@ -127,14 +139,7 @@ func main() {
 		log.Fatal("no output format specified (e.g.: -png)")
 	}
 
-	// expand wildcards which are not expanded by the shell
-	// (pointing a finger at cmd.exe)
-	var fnames []string
-	for _, input := range flag.Args() {
-		fmt.Println(input)
-		expanded, _ := filepath.Glob(input)
-		fnames = append(fnames, expanded...)
-	}
+	fnames := expandGlobs(flag.Args())
 	// read all input files and put them in the task que
 	for _, fname := range fnames {
 		for _, outp := range wantOut {
@@ -159,6 +164,18 @@ var (
 	failed, skipped, succeeded util.Atom
 )
 
+// expand wildcards which are not expanded by the shell
+// (pointing a finger at cmd.exe)
+func expandGlobs(args []string) []string {
+	var fnames []string
+	for _, input := range args {
+		fmt.Println(input)
+		expanded, _ := filepath.Glob(input)
+		fnames = append(fnames, expanded...)
+	}
+	return fnames
+}
+
 func doFile(infname string, outp output) {
 	// determine output file
 	outfname := util.NoExt(infname) + outp.Ext
@@ -209,10 +226,23 @@ func doFile(infname string, outp output) {
 	default:
 		msg = fail(msg, ": skipping unsupported type: "+path.Ext(infname))
 		return
+	case ".delta":
+		n, err := doDelta(in, outfname, outp)
+		if err != nil {
+			msg = fail(msg, err)
+			return
+		}
+		succeeded.Add(1)
+		msg = fmt.Sprint("[ ok ] ", msg, ": ", n, " frames")
+		return
 	case ".ovf", ".omf", ".ovf2":
 		slice, info, err = oommf.Read(in)
 	case ".dump":
-		slice, info, err = dump.Read(in)
+		if *flag_mumax2 {
+			slice, info, err = dump.ReadMumax2(in)
+		} else {
+			slice, info, err = dump.Read(in)
+		}
 	}
 
 	if err != nil {
@@ -227,7 +257,7 @@ func doFile(infname string, outp output) {
 	}
 	defer out.Close()
 
-	preprocess(slice)
+	shiftOrigin(&info, preprocess(slice))
 	outp.Convert(slice, info, panicWriter{out})
 	succeeded.Add(1)
 	msg = "[ ok ] " + msg
@@ -318,7 +348,8 @@ func show(f *data.Slice, info data.Meta, out io.Writer) {
 	util.Fprintf(os.Stdout, *flag_format, f.Tensors())
 }
 
-func preprocess(f *data.Slice) {
+// Returns the index of the first cell kept by cropping.
+func preprocess(f *data.Slice) (first [3]int) {
 	if *flag_normalize {
 		normalize(f, 1)
 	}
@@ -333,10 +364,18 @@ func preprocess(f *data.Slice) {
 			*f = *f.Comp(c)
 		}
 	}
-	crop(f)
+	first = crop(f)
 	if *flag_resize != "" {
 		resize(f, *flag_resize)
 	}
+	return first
+}
+
+// moves the origin in info to the first cell kept by cropping
+func shiftOrigin(info *data.Meta, first [3]int) {
+	for c := range first {
+		info.Origin[c] += float64(first[c]) * info.CellSize[c]
+	}
 }
 
 func parseComp(c string) int {
@@ -356,7 +395,8 @@ func parseComp(c string) int {
 	}
 }
 
-func crop(f *data.Slice) {
+// Returns the index of the first cell kept.
+func crop(f *data.Slice) [3]int {
 	N := f.Size()
 	// default ranges
 	x1, x2 := 0, N[X]
@@ -380,6 +420,7 @@ func crop(f *data.Slice) {
 	if havework {
 		*f = *data.Crop(f, x1, x2, y1, y2, z1, z2)
 	}
+	return [3]int{x1, y1, z1}
 }
 
 func parseRange(r string, max int) (int, int) {
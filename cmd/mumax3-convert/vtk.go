@@ -17,18 +17,19 @@ import (
 )
 
 func dumpVTK(out io.Writer, q *data.Slice, meta data.Meta, dataformat string) (err error) {
-	err = writeVTKHeader(out, q)
+	err = writeVTKHeader(out, q, meta)
 	err = writeVTKCellData(out, q, meta, dataformat)
 	err = writeVTKPoints(out, q, dataformat, meta)
 	err = writeVTKFooter(out)
 	return
 }
 
-func writeVTKHeader(out io.Writer, q *data.Slice) (err error) {
+func writeVTKHeader(out io.Writer, q *data.Slice, meta data.Meta) (err error) {
 	gridsize := q.Size()
 	_, err = fmt.Fprintln(out, "<?xml version=\"1.0\"?>")
 	_, err = fmt.Fprintln(out, "<VTKFile type=\"StructuredGrid\" version=\"0.1\" byte_order=\"LittleEndian\">")
 	_, err = fmt.Fprintf(out, "\t<StructuredGrid WholeExtent=\"0 %d 0 %d 0 %d\">\n", gridsize[0]-1, gridsize[1]-1, gridsize[2]-1)
+	err = writeVTKFieldData(out, meta)
 	_, err = fmt.Fprintf(out, "\t\t<Piece Extent=\"0 %d 0 %d 0 %d\">\n", gridsize[0]-1, gridsize[1]-1, gridsize[2]-1)
 	return
 }
@@ -38,14 +39,15 @@ func writeVTKPoints(out io.Writer, q *data.Slice, dataformat string, info data.M
 	fmt.Fprintf(out, "\t\t\t\t<DataArray type=\"Float32\" NumberOfComponents=\"3\" format=\"%s\">\n\t\t\t\t\t", dataformat)
 	gridsize := q.Size()
 	cellsize := info.CellSize
+	origin := info.Origin
 	switch dataformat {
 	case "ascii":
 		for k := 0; k < gridsize[2]; k++ {
 			for j := 0; j < gridsize[1]; j++ {
 				for i := 0; i < gridsize[0]; i++ {
-					x := (float32)(i) * (float32)(cellsize[0])
-					y := (float32)(j) * (float32)(cellsize[1])
-					z := (float32)(k) * (float32)(cellsize[2])
+					x := (float32)(origin[0] + float64(i)*cellsize[0])
+					y := (float32)(origin[1] + float64(j)*cellsize[1])
+					z := (float32)(origin[2] + float64(k)*cellsize[2])
 					_, err = fmt.Fprint(out, x, " ", y, " ", z, " ")
 				}
 			}
@@ -55,9 +57,9 @@ func writeVTKPoints(out io.Writer, q *data.Slice, dataformat string, info data.M
 		for k := 0; k < gridsize[2]; k++ {
 			for j := 0; j < gridsize[1]; j++ {
 				for i := 0; i < gridsize[0]; i++ {
-					x := (float32)(i) * (float32)(cellsize[0])
-					y := (float32)(j) * (float32)(cellsize[1])
-					z := (float32)(k) * (float32)(cellsize[2])
+					x := (float32)(origin[0] + float64(i)*cellsize[0])
+					y := (float32)(origin[1] + float64(j)*cellsize[1])
+					z := (float32)(origin[2] + float64(k)*cellsize[2])
 					binary.Write(buffer, binary.LittleEndian, x)
 					binary.Write(buffer, binary.LittleEndian, y)
 					binary.Write(buffer, binary.LittleEndian, z)
@@ -87,8 +89,9 @@ func writeVTKCellData(out io.Writer, q *data.Slice, meta data.Meta, dataformat s
 		fmt.Fprintf(out, "\t\t\t<PointData Scalars=\"%s\">\n", meta.Name)
 		fmt.Fprintf(out, "\t\t\t\t<DataArray type=\"Float32\" Name=\"%s\" NumberOfComponents=\"%d\" format=\"%s\">\n\t\t\t\t\t", meta.Name, N, dataformat)
 	case 3:
+		l := meta.Labels(N)
 		fmt.Fprintf(out, "\t\t\t<PointData Vectors=\"%s\">\n", meta.Name)
-		fmt.Fprintf(out, "\t\t\t\t<DataArray type=\"Float32\" Name=\"%s\" NumberOfComponents=\"%d\" format=\"%s\">\n\t\t\t\t\t", meta.Name, N, dataformat)
+		fmt.Fprintf(out, "\t\t\t\t<DataArray type=\"Float32\" Name=\"%s\" NumberOfComponents=\"%d\" ComponentName0=\"%s\" ComponentName1=\"%s\" ComponentName2=\"%s\" format=\"%s\">\n\t\t\t\t\t", meta.Name, N, l[0], l[1], l[2], dataformat)
 	case 6, 9:
 		fmt.Fprintf(out, "\t\t\t<PointData Tensors=\"%s\">\n", meta.Name)
 		fmt.Fprintf(out, "\t\t\t\t<DataArray type=\"Float32\" Name=\"%s\" NumberOfComponents=\"%d\" format=\"%s\">\n\t\t\t\t\t", meta.Name, 9, dataformat) // must be 9!
@@ -161,6 +164,22 @@ func writeVTKCellData(out io.Writer, q *data.Slice, meta data.Meta, dataformat s
 	return
 }
 
+// Time, unit and cell size as field data of the grid.
+// ParaView picks up TimeValue as the time of the data set.
+func writeVTKFieldData(out io.Writer, meta data.Meta) (err error) {
+	_, err = fmt.Fprintln(out, "\t\t<FieldData>")
+	_, err = fmt.Fprintf(out, "\t\t\t<DataArray type=\"Float64\" Name=\"TimeValue\" NumberOfTuples=\"1\" format=\"ascii\">%v</DataArray>\n", meta.Time)
+	_, err = fmt.Fprintf(out, "\t\t\t<DataArray type=\"Float64\" Name=\"CellSize\" NumberOfComponents=\"3\" NumberOfTuples=\"1\" format=\"ascii\">%v %v %v</DataArray>\n", meta.CellSize[0], meta.CellSize[1], meta.CellSize[2])
+	// ascii string arrays hold the character codes, each string terminated by 0
+	unit := ""
+	for _, c := range []byte(meta.Unit) {
+		unit += fmt.Sprint(c, " ")
+	}
+	_, err = fmt.Fprintf(out, "\t\t\t<DataArray type=\"String\" Name=\"Unit\" NumberOfTuples=\"1\" format=\"ascii\">%v0</DataArray>\n", unit)
+	_, err = fmt.Fprintln(out, "\t\t</FieldData>")
+	return
+}
+
 func writeVTKFooter(out io.Writer) (err error) {
 	_, err = fmt.Fprintln(out, "\t\t</Piece>")
 	_, err = fmt.Fprintln(out, "\t</StructuredGrid>")
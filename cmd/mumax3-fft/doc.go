@@ -53,8 +53,11 @@ Windowing
 
 
 
-The following windowing functions are provided: boxcar (no windowing), hamming, hann, welch:
+The following windowing functions are provided: boxcar (no windowing), hamming, hann, tukey, welch:
  	mumax3-fft -window hann table.txt
+The tukey window is flat in the middle and tapered with a cosine over a fraction of its length given by -tukey (default 0.5):
+ 	mumax3-fft -window tukey -tukey 0.2 table.txt
+Windows are also applied to the time series of each cell for the FFT of .ovf files.
 
 
 
@@ -22,6 +22,7 @@ var (
 	flag_Re         = flag.Bool("re", false, "output real part")
 	flag_Stdout     = flag.Bool("stdout", false, "output to stdout instead of file")
 	flag_Win        = flag.String("window", "boxcar", "apply windowing function")
+	flag_Tukey      = flag.Float64("tukey", 0.5, "fraction of the tukey window that is tapered")
 )
 
 func main() {
@@ -44,6 +44,11 @@ func mainSpatial() {
 	dataList := make([]complex64, Nt*Nx*Ny*Nz)
 	dataLists := matrix.ReshapeC2(dataList, [2]int{Nt, Nz * Ny * Nx})
 
+	window := windows[*flag_Win]
+	if window == nil {
+		log.Fatal("invalid window: ", *flag_Win, " options: ", windows)
+	}
+
 	// interpolate non-equidistant time points
 	// make complex in the meanwhile
 	time0 := t0                  // start time, not neccesarily 0
@@ -59,6 +64,10 @@ func mainSpatial() {
 			panic(fmt.Sprint("x=", x))
 		}
 		interp3D(dataLists[di], 1-x, file(si).Host()[comp], x, file(si + 1).Host()[comp])
+		w := complex(window(float32(di), float32(Nt)), 0)
+		for i := range dataLists[di] {
+			dataLists[di][i] *= w
+		}
 	}
 
 	log.Println("FFT")
@@ -7,6 +7,7 @@ var windows = map[string]windowFunc{
 	"boxcar":  boxcar,
 	"hamming": hamming,
 	"hann":    hann,
+	"tukey":   tukey,
 	"welch":   welch,
 }
 
@@ -31,13 +32,30 @@ func welch(n, N float32) float32 {
 }
 
 func hann(n, N float32) float32 {
-	return 0.5 * (1 + cos((2*math.Pi*n)/(N-1)))
+	return 0.5 * (1 - cos((2*math.Pi*n)/(N-1)))
 }
 
 func hamming(n, N float32) float32 {
 	const a = 0.54
 	const b = 1 - a
-	return a + b*cos((2*math.Pi*n)/(N-1))
+	return a - b*cos((2*math.Pi*n)/(N-1))
+}
+
+// flat in the middle, cosine tapered over a fraction -tukey of the window
+func tukey(n, N float32) float32 {
+	a := float32(*flag_Tukey)
+	if a <= 0 {
+		return 1
+	}
+	w := a * (N - 1) / 2 // taper width
+	switch {
+	case n < w:
+		return 0.5 * (1 - cos(math.Pi*n/w))
+	case n > (N-1)-w:
+		return 0.5 * (1 - cos(math.Pi*((N-1)-n)/w))
+	default:
+		return 1
+	}
 }
 
 func sqr(x float32) float32 { return x * x }
@@ -0,0 +1,100 @@
+package main
+
+// Benchmark with fixed workloads, for comparing GPUs and driver versions.
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/engine"
+)
+
+// benchmark workload: mesh size and whether demag is enabled
+type workload struct {
+	size  [3]int
+	demag bool
+}
+
+var workloads = []workload{
+	{[3]int{128, 128, 1}, true},
+	{[3]int{256, 256, 1}, true},
+	{[3]int{512, 512, 1}, true},
+	{[3]int{128, 128, 16}, true}, // same number of cells as 512x512x1: shows the 2D speedup
+	{[3]int{1024, 1024, 1}, true},
+	{[3]int{1024, 1024, 1}, false},
+	{[3]int{64, 64, 64}, true},
+	{[3]int{128, 128, 32}, true},
+	{[3]int{128, 128, 32}, false},
+}
+
+// field terms timed separately
+var benchTerms = []struct {
+	name string
+	f    func(*data.Slice)
+}{
+	{"demag", engine.SetDemagField},
+	{"exchange", engine.AddExchangeField},
+	{"anisotropy", engine.AddAnisotropyField},
+	{"torque", engine.SetTorque},
+}
+
+// run all workloads and print throughput in cell-steps/s (solver)
+// and cell-evaluations/s per field term.
+func bench() {
+	engine.InitIO("mumax3-bench.mx3", "mumax3-bench.out", true)
+	fmt.Println("#", cuda.GPUInfo)
+	fmt.Printf("#%-16s %6s %14s", "size", "demag", "steps(cells/s)")
+	for _, t := range benchTerms {
+		fmt.Printf(" %14s", t.name)
+	}
+	fmt.Println()
+
+	for _, w := range workloads {
+		s := w.size
+		engine.Eval(fmt.Sprintf(`SetGridSize(%v, %v, %v)
+			SetCellSize(4e-9, 4e-9, 4e-9)
+			Msat  = 800e3
+			Aex   = 13e-12
+			Ku1   = 1e3
+			anisU = vector(1, 0, 0)
+			alpha = 0.02
+			B_ext = vector(0, 0.01, 0)
+			EnableDemag = %v
+			SetSolver(5)
+			FixDt = 1e-14
+			m = uniform(1, 0.1, 0)`, s[0], s[1], s[2], w.demag))
+		N := float64(s[0] * s[1] * s[2])
+
+		engine.Steps(10) // warm-up
+		cuda.Sync()
+		nsteps := 100
+		start := time.Now()
+		engine.Steps(nsteps)
+		cuda.Sync()
+		stepRate := N * float64(nsteps) / time.Since(start).Seconds()
+
+		fmt.Printf("%-17s %6v %14.4g", fmt.Sprint(s), w.demag, stepRate)
+		buf := cuda.NewSlice(3, s)
+		for _, t := range benchTerms {
+			fmt.Printf(" %14.4g", N*timeTerm(t.f, buf))
+		}
+		buf.Free()
+		fmt.Println()
+	}
+	engine.Eval("FixDt = 0")
+}
+
+// number of evaluations of f per second.
+func timeTerm(f func(*data.Slice), buf *data.Slice) float64 {
+	const n = 50
+	f(buf) // warm-up
+	cuda.Sync()
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		f(buf)
+	}
+	cuda.Sync()
+	return n / time.Since(start).Seconds()
+}
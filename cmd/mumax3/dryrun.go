@@ -0,0 +1,343 @@
+package main
+
+// Dry run: check input files and estimate resources, without using the GPU.
+// The script is only compiled, not executed: the setup is found by static inspection
+// of the top-level calls and assignments with constant arguments.
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mumax/3/engine"
+	"github.com/mumax/3/mag"
+	"github.com/mumax/3/util"
+)
+
+// rough throughput used for run time estimates: cell field evaluations per second
+// on a mid-range GPU, including demag.
+const nominalCellEvals = 1e9
+
+// check input files and print a resource estimate, don't run.
+func dryrun() {
+	status := 0
+	for _, f := range flag.Args() {
+		src, ioerr := ioutil.ReadFile(f)
+		util.FatalErr(ioerr)
+
+		fmt.Println(f, ":")
+		engine.World.EnterScope() // avoid name collisions between separate files
+		_, err := engine.World.Compile(string(src))
+		engine.World.ExitScope()
+		if err != nil {
+			fmt.Println("\terror:", err)
+			status = 1
+			continue
+		}
+
+		p := newPlan()
+		p.analyze(string(src))
+		if !p.report() {
+			status = 1
+		}
+	}
+	os.Exit(status)
+}
+
+// setup extracted from a script by static inspection
+type plan struct {
+	vars      map[string]float64
+	size      [3]int
+	cell      [3]float64
+	pbc       [3]int
+	msat, aex float64
+	simTime   float64   // total time passed to Run()
+	steps     int       // total steps passed to Steps()
+	relax     int       // number of Relax/Minimize calls
+	autosave  []float64 // autosave periods
+	table     float64   // table autosave period
+	loops     bool      // contains loops: estimates are lower bounds
+	unknown   []string  // setup calls with arguments we could not evaluate
+	errors    []string
+	warnings  []string
+}
+
+func newPlan() *plan {
+	return &plan{vars: map[string]float64{"pi": math.Pi, "inf": math.Inf(1), "mu0": mag.Mu0}}
+}
+
+func (p *plan) analyze(src string) {
+	tree, err := parser.ParseExpr("func(){\n" + src + "\n}")
+	util.FatalErr(err) // already compiled successfully
+	p.block(tree.(*ast.FuncLit).Body.List)
+}
+
+func (p *plan) block(list []ast.Stmt) {
+	for _, s := range list {
+		p.stmt(s)
+	}
+}
+
+func (p *plan) stmt(s ast.Stmt) {
+	switch s := s.(type) {
+	case *ast.AssignStmt:
+		if len(s.Lhs) == 1 && len(s.Rhs) == 1 {
+			if id, ok := s.Lhs[0].(*ast.Ident); ok {
+				if v, ok := p.eval(s.Rhs[0]); ok {
+					p.assign(strings.ToLower(id.Name), v)
+				}
+			}
+		}
+	case *ast.ExprStmt:
+		if call, ok := s.X.(*ast.CallExpr); ok {
+			p.call(call)
+		}
+	case *ast.ForStmt:
+		p.loops = true
+		p.block(s.Body.List)
+	case *ast.IfStmt:
+		p.block(s.Body.List)
+	case *ast.BlockStmt:
+		p.block(s.List)
+	}
+}
+
+func (p *plan) assign(name string, v float64) {
+	switch name {
+	case "msat":
+		p.msat = v
+	case "aex":
+		p.aex = v
+	}
+	p.vars[name] = v
+}
+
+func (p *plan) call(call *ast.CallExpr) {
+	id, ok := call.Fun.(*ast.Ident)
+	if !ok {
+		return
+	}
+	name := strings.ToLower(id.Name)
+	args, ok := p.evalArgs(call.Args)
+	switch name {
+	case "setgridsize", "setcellsize", "setpbc", "setmesh", "run", "steps", "tableautosave":
+		if !ok {
+			p.unknown = append(p.unknown, id.Name)
+			return
+		}
+	}
+	switch name {
+	case "setgridsize":
+		p.size = [3]int{int(args[0]), int(args[1]), int(args[2])}
+	case "setcellsize":
+		p.cell = [3]float64{args[0], args[1], args[2]}
+	case "setpbc":
+		p.pbc = [3]int{int(args[0]), int(args[1]), int(args[2])}
+	case "setmesh":
+		p.size = [3]int{int(args[0]), int(args[1]), int(args[2])}
+		p.cell = [3]float64{args[3], args[4], args[5]}
+		p.pbc = [3]int{int(args[6]), int(args[7]), int(args[8])}
+	case "run":
+		p.simTime += args[0]
+	case "steps":
+		p.steps += int(args[0])
+	case "relax", "minimize":
+		p.relax++
+	case "tableautosave":
+		p.table = args[0]
+	case "autosave":
+		if len(call.Args) == 2 {
+			if T, ok := p.eval(call.Args[1]); ok {
+				p.autosave = append(p.autosave, T)
+			}
+		}
+	}
+}
+
+func (p *plan) evalArgs(args []ast.Expr) ([]float64, bool) {
+	v := make([]float64, len(args))
+	for i, a := range args {
+		var ok bool
+		if v[i], ok = p.eval(a); !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// evaluate simple numerical expressions: literals, known variables, arithmetic.
+func (p *plan) eval(e ast.Expr) (float64, bool) {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.INT || e.Kind == token.FLOAT {
+			v, err := strconv.ParseFloat(e.Value, 64)
+			return v, err == nil
+		}
+	case *ast.Ident:
+		v, ok := p.vars[strings.ToLower(e.Name)]
+		return v, ok
+	case *ast.ParenExpr:
+		return p.eval(e.X)
+	case *ast.UnaryExpr:
+		if v, ok := p.eval(e.X); ok && e.Op == token.SUB {
+			return -v, true
+		}
+	case *ast.BinaryExpr:
+		a, okA := p.eval(e.X)
+		b, okB := p.eval(e.Y)
+		if !okA || !okB {
+			return 0, false
+		}
+		switch e.Op {
+		case token.ADD:
+			return a + b, true
+		case token.SUB:
+			return a - b, true
+		case token.MUL:
+			return a * b, true
+		case token.QUO:
+			return a / b, true
+		}
+	}
+	return 0, false
+}
+
+// print the report, return false if the setup is invalid.
+func (p *plan) report() bool {
+	p.validate()
+
+	N := p.size[0] * p.size[1] * p.size[2]
+	if N > 0 {
+		fmt.Printf("\tmesh: %v cells of %v m, PBC %v (%v cells)\n", p.size, p.cell, p.pbc, N)
+		fmt.Printf("\tGPU memory: ~%.3g MB\n", float64(p.memory())/(1024*1024))
+	}
+	fmt.Printf("\tsimulated time: %v s in Run(), %v Steps(), %v Relax/Minimize\n", p.simTime, p.steps, p.relax)
+	if dt := p.timeStep(); dt > 0 && N > 0 {
+		steps := p.simTime/dt + float64(p.steps)
+		wall := steps * 6 * float64(N) / nominalCellEvals // RK45: 6 evaluations per step
+		fmt.Printf("\trun time: ~%.3g steps, roughly %v (order of magnitude, excluding relax)\n",
+			steps, secondsString(wall))
+	}
+	for _, T := range p.autosave {
+		fmt.Printf("\toutput: autosave every %v s: ~%v files\n", T, int(p.simTime/T)+1)
+	}
+	if p.table > 0 {
+		fmt.Printf("\toutput: table every %v s: ~%v rows\n", p.table, int(p.simTime/p.table)+1)
+	}
+	if p.loops {
+		p.warnings = append(p.warnings, "script contains loops: estimates count each loop body once")
+	}
+	for _, u := range p.unknown {
+		p.warnings = append(p.warnings, "could not evaluate arguments of "+u+", estimates may be off")
+	}
+	for _, w := range p.warnings {
+		fmt.Println("\twarning:", w)
+	}
+	for _, e := range p.errors {
+		fmt.Println("\terror:", e)
+	}
+	if len(p.errors) == 0 {
+		fmt.Println("\tOK")
+	}
+	return len(p.errors) == 0
+}
+
+func (p *plan) validate() {
+	if p.size == [3]int{} {
+		p.errors = append(p.errors, "mesh size not set (SetGridSize or SetMesh)")
+		return
+	}
+	for c := 0; c < 3; c++ {
+		if p.size[c] <= 0 {
+			p.errors = append(p.errors, fmt.Sprint("grid size should be > 0: ", p.size))
+		}
+		if p.cell[c] <= 0 {
+			p.errors = append(p.errors, fmt.Sprint("cell size should be > 0: ", p.cell))
+			return
+		}
+		if !smooth(p.size[c]) {
+			p.warnings = append(p.warnings, fmt.Sprint("grid size ", p.size[c], " has prime factors > 7: slow FFTs"))
+		}
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if p.cell[i]/p.cell[j] > 100 {
+				p.errors = append(p.errors, fmt.Sprint("unrealistic cell aspect ratio: ", p.cell))
+				return
+			}
+		}
+	}
+	if p.msat == 0 {
+		p.warnings = append(p.warnings, "Msat not set (or not a constant)")
+	}
+	if p.aex == 0 {
+		p.warnings = append(p.warnings, "Aex not set (or not a constant)")
+	}
+	if p.msat != 0 && p.aex != 0 {
+		lex := math.Sqrt(2 * p.aex / (mag.Mu0 * p.msat * p.msat))
+		for c := 0; c < 3; c++ {
+			if p.size[c] > 1 && p.cell[c] > lex {
+				p.warnings = append(p.warnings, fmt.Sprintf("cell size %v m exceeds exchange length %.3g m", p.cell[c], lex))
+			}
+		}
+	}
+}
+
+// GPU memory estimate in bytes: solver buffers + demag convolution.
+func (p *plan) memory() int {
+	N := p.size[0] * p.size[1] * p.size[2]
+	K := mag.KernelSize(p.size, p.pbc)
+	NK := K[0] * K[1] * K[2]
+	floats := 30 * N // m, RK45 stages, effective field, temporary buffers
+	if p.size[2] == 1 {
+		floats += 6 * NK // 2D: 2 real + 2 complex buffers, 4 kernel components
+	} else {
+		floats += 9 * NK
+	}
+	return 4*floats + N // + regions
+}
+
+// rough time step, limited by exchange precession in the smallest cell.
+func (p *plan) timeStep() float64 {
+	if p.msat == 0 || p.aex == 0 {
+		return 0
+	}
+	sum := 0.
+	for c := 0; c < 3; c++ {
+		if p.size[c] > 1 {
+			sum += 4 / (p.cell[c] * p.cell[c])
+		}
+	}
+	Bex := 2 * p.aex / p.msat * sum
+	return 1 / (engine.GammaLL * Bex)
+}
+
+// has only prime factors 2, 3, 5, 7.
+func smooth(n int) bool {
+	for _, f := range []int{2, 3, 5, 7} {
+		for n%f == 0 && n > 1 {
+			n /= f
+		}
+	}
+	return n == 1
+}
+
+func secondsString(s float64) string {
+	switch {
+	case s < 60:
+		return fmt.Sprintf("%.2g s", s)
+	case s < 3600:
+		return fmt.Sprintf("%.2g min", s/60)
+	case s < 86400:
+		return fmt.Sprintf("%.2g h", s/3600)
+	default:
+		return fmt.Sprintf("%.2g days", s/86400)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+// Continue on another GPU after a GPU failure (-failover flag).
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/engine"
+	"github.com/mumax/3/httpfs"
+)
+
+// Deferred by runScript: on a lost device with -failover set,
+// re-runs mumax3 on the next GPU, continuing from the last checkpoint if any,
+// and exits with its status. Other errors are passed on.
+func failover() {
+	err := recover()
+	if err == nil {
+		return
+	}
+	if !cuda.DeviceLost(err) || *engine.Flag_failover == "" {
+		panic(err)
+	}
+
+	// flush what can still be flushed, pending GPU downloads will fail
+	func() {
+		defer func() { recover() }()
+		engine.Close()
+	}()
+
+	gpus := strings.Split(*engine.Flag_failover, ",")
+	args := []string{
+		"-gpu=" + strings.TrimSpace(gpus[0]),
+		"-failover=" + strings.Join(gpus[1:], ","),
+		"-o=" + engine.OD(),
+		"-resume",
+	}
+	if _, errC := httpfs.Read(engine.CheckpointFile()); errC == nil {
+		args = append(args, "-recover="+engine.CheckpointFile())
+	}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "gpu", "failover", "o", "resume", "recover", "f":
+		default:
+			args = append(args, fmt.Sprintf("-%v=%v", f.Name, f.Value))
+		}
+	})
+	args = append(args, flag.Args()...)
+
+	log.Println("GPU", *engine.Flag_gpu, "failed:", err, ", continuing on GPU", gpus[0])
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if errR := cmd.Run(); errR != nil {
+		log.Println(errR)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
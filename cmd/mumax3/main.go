@@ -16,10 +16,12 @@ import (
 )
 
 var (
+	flag_bench    = flag.Bool("bench", false, "Run benchmark workloads and print throughput")
 	flag_failfast = flag.Bool("failfast", false, "If one simulation fails, stop entire batch immediately")
 	flag_test     = flag.Bool("test", false, "Cuda test (internal)")
 	flag_version  = flag.Bool("v", true, "Print version")
 	flag_vet      = flag.Bool("vet", false, "Check input files for errors, but don't run them")
+	flag_dryrun   = flag.Bool("dryrun", false, "Compile input files and estimate memory, run time and output from their literal setup calls, without using the GPU (the script is not executed: values computed at run time are not seen)")
 	// more flags in engine/gofiles.go
 )
 
@@ -28,6 +30,12 @@ func main() {
 	log.SetPrefix("")
 	log.SetFlags(0)
 
+	// before cuda.Init, so it works on machines without GPU
+	if *flag_dryrun {
+		dryrun()
+		return
+	}
+
 	cuda.Init(*engine.Flag_gpu)
 
 	cuda.Synchronous = *engine.Flag_sync
@@ -42,6 +50,11 @@ func main() {
 		os.Exit(0)
 	}
 
+	// .go files are run in a separate process, which tunes in InitAndClose
+	if *engine.Flag_autotune && !*flag_vet && !(flag.NArg() == 1 && path.Ext(flag.Arg(0)) == ".go") {
+		cuda.Autotune(*engine.Flag_cachedir)
+	}
+
 	defer engine.Close() // flushes pending output, if any
 
 	if *flag_vet {
@@ -49,6 +62,11 @@ func main() {
 		return
 	}
 
+	if *flag_bench {
+		bench()
+		return
+	}
+
 	switch flag.NArg() {
 	case 0:
 		runInteractive()
@@ -78,6 +96,9 @@ func runInteractive() {
 		alpha = 1
 		m = RandomMag()`)
 	addr := goServeGUI()
+	if *engine.Flag_console != "" {
+		engine.GoServeConsole(*engine.Flag_console)
+	}
 	openbrowser("http://127.0.0.1" + addr)
 	engine.RunInteractive()
 }
@@ -91,6 +112,8 @@ func runFileAndServe(fname string) {
 }
 
 func runScript(fname string) {
+	defer failover()
+
 	outDir := util.NoExt(fname) + ".out"
 	if *engine.Flag_od != "" {
 		outDir = *engine.Flag_od
@@ -109,6 +132,9 @@ func runScript(fname string) {
 
 	// now the parser is not used anymore so it can handle web requests
 	goServeGUI()
+	if *engine.Flag_console != "" {
+		engine.GoServeConsole(*engine.Flag_console)
+	}
 
 	if *engine.Flag_interactive {
 		openbrowser("http://127.0.0.1" + *engine.Flag_port)
@@ -117,7 +143,7 @@ func runScript(fname string) {
 	// start executing the tree, possibly injecting commands from web gui
 	engine.EvalFile(code)
 
-	if *engine.Flag_interactive {
+	if *engine.Flag_interactive || *engine.Flag_console != "" {
 		engine.RunInteractive()
 	}
 }
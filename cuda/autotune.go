@@ -0,0 +1,162 @@
+package cuda
+
+// Autotuning of kernel launch configurations.
+// The 1D block size and 3D tile size are benchmarked on the actual device
+// with the madd2 and exchange kernels, and the fastest ones are used for
+// the madd and exchange kernels only: other kernels keep the default configuration,
+// which they were written for.
+// The result is cached per GPU model, so this is done only once.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/util"
+)
+
+var (
+	tuneBlockSizes = []int{128, 256, 512, 1024}
+	tuneTiles      = [][2]int{{32, 4}, {32, 8}, {16, 16}, {32, 16}, {32, 32}}
+	tuneSize       = [3]int{512, 512, 1} // benchmark problem size
+	tuneRepeat     = 20                  // timed launches per candidate
+	tuned          bool                  // Autotune was called
+)
+
+// Benchmarks the launch configurations and selects the fastest ones,
+// or loads them from the cache in cacheDir (if not empty).
+// Must be called after Init. Only the first call has an effect.
+func Autotune(cacheDir string) {
+	if tuned {
+		return
+	}
+	tuned = true
+	fname := ""
+	if cacheDir != "" {
+		fname = fmt.Sprint(cacheDir, "/", "mumax3autotune_", tuneName(DevName), ".txt")
+		if loadTuning(fname) {
+			util.Log("//Using cached launch configuration:", fname)
+			logTuning()
+			return
+		}
+	}
+
+	util.Log("//Autotuning kernel launch configuration")
+	best := tuneBest(len(tuneBlockSizes), func(i int) { blockSize = tuneBlockSizes[i] }, benchMadd2)
+	blockSize = BlockSize
+	if best >= 0 {
+		blockSize = tuneBlockSizes[best]
+	}
+	best = tuneBest(len(tuneTiles), func(i int) { tileX, tileY = tuneTiles[i][X], tuneTiles[i][Y] }, benchExchange)
+	tileX, tileY = TileX, TileY
+	if best >= 0 {
+		tileX, tileY = tuneTiles[best][X], tuneTiles[best][Y]
+	}
+	logTuning()
+
+	if fname != "" {
+		if err := ioutil.WriteFile(fname, []byte(fmt.Sprintln(blockSize, tileX, tileY)), 0666); err != nil {
+			util.Log("//Failed to cache launch configuration:", err)
+		}
+	}
+}
+
+func logTuning() {
+	util.Log("//Launch configuration: block size", blockSize, ", tile size", tileX, "x", tileY)
+}
+
+// GPU model name usable in a file name
+func tuneName(dev string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, dev)
+}
+
+// loads a cached launch configuration, reports success.
+func loadTuning(fname string) bool {
+	f, err := os.Open(fname)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	var b, tx, ty int
+	if _, err := fmt.Fscan(f, &b, &tx, &ty); err != nil || b <= 0 || tx <= 0 || ty <= 0 {
+		util.Log("//Did not use cached launch configuration:", fname)
+		return false
+	}
+	blockSize, tileX, tileY = b, tx, ty
+	return true
+}
+
+// Returns the index of the candidate for which bench runs fastest.
+// set(i) selects candidate i. Candidates that fail to launch
+// (e.g. too many threads per block for the device) are skipped.
+// Returns -1 if all fail, the caller then keeps the default.
+func tuneBest(n int, set func(i int), bench func() time.Duration) int {
+	best, bestTime := -1, time.Duration(0)
+	for i := 0; i < n; i++ {
+		set(i)
+		t, ok := tryBench(bench)
+		if ok && (best < 0 || t < bestTime) {
+			best, bestTime = i, t
+		}
+	}
+	if best < 0 {
+		util.Log("//Autotuning failed, using defaults")
+	}
+	return best
+}
+
+func tryBench(bench func() time.Duration) (t time.Duration, ok bool) {
+	defer func() {
+		if err := recover(); err != nil {
+			if DeviceLost(err) {
+				panic(err)
+			}
+			ok = false
+		}
+	}()
+	return bench(), true
+}
+
+// times f after one warm-up call.
+func timeLaunches(f func()) time.Duration {
+	f()
+	Sync()
+	start := time.Now()
+	for i := 0; i < tuneRepeat; i++ {
+		f()
+	}
+	Sync()
+	return time.Since(start)
+}
+
+func benchMadd2() time.Duration {
+	a := NewSlice(3, tuneSize)
+	defer a.Free()
+	b := NewSlice(3, tuneSize)
+	defer b.Free()
+	return timeLaunches(func() { Madd2(a, a, b, 1, 0.5) })
+}
+
+func benchExchange() time.Duration {
+	mesh := data.NewMesh(tuneSize[X], tuneSize[Y], tuneSize[Z], 1e-9, 1e-9, 1e-9)
+	B := NewSlice(3, tuneSize)
+	defer B.Free()
+	m := NewSlice(3, tuneSize)
+	defer m.Free()
+	Memset(m, 1, 0, 0)
+	lut := NewSlice(1, [3]int{256 * 257 / 2, 1, 1}) // zero exchange for all region pairs
+	defer lut.Free()
+	Zero(lut)
+	regions := NewBytes(prod(tuneSize))
+	defer regions.Free()
+	Msat := MakeMSlice(data.NilSlice(1, tuneSize), []float64{1})
+	return timeLaunches(func() { AddExchange(B, m, SymmLUT(lut.DevPtr(0)), Msat, regions, mesh) })
+}
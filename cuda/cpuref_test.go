@@ -0,0 +1,189 @@
+package cuda
+
+// CPU reference implementations of field and torque kernels,
+// straightforward translations of the .cu sources in float64.
+// Arrays are indexed [component][cell], cells in x-fastest order.
+
+import "math"
+
+type vec [3]float64
+
+func (a vec) add(b vec) vec     { return vec{a[0] + b[0], a[1] + b[1], a[2] + b[2]} }
+func (a vec) sub(b vec) vec     { return vec{a[0] - b[0], a[1] - b[1], a[2] - b[2]} }
+func (a vec) mul(s float64) vec { return vec{s * a[0], s * a[1], s * a[2]} }
+func (a vec) dot(b vec) float64 { return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] }
+func (a vec) is0() bool         { return a.dot(a) == 0 }
+func (a vec) cross(b vec) vec {
+	return vec{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+// host version of the stencil.h index helpers
+type refGrid struct {
+	N   [3]int
+	pbc [3]bool
+}
+
+func (g refGrid) idx(ix, iy, iz int) int { return (iz*g.N[1]+iy)*g.N[0] + ix }
+
+// neighbor of cell i along dir at offset d (±1): clamped or wrapped index,
+// and whether it lies inside the grid (or wraps around with PBC).
+func (g refGrid) neighbor(i [3]int, dir, d int) (int, bool) {
+	j := i
+	j[dir] += d
+	inside := j[dir] >= 0 && j[dir] < g.N[dir]
+	if g.pbc[dir] {
+		j[dir] = ((j[dir] % g.N[dir]) + g.N[dir]) % g.N[dir]
+		inside = true
+	} else {
+		if j[dir] < 0 {
+			j[dir] = 0
+		}
+		if j[dir] >= g.N[dir] {
+			j[dir] = g.N[dir] - 1
+		}
+	}
+	return g.idx(j[0], j[1], j[2]), inside
+}
+
+func (g refGrid) len() int { return g.N[0] * g.N[1] * g.N[2] }
+
+func getVec(a [][]float32, i int) vec {
+	return vec{float64(a[0][i]), float64(a[1][i]), float64(a[2][i])}
+}
+
+func refSymIdx(i, j int) int {
+	if j <= i {
+		return i*(i+1)/2 + j
+	}
+	return j*(j+1)/2 + i
+}
+
+func refInvMs(Ms float64) float64 {
+	if Ms == 0 {
+		return 0
+	}
+	return 1 / Ms
+}
+
+// exchange.cu
+func refExchange(B, m [][]float32, Ms []float32, aLUT []float32, regions []byte, g refGrid, cell [3]float64) {
+	for iz := 0; iz < g.N[2]; iz++ {
+		for iy := 0; iy < g.N[1]; iy++ {
+			for ix := 0; ix < g.N[0]; ix++ {
+				I := g.idx(ix, iy, iz)
+				m0 := getVec(m, I)
+				if m0.is0() {
+					continue
+				}
+				r0 := int(regions[I])
+				var h vec
+				for dir := 0; dir < 3; dir++ {
+					if dir == 2 && g.N[2] == 1 {
+						continue
+					}
+					w := 2 / (cell[dir] * cell[dir])
+					for _, d := range []int{-1, 1} {
+						j, _ := g.neighbor([3]int{ix, iy, iz}, dir, d)
+						mj := getVec(m, j)
+						if mj.is0() {
+							mj = m0
+						}
+						a := float64(aLUT[refSymIdx(r0, int(regions[j]))])
+						h = h.add(mj.sub(m0).mul(w * a))
+					}
+				}
+				addVec(B, I, h.mul(refInvMs(float64(Ms[I]))))
+			}
+		}
+	}
+}
+
+// dmi.cu: exchange + interfacial DMI, with boundary conditions
+// extrapolating missing neighbors in the x and y directions.
+func refDMI(B, m [][]float32, Ms []float32, aLUT, dLUT []float32, regions []byte, g refGrid, cell [3]float64) {
+	for iz := 0; iz < g.N[2]; iz++ {
+		for iy := 0; iy < g.N[1]; iy++ {
+			for ix := 0; ix < g.N[0]; ix++ {
+				I := g.idx(ix, iy, iz)
+				m0 := getVec(m, I)
+				if m0.is0() {
+					continue
+				}
+				r0 := int(regions[I])
+				var h vec
+				for dir := 0; dir < 2; dir++ {
+					c := cell[dir]
+					for _, d := range []int{-1, 1} {
+						j, inside := g.neighbor([3]int{ix, iy, iz}, dir, d)
+						var mj vec
+						if inside {
+							mj = getVec(m, j)
+						}
+						rj := r0
+						if !mj.is0() {
+							rj = int(regions[j])
+						}
+						A := float64(aLUT[refSymIdx(r0, rj)])
+						D := float64(dLUT[refSymIdx(r0, rj)])
+						s := float64(d) * c * 0.5 * D / A
+						if mj.is0() {
+							mj = m0
+							mj[dir] = m0[dir] - s*m0[2]
+							mj[2] = m0[2] + s*m0[dir]
+						}
+						h = h.add(mj.sub(m0).mul(2 * A / (c * c)))
+						h[dir] += float64(d) * (D / c) * mj[2]
+						h[2] -= float64(d) * (D / c) * mj[dir]
+					}
+				}
+				if g.N[2] != 1 {
+					c := cell[2]
+					for _, d := range []int{-1, 1} {
+						j, _ := g.neighbor([3]int{ix, iy, iz}, 2, d)
+						mj := getVec(m, j)
+						if mj.is0() {
+							mj = m0
+						}
+						A := float64(aLUT[refSymIdx(r0, int(regions[j]))])
+						h = h.add(mj.sub(m0).mul(2 * A / (c * c)))
+					}
+				}
+				addVec(B, I, h.mul(refInvMs(float64(Ms[I]))))
+			}
+		}
+	}
+}
+
+// uniaxialanisotropy2.cu
+func refUniaxialAnisotropy(B, m [][]float32, Ms, K1, K2 []float32, u [][]float32) {
+	for i := range Ms {
+		U := getVec(u, i)
+		if !U.is0() {
+			U = U.mul(1 / math.Sqrt(U.dot(U)))
+		}
+		invMs := refInvMs(float64(Ms[i]))
+		k1 := float64(K1[i]) * invMs
+		k2 := float64(K2[i]) * invMs
+		mu := getVec(m, i).dot(U)
+		addVec(B, i, U.mul(2*k1*mu+4*k2*mu*mu*mu))
+	}
+}
+
+// lltorque2.cu
+func refLLTorque(torque, m, B [][]float32, alpha []float32) {
+	for i := range alpha {
+		M := getVec(m, i)
+		a := float64(alpha[i])
+		mxB := M.cross(getVec(B, i))
+		t := mxB.add(M.cross(mxB).mul(a)).mul(-1 / (1 + a*a))
+		for c := 0; c < 3; c++ {
+			torque[c][i] = float32(t[c])
+		}
+	}
+}
+
+func addVec(dst [][]float32, i int, v vec) {
+	for c := 0; c < 3; c++ {
+		dst[c][i] += float32(v[c])
+	}
+}
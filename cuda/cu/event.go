@@ -0,0 +1,57 @@
+package cu
+
+// This file implements CUDA events
+
+//#include <cuda.h>
+import "C"
+import "unsafe"
+
+// CUDA event.
+type Event uintptr
+
+// Event creation flags
+const (
+	EVENT_DEFAULT        = C.CU_EVENT_DEFAULT
+	EVENT_BLOCKING_SYNC  = C.CU_EVENT_BLOCKING_SYNC
+	EVENT_DISABLE_TIMING = C.CU_EVENT_DISABLE_TIMING
+)
+
+// Creates an event
+func EventCreate(flags uint) Event {
+	var event C.CUevent
+	err := Result(C.cuEventCreate(&event, C.uint(flags)))
+	if err != SUCCESS {
+		panic(err)
+	}
+	return Event(uintptr(unsafe.Pointer(event)))
+}
+
+// Destroys the event
+func (event *Event) Destroy() {
+	err := Result(C.cuEventDestroy(C.CUevent(unsafe.Pointer(uintptr(*event)))))
+	*event = 0
+	if err != SUCCESS {
+		panic(err)
+	}
+}
+
+// Records the event in the stream: it completes when all preceding work in the stream has completed.
+func (event Event) Record(stream Stream) {
+	err := Result(C.cuEventRecord(C.CUevent(unsafe.Pointer(uintptr(event))), C.CUstream(unsafe.Pointer(uintptr(stream)))))
+	if err != SUCCESS {
+		panic(err)
+	}
+}
+
+// Blocks until the event has completed.
+func (event Event) Synchronize() {
+	err := Result(C.cuEventSynchronize(C.CUevent(unsafe.Pointer(uintptr(event)))))
+	if err != SUCCESS {
+		panic(&Error{"cuEventSynchronize", err})
+	}
+}
+
+// Returns Success if the event has completed, ErrorNotReady otherwise
+func (event Event) Query() Result {
+	return Result(C.cuEventQuery(C.CUevent(unsafe.Pointer(uintptr(event)))))
+}
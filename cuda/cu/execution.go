@@ -37,7 +37,7 @@ func LaunchKernel(f Function, gridDimX, gridDimY, gridDimZ int, blockDimX, block
 		(*unsafe.Pointer)(argp),
 		(*unsafe.Pointer)(unsafe.Pointer(uintptr(0)))))
 	if err != SUCCESS {
-		panic(err)
+		panic(&Error{"cuLaunchKernel", err})
 	}
 }
 
@@ -47,7 +47,7 @@ func (ptr DevicePtr) Free() {
 func Memcpy(dst, src DevicePtr, bytes int64) {
 	err := Result(C.cuMemcpy(C.CUdeviceptr(dst), C.CUdeviceptr(src), C.size_t(bytes)))
 	if err != SUCCESS {
-		panic(err)
+		panic(&Error{"cuMemcpy", err})
 	}
 }
 
@@ -55,7 +55,7 @@ func Memcpy(dst, src DevicePtr, bytes int64) {
 func MemcpyAsync(dst, src DevicePtr, bytes int64, stream Stream) {
 	err := Result(C.cuMemcpyAsync(C.CUdeviceptr(dst), C.CUdeviceptr(src), C.size_t(bytes), C.CUstream(unsafe.Pointer(uintptr(stream)))))
 	if err != SUCCESS {
-		panic(err)
+		panic(&Error{"cuMemcpyAsync", err})
 	}
 }
 
@@ -63,7 +63,7 @@ func MemcpyAsync(dst, src DevicePtr, bytes int64, stream Stream) {
 func MemcpyDtoD(dst, src DevicePtr, bytes int64) {
 	err := Result(C.cuMemcpyDtoD(C.CUdeviceptr(dst), C.CUdeviceptr(src), C.size_t(bytes)))
 	if err != SUCCESS {
-		panic(err)
+		panic(&Error{"cuMemcpyDtoD", err})
 	}
 }
 
@@ -71,7 +71,7 @@ func MemcpyDtoD(dst, src DevicePtr, bytes int64) {
 func MemcpyDtoDAsync(dst, src DevicePtr, bytes int64, stream Stream) {
 	err := Result(C.cuMemcpyDtoDAsync(C.CUdeviceptr(dst), C.CUdeviceptr(src), C.size_t(bytes), C.CUstream(unsafe.Pointer(uintptr(stream)))))
 	if err != SUCCESS {
-		panic(err)
+		panic(&Error{"cuMemcpyDtoDAsync", err})
 	}
 }
 
@@ -79,7 +79,7 @@ func MemcpyDtoDAsync(dst, src DevicePtr, bytes int64, stream Stream) {
 func MemcpyHtoD(dst DevicePtr, src unsafe.Pointer, bytes int64) {
 	err := Result(C.cuMemcpyHtoD(C.CUdeviceptr(dst), src, C.size_t(bytes)))
 	if err != SUCCESS {
-		panic(err)
+		panic(&Error{"cuMemcpyHtoD", err})
 	}
 }
 
@@ -88,7 +88,7 @@ func MemcpyHtoD(dst DevicePtr, src unsafe.Pointer, bytes int64) {
 func MemcpyHtoDAsync(dst DevicePtr, src unsafe.Pointer, bytes int64, stream Stream) {
 	err := Result(C.cuMemcpyHtoDAsync(C.CUdeviceptr(dst), src, C.size_t(bytes), C.CUstream(unsafe.Pointer(uintptr(stream)))))
 	if err != SUCCESS {
-		panic(err)
+		panic(&Error{"cuMemcpyHtoDAsync", err})
 	}
 }
 
@@ -96,7 +96,7 @@ func MemcpyHtoDAsync(dst DevicePtr, src unsafe.Pointer, bytes int64, stream Stre
 func MemcpyDtoH(dst unsafe.Pointer, src DevicePtr, bytes int64) {
 	err := Result(C.cuMemcpyDtoH(dst, C.CUdeviceptr(src), C.size_t(bytes)))
 	if err != SUCCESS {
-		panic(err)
+		panic(&Error{"cuMemcpyDtoH", err})
 	}
 }
 
@@ -105,7 +105,7 @@ func MemcpyDtoH(dst unsafe.Pointer, src DevicePtr, bytes int64) {
 func MemcpyDtoHAsync(dst unsafe.Pointer, src DevicePtr, bytes int64, stream Stream) {
 	err := Result(C.cuMemcpyDtoHAsync(dst, C.CUdeviceptr(src), C.size_t(bytes), C.CUstream(unsafe.Pointer(uintptr(stream)))))
 	if err != SUCCESS {
-		panic(err)
+		panic(&Error{"cuMemcpyDtoHAsync", err})
 	}
 }
 
@@ -113,7 +113,7 @@ func MemcpyDtoHAsync(dst unsafe.Pointer, src DevicePtr, bytes int64, stream Stre
 func MemcpyPeer(dst DevicePtr, dstCtx Context, src DevicePtr, srcCtx Context, bytes int64) {
 	err := Result(C.cuMemcpyPeer(C.CUdeviceptr(dst), C.CUcontext(unsafe.Pointer(uintptr(dstCtx))), C.CUdeviceptr(src), C.CUcontext(unsafe.Pointer(uintptr(srcCtx))), C.size_t(bytes)))
 	if err != SUCCESS {
-		panic(err)
+		panic(&Error{"cuMemcpyPeer", err})
 	}
 }
 
@@ -121,7 +121,7 @@ func MemcpyPeer(dst DevicePtr, dstCtx Context, src DevicePtr, srcCtx Context, by
 func MemcpyPeerAsync(dst DevicePtr, dstCtx Context, src DevicePtr, srcCtx Context, bytes int64, stream Stream) {
 	err := Result(C.cuMemcpyPeerAsync(C.CUdeviceptr(dst), C.CUcontext(unsafe.Pointer(uintptr(dstCtx))), C.CUdeviceptr(src), C.CUcontext(unsafe.Pointer(uintptr(srcCtx))), C.size_t(bytes), C.CUstream(unsafe.Pointer(uintptr(stream)))))
 	if err != SUCCESS {
-		panic(err)
+		panic(&Error{"cuMemcpyPeerAsync", err})
 	}
 }
 
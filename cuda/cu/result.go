@@ -23,6 +23,36 @@ func (err Result) String() string {
 	return str
 }
 
+// Implements the error interface, so a recovered Result can be handled as a Go error.
+func (err Result) Error() string {
+	return err.String()
+}
+
+// A CUDA error status together with the driver call that returned it.
+// Kernel launches, memory copies and synchronization panic with an *Error,
+// other calls with a plain Result.
+type Error struct {
+	Op     string // driver call, e.g. "cuLaunchKernel"
+	Result Result
+}
+
+func (e *Error) Error() string {
+	return e.Op + ": " + e.Result.String()
+}
+
+// DeviceLost reports whether the error leaves the context unusable
+// (a sticky error, e.g. an illegal address or uncorrectable ECC error),
+// so that work can only continue in a new process, possibly on another device.
+func (err Result) DeviceLost() bool {
+	switch err {
+	case ERROR_LAUNCH_FAILED, ERROR_ILLEGAL_ADDRESS, ERROR_HARDWARE_STACK_ERROR, ERROR_ILLEGAL_INSTRUCTION,
+		ERROR_MISALIGNED_ADDRESS, ERROR_INVALID_ADDRESS_SPACE, ERROR_INVALID_PC, ERROR_ECC_UNCORRECTABLE,
+		ERROR_NO_DEVICE, ERROR_CONTEXT_IS_DESTROYED:
+		return true
+	}
+	return false
+}
+
 const (
 	SUCCESS                              Result = C.CUDA_SUCCESS
 	ERROR_INVALID_VALUE                  Result = C.CUDA_ERROR_INVALID_VALUE
@@ -70,6 +100,7 @@ const (
 	ERROR_TOO_MANY_PEERS                 Result = C.CUDA_ERROR_TOO_MANY_PEERS
 	ERROR_HOST_MEMORY_ALREADY_REGISTERED Result = C.CUDA_ERROR_HOST_MEMORY_ALREADY_REGISTERED
 	ERROR_HOST_MEMORY_NOT_REGISTERED     Result = C.CUDA_ERROR_HOST_MEMORY_NOT_REGISTERED
+	ERROR_ILLEGAL_ADDRESS                Result = 700 //C.CUDA_ERROR_ILLEGAL_ADDRESS
 	ERROR_HARDWARE_STACK_ERROR           Result = 714 //C.CUDA_ERROR_HARDWARE_STACK_ERROR
 	ERROR_ILLEGAL_INSTRUCTION            Result = 715 //C.CUDA_ERROR_ILLEGAL_INSTRUCTION
 	ERROR_MISALIGNED_ADDRESS             Result = 716 //C.CUDA_ERROR_MISALIGNED_ADDRESS
@@ -127,6 +158,7 @@ var errorString map[Result]string = map[Result]string{
 	ERROR_TOO_MANY_PEERS:                 "CUDA_ERROR_TOO_MANY_PEERS",
 	ERROR_HOST_MEMORY_ALREADY_REGISTERED: "CUDA_ERROR_HOST_MEMORY_ALREADY_REGISTERED",
 	ERROR_HOST_MEMORY_NOT_REGISTERED:     "CUDA_ERROR_HOST_MEMORY_NOT_REGISTERED",
+	ERROR_ILLEGAL_ADDRESS:                "CUDA_ERROR_ILLEGAL_ADDRESS",
 	ERROR_HARDWARE_STACK_ERROR:           "CUDA_ERROR_HARDWARE_STACK_ERROR",
 	ERROR_ILLEGAL_INSTRUCTION:            "CUDA_ERROR_ILLEGAL_INSTRUCTION",
 	ERROR_MISALIGNED_ADDRESS:             "CUDA_ERROR_MISALIGNED_ADDRESS",
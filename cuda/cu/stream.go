@@ -38,7 +38,7 @@ func StreamDestroy(stream *Stream) {
 func (stream Stream) Synchronize() {
 	err := Result(C.cuStreamSynchronize(C.CUstream(unsafe.Pointer(uintptr(stream)))))
 	if err != SUCCESS {
-		panic(err)
+		panic(&Error{"cuStreamSynchronize", err})
 	}
 }
 
@@ -6,6 +6,10 @@ import (
 	"github.com/mumax/3/data"
 )
 
+// TODO: shared-memory tiling of the exchange and DMI stencils (exchange.cu, dmi.cu,
+// dmibulk.cu), to reuse neighbor loads within a block. Needs new kernels built with
+// nvcc for all supported compute capabilities, and benchmarks (mumax3 -bench).
+
 // Add exchange field to Beff.
 // 	m: normalized magnetization
 // 	B: effective field in Tesla
@@ -18,7 +22,7 @@ func AddExchange(B, m *data.Slice, Aex_red SymmLUT, Msat MSlice, regions *Bytes,
 	wz := float32(2 / (c[Z] * c[Z]))
 	N := mesh.Size()
 	pbc := mesh.PBC_code()
-	cfg := make3DConf(N)
+	cfg := tuned3DConf(N)
 	k_addexchange_async(B.DevPtr(X), B.DevPtr(Y), B.DevPtr(Z),
 		m.DevPtr(X), m.DevPtr(Y), m.DevPtr(Z),
 		Msat.DevPtr(0), Msat.Mul(0),
@@ -34,6 +38,6 @@ func ExchangeDecode(dst *data.Slice, Aex_red SymmLUT, regions *Bytes, mesh *data
 	wz := float32(2 / (c[Z] * c[Z]))
 	N := mesh.Size()
 	pbc := mesh.PBC_code()
-	cfg := make3DConf(N)
+	cfg := tuned3DConf(N)
 	k_exchangedecode_async(dst.DevPtr(0), unsafe.Pointer(Aex_red), regions.Ptr, wx, wy, wz, N[X], N[Y], N[Z], pbc, cfg)
 }
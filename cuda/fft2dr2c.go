@@ -0,0 +1,13 @@
+package cuda
+
+// 2D single-precission real-to-complex FFT plan,
+// for spatial spectra of single layers.
+type FFT2DR2CPlan struct {
+	fft3DR2CPlan
+}
+
+// 2D single-precission real-to-complex FFT plan for Nx x Ny data.
+// The output has Nx/2+1 complex numbers (stored as re, im pairs) per row.
+func NewFFT2DR2C(Nx, Ny int) *FFT2DR2CPlan {
+	return &FFT2DR2CPlan{newFFT3DR2C(Nx, Ny, 1)}
+}
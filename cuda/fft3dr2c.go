@@ -18,10 +18,7 @@ type fft3DR2CPlan struct {
 
 // 3D single-precission real-to-complex FFT plan.
 func newFFT3DR2C(Nx, Ny, Nz int) fft3DR2CPlan {
-	handle := cufft.Plan3d(Nz, Ny, Nx, cufft.R2C) // new xyz swap
-	handle.SetCompatibilityMode(cufft.COMPATIBILITY_FFTW_PADDING)
-	handle.SetStream(stream0)
-	return fft3DR2CPlan{fftplan{handle}, [3]int{Nx, Ny, Nz}}
+	return fft3DR2CPlan{getPlan(cufft.R2C, Nx, Ny, Nz), [3]int{Nx, Ny, Nz}}
 }
 
 // Execute the FFT plan, asynchronous.
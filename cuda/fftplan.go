@@ -2,15 +2,72 @@ package cuda
 
 // INTERNAL
 // Base implementation for all FFT plans.
+//
+// FFT precision and in-place transforms are deliberately not configurable:
+// the convolution kernels (kernMulRSymm2Dxy/2Dz/3D, kernMulC) only handle single-precision
+// complex data, and the forward and backward transforms work on separately padded
+// buffers. All plans are therefore single-precision and out-of-place (R2C and C2R).
 
 import (
+	"fmt"
+
 	"github.com/mumax/3/cuda/cu"
 	"github.com/mumax/3/cuda/cufft"
+	"github.com/mumax/3/util"
 )
 
 // Base implementation for all FFT plans.
 type fftplan struct {
 	handle cufft.Handle
+	key    planKey
+}
+
+// FFT plans are shared by all users with the same type and size
+// (e.g. demag and MFM convolutions with the same padded size),
+// and destroyed when the last one frees it.
+// Every plan creation is logged. A plan that keeps being re-created
+// (e.g. every time step) is reported, as that costs time and memory.
+type planKey struct {
+	typ  cufft.Type
+	size [3]int
+}
+
+type sharedPlan struct {
+	handle cufft.Handle
+	users  int
+}
+
+var (
+	plans       = make(map[planKey]*sharedPlan)
+	planCreated = make(map[planKey]int) // number of times each plan was created
+	PlanChurn   = 3                     // report plans created more often than this
+)
+
+// Returns a (shared) 3D plan of given type for Nx x Ny x Nz data.
+func getPlan(typ cufft.Type, Nx, Ny, Nz int) fftplan {
+	key := planKey{typ, [3]int{Nx, Ny, Nz}}
+	if p, ok := plans[key]; ok {
+		p.users++
+		return fftplan{p.handle, key}
+	}
+	handle := cufft.Plan3d(Nz, Ny, Nx, typ) // new xyz swap
+	handle.SetCompatibilityMode(cufft.COMPATIBILITY_FFTW_PADDING)
+	handle.SetStream(stream0)
+	plans[key] = &sharedPlan{handle, 1}
+	planCreated[key]++
+	util.Log("//FFT plan:", typ, Nx, "x", Ny, "x", Nz)
+	if n := planCreated[key]; n == PlanChurn+1 {
+		util.Log(fmt.Sprint("//WARNING: FFT plan ", typ, " ", Nx, "x", Ny, "x", Nz, " created ", n, " times, it should be kept instead of re-created"))
+	}
+	return fftplan{handle, key}
+}
+
+// Number of FFT plans in use and total number created so far.
+func FFTPlanStats() (inUse, created int) {
+	for _, n := range planCreated {
+		created += n
+	}
+	return len(plans), created
 }
 
 func prod3(x, y, z int) int {
@@ -19,10 +76,17 @@ func prod3(x, y, z int) int {
 
 // Releases all resources associated with the FFT plan.
 func (p *fftplan) Free() {
-	if p.handle != 0 {
-		p.handle.Destroy()
-		p.handle = 0
+	if p.handle == 0 {
+		return
+	}
+	if sp, ok := plans[p.key]; ok && sp.handle == p.handle {
+		sp.users--
+		if sp.users == 0 {
+			sp.handle.Destroy()
+			delete(plans, p.key)
+		}
 	}
+	p.handle = 0
 }
 
 // Associates a CUDA stream with the FFT plan.
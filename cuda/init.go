@@ -15,6 +15,7 @@ var (
 	DevName     string     // GPU name
 	TotalMem    int64      // total GPU memory
 	GPUInfo     string     // Human-readable GPU description
+	PCIBusID    string     // PCI bus ID of the GPU, as used by nvidia-smi
 	Synchronous bool       // for debug: synchronize stream0 at every kernel launch
 	cudaCtx     cu.Context // global CUDA context
 	cudaCC      int        // compute capablity (used for fatbin)
@@ -37,6 +38,7 @@ func Init(gpu int) {
 	Version = cu.Version()
 	DevName = dev.Name()
 	TotalMem = dev.TotalMem()
+	PCIBusID = fmt.Sprintf("%08X:%02X:%02X.0", dev.Attribute(cu.PCI_DOMAIN_ID), dev.Attribute(cu.PCI_BUS_ID), dev.Attribute(cu.PCI_DEVICE_ID))
 	GPUInfo = fmt.Sprint("CUDA ", Version, " ", DevName, "(", (TotalMem)/(1024*1024), "MB) ", "cc", M, ".", m)
 
 	if M < 2 {
@@ -70,3 +72,14 @@ const stream0 = cu.Stream(0)
 func Sync() {
 	stream0.Synchronize()
 }
+
+// Reports whether a recovered panic value is a CUDA error after which the device can not be used anymore.
+func DeviceLost(err interface{}) bool {
+	switch e := err.(type) {
+	case cu.Result:
+		return e.DeviceLost()
+	case *cu.Error:
+		return e.Result.DeviceLost()
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package cuda
+
+// Randomized comparison of GPU kernels against the CPU references in cpuref_test.go.
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"unsafe"
+
+	"github.com/mumax/3/cuda/cu"
+	"github.com/mumax/3/data"
+)
+
+const (
+	testNRegion = 256  // regions supported by the LUTs
+	testRegions = 4    // regions actually used
+	kernelTol   = 1e-4 // max error relative to the largest output value
+)
+
+var testCell = [3]float64{4e-9, 3e-9, 2e-9}
+
+// grids to test on: 2D, 3D with PBC in-plane, 3D with PBC along z
+var testGrids = []refGrid{
+	{N: [3]int{13, 7, 1}},
+	{N: [3]int{8, 6, 4}, pbc: [3]bool{true, true, false}},
+	{N: [3]int{5, 9, 3}, pbc: [3]bool{false, false, true}},
+}
+
+func (g refGrid) mesh() *data.Mesh {
+	var p [3]int
+	for i := range p {
+		if g.pbc[i] {
+			p[i] = 1
+		}
+	}
+	return data.NewMesh(g.N[0], g.N[1], g.N[2], testCell[0], testCell[1], testCell[2], p[0], p[1], p[2])
+}
+
+// random test input on the host
+type kernelInput struct {
+	m, u       *data.Slice // random unit vectors, m has holes
+	Ms         *data.Slice // random Msat, 0 in holes
+	K1, K2     *data.Slice
+	alpha      *data.Slice
+	regions    []byte
+	aLUT, dLUT []float32
+}
+
+func randomInput(g refGrid, rng *rand.Rand) *kernelInput {
+	in := &kernelInput{
+		m:       data.NewSlice(3, g.N),
+		u:       data.NewSlice(3, g.N),
+		Ms:      data.NewSlice(1, g.N),
+		K1:      data.NewSlice(1, g.N),
+		K2:      data.NewSlice(1, g.N),
+		alpha:   data.NewSlice(1, g.N),
+		regions: make([]byte, g.len()),
+		aLUT:    make([]float32, testNRegion*(testNRegion+1)/2),
+		dLUT:    make([]float32, testNRegion*(testNRegion+1)/2),
+	}
+	m, u := in.m.Host(), in.u.Host()
+	Ms, K1, K2, alpha := in.Ms.Host()[0], in.K1.Host()[0], in.K2.Host()[0], in.alpha.Host()[0]
+	for i := 0; i < g.len(); i++ {
+		in.regions[i] = byte(rng.Intn(testRegions))
+		K1[i] = float32(1e5 * rng.NormFloat64())
+		K2[i] = float32(1e4 * rng.NormFloat64())
+		alpha[i] = float32(rng.Float64())
+		for c := 0; c < 3; c++ {
+			u[c][i] = float32(rng.NormFloat64())
+		}
+		if rng.Float64() < 0.1 {
+			continue // hole: m = 0, Msat = 0
+		}
+		Ms[i] = float32(5e5 + 5e5*rng.Float64())
+		v := vec{rng.NormFloat64(), rng.NormFloat64(), rng.NormFloat64()}
+		v = v.mul(1 / math.Sqrt(v.dot(v)))
+		for c := 0; c < 3; c++ {
+			m[c][i] = float32(v[c])
+		}
+	}
+	for i := 0; i < testRegions; i++ {
+		for j := 0; j <= i; j++ {
+			in.aLUT[refSymIdx(i, j)] = float32(5e-12 + 1e-11*rng.Float64())
+			in.dLUT[refSymIdx(i, j)] = float32(1e-3 * rng.NormFloat64())
+		}
+	}
+	return in
+}
+
+func uploadSymmLUT(lut []float32) SymmLUT {
+	ptr := MemAlloc(int64(len(lut)) * cu.SIZEOF_FLOAT32)
+	MemCpyHtoD(ptr, unsafe.Pointer(&lut[0]), int64(len(lut))*cu.SIZEOF_FLOAT32)
+	return SymmLUT(ptr)
+}
+
+func freeSymmLUT(lut SymmLUT) {
+	cu.MemFree(cu.DevicePtr(uintptr(unsafe.Pointer(lut))))
+}
+
+func uploadRegions(r []byte) *Bytes {
+	b := NewBytes(len(r))
+	b.Upload(r)
+	return b
+}
+
+// compares the GPU result to the reference, relative to the largest reference value.
+func compareKernel(t *testing.T, name string, gpu *data.Slice, ref [][]float32) {
+	got := gpu.HostCopy().Host()
+	max, maxErr := 0.0, 0.0
+	for c := range ref {
+		for i := range ref[c] {
+			max = math.Max(max, math.Abs(float64(ref[c][i])))
+			maxErr = math.Max(maxErr, math.Abs(float64(got[c][i]-ref[c][i])))
+		}
+	}
+	if max == 0 {
+		t.Error(name, ": reference is zero, test is not meaningful")
+		return
+	}
+	if maxErr/max > kernelTol {
+		t.Error(name, ": relative error", maxErr/max, "exceeds", kernelTol)
+	}
+}
+
+func TestExchangeKernel(t *testing.T) {
+	Init(0)
+	rng := rand.New(rand.NewSource(1))
+	for _, g := range testGrids {
+		in := randomInput(g, rng)
+		aLUT := uploadSymmLUT(in.aLUT)
+		regions := uploadRegions(in.regions)
+		m, Ms := GPUCopy(in.m), GPUCopy(in.Ms)
+		B := NewSlice(3, g.N)
+		Zero(B)
+
+		AddExchange(B, m, aLUT, ToMSlice(Ms), regions, g.mesh())
+
+		ref := data.NewSlice(3, g.N).Host()
+		refExchange(ref, in.m.Host(), in.Ms.Host()[0], in.aLUT, in.regions, g, testCell)
+		compareKernel(t, "exchange", B, ref)
+
+		freeSymmLUT(aLUT)
+		regions.Free()
+		m.Free()
+		Ms.Free()
+		B.Free()
+	}
+}
+
+func TestDMIKernel(t *testing.T) {
+	Init(0)
+	rng := rand.New(rand.NewSource(2))
+	for _, g := range testGrids {
+		in := randomInput(g, rng)
+		aLUT, dLUT := uploadSymmLUT(in.aLUT), uploadSymmLUT(in.dLUT)
+		regions := uploadRegions(in.regions)
+		m, Ms := GPUCopy(in.m), GPUCopy(in.Ms)
+		B := NewSlice(3, g.N)
+		Zero(B)
+
+		AddDMI(B, m, aLUT, dLUT, ToMSlice(Ms), regions, g.mesh())
+
+		ref := data.NewSlice(3, g.N).Host()
+		refDMI(ref, in.m.Host(), in.Ms.Host()[0], in.aLUT, in.dLUT, in.regions, g, testCell)
+		compareKernel(t, "DMI", B, ref)
+
+		freeSymmLUT(aLUT)
+		freeSymmLUT(dLUT)
+		regions.Free()
+		m.Free()
+		Ms.Free()
+		B.Free()
+	}
+}
+
+func TestUniaxialAnisotropyKernel(t *testing.T) {
+	Init(0)
+	rng := rand.New(rand.NewSource(3))
+	g := testGrids[1]
+	in := randomInput(g, rng)
+	m, u, Ms, K1, K2 := GPUCopy(in.m), GPUCopy(in.u), GPUCopy(in.Ms), GPUCopy(in.K1), GPUCopy(in.K2)
+	defer m.Free()
+	defer u.Free()
+	defer Ms.Free()
+	defer K1.Free()
+	defer K2.Free()
+	B := NewSlice(3, g.N)
+	defer B.Free()
+	Zero(B)
+
+	AddUniaxialAnisotropy2(B, m, ToMSlice(Ms), ToMSlice(K1), ToMSlice(K2), ToMSlice(u))
+
+	ref := data.NewSlice(3, g.N).Host()
+	refUniaxialAnisotropy(ref, in.m.Host(), in.Ms.Host()[0], in.K1.Host()[0], in.K2.Host()[0], in.u.Host())
+	compareKernel(t, "uniaxial anisotropy", B, ref)
+}
+
+func TestLLTorqueKernel(t *testing.T) {
+	Init(0)
+	rng := rand.New(rand.NewSource(4))
+	g := testGrids[1]
+	in := randomInput(g, rng)
+	Bh := randomInput(g, rng).u // arbitrary field, need not be normalized
+	m, B, alpha := GPUCopy(in.m), GPUCopy(Bh), GPUCopy(in.alpha)
+	defer m.Free()
+	defer B.Free()
+	defer alpha.Free()
+	torque := NewSlice(3, g.N)
+	defer torque.Free()
+
+	LLTorque(torque, m, B, ToMSlice(alpha))
+
+	ref := data.NewSlice(3, g.N).Host()
+	refLLTorque(ref, in.m.Host(), Bh.Host(), in.alpha.Host()[0])
+	compareKernel(t, "LL torque", torque, ref)
+}
@@ -11,7 +11,7 @@ func Mul(dst, a, b *data.Slice) {
 	N := dst.Len()
 	nComp := dst.NComp()
 	util.Assert(a.Len() == N && a.NComp() == nComp && b.Len() == N && b.NComp() == nComp)
-	cfg := make1DConf(N)
+	cfg := tuned1DConf(N)
 	for c := 0; c < nComp; c++ {
 		k_mul_async(dst.DevPtr(c), a.DevPtr(c), b.DevPtr(c), N, cfg)
 	}
@@ -23,7 +23,7 @@ func Div(dst, a, b *data.Slice) {
 	N := dst.Len()
 	nComp := dst.NComp()
 	util.Assert(a.Len() == N && a.NComp() == nComp && b.Len() == N && b.NComp() == nComp)
-	cfg := make1DConf(N)
+	cfg := tuned1DConf(N)
 	for c := 0; c < nComp; c++ {
 		k_pointwise_div_async(dst.DevPtr(c), a.DevPtr(c), b.DevPtr(c), N, cfg)
 	}
@@ -40,7 +40,7 @@ func Madd2(dst, src1, src2 *data.Slice, factor1, factor2 float32) {
 	nComp := dst.NComp()
 	util.Assert(src1.Len() == N && src2.Len() == N)
 	util.Assert(src1.NComp() == nComp && src2.NComp() == nComp)
-	cfg := make1DConf(N)
+	cfg := tuned1DConf(N)
 	for c := 0; c < nComp; c++ {
 		k_madd2_async(dst.DevPtr(c), src1.DevPtr(c), factor1,
 			src2.DevPtr(c), factor2, N, cfg)
@@ -53,7 +53,7 @@ func Madd3(dst, src1, src2, src3 *data.Slice, factor1, factor2, factor3 float32)
 	nComp := dst.NComp()
 	util.Assert(src1.Len() == N && src2.Len() == N && src3.Len() == N)
 	util.Assert(src1.NComp() == nComp && src2.NComp() == nComp && src3.NComp() == nComp)
-	cfg := make1DConf(N)
+	cfg := tuned1DConf(N)
 	for c := 0; c < nComp; c++ {
 		k_madd3_async(dst.DevPtr(c), src1.DevPtr(c), factor1,
 			src2.DevPtr(c), factor2, src3.DevPtr(c), factor3, N, cfg)
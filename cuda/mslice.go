@@ -22,6 +22,11 @@ func MakeMSlice(arr *data.Slice, mul []float64) MSlice {
 	return MSlice{arr, mul}
 }
 
+// Underlying array, may be a nil slice.
+func (m MSlice) Arr() *data.Slice {
+	return m.arr
+}
+
 func (m MSlice) Size() [3]int {
 	return m.arr.Size()
 }
@@ -0,0 +1,567 @@
+package cuda
+
+// Magnetostatic field by a geometric multigrid Poisson solver, as alternative to the FFT convolution.
+
+import (
+	"math"
+	"unsafe"
+
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/mag"
+	"github.com/mumax/3/util"
+)
+
+// Multigrid calculates the magnetostatic field of m * vol * Bsat by solving
+// Poisson's equation for the magnetic scalar potential, ∇²φ = ∇·M with H = -∇φ,
+// with a geometric multigrid method. Finite meshes only, no PBC.
+//
+// The potential lives on the cell corners. The source is the flux of M through
+// the cell faces, the field is the cell average of -∇φ. The Laplacian is the
+// compact fourth-order (Mehrstellen) stencil, and the face charges and field average
+// are filtered to have the second moments of a uniformly charged face, so that the
+// error of the lattice Green's function falls off fast with distance. What remains
+// within a few cells of each source is removed by a short-range correction: the
+// difference between the exact (Newell) kernel and the solver's own response to
+// a single cell. The open boundary is vacuum padding, with the potential of the
+// total dipole moment imposed just outside.
+//
+// Much slower than the FFT convolution, but independent of it:
+// intended for cross-validation and small problems.
+type Multigrid struct {
+	inputSize [3]int          // size of the magnet mesh
+	cellsize  [3]float64      // cell size of the magnet mesh
+	off       [3]int          // corner grid index of the first corner of the magnet
+	levels    []*mgLevel      // finest first
+	bound     [3]*data.Slice  // right-hand side of the boundary potential, per unit dipole moment along X, Y, Z
+	near      [3]int          // reach of the short-range correction, in cells
+	corr      [3][3][]float32 // short-range correction [dst][src][offset]
+	MaxIter   int             // maximum number of V-cycles per solve
+	Tol       float64         // relative residual tolerance
+}
+
+// one grid of the multigrid hierarchy
+type mgLevel struct {
+	n             [3]int
+	h             [3]float64
+	phi, rhs, res *data.Slice
+}
+
+const (
+	mgLevels = 4   // number of grid levels
+	mgSmooth = 3   // Jacobi sweeps before and after coarse-grid correction
+	mgCoarse = 20  // Jacobi sweeps on the coarsest grid
+	mgOmega  = 0.8 // Jacobi damping
+	mgNear   = 3   // reach of the short-range correction, in cells
+	mgMaxZ   = 65535
+)
+
+// NewMultigrid prepares a multigrid solver for the given mesh size and cell size.
+// The short-range correction uses the demag kernel calculated with the given accuracy.
+func NewMultigrid(inputSize [3]int, cellsize [3]float64, accuracy float64) *Multigrid {
+	mg := newMultigrid(inputSize, cellsize)
+	mg.initCorrection(accuracy)
+	return mg
+}
+
+// multigrid solver without short-range correction
+func newMultigrid(inputSize [3]int, cellsize [3]float64) *Multigrid {
+	mg := &Multigrid{inputSize: inputSize, cellsize: cellsize, MaxIter: 100, Tol: 1e-5}
+	align := 1 << (mgLevels - 1)
+	maxSize := 0.
+	for c := range inputSize {
+		maxSize = math.Max(maxSize, float64(inputSize[c])*cellsize[c])
+	}
+	var n [3]int
+	for c := range n {
+		// vacuum padding on each side, half the largest dimension of the magnet
+		p := int(maxSize/cellsize[c])/2 + 2
+		n[c] = divUp(inputSize[c]+1+2*p, align) * align
+		mg.off[c] = (n[c] - inputSize[c] - 1) / 2
+	}
+	h := cellsize
+	for l := 0; l < mgLevels; l++ {
+		lv := &mgLevel{n: n, h: h, phi: NewSlice(1, n), rhs: NewSlice(1, n), res: NewSlice(1, n)}
+		Zero(lv.phi)
+		mg.levels = append(mg.levels, lv)
+		for c := range n {
+			n[c] /= 2
+			h[c] *= 2
+		}
+	}
+	mg.initBoundary()
+	return mg
+}
+
+// Calculate the demag field of m * vol * Bsat, store result in B.
+// Arguments as in DemagConvolution.Exec. The potential of the previous
+// call is used as initial guess.
+func (mg *Multigrid) Exec(B, m, vol *data.Slice, Msat MSlice) {
+	util.Argument(B.Size() == mg.inputSize && m.Size() == mg.inputSize)
+	n := mg.levels[0].n
+
+	M := Buffer(3, n) // µ0 M on the corner grid
+	defer Recycle(M)
+	for c := 0; c < 3; c++ {
+		mg.pad(M.Comp(c), m.Comp(c), vol, Msat)
+	}
+
+	mg.setRHS(M)
+	mg.solve()
+
+	Bn := Buffer(3, n)
+	defer Recycle(Bn)
+	mg.setField(Bn)
+	mg.addNear(Bn, M)
+	Crop(B, Bn, mg.off[X], mg.off[Y], mg.off[Z])
+}
+
+// Free releases the GPU memory.
+func (mg *Multigrid) Free() {
+	if mg == nil {
+		return
+	}
+	for _, l := range mg.levels {
+		l.phi.Free()
+		l.rhs.Free()
+		l.res.Free()
+	}
+	mg.levels = nil
+	for c := range mg.bound {
+		mg.bound[c].Free()
+		mg.bound[c] = nil
+	}
+}
+
+// dst = µ0 Msat vol m on the corner grid: cell i is stored at corner i+off.
+func (mg *Multigrid) pad(dst, m, vol *data.Slice, Msat MSlice) {
+	n := dst.Size()
+	buf := Buffer(1, n)
+	defer Recycle(buf)
+	Zero(buf)
+	copyPadMul(buf, m, vol, n, mg.inputSize, Msat)
+	ShiftX(dst, buf, mg.off[X], 0, 0)
+	ShiftY(buf, dst, mg.off[Y], 0, 0)
+	ShiftZ(dst, buf, mg.off[Z], 0, 0)
+}
+
+// rhs = ∇·M: the flux of M through each cell face, divided over the 4 corners of the face.
+// Includes the Mehrstellen right-hand side operator and the boundary potential.
+func (mg *Multigrid) setRHS(M *data.Slice) {
+	l := mg.levels[0]
+	Zero(l.rhs)
+	t := Buffer(1, l.n)
+	defer Recycle(t)
+	for c := 0; c < 3; c++ {
+		// (M(i) - M(i-1)) / 4h along c, summed over the 4 cells that share the corner
+		w := float32(1 / (4 * l.h[c]))
+		shift(t, M.Comp(c), c, 1)
+		Madd2(t, M.Comp(c), t, w, -w)
+		for _, a := range others(c) {
+			pairSum(t, t, a, 1)
+			filter(t, t, a, -1./12.) // second moment of a uniform face
+		}
+		Madd2(l.rhs, l.rhs, t, 1, 1)
+	}
+	for c := 0; c < 3; c++ {
+		diff2(t, l.rhs, c)
+		Madd2(l.rhs, l.rhs, t, 1, 1./12.)
+	}
+
+	vol := mg.cellsize[X] * mg.cellsize[Y] * mg.cellsize[Z]
+	for c := 0; c < 3; c++ {
+		moment := float64(Sum(M.Comp(c))) * vol
+		Madd2(l.rhs, l.rhs, mg.bound[c], 1, float32(moment))
+	}
+}
+
+// B = -∇φ averaged over each cell, stored at the first corner of the cell.
+func (mg *Multigrid) setField(B *data.Slice) {
+	l := mg.levels[0]
+	t := Buffer(1, l.n)
+	defer Recycle(t)
+	for c := 0; c < 3; c++ {
+		b := B.Comp(c)
+		o := others(c)
+		filter(b, l.phi, o[0], -1./12.)
+		filter(b, b, o[1], -1./12.)
+		// -(φ(i+1) - φ(i)) / 4h along c, summed over the 4 corners of the face
+		w := float32(1 / (4 * l.h[c]))
+		shift(t, b, c, -1)
+		Madd2(b, t, b, -w, w)
+		pairSum(b, b, o[0], -1)
+		pairSum(b, b, o[1], -1)
+	}
+}
+
+// B += short-range correction applied to M, both on the corner grid.
+func (mg *Multigrid) addNear(B, M *data.Slice) {
+	if len(mg.corr[X][X]) == 0 {
+		return
+	}
+	n := M.Size()
+	sz, syz, s := Buffer(3, n), Buffer(3, n), Buffer(3, n)
+	defer Recycle(sz)
+	defer Recycle(syz)
+	defer Recycle(s)
+	R := mg.near
+	i := 0
+	for dz := -R[Z]; dz <= R[Z]; dz++ {
+		for c := 0; c < 3; c++ {
+			ShiftZ(sz.Comp(c), M.Comp(c), dz, 0, 0)
+		}
+		for dy := -R[Y]; dy <= R[Y]; dy++ {
+			for c := 0; c < 3; c++ {
+				ShiftY(syz.Comp(c), sz.Comp(c), dy, 0, 0)
+			}
+			for dx := -R[X]; dx <= R[X]; dx++ {
+				for c := 0; c < 3; c++ {
+					ShiftX(s.Comp(c), syz.Comp(c), dx, 0, 0)
+				}
+				for d := 0; d < 3; d++ {
+					k := mg.corr[d]
+					Madd3(B.Comp(d), B.Comp(d), s.Comp(X), s.Comp(Y), 1, k[X][i], k[Y][i])
+					Madd2(B.Comp(d), B.Comp(d), s.Comp(Z), 1, k[Z][i])
+				}
+				i++
+			}
+		}
+	}
+}
+
+// The short-range correction is the exact kernel minus the response
+// of the uncorrected solver to a single cell, up to mgNear cells away.
+func (mg *Multigrid) initCorrection(accuracy float64) {
+	var size, center [3]int
+	for c := range size {
+		mg.near[c] = iMin(mgNear, mg.inputSize[c]-1)
+		size[c] = 2*mg.near[c] + 1
+		center[c] = mg.near[c]
+	}
+	kern := mag.CalcDemagKernel(size, [3]int{}, mg.cellsize, accuracy)
+	kernSize := kern[X][X].Size()
+
+	probe := newMultigrid(size, mg.cellsize)
+	defer probe.Free()
+	probe.Tol = 1e-6
+	m := NewSlice(3, size)
+	defer m.Free()
+	B := NewSlice(3, size)
+	defer B.Free()
+	one := MakeMSlice(data.NilSlice(1, size), []float64{1 / mag.Mu0}) // µ0M = 1 T
+	vol := data.NilSlice(1, size)
+
+	R := mg.near
+	for s := 0; s < 3; s++ {
+		src := data.NewSlice(3, size)
+		src.Vectors()[s][center[Z]][center[Y]][center[X]] = 1
+		data.Copy(m, src)
+		Zero(probe.levels[0].phi)
+		probe.Exec(B, m, vol, one)
+		resp := B.HostCopy().Vectors()
+		for d := 0; d < 3; d++ {
+			var K [][][]float32
+			if kern[d][s] != nil {
+				K = kern[d][s].Scalars()
+			}
+			mg.corr[d][s] = nil
+			for dz := -R[Z]; dz <= R[Z]; dz++ {
+				for dy := -R[Y]; dy <= R[Y]; dy++ {
+					for dx := -R[X]; dx <= R[X]; dx++ {
+						k := float32(0)
+						if K != nil {
+							k = K[wrap(dz, kernSize[Z])][wrap(dy, kernSize[Y])][wrap(dx, kernSize[X])]
+						}
+						r := resp[d][center[Z]+dz][center[Y]+dy][center[X]+dx]
+						mg.corr[d][s] = append(mg.corr[d][s], k-r)
+					}
+				}
+			}
+		}
+	}
+}
+
+// right-hand side of the potential of a unit dipole along X, Y, Z
+// in the center of the magnet, imposed on the corners just outside the grid.
+func (mg *Multigrid) initBoundary() {
+	l := mg.levels[0]
+	n, h := l.n, l.h
+	w := mehrstellen(h)
+	var center [3]float64
+	for c := range center {
+		center[c] = float64(mg.off[c]) + float64(mg.inputSize[c])/2
+	}
+	var host [3]*data.Slice
+	var b [3][][][]float32
+	for c := range host {
+		host[c] = data.NewSlice(1, n)
+		b[c] = host[c].Scalars()
+	}
+	for iz := 0; iz < n[Z]; iz++ {
+		for iy := 0; iy < n[Y]; iy++ {
+			for ix := 0; ix < n[X]; ix++ {
+				if ix > 0 && iy > 0 && iz > 0 && ix < n[X]-1 && iy < n[Y]-1 && iz < n[Z]-1 {
+					continue // not next to the boundary
+				}
+				for dz := -1; dz <= 1; dz++ {
+					for dy := -1; dy <= 1; dy++ {
+						for dx := -1; dx <= 1; dx++ {
+							j := [3]int{ix + dx, iy + dy, iz + dz}
+							wj := w[dz+1][dy+1][dx+1]
+							if wj == 0 || (j[X] >= 0 && j[Y] >= 0 && j[Z] >= 0 && j[X] < n[X] && j[Y] < n[Y] && j[Z] < n[Z]) {
+								continue
+							}
+							var r [3]float64
+							for c := range r {
+								r[c] = (float64(j[c]) - center[c]) * h[c]
+							}
+							r2 := r[X]*r[X] + r[Y]*r[Y] + r[Z]*r[Z]
+							for c := 0; c < 3; c++ {
+								// known potential moved to the right-hand side
+								b[c][iz][iy][ix] -= float32(wj * r[c] / (4 * math.Pi * r2 * math.Sqrt(r2)))
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	for c := range host {
+		mg.bound[c] = NewSlice(1, n)
+		data.Copy(mg.bound[c], host[c])
+	}
+}
+
+// solve ∇²φ = rhs on the finest level.
+func (mg *Multigrid) solve() {
+	fine := mg.levels[0]
+	norm := math.Sqrt(float64(Dot(fine.rhs, fine.rhs)))
+	if norm == 0 {
+		Zero(fine.phi)
+		return
+	}
+	for iter := 0; iter < mg.MaxIter; iter++ {
+		mg.vcycle(0)
+		fine.residual()
+		if math.Sqrt(float64(Dot(fine.res, fine.res))) < mg.Tol*norm {
+			return
+		}
+	}
+}
+
+// multigrid V-cycle starting at level k.
+func (mg *Multigrid) vcycle(k int) {
+	l := mg.levels[k]
+	if k == len(mg.levels)-1 {
+		for i := 0; i < mgCoarse; i++ {
+			l.smooth()
+		}
+		return
+	}
+	for i := 0; i < mgSmooth; i++ {
+		l.smooth()
+	}
+	l.residual()
+	coarse := mg.levels[k+1]
+	restrict(coarse.rhs, l.res)
+	Zero(coarse.phi)
+	mg.vcycle(k + 1)
+	prolongAdd(l.phi, coarse.phi)
+	for i := 0; i < mgSmooth; i++ {
+		l.smooth()
+	}
+}
+
+// damped Jacobi sweep.
+func (l *mgLevel) smooth() {
+	l.residual()
+	Madd2(l.phi, l.phi, l.res, 1, float32(mgOmega/mehrstellen(l.h)[1][1][1]))
+}
+
+// res = rhs - ∇²φ
+func (l *mgLevel) residual() {
+	laplace(l.res, l.phi, l.h)
+	Madd2(l.res, l.rhs, l.res, 1, -1)
+}
+
+// dst = Mehrstellen Laplacian of src, zero outside:
+// Σ δi²/hi² + 1/12 Σ(i<j) (1/hi² + 1/hj²) δi²δj²
+func laplace(dst, src *data.Slice, h [3]float64) {
+	var d2 [3]*data.Slice
+	for c := range d2 {
+		d2[c] = Buffer(1, src.Size())
+		defer Recycle(d2[c])
+		diff2(d2[c], src, c)
+	}
+	Madd3(dst, d2[X], d2[Y], d2[Z], float32(1/(h[X]*h[X])), float32(1/(h[Y]*h[Y])), float32(1/(h[Z]*h[Z])))
+	t := Buffer(1, src.Size())
+	defer Recycle(t)
+	for i := 0; i < 3; i++ {
+		for j := i + 1; j < 3; j++ {
+			diff2(t, d2[i], j)
+			Madd2(dst, dst, t, 1, float32((1/(h[i]*h[i])+1/(h[j]*h[j]))/12))
+		}
+	}
+}
+
+// stencil weights of laplace(), indexed [dz+1][dy+1][dx+1].
+func mehrstellen(h [3]float64) (w [3][3][3]float64) {
+	var inv [3]float64
+	for c := range inv {
+		inv[c] = 1 / (h[c] * h[c])
+	}
+	at := func(d [3]int) *float64 { return &w[d[Z]+1][d[Y]+1][d[X]+1] }
+	for i := 0; i < 3; i++ {
+		for _, s := range []int{-1, 1} {
+			var d [3]int
+			d[i] = s
+			*at(d) += inv[i]
+			*at([3]int{}) -= inv[i]
+		}
+		for j := i + 1; j < 3; j++ {
+			wij := (inv[i] + inv[j]) / 12
+			for _, si := range []int{-1, 0, 1} {
+				for _, sj := range []int{-1, 0, 1} {
+					var d [3]int
+					d[i], d[j] = si, sj
+					*at(d) += wij * d2w(si) * d2w(sj)
+				}
+			}
+		}
+	}
+	return w
+}
+
+// weight of the second difference [1, -2, 1]
+func d2w(d int) float64 {
+	if d == 0 {
+		return -2
+	}
+	return 1
+}
+
+// dst = src(i-1) - 2 src(i) + src(i+1) along axis c, zero outside.
+func diff2(dst, src *data.Slice, c int) {
+	filter(dst, src, c, 1)
+	Madd2(dst, dst, src, 1, -1)
+}
+
+// dst = src + a (src(i-1) - 2 src(i) + src(i+1)) along axis c, zero outside. dst may be src.
+func filter(dst, src *data.Slice, c int, a float32) {
+	l, r := Buffer(1, src.Size()), Buffer(1, src.Size())
+	defer Recycle(l)
+	defer Recycle(r)
+	shift(l, src, c, 1)
+	shift(r, src, c, -1)
+	Madd3(dst, src, l, r, 1-2*a, a, a)
+}
+
+// dst = src(i) + src(i-dir) along axis c, zero outside. dst may be src.
+func pairSum(dst, src *data.Slice, c, dir int) {
+	t := Buffer(1, src.Size())
+	defer Recycle(t)
+	shift(t, src, c, dir)
+	Madd2(dst, src, t, 1, 1)
+}
+
+// dst(i) = src(i-n) along axis c, zero outside.
+func shift(dst, src *data.Slice, c, n int) {
+	switch c {
+	case X:
+		ShiftX(dst, src, n, 0, 0)
+	case Y:
+		ShiftY(dst, src, n, 0, 0)
+	case Z:
+		ShiftZ(dst, src, n, 0, 0)
+	}
+}
+
+// the two axes other than c
+func others(c int) [2]int {
+	return [2]int{(c + 1) % 3, (c + 2) % 3}
+}
+
+// dst = average of each 2x2x2 block of src.
+func restrict(dst, src *data.Slice) {
+	a := src
+	for c := 0; c < 3; c++ {
+		n := a.Size()
+		t := Buffer(1, n)
+		pairSum(t, a, c, -1)
+		n[c] /= 2
+		b := Buffer(1, n)
+		evenCells(b, t, c)
+		Recycle(t)
+		if a != src {
+			Recycle(a)
+		}
+		a = b
+	}
+	Madd2(dst, a, a, 1./16., 1./16.) // sum of 8 cells
+	Recycle(a)
+}
+
+// dst += trilinear interpolation of src, which has half the size of dst.
+func prolongAdd(dst, src *data.Slice) {
+	a := src
+	for c := 0; c < 3; c++ {
+		n := a.Size()
+		n[c] *= 2
+		b := Buffer(1, n)
+		// piecewise constant, then [1/4, 1/2, 1/4] makes it linear
+		upsample(b, a, c)
+		filter(b, b, c, 1./4.)
+		if a != src {
+			Recycle(a)
+		}
+		a = b
+	}
+	Madd2(dst, dst, a, 1, 1)
+	Recycle(a)
+}
+
+// dst = the even cells of src along axis c. dst has half the size of src along c.
+func evenCells(dst, src *data.Slice, c int) {
+	full, half := pairViews(src.Size(), c)
+	for z0 := 0; z0 < full[Z]; z0 += mgMaxZ {
+		nz := iMin(mgMaxZ, full[Z]-z0)
+		f, h := full, half
+		f[Z], h[Z] = nz, nz
+		Crop(view(dst, z0*h[X]*h[Y], h), view(src, z0*f[X]*f[Y], f), 0, 0, 0)
+	}
+}
+
+// dst = src with each cell repeated twice along axis c.
+func upsample(dst, src *data.Slice, c int) {
+	full, half := pairViews(dst.Size(), c)
+	one := MakeMSlice(data.NilSlice(1, half), []float64{1 / mag.Mu0}) // cancels copyPadMul's µ0
+	Zero(dst)
+	for z0 := 0; z0 < full[Z]; z0 += mgMaxZ {
+		nz := iMin(mgMaxZ, full[Z]-z0)
+		f, h := full, half
+		f[Z], h[Z] = nz, nz
+		copyPadMul(view(dst, z0*f[X]*f[Y], f), view(src, z0*h[X]*h[Y], h), data.NilSlice(1, h), f, h, one)
+	}
+	// fill the odd cells
+	pairSum(dst, dst, c, 1)
+}
+
+// Sizes of views on a grid of size n and on the grid with half the size along c,
+// in which the pairs of cells along c are a separate dimension (of size 2 or 1).
+func pairViews(n [3]int, c int) (full, half [3]int) {
+	switch c {
+	default:
+		panic(c)
+	case X:
+		return [3]int{2, n[X] / 2 * n[Y], n[Z]}, [3]int{1, n[X] / 2 * n[Y], n[Z]}
+	case Y:
+		return [3]int{n[X], 2, n[Y] / 2 * n[Z]}, [3]int{n[X], 1, n[Y] / 2 * n[Z]}
+	case Z:
+		return [3]int{n[X] * n[Y], 2, n[Z] / 2}, [3]int{n[X] * n[Y], 1, n[Z] / 2}
+	}
+}
+
+// view of the single-component slice s with given size, starting at element off.
+func view(s *data.Slice, off int, size [3]int) *data.Slice {
+	ptr := unsafe.Pointer(uintptr(s.DevPtr(0)) + uintptr(off)*data.SIZEOF_FLOAT32)
+	return data.SliceFromPtrs(size, data.GPUMemory, []unsafe.Pointer{ptr})
+}
@@ -0,0 +1,87 @@
+package cuda
+
+// Compares the multigrid demag solver against the FFT convolution.
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/mag"
+)
+
+const (
+	multigridTol  = 0.01 // max field error relative to µ0Msat
+	multigridETol = 0.02 // relative error on the demag energy
+)
+
+func TestMultigrid(t *testing.T) {
+	Init(0)
+	const Ms = 800e3
+	for _, N := range [][3]int{{16, 12, 1}, {12, 10, 3}} {
+		kern := mag.CalcDemagKernel(N, [3]int{}, testCell, 6)
+		conv := NewDemag(N, [3]int{}, kern, false)
+		mg := NewMultigrid(N, testCell, 6)
+		vol := data.NilSlice(1, N)
+		msat := MakeMSlice(data.NilSlice(1, N), []float64{Ms})
+
+		for name, state := range map[string]func(x, y, z float64) [3]float64{
+			"uniform x": func(x, y, z float64) [3]float64 { return [3]float64{1, 0, 0} },
+			"uniform z": func(x, y, z float64) [3]float64 { return [3]float64{0, 0, 1} },
+			"vortex": func(x, y, z float64) [3]float64 {
+				mz := 1.5 * math.Exp(-(x*x+y*y)/2)
+				return [3]float64{-y, x, mz}
+			},
+		} {
+			mh := testState(N, state)
+			m := GPUCopy(mh)
+			Bfft, Bmg := NewSlice(3, N), NewSlice(3, N)
+			conv.Exec(Bfft, m, vol, msat)
+			mg.Exec(Bmg, m, vol, msat)
+
+			want, got := Bfft.HostCopy().Host(), Bmg.HostCopy().Host()
+			maxErr, Ewant, Egot := 0.0, 0.0, 0.0
+			for c := range want {
+				for i := range want[c] {
+					maxErr = math.Max(maxErr, math.Abs(float64(got[c][i]-want[c][i])))
+					Ewant += float64(want[c][i] * mh.Host()[c][i])
+					Egot += float64(got[c][i] * mh.Host()[c][i])
+				}
+			}
+			if maxErr/(mag.Mu0*Ms) > multigridTol {
+				t.Error(N, name, ": field error", maxErr/(mag.Mu0*Ms), "exceeds", multigridTol)
+			}
+			if math.Abs(Egot-Ewant) > multigridETol*math.Abs(Ewant) {
+				t.Error(N, name, ": energy", Egot, "want", Ewant)
+			}
+			m.Free()
+			Bfft.Free()
+			Bmg.Free()
+		}
+		conv.Free()
+		mg.Free()
+	}
+}
+
+// unit vector field on the host, state given as function of the position relative to the center,
+// in units of the smallest in-plane half size.
+func testState(N [3]int, state func(x, y, z float64) [3]float64) *data.Slice {
+	m := data.NewSlice(3, N)
+	v := m.Vectors()
+	r := float64(iMin(N[X], N[Y])) / 4
+	for iz := 0; iz < N[Z]; iz++ {
+		for iy := 0; iy < N[Y]; iy++ {
+			for ix := 0; ix < N[X]; ix++ {
+				x := (float64(ix) - float64(N[X]-1)/2) / r
+				y := (float64(iy) - float64(N[Y]-1)/2) / r
+				z := (float64(iz) - float64(N[Z]-1)/2) / r
+				s := state(x, y, z)
+				n := math.Sqrt(s[X]*s[X] + s[Y]*s[Y] + s[Z]*s[Z])
+				for c := range s {
+					v[c][iz][iy][ix] = float32(s[c] / n)
+				}
+			}
+		}
+	}
+	return m
+}
@@ -0,0 +1,33 @@
+package cuda
+
+import (
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/mag"
+	"github.com/mumax/3/util"
+)
+
+// Paste stores src in the larger dst at given offset position,
+// the remainder of dst is set to zero. Inverse of Crop.
+func Paste(dst, src *data.Slice, offX, offY, offZ int) {
+	D := dst.Size()
+	S := src.Size()
+	util.Argument(dst.NComp() == src.NComp())
+	util.Argument(S[X]+offX <= D[X] && S[Y]+offY <= D[Y] && S[Z]+offZ <= D[Z])
+
+	// copypadmul multiplies by mu0*Msat: cancel with Msat = 1/mu0.
+	one := MakeMSlice(data.NilSlice(1, S), []float64{1 / mag.Mu0})
+	vol := data.NilSlice(1, S)
+
+	buf := Buffer(1, D)
+	defer Recycle(buf)
+
+	for c := 0; c < dst.NComp(); c++ {
+		d := dst.Comp(c)
+		Zero(buf)
+		copyPadMul(buf, src.Comp(c), vol, D, S, one)
+		// move from the origin to the offset position
+		ShiftX(d, buf, offX, 0, 0)
+		ShiftY(buf, d, offY, 0, 0)
+		ShiftZ(d, buf, offZ, 0, 0)
+	}
+}
@@ -0,0 +1,154 @@
+package cuda
+
+// Asynchronous downloads through a pool of pinned (page-locked) host buffers.
+// The copy is queued in stream0 behind the kernels producing the data,
+// so the calling thread does not wait for the GPU. The data is picked up later,
+// typically by the output goroutine, with Download.HostCopy.
+// Single numbers needed right away (reduction results for Average and table rows,
+// GetCell) are copied synchronously, but through pinned buffers as well,
+// which avoids the driver's pageable staging copy.
+
+import (
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"github.com/mumax/3/cuda/cu"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/timer"
+	"github.com/mumax/3/util"
+)
+
+var (
+	pinnedPool = make(map[int][]unsafe.Pointer) // pinned host buffers indexed by number of floats
+	pinnedLock sync.Mutex                       // pool is used by the main and output goroutines
+)
+
+const pinnedMax = 64 // maximum number of pooled buffers per size
+
+// A pending download of GPU data to host.
+type Download struct {
+	size  [3]int
+	ptrs  []unsafe.Pointer // pinned host buffers, one per component
+	done  cu.Event         // completes when the copy has finished
+	ready *data.Slice      // data that was already on the host
+}
+
+// Queues a copy of src to pinned host memory, without waiting for the GPU.
+// src may be recycled or overwritten by later kernels right away.
+func DownloadAsync(src *data.Slice) *Download {
+	if src.CPUAccess() {
+		return &Download{ready: src.HostCopy()}
+	}
+	if Synchronous {
+		Sync()
+		timer.Start("memcpyDtoH")
+	}
+	n := src.Len()
+	d := &Download{size: src.Size(), ptrs: make([]unsafe.Pointer, src.NComp())}
+	for c := range d.ptrs {
+		d.ptrs[c] = pinnedAlloc(n)
+		cu.MemcpyDtoHAsync(d.ptrs[c], cu.DevicePtr(uintptr(src.DevPtr(c))), int64(n)*cu.SIZEOF_FLOAT32, stream0)
+	}
+	d.done = cu.EventCreate(cu.EVENT_DISABLE_TIMING)
+	d.done.Record(stream0)
+	if Synchronous {
+		Sync()
+		timer.Stop("memcpyDtoH")
+	}
+	return d
+}
+
+// Waits for the download to finish and returns its data in ordinary host memory.
+// May be called from any goroutine, but only once.
+func (d *Download) HostCopy() *data.Slice {
+	if d.ready != nil {
+		return d.ready
+	}
+	lockContext()
+	d.done.Synchronize()
+	d.done.Destroy()
+	pinned := data.SliceFromPtrs(d.size, data.CPUMemory, d.ptrs)
+	cpy := pinned.HostCopy()
+	for _, p := range d.ptrs {
+		pinnedFree(p, pinned.Len())
+	}
+	d.ptrs = nil
+	return cpy
+}
+
+// Slice of nComp components in pinned host memory, to be reused as the destination
+// of DownloadPinnedAsync, e.g. as one frame of a ring buffer. Free with FreePinned.
+func NewPinnedSlice(nComp int, size [3]int) *data.Slice {
+	n := size[0] * size[1] * size[2]
+	ptrs := make([]unsafe.Pointer, nComp)
+	for c := range ptrs {
+		ptrs[c] = cu.MemAllocHost(int64(n) * cu.SIZEOF_FLOAT32)
+	}
+	return data.SliceFromPtrs(size, data.CPUMemory, ptrs)
+}
+
+// Frees a slice made by NewPinnedSlice.
+func FreePinned(s *data.Slice) {
+	if s == nil || s.NComp() == 0 {
+		return
+	}
+	for _, c := range s.Host() {
+		cu.MemFreeHost(unsafe.Pointer(&c[0]))
+	}
+	s.Disable()
+}
+
+// Queues a copy of GPU slice src into pinned host slice dst (NewPinnedSlice) in stream0,
+// without waiting for the GPU. dst may only be read after the next Sync.
+func DownloadPinnedAsync(dst, src *data.Slice) {
+	util.Argument(dst.NComp() == src.NComp() && dst.Len() == src.Len())
+	n := int64(src.Len()) * cu.SIZEOF_FLOAT32
+	for c, h := range dst.Host() {
+		cu.MemcpyDtoHAsync(unsafe.Pointer(&h[0]), cu.DevicePtr(uintptr(src.DevPtr(c))), n, stream0)
+	}
+}
+
+// Copies one float from GPU memory to the host through a pinned buffer, waits for it.
+func downloadFloat(src unsafe.Pointer) float32 {
+	p := pinnedAlloc(1)
+	MemCpyDtoH(p, src, cu.SIZEOF_FLOAT32)
+	v := *(*float32)(p)
+	pinnedFree(p, 1)
+	return v
+}
+
+// pinned host buffer of n floats, from the pool if possible.
+func pinnedAlloc(n int) unsafe.Pointer {
+	pinnedLock.Lock()
+	defer pinnedLock.Unlock()
+	pool := pinnedPool[n]
+	if len(pool) > 0 {
+		p := pool[len(pool)-1]
+		pinnedPool[n] = pool[:len(pool)-1]
+		return p
+	}
+	return cu.MemAllocHost(int64(n) * cu.SIZEOF_FLOAT32)
+}
+
+// returns a pinned buffer of n floats to the pool.
+func pinnedFree(p unsafe.Pointer, n int) {
+	pinnedLock.Lock()
+	defer pinnedLock.Unlock()
+	if len(pinnedPool[n]) >= pinnedMax {
+		cu.MemFreeHost(p)
+		return
+	}
+	pinnedPool[n] = append(pinnedPool[n], p)
+}
+
+// Makes the CUDA context current on the calling goroutine,
+// which gets locked to its OS thread if it was not already.
+// Only threads locked this way (or by Init) have the context current.
+func lockContext() {
+	if cu.CtxGetCurrent() == cudaCtx {
+		return
+	}
+	runtime.LockOSThread()
+	cudaCtx.SetCurrent()
+}
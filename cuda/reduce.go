@@ -78,8 +78,7 @@ func reduceBuf(initVal float32) unsafe.Pointer {
 
 // copy back single float result from GPU and recycle buffer
 func copyback(buf unsafe.Pointer) float32 {
-	var result float32
-	MemCpyDtoH(unsafe.Pointer(&result), buf, cu.SIZEOF_FLOAT32)
+	result := downloadFloat(buf)
 	reduceBuffers <- buf
 	return result
 }
@@ -93,10 +93,8 @@ func SetElem(s *data.Slice, comp int, index int, value float32) {
 }
 
 func GetElem(s *data.Slice, comp int, index int) float32 {
-	var f float32
 	src := unsafe.Pointer(uintptr(s.DevPtr(comp)) + uintptr(index)*cu.SIZEOF_FLOAT32)
-	MemCpyDtoH(unsafe.Pointer(&f), src, cu.SIZEOF_FLOAT32)
-	return f
+	return downloadFloat(src)
 }
 
 func GetCell(s *data.Slice, comp, ix, iy, iz int) float32 {
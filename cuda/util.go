@@ -14,6 +14,13 @@ const (
 	MaxGridSize  = 65535
 )
 
+// Launch parameters of the madd and exchange kernels, may be changed by Autotune.
+// Other kernels use the defaults.
+var (
+	blockSize    = BlockSize
+	tileX, tileY = TileX, TileY
+)
+
 // cuda launch configuration
 type config struct {
 	Grid, Block cu.Dim3
@@ -21,9 +28,18 @@ type config struct {
 
 // Make a 1D kernel launch configuration suited for N threads.
 func make1DConf(N int) *config {
-	bl := cu.Dim3{X: BlockSize, Y: 1, Z: 1}
+	return conf1D(N, BlockSize)
+}
+
+// make1DConf with the autotuned block size, for the madd kernels.
+func tuned1DConf(N int) *config {
+	return conf1D(N, blockSize)
+}
 
-	n2 := divUp(N, BlockSize) // N2 blocks left
+func conf1D(N, blockSize int) *config {
+	bl := cu.Dim3{X: blockSize, Y: 1, Z: 1}
+
+	n2 := divUp(N, blockSize) // N2 blocks left
 	nx := divUp(n2, MaxGridSize)
 	ny := divUp(n2, nx)
 	gr := cu.Dim3{X: nx, Y: ny, Z: 1}
@@ -33,10 +49,19 @@ func make1DConf(N int) *config {
 
 // Make a 3D kernel launch configuration suited for N threads.
 func make3DConf(N [3]int) *config {
-	bl := cu.Dim3{X: TileX, Y: TileY, Z: 1}
+	return conf3D(N, TileX, TileY)
+}
+
+// make3DConf with the autotuned tile size, for the exchange kernels.
+func tuned3DConf(N [3]int) *config {
+	return conf3D(N, tileX, tileY)
+}
+
+func conf3D(N [3]int, tileX, tileY int) *config {
+	bl := cu.Dim3{X: tileX, Y: tileY, Z: 1}
 
-	nx := divUp(N[X], TileX)
-	ny := divUp(N[Y], TileY)
+	nx := divUp(N[X], tileX)
+	ny := divUp(N[Y], tileY)
 	gr := cu.Dim3{X: nx, Y: ny, Z: N[Z]}
 
 	return &config{gr, bl}
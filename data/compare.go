@@ -0,0 +1,68 @@
+package data
+
+import (
+	"fmt"
+	"math"
+)
+
+// Difference between two fields, as returned by Compare.
+type Diff struct {
+	MaxAbs []float64 // maximum absolute difference, per component
+	RMS    []float64 // root mean square difference, per component
+	MaxLen float64   // maximum length of the difference vector
+	N      int       // number of compared cells
+}
+
+// Compares fields a and b (in CPU memory) over all cells where mask is nonzero.
+// mask is a scalar field of the same size, or nil to compare all cells.
+func Compare(a, b, mask *Slice) (Diff, error) {
+	if a.NComp() != b.NComp() || a.Size() != b.Size() {
+		return Diff{}, fmt.Errorf("compare: size mismatch: %v components %v vs %v components %v", a.NComp(), a.Size(), b.NComp(), b.Size())
+	}
+	if mask != nil && (mask.NComp() != 1 || mask.Size() != a.Size()) {
+		return Diff{}, fmt.Errorf("compare: mask should be scalar with size %v, have %v components %v", a.Size(), mask.NComp(), mask.Size())
+	}
+
+	ncomp := a.NComp()
+	A, B := a.Host(), b.Host()
+	var M []float32
+	if mask != nil {
+		M = mask.Host()[0]
+	}
+
+	d := Diff{MaxAbs: make([]float64, ncomp), RMS: make([]float64, ncomp)}
+	for i := 0; i < a.Len(); i++ {
+		if M != nil && M[i] == 0 {
+			continue
+		}
+		d.N++
+		len2 := 0.0
+		for c := 0; c < ncomp; c++ {
+			delta := float64(A[c][i]) - float64(B[c][i])
+			d.MaxAbs[c] = math.Max(d.MaxAbs[c], math.Abs(delta))
+			d.RMS[c] += delta * delta
+			len2 += delta * delta
+		}
+		d.MaxLen = math.Max(d.MaxLen, math.Sqrt(len2))
+	}
+	for c := range d.RMS {
+		if d.N > 0 {
+			d.RMS[c] = math.Sqrt(d.RMS[c] / float64(d.N))
+		}
+	}
+	return d, nil
+}
+
+// Reports whether the maximum difference of all components is at most tol.
+func (d Diff) Within(tol float64) bool {
+	for _, m := range d.MaxAbs {
+		if !(m <= tol) { // also catches NaN
+			return false
+		}
+	}
+	return true
+}
+
+func (d Diff) String() string {
+	return fmt.Sprintf("max: %v rms: %v (%v cells)", d.MaxAbs, d.RMS, d.N)
+}
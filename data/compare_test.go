@@ -0,0 +1,54 @@
+package data
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	size := [3]int{4, 3, 2}
+	a := NewSlice(3, size)
+	b := NewSlice(3, size)
+	mask := NewSlice(1, size)
+
+	b.Set(0, 1, 1, 0, 0.5)
+	b.Set(2, 1, 1, 0, -0.5)
+	b.Set(1, 3, 2, 1, 2)
+
+	d, err := Compare(a, b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.N != 24 {
+		t.Error("N:", d.N)
+	}
+	if d.MaxAbs[0] != 0.5 || d.MaxAbs[1] != 2 || d.MaxAbs[2] != 0.5 {
+		t.Error("MaxAbs:", d.MaxAbs)
+	}
+	if math.Abs(d.RMS[1]-math.Sqrt(4./24.)) > 1e-12 {
+		t.Error("RMS:", d.RMS)
+	}
+	if d.MaxLen != 2 {
+		t.Error("MaxLen:", d.MaxLen)
+	}
+	if d.Within(1) || !d.Within(2) {
+		t.Error("Within:", d)
+	}
+
+	// only the cell with the x,z difference
+	mask.SetScalar(1, 1, 0, 1)
+	d, err = Compare(a, b, mask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.N != 1 || d.MaxAbs[1] != 0 || math.Abs(d.MaxLen-math.Sqrt(0.5)) > 1e-12 {
+		t.Error("masked:", d, d.MaxLen)
+	}
+
+	if _, err := Compare(a, NewSlice(3, [3]int{4, 3, 1}), nil); err == nil {
+		t.Error("size mismatch: expected error")
+	}
+	if _, err := Compare(a, b, NewSlice(1, [3]int{2, 3, 2})); err == nil {
+		t.Error("mask size mismatch: expected error")
+	}
+}
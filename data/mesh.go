@@ -7,10 +7,12 @@ import (
 
 // Mesh stores info of a finite-difference mesh.
 type Mesh struct {
-	gridSize [3]int
-	cellSize [3]float64
-	pbc      [3]int
-	Unit     string // unit of cellSize, default: "m"
+	gridSize  [3]int
+	cellSize  [3]float64
+	pbc       [3]int
+	origin    [3]float64 // world position of the lower corner, if originSet
+	originSet bool
+	Unit      string // unit of cellSize, default: "m"
 }
 
 // Retruns a new mesh with N0 x N1 x N2 cells of size cellx x celly x cellz.
@@ -26,7 +28,7 @@ func NewMesh(N0, N1, N2 int, cellx, celly, cellz float64, pbc ...int) *Mesh {
 		}
 	}
 	size := [3]int{N0, N1, N2}
-	return &Mesh{size, [3]float64{cellx, celly, cellz}, pbc3, "m"}
+	return &Mesh{gridSize: size, cellSize: [3]float64{cellx, celly, cellz}, pbc: pbc3, Unit: "m"}
 }
 
 // Returns N0, N1, N2, as passed to constructor.
@@ -38,6 +40,10 @@ func (m *Mesh) Size() [3]int {
 	}
 }
 
+// TODO: graded cell sizes along z (a thickness per layer). The demag kernel
+// then depends on the pair of layers, not only on their offset, and the
+// exchange, DMI and Zhang-Li kernels need the distance to each neighbor layer.
+
 // Returns cellx, celly, cellz, as passed to constructor.
 func (m *Mesh) CellSize() [3]float64 {
 	return m.cellSize
@@ -52,6 +58,24 @@ func (m *Mesh) SetPBC(nx, ny, nz int) {
 	m.pbc = [3]int{nx, ny, nz}
 }
 
+// Sets the world position of the lower corner of the mesh (the corner of cell 0,0,0).
+// Meshes of different parts of a study can so share one coordinate frame.
+func (m *Mesh) SetOrigin(x, y, z float64) {
+	m.origin = [3]float64{x, y, z}
+	m.originSet = true
+}
+
+// Returns the world position of the lower corner of the mesh, as passed to SetOrigin,
+// or 0,0,0 if no origin was set.
+func (m *Mesh) Origin() [3]float64 {
+	return m.origin
+}
+
+// Returns whether an origin was set with SetOrigin.
+func (m *Mesh) HasOrigin() bool {
+	return m.originSet
+}
+
 // Total number of cells, not taking into account PBCs.
 // 	N0 * N1 * N2
 func (m *Mesh) NCell() int {
@@ -86,7 +110,12 @@ func (m *Mesh) String() string {
 	if m.pbc != [3]int{0, 0, 0} {
 		pbc = fmt.Sprintf(", PBC: [%v x %v x %v],", m.pbc[0], m.pbc[1], m.pbc[2])
 	}
-	return fmt.Sprintf("[%v x %v x %v] x [%vm x %vm x %vm]%v", s[0], s[1], s[2], float32(c[0]), float32(c[1]), float32(c[2]), pbc)
+	origin := ""
+	if m.originSet {
+		o := m.origin
+		origin = fmt.Sprintf(", origin: (%vm, %vm, %vm)", float32(o[0]), float32(o[1]), float32(o[2]))
+	}
+	return fmt.Sprintf("[%v x %v x %v] x [%vm x %vm x %vm]%v%v", s[0], s[1], s[2], float32(c[0]), float32(c[1]), float32(c[2]), pbc, origin)
 }
 
 // product of elements.
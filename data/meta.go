@@ -1,5 +1,7 @@
 package data
 
+import "fmt"
+
 // Holds meta data to be saved together with a slice.
 // Typically winds up in OVF or DUMP header
 type Meta struct {
@@ -7,4 +9,31 @@ type Meta struct {
 	Time, TimeStep float64
 	CellSize       [3]float64
 	MeshUnit       string
+	Origin         [3]float64 // world position of the lower corner of the mesh (MeshUnit)
+}
+
+// Labels of the ncomp components of the quantity:
+// its name for a scalar, name_x, name_y, name_z for a vector,
+// name_xx, name_xy, name_xz, name_yy, name_yz, name_zz for a symmetric tensor
+// (the upper triangle, as written to VTK), name_0, name_1, ... otherwise.
+func (m Meta) Labels(ncomp int) []string {
+	var suffix []string
+	switch ncomp {
+	case 1:
+		return []string{m.Name}
+	case 3:
+		suffix = []string{"x", "y", "z"}
+	case 6:
+		suffix = []string{"xx", "xy", "xz", "yy", "yz", "zz"}
+	default:
+		suffix = make([]string, ncomp)
+		for i := range suffix {
+			suffix[i] = fmt.Sprint(i)
+		}
+	}
+	l := make([]string, ncomp)
+	for i := range l {
+		l[i] = m.Name + "_" + suffix[i]
+	}
+	return l
 }
@@ -0,0 +1,259 @@
+// package delta stores time series of mumax3 data as delta-encoded frames:
+// each frame holds the bitwise difference (XOR) of its float32 values with the previous frame,
+// byte-shuffled and compressed with DEFLATE, so that slowly varying fields take little space.
+// The encoding is lossless. Every Keyframe'th frame is stored in full, which limits the damage
+// of a corrupt frame. A file holds one quantity: a header written once with Header,
+// followed by frames appended with Encoder.Frame. Reader reconstructs the full frames.
+package delta
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"github.com/mumax/3/data"
+)
+
+const (
+	magic   = "MX3DELTA"
+	version = 1
+	key     = 0 // frame kinds
+	diff    = 1
+)
+
+var order = binary.LittleEndian
+
+// Header of a file holding a time series of quantity info.Name (info.Unit)
+// with ncomp components on a mesh of size cells with cell size info.CellSize and lower corner info.Origin (m).
+func Header(info data.Meta, ncomp int, size [3]int) []byte {
+	var b bytes.Buffer
+	b.WriteString(magic)
+	w := func(v interface{}) { binary.Write(&b, order, v) }
+	w(uint32(version))
+	w(uint32(ncomp))
+	for _, n := range size {
+		w(uint32(n))
+	}
+	w(info.CellSize)
+	w(info.Origin)
+	for _, s := range []string{info.Name, info.Unit} {
+		w(uint16(len(s)))
+		b.WriteString(s)
+	}
+	return b.Bytes()
+}
+
+// Layout of an existing file, as needed to append to it.
+type Layout struct {
+	NComp  int
+	Size   [3]int
+	Frames int // number of complete frames in the file
+	End    int // file offset after the last complete frame
+}
+
+// ReadLayout parses the header of a file written by Header (and Encoder.Frame)
+// and finds its complete frames, without decoding them,
+// so that new frames can be appended after a restart.
+func ReadLayout(file []byte) (Layout, error) {
+	r, err := NewReader(bytes.NewReader(file))
+	if err != nil {
+		return Layout{}, err
+	}
+	l := Layout{NComp: r.ncomp, Size: r.size}
+	// header: magic, version, ncomp, size, cell size, origin and the name and unit strings
+	pos := len(magic) + 4 + 4 + 3*4 + 3*8 + 3*8 + 2 + len(r.info.Name) + 2 + len(r.info.Unit)
+	const frameHeader = 8 + 1 + 4 // time, kind, length
+	for pos+frameHeader <= len(file) {
+		n := int(order.Uint32(file[pos+9:]))
+		if pos+frameHeader+n > len(file) {
+			break
+		}
+		pos += frameHeader + n
+		l.Frames++
+	}
+	l.End = pos
+	return l, nil
+}
+
+// Encodes frames of one time series.
+type Encoder struct {
+	Keyframe int      // store every Keyframe'th frame in full (0: only the first)
+	prev     []uint32 // previous frame
+	n        int      // number of frames so far
+}
+
+// Encoded frame at time t, to be appended to the file.
+func (e *Encoder) Frame(t float64, s *data.Slice) []byte {
+	words := toWords(s)
+	kind := byte(diff)
+	if e.prev == nil || len(e.prev) != len(words) || (e.Keyframe > 0 && e.n%e.Keyframe == 0) {
+		kind = key
+	}
+	enc := make([]uint32, len(words))
+	copy(enc, words)
+	if kind == diff {
+		for i := range enc {
+			enc[i] ^= e.prev[i]
+		}
+	}
+	e.prev = words
+	e.n++
+
+	var z bytes.Buffer
+	zw, _ := flate.NewWriter(&z, flate.BestSpeed)
+	zw.Write(shuffle(enc))
+	zw.Close()
+
+	var b bytes.Buffer
+	binary.Write(&b, order, t)
+	b.WriteByte(kind)
+	binary.Write(&b, order, uint32(z.Len()))
+	b.Write(z.Bytes())
+	return b.Bytes()
+}
+
+// Reads the full frames of a delta file.
+type Reader struct {
+	in    *bufio.Reader
+	info  data.Meta
+	ncomp int
+	size  [3]int
+	prev  []uint32
+}
+
+func NewReader(in io.Reader) (*Reader, error) {
+	r := &Reader{in: bufio.NewReader(in)}
+	m := make([]byte, len(magic))
+	if _, err := io.ReadFull(r.in, m); err != nil {
+		return nil, err
+	}
+	if string(m) != magic {
+		return nil, errors.New("delta: not a delta file")
+	}
+	var h struct {
+		Version, NComp uint32
+		Size           [3]uint32
+		CellSize       [3]float64
+		Origin         [3]float64
+	}
+	if err := binary.Read(r.in, order, &h); err != nil {
+		return nil, err
+	}
+	if h.Version != version {
+		return nil, fmt.Errorf("delta: unsupported version %v", h.Version)
+	}
+	r.ncomp = int(h.NComp)
+	for i := range r.size {
+		r.size[i] = int(h.Size[i])
+	}
+	r.info.CellSize = h.CellSize
+	r.info.Origin = h.Origin
+	r.info.MeshUnit = "m"
+	for _, s := range []*string{&r.info.Name, &r.info.Unit} {
+		var n uint16
+		if err := binary.Read(r.in, order, &n); err != nil {
+			return nil, err
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r.in, b); err != nil {
+			return nil, err
+		}
+		*s = string(b)
+	}
+	return r, nil
+}
+
+// Next full frame, io.EOF after the last one.
+func (r *Reader) Next() (*data.Slice, data.Meta, error) {
+	var h struct {
+		Time float64
+		Kind byte
+		Len  uint32
+	}
+	if err := binary.Read(r.in, order, &h); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF // truncated last frame, e.g. while being written
+		}
+		return nil, data.Meta{}, err
+	}
+	z := make([]byte, h.Len)
+	if _, err := io.ReadFull(r.in, z); err != nil {
+		return nil, data.Meta{}, io.EOF
+	}
+	b, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(z)))
+	if err != nil {
+		return nil, data.Meta{}, err
+	}
+	n := r.ncomp * r.size[0] * r.size[1] * r.size[2]
+	if len(b) != 4*n {
+		return nil, data.Meta{}, fmt.Errorf("delta: frame has %v bytes, want %v", len(b), 4*n)
+	}
+	words := unshuffle(b)
+	switch h.Kind {
+	default:
+		return nil, data.Meta{}, fmt.Errorf("delta: bad frame kind %v", h.Kind)
+	case key:
+	case diff:
+		if r.prev == nil {
+			return nil, data.Meta{}, errors.New("delta: difference frame without preceding frame")
+		}
+		for i := range words {
+			words[i] ^= r.prev[i]
+		}
+	}
+	r.prev = words
+
+	s := data.NewSlice(r.ncomp, r.size)
+	for c := 0; c < r.ncomp; c++ {
+		v := s.Host()[c]
+		w := words[c*len(v):]
+		for i := range v {
+			v[i] = math.Float32frombits(w[i])
+		}
+	}
+	info := r.info
+	info.Time = h.Time
+	return s, info, nil
+}
+
+// all values, component by component, as float32 bits
+func toWords(s *data.Slice) []uint32 {
+	h := s.Host()
+	words := make([]uint32, 0, s.NComp()*s.Len())
+	for _, c := range h {
+		for _, v := range c {
+			words = append(words, math.Float32bits(v))
+		}
+	}
+	return words
+}
+
+// bytes grouped by significance, most significant first:
+// the zero high bytes of small differences form long runs.
+func shuffle(words []uint32) []byte {
+	n := len(words)
+	b := make([]byte, 4*n)
+	for i, w := range words {
+		for j := 0; j < 4; j++ {
+			b[(3-j)*n+i] = byte(w >> (8 * uint(j)))
+		}
+	}
+	return b
+}
+
+func unshuffle(b []byte) []uint32 {
+	n := len(b) / 4
+	words := make([]uint32, n)
+	for i := range words {
+		for j := 0; j < 4; j++ {
+			words[i] |= uint32(b[(3-j)*n+i]) << (8 * uint(j))
+		}
+	}
+	return words
+}
@@ -0,0 +1,97 @@
+package delta
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/mumax/3/data"
+)
+
+func TestRoundTrip(t *testing.T) {
+	size := [3]int{16, 8, 2}
+	info := data.Meta{Name: "m", Unit: "", CellSize: [3]float64{1e-9, 2e-9, 3e-9}, Origin: [3]float64{-8e-9, 0, 1e-9}}
+	var f bytes.Buffer
+	f.Write(Header(info, 3, size))
+
+	// slowly varying frames
+	frames := make([]*data.Slice, 10)
+	enc := Encoder{Keyframe: 4}
+	full := 0
+	for n := range frames {
+		s := data.NewSlice(3, size)
+		for c := range s.Host() {
+			for i := range s.Host()[c] {
+				s.Host()[c][i] = float32(c) + float32(i)*1e-3 + float32(n)*1e-6
+			}
+		}
+		frames[n] = s
+		b := enc.Frame(float64(n)*1e-12, s)
+		if n == 0 {
+			full = len(b)
+		}
+		if n%4 != 0 && len(b) >= full {
+			t.Errorf("frame %v: delta frame (%v bytes) not smaller than key frame (%v bytes)", n, len(b), full)
+		}
+		f.Write(b)
+	}
+
+	r, err := NewReader(&f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n, want := range frames {
+		s, meta, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if meta.Name != "m" || meta.CellSize != info.CellSize || meta.Origin != info.Origin || meta.Time != float64(n)*1e-12 {
+			t.Errorf("frame %v: bad meta: %+v", n, meta)
+		}
+		for c := range want.Host() {
+			for i, v := range want.Host()[c] {
+				if have := s.Host()[c][i]; have != v {
+					t.Fatalf("frame %v, comp %v, cell %v: have %v, want %v", n, c, i, have, v)
+				}
+			}
+		}
+	}
+	if _, _, err := r.Next(); err != io.EOF {
+		t.Errorf("after last frame: have %v, want EOF", err)
+	}
+}
+
+func TestReadLayout(t *testing.T) {
+	// the large mesh gives a file larger than the reader's buffer
+	for _, size := range [][3]int{{4, 3, 1}, {32, 32, 1}} {
+		file := Header(data.Meta{Name: "m", CellSize: [3]float64{1e-9, 1e-9, 1e-9}}, 3, size)
+		enc := Encoder{Keyframe: 2}
+		s := data.NewSlice(3, size)
+		rng := rand.New(rand.NewSource(1))
+		for n := 0; n < 3; n++ {
+			for _, c := range s.Host() {
+				for i := range c {
+					c[i] = rng.Float32()
+				}
+			}
+			file = append(file, enc.Frame(float64(n), s)...)
+		}
+		end := len(file)
+
+		last := enc.Frame(3, s)
+		for _, partial := range []int{0, 5, len(last) - 1} { // none, in the frame header, in the data
+			f := append(append([]byte{}, file...), last[:partial]...)
+			l, err := ReadLayout(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if l.NComp != 3 || l.Size != size || l.Frames != 3 || l.End != end {
+				t.Errorf("size %v, partial %v: have %+v, want 3 frames ending at %v", size, partial, l, end)
+			}
+		}
+	}
+	if _, err := ReadLayout([]byte("not a delta file")); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,43 @@
+package dump
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mumax/3/data"
+)
+
+func TestRoundTrip(t *testing.T) {
+	s := data.NewSlice(3, [3]int{3, 2, 1})
+	for c := range s.Host() {
+		for i := range s.Host()[c] {
+			s.Host()[c][i] = float32(10*c + i)
+		}
+	}
+	meta := data.Meta{Name: "m", Unit: "T", Time: 1e-9, CellSize: [3]float64{1e-9, 2e-9, 3e-9}, MeshUnit: "m"}
+
+	for origin, magic := range map[[3]float64]string{{}: MAGIC_002, {-5e-9, 1e-9, 2e-9}: MAGIC} {
+		meta.Origin = origin
+		var buf bytes.Buffer
+		if err := Write(&buf, s, meta); err != nil {
+			t.Fatal(err)
+		}
+		if have := buf.String()[:8]; have != magic {
+			t.Errorf("origin %v: magic %v, want %v", origin, have, magic)
+		}
+
+		s2, meta2, err := Read(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if meta2 != meta {
+			t.Errorf("meta: have %+v, want %+v", meta2, meta)
+		}
+		if l := meta2.Labels(s2.NComp()); l[0] != "m_x" || l[2] != "m_z" {
+			t.Errorf("labels: have %v", l)
+		}
+		if s2.Size() != s.Size() || s2.Host()[2][5] != s.Host()[2][5] {
+			t.Errorf("data: have %v", s2.Host())
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package dump
+
+// mumax2 compatibility.
+// mumax2 stored its data in ZYX order internally, and wrote dump files that way:
+// the sizes and cell sizes in the header are in ZYX order like in mumax3 dump files,
+// but the vector components are stored as (z, y, x) as well.
+// (mumax2's OVF files are in the usual XYZ order and can be read with oommf.Read.)
+// mumax2's .tensor output is not supported.
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/mumax/3/data"
+)
+
+// Reads a dump file written by mumax2, with vector components converted to XYZ order.
+func ReadMumax2(in io.Reader) (*data.Slice, data.Meta, error) {
+	s, info, err := Read(in)
+	if err != nil {
+		return nil, info, err
+	}
+	SwapMumax2(s)
+	return s, info, nil
+}
+
+func ReadMumax2File(fname string) (*data.Slice, data.Meta, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, data.Meta{}, err
+	}
+	defer f.Close()
+	return ReadMumax2(f)
+}
+
+// Converts the vector components of s between mumax2's ZYX and XYZ order, in place.
+// Scalar data is left unchanged.
+func SwapMumax2(s *data.Slice) {
+	if s.NComp() != 3 {
+		return
+	}
+	x, z := s.Host()[0], s.Host()[2]
+	for i := range x {
+		x[i], z[i] = z[i], x[i]
+	}
+}
+
+// Format of a mumax2 output file, from its extension: "dump" or "ovf".
+func Mumax2Format(fname string) (string, error) {
+	switch strings.ToLower(path.Ext(fname)) {
+	case ".dump":
+		return "dump", nil
+	case ".omf", ".ovf":
+		return "ovf", nil
+	case ".tensor":
+		return "", fmt.Errorf("%v: mumax2 .tensor files are not supported, save m as dump or omf in mumax2", fname)
+	default:
+		return "", fmt.Errorf("%v: not a mumax2 dump or omf file", fname)
+	}
+}
@@ -0,0 +1,52 @@
+package dump
+
+import (
+	"testing"
+)
+
+// testdata/mumax2.dump is laid out as mumax2 writes dump files: #dump002 header,
+// sizes and cell sizes in ZYX order and vector components stored as z, y, x.
+// ../oommf/testdata/mumax2.omf holds the same field as mumax2 writes OMF files, in XYZ order.
+func TestReadMumax2(t *testing.T) {
+	s, info, err := ReadMumax2File("testdata/mumax2.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.NComp() != 3 || s.Size() != [3]int{3, 2, 1} {
+		t.Fatalf("have %v components of size %v, want 3 of [3 2 1]", s.NComp(), s.Size())
+	}
+	if info.CellSize != [3]float64{5e-9, 4e-9, 3e-9} || info.Time != 2e-9 || info.Name != "m" {
+		t.Errorf("bad meta: %+v", info)
+	}
+	m := s.Host()
+	for i := 0; i < s.Len(); i++ {
+		want := [3]float32{0.1 * float32(i+1), 0.2, -0.05 * float32(i)}
+		for c := range want {
+			if d := m[c][i] - want[c]; d > 1e-6 || d < -1e-6 {
+				t.Errorf("cell %v component %v: have %v, want %v", i, c, m[c][i], want[c])
+			}
+		}
+	}
+
+	// read as a mumax3 dump, the components come out reversed
+	s3, _, err := ReadFile("testdata/mumax2.dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s3.Host()[0][1] != m[2][1] || s3.Host()[2][1] != m[0][1] {
+		t.Error("SwapMumax2 did not swap x and z")
+	}
+}
+
+func TestMumax2Format(t *testing.T) {
+	for fname, want := range map[string]string{"m000000.dump": "dump", "m000000.omf": "ovf", "m.OVF": "ovf"} {
+		if have, err := Mumax2Format(fname); err != nil || have != want {
+			t.Errorf("%v: have %v, %v, want %v", fname, have, err, want)
+		}
+	}
+	for _, fname := range []string{"m000000.tensor", "table.txt"} {
+		if _, err := Mumax2Format(fname); err == nil {
+			t.Errorf("%v: expected error", fname)
+		}
+	}
+}
@@ -53,7 +53,7 @@ func (r *reader) readSlice() (s *data.Slice, info data.Meta, err error) {
 	if r.err != nil {
 		return nil, data.Meta{}, r.err
 	}
-	if magic != MAGIC {
+	if magic != MAGIC && magic != MAGIC_002 {
 		r.err = fmt.Errorf("dump: bad magic number:%v", magic)
 		return nil, data.Meta{}, r.err
 	}
@@ -67,6 +67,11 @@ func (r *reader) readSlice() (s *data.Slice, info data.Meta, err error) {
 	cell[1] = r.readFloat64()
 	cell[0] = r.readFloat64()
 	info.CellSize = cell
+	if magic == MAGIC {
+		info.Origin[2] = r.readFloat64()
+		info.Origin[1] = r.readFloat64()
+		info.Origin[0] = r.readFloat64()
+	}
 
 	info.MeshUnit = r.readString()
 	info.Time = r.readFloat64()
@@ -16,8 +16,13 @@ import (
 func Write(out io.Writer, s *data.Slice, info data.Meta) error {
 	w := newWriter(out)
 
-	// Writes the header.
-	w.writeString(MAGIC)
+	// Writes the header. Without an origin, stay readable by older versions.
+	origin := info.Origin
+	magic := MAGIC
+	if origin == [3]float64{} {
+		magic = MAGIC_002
+	}
+	w.writeString(magic)
 	w.writeUInt64(uint64(s.NComp()))
 	size := s.Size()
 	w.writeUInt64(uint64(size[2])) // backwards compatible coordinates!
@@ -27,6 +32,11 @@ func Write(out io.Writer, s *data.Slice, info data.Meta) error {
 	w.writeFloat64(cell[2])
 	w.writeFloat64(cell[1])
 	w.writeFloat64(cell[0])
+	if magic == MAGIC {
+		w.writeFloat64(origin[2])
+		w.writeFloat64(origin[1])
+		w.writeFloat64(origin[0])
+	}
 	w.writeString(info.MeshUnit)
 	w.writeFloat64(info.Time)
 	w.writeString("s") // time unit
@@ -77,7 +87,10 @@ func newWriter(out io.Writer) *writer {
 	return w
 }
 
-const MAGIC = "#dump002" // identifies dump format
+const (
+	MAGIC     = "#dump003" // identifies dump format with an origin
+	MAGIC_002 = "#dump002" // format without origin, written when the origin is zero
+)
 
 // Writes the data.
 func (w *writer) writeData(array *data.Slice) {
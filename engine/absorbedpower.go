@@ -0,0 +1,27 @@
+package engine
+
+// Power absorbed from the applied field, for spatially resolved FMR.
+// Averaged over whole periods of a harmonic drive, the instantaneous
+// B_ext·dM/dt gives the absorbed power density, e.g.:
+//
+//	P := RunningAverage(ext_absorbedpower, 1/f)
+
+import (
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+)
+
+var Ext_AbsorbedPower = NewScalarField("ext_absorbedpower", "W/m3", "Power density delivered by the applied field B_ext·dM/dt, average over drive periods with RunningAverage", SetAbsorbedPower)
+
+func SetAbsorbedPower(dst *data.Slice) {
+	B := ValueOf(B_ext)
+	defer cuda.Recycle(B)
+	torque := ValueOf(Torque) // dm/dt / γ
+	defer cuda.Recycle(torque)
+	ms := ValueOf(Msat)
+	defer cuda.Recycle(ms)
+
+	cuda.Zero(dst)
+	cuda.AddDotProduct(dst, float32(GammaLL), B, torque)
+	cuda.Mul(dst, dst, ms)
+}
@@ -0,0 +1,87 @@
+package engine
+
+// Analysis plugins for programs embedding mumax3: Go code computing table columns
+// from the saved data of one quantity, e.g. spectra or domain wall positions,
+// each running on its own goroutine concurrently with the simulation and the output:
+//
+//	AddAnalysis("wallpos", "m", wallTracker{})
+//	OutputFiles = false // analyze only
+//	Eval("AutoSave(m, 1e-12)")
+//
+// writes a row of the analysis columns, preceded by the time, to wallpos.txt
+// for every saved frame of m, avoiding a separate post-processing pass.
+// Analyses receive the data through an output consumer (see outputconsumer.go).
+
+import (
+	"sync"
+
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+// Computes one row of an analysis table from saved data.
+// Analyze is called on the analysis' own goroutine, for each frame in the order they were saved.
+// s is shared with other consumers and must not be modified. Analyze must not call into the engine.
+type Analysis interface {
+	Columns() []string                               // column headers with unit, e.g. "x (m)"
+	Analyze(s *data.Slice, info data.Meta) []float64 // one value per column
+}
+
+const maxAnalysisQueLen = 16 // frames that can wait for an analysis before output blocks
+
+var analyses []*analysisWorker
+
+type analysisWorker struct {
+	name    string
+	a       Analysis
+	in      chan analysisJob
+	pending sync.WaitGroup // frames queued or being analyzed
+	out     httpfs.WriteCloseFlusher
+}
+
+type analysisJob struct {
+	s    *data.Slice
+	info data.Meta
+}
+
+// Runs a on every frame of the named quantity saved from now on, writing its results to name.txt.
+func AddAnalysis(name, quantity string, a Analysis) {
+	out, err := httpfs.Create(OD() + name + ".txt")
+	util.FatalErr(err)
+	fprint(out, "# t (s)")
+	for _, c := range a.Columns() {
+		fprint(out, "\t", c)
+	}
+	fprintln(out)
+
+	w := &analysisWorker{name: name, a: a, in: make(chan analysisJob, maxAnalysisQueLen), out: out}
+	analyses = append(analyses, w)
+	go w.run()
+	AddOutputConsumer(func(fname string, s *data.Slice, info data.Meta) {
+		if info.Name == quantity {
+			w.pending.Add(1)
+			w.in <- analysisJob{s, info}
+		}
+	})
+}
+
+func (w *analysisWorker) run() {
+	for j := range w.in {
+		row := w.a.Analyze(j.s, j.info)
+		fprint(w.out, j.info.Time)
+		for _, v := range row {
+			fprint(w.out, "\t", float32(v))
+		}
+		fprintln(w.out)
+		w.out.Flush()
+		w.pending.Done()
+	}
+}
+
+// waits until all queued frames have been analyzed.
+func drainAnalyses() {
+	for _, w := range analyses {
+		w.pending.Wait()
+	}
+}
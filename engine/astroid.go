@@ -0,0 +1,107 @@
+package engine
+
+// Switching field measurements as a function of the applied field angle,
+// e.g. to construct the Stoner-Wohlfarth astroid.
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+func init() {
+	DeclFunc("SwitchingField", SwitchingField, "Ramp B_ext along dir from 0 to Bmax (T) in N steps, minimizing at each step. Returns the field where m.u changes sign, NaN if it does not switch")
+	DeclFunc("Astroid", Astroid, "Measure SwitchingField for N field angles from easy axis u to hard axis v, output in astroid.txt")
+}
+
+// Ramps B_ext along dir in nfield steps up to Bmax, minimizing the energy at each step,
+// starting from the current magnetization. Returns the first field magnitude for which
+// the sign of <m>.u differs from the initial one, or NaN if this does not happen.
+// The magnetization and B_ext are restored afterwards.
+func SwitchingField(u, dir data.Vector, Bmax float64, nfield int) float64 {
+	checkMesh()
+	util.Argument(nfield > 0)
+	u = u.Div(u.Len())
+	dir = dir.Div(dir.Len())
+
+	m0 := cuda.Buffer(3, Mesh().Size())
+	defer cuda.Recycle(m0)
+	data.Copy(m0, M.Buffer())
+	defer M.SetArray(m0)
+	defer restoreExcitation(B_ext, saveExcitation(B_ext))
+
+	s0 := math.Signbit(M.Average().Dot(u))
+	for i := 1; i <= nfield; i++ {
+		B := Bmax * float64(i) / float64(nfield)
+		B_ext.Set(dir.Mul(B))
+		Minimize()
+		if math.Signbit(M.Average().Dot(u)) != s0 {
+			return B
+		}
+	}
+	return math.NaN()
+}
+
+// Measures the switching field for nangles field directions between easy axis u (0°)
+// and hard axis v (90°), each time starting from the current magnetization,
+// which should be saturated along -u. Writes angle, switching field and
+// its components along u and v to astroid.txt in the output directory.
+// The end points are tilted by astroidTilt towards the inside: a field exactly
+// antiparallel to m exerts no torque, and along the hard axis m.u does not change sign.
+func Astroid(u, v data.Vector, Bmax float64, nangles, nfield int) {
+	util.Argument(nangles > 1)
+	u = u.Div(u.Len())
+	v = v.Div(v.Len())
+
+	out, err := httpfs.Create(OD() + "astroid.txt")
+	util.FatalErr(err)
+	defer out.Close()
+	fmt.Fprintln(out, "# angle (deg)\tBsw (T)\tBu (T)\tBv (T)")
+
+	for i := 0; i < nangles; i++ {
+		theta := float64(i) / float64(nangles-1) * math.Pi / 2
+		theta = math.Min(math.Max(theta, astroidTilt), math.Pi/2-astroidTilt)
+		dir := u.Mul(math.Cos(theta)).Add(v.Mul(math.Sin(theta)))
+		Bsw := SwitchingField(u, dir, Bmax, nfield)
+		fmt.Fprintf(out, "%g\t%g\t%g\t%g\n", theta*180/math.Pi, Bsw, Bsw*math.Cos(theta), Bsw*math.Sin(theta))
+		LogOut(fmt.Sprintf("astroid: angle %.4g deg: switching field %.4g T", theta*180/math.Pi, Bsw))
+	}
+}
+
+// tilt of the first and last field direction of Astroid away from the axes (rad)
+const astroidTilt = 0.5 * math.Pi / 180
+
+// region values and time dependences of a region-wise parameter
+type paramState struct {
+	values [NREGION][]float64
+	funcs  [NREGION]func() []float64
+}
+
+func saveParam(p *regionwise) *paramState {
+	s := &paramState{funcs: p.upd_reg}
+	for r := range s.values {
+		s.values[r] = p.getRegion(r)
+	}
+	return s
+}
+
+func restoreParam(p *regionwise, s *paramState) {
+	for r := range s.values {
+		p.bufset_(r, s.values[r])
+	}
+	p.upd_reg = s.funcs
+	p.invalidate()
+}
+
+// state of an excitation's region-wise part
+func saveExcitation(e *Excitation) *paramState {
+	return saveParam(&e.perRegion.regionwise)
+}
+
+func restoreExcitation(e *Excitation, s *paramState) {
+	restoreParam(&e.perRegion.regionwise, s)
+}
@@ -11,8 +11,9 @@ import (
 // See save.go, autosave.go
 
 var (
-	saveQue chan func() // passes save requests to runSaver for asyc IO
-	queLen  util.Atom   // # tasks in queue
+	saveQue chan func()                 // passes save requests to runSaver for asyc IO
+	queLen  util.Atom                   // # tasks in queue
+	saveErr = make(chan interface{}, 1) // first panic of a queued task, re-raised on the main goroutine
 )
 
 const maxOutputQueLen = 16 // number of outputs that can be queued for asynchronous I/O.
@@ -38,13 +39,38 @@ func queOutput(f func()) {
 // Continuously executes tasks the from SaveQue channel.
 func runSaver() {
 	for f := range saveQue {
-		f()
+		runQueued(f)
+	}
+}
+
+// runs a queued task. A panic, e.g. a lost GPU during a download,
+// is passed to the main goroutine, which re-raises it in checkOutputErr
+// so that it can be handled there (e.g. by -failover).
+func runQueued(f func()) {
+	defer func() {
+		if err := recover(); err != nil {
+			select {
+			case saveErr <- err:
+			default: // keep the first
+			}
+		}
 		queLen.Add(-1)
+	}()
+	f()
+}
+
+// re-raises the panic of a queued output task, if any.
+// Called by the main goroutine between time steps and after flushing output.
+func checkOutputErr() {
+	select {
+	case err := <-saveErr:
+		panic(err)
+	default:
 	}
 }
 
 // Finalizer function called upon program exit.
-// Waits until all asynchronous output has been saved.
+// Waits until all asynchronous output has been saved and analyzed.
 func drainOutput() {
 	if saveQue == nil {
 		return
@@ -54,8 +80,9 @@ func drainOutput() {
 		default:
 			time.Sleep(1 * time.Millisecond) // other goroutine has the last job, wait for it to finish
 		case f := <-saveQue:
-			f()
-			queLen.Add(-1)
+			runQueued(f)
 		}
 	}
+	drainAnalyses()
+	checkOutputErr()
 }
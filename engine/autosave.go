@@ -2,16 +2,37 @@ package engine
 
 // Bookkeeping for auto-saving quantities at given intervals.
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+)
 
 var (
-	output  = make(map[Quantity]*autosave) // when to save quantities
-	autonum = make(map[interface{}]int)    // auto number for out file
+	output         = make(map[Quantity]*autosave)     // when to save quantities
+	outputAt       = make(map[Quantity]*saveAt)       // save quantities at given times
+	outputIf       = make(map[Quantity]*saveIf)       // save quantities when a condition changes
+	outputOnChange = make(map[Quantity]*saveOnChange) // save quantities when they changed enough
+	autonum        = make(map[interface{}]int)        // auto number for out file
+	subdirs        = make(map[string]bool)            // output subdirectories already created
+	ExactOutput    = false                            // shorten time steps to land on output times
 )
 
 func init() {
 	DeclFunc("AutoSave", AutoSave, "Auto save space-dependent quantity every period (s).")
+	DeclFunc("AutoSaveFrom", AutoSaveFrom, "Auto save space-dependent quantity every period (s), starting at time start (s). E.g.: AutoSaveFrom(B_demag, 100e-12, 50e-12)")
+	DeclVar("ExactOutput", &ExactOutput, "Shorten time steps so that AutoSave, SaveAt and TableAutoSave outputs happen exactly on time (default false)")
 	DeclFunc("AutoSnapshot", AutoSnapshot, "Auto save image of quantity every period (s).")
+	DeclFunc("SaveAt", SaveAt, "Save space-dependent quantity at the given times (s). E.g.: SaveAt(m, 1e-9, 2e-9, 5e-9)")
+	DeclFunc("AutoSaveIf", AutoSaveIf, "Save space-dependent quantity each time the condition changes value. E.g.: AutoSaveIf(m, m.comp(2).average() > 0)")
+	DeclFunc("AutoSaveOnChange", AutoSaveOnChange, "Save space-dependent quantity each time its rms difference from the last saved value exceeds threshold (0 stops)")
 }
 
 // Periodically called by run loop to save everything that's needed at this time.
@@ -22,6 +43,21 @@ func DoOutput() {
 			a.count++
 		}
 	}
+	for q, a := range outputAt {
+		if a.needSave() {
+			Save(q)
+		}
+	}
+	for q, a := range outputIf {
+		if a.needSave() {
+			Save(q)
+		}
+	}
+	for q, a := range outputOnChange {
+		if a.needSave(q) {
+			Save(q)
+		}
+	}
 	if Table.needSave() {
 		Table.Save()
 	}
@@ -33,6 +69,19 @@ func AutoSave(q Quantity, period float64) {
 	autoSave(q, period, Save)
 }
 
+// Register quant to be auto-saved every period, starting at time start
+// (which may be in the past). Quantities with different periods and starts,
+// e.g. m every 10 ps and B_demag every 100 ps from 50 ps on, are saved independently.
+func AutoSaveFrom(q Quantity, period, start float64) {
+	AutoSave(q, period)
+	if a, ok := output[q]; ok {
+		a.start = start
+		if start < Time {
+			a.count = int(math.Ceil((Time-start)/period)) - 1 // next save at the first time >= now
+		}
+	}
+}
+
 // Register quant to be auto-saved as image, every period.
 func AutoSnapshot(q Quantity, period float64) {
 	autoSave(q, period, Snapshot)
@@ -47,10 +96,105 @@ func autoSave(q Quantity, period float64, save func(Quantity)) {
 	}
 }
 
-// generate auto file name based on save count and FilenameFormat. E.g.:
-// 	m000001.ovf
-func autoFname(name string, format OutputFormat, num int) string {
-	return fmt.Sprintf(OD()+FilenameFormat+"."+StringFromOutputFormat[format], name, num)
+// Register quant to be saved at the given times (s).
+// It is saved at the first time step at or after each time, at most once per step.
+// Times in the past are ignored. Without times, stops saving at times.
+func SaveAt(q Quantity, times ...interface{}) {
+	if len(times) == 0 {
+		delete(outputAt, q)
+		return
+	}
+	t := make([]float64, 0, len(times))
+	for _, x := range times {
+		switch x := x.(type) {
+		case float64:
+			t = append(t, x)
+		case int:
+			t = append(t, float64(x))
+		default:
+			panic(UserErr(fmt.Sprintf("SaveAt: times should be numbers, have: %v (%T)", x, x)))
+		}
+	}
+	sort.Float64s(t)
+	a := &saveAt{times: t}
+	for a.next < len(t) && t[a.next] < Time {
+		a.next++
+	}
+	outputAt[q] = a
+}
+
+// Register quant to be saved each time condition changes value (edge-triggered),
+// e.g. to save m whenever <mz> crosses zero. A nil condition stops saving.
+func AutoSaveIf(q Quantity, condition func() bool) {
+	if condition == nil {
+		delete(outputIf, q)
+		return
+	}
+	outputIf[q] = &saveIf{cond: condition, last: condition()}
+}
+
+// Register quant to be saved each time its rms difference per cell with the last saved value
+// exceeds threshold (in the unit of quant), and once right away.
+// Quiescent phases of a run then produce no output. Threshold 0 stops saving on change.
+func AutoSaveOnChange(q Quantity, threshold float64) {
+	if a, ok := outputOnChange[q]; ok {
+		a.free()
+		delete(outputOnChange, q)
+	}
+	if threshold != 0 {
+		outputOnChange[q] = &saveOnChange{threshold: threshold}
+	}
+}
+
+// generate auto file name based on number and FilenameFormat, e.g. m000001.ovf.
+// With OutputSubdirs, the file is placed in a subdirectory named after the quantity.
+func autoFname(name string, ext string, num int) string {
+	name = fnameSafe(name)
+	return fmt.Sprintf(autoDir(name)+FilenameFormat+"."+ext, name, num)
+}
+
+// file name for formats that append all saves of a quantity to one file (or store), e.g. m.nc.
+// Named and placed like autoFname, without number.
+func seriesFname(name string, ext string) string {
+	name = fnameSafe(name)
+	return autoDir(name) + name + "." + ext
+}
+
+// output directory for the auto-saved files of (file-safe) name.
+func autoDir(name string) string {
+	dir := OD()
+	if OutputSubdirs {
+		dir += name + "/"
+		if !subdirs[dir] {
+			_ = httpfs.Mkdir(dir) // may already exist
+			subdirs[dir] = true
+		}
+	}
+	return dir
+}
+
+// quantity name with characters other than letters, digits, '_', '-' and '.' replaced by '_',
+// so that e.g. cropped or region-restricted quantities give valid file names.
+func fnameSafe(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.') {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// number for the next auto filename of q, according to FilenameNumber.
+func autoNumber(q Quantity, ext string) int {
+	switch FilenameNumber {
+	default:
+		resumeNumber(q, ext)
+		return autonum[q]
+	case "step":
+		return NSteps
+	case "ps":
+		return int(Time*1e12 + 0.5)
+	}
 }
 
 // keeps info needed to decide when a quantity needs to be periodically saved
@@ -63,6 +207,101 @@ type autosave struct {
 
 // returns true when the time is right to save.
 func (a *autosave) needSave() bool {
-	t := Time - a.start
-	return a.period != 0 && t-float64(a.count)*a.period >= a.period
+	return a.period != 0 && Time >= a.next()
+}
+
+// time of the next save
+func (a *autosave) next() float64 {
+	return a.start + float64(a.count+1)*a.period
+}
+
+// keeps the (sorted) list of times at which a quantity needs to be saved
+type saveAt struct {
+	times []float64
+	next  int // index of next time to save
+}
+
+// returns true if one or more save times have been reached since the last save.
+func (a *saveAt) needSave() bool {
+	if a.next >= len(a.times) || Time < a.times[a.next] {
+		return false
+	}
+	for a.next < len(a.times) && Time >= a.times[a.next] {
+		a.next++
+	}
+	return true
+}
+
+// Time of the first scheduled output after the current time (AutoSave, AutoSnapshot,
+// SaveAt and TableAutoSave), or +Inf if there is none.
+// Outputs that are already due (to be saved after this step) are not included.
+func nextOutputTime() float64 {
+	next := math.Inf(1)
+	add := func(t float64) {
+		if t > Time && t < next {
+			next = t
+		}
+	}
+	for _, a := range output {
+		if a.period != 0 {
+			add(a.next())
+		}
+	}
+	for _, a := range outputAt {
+		if a.next < len(a.times) {
+			add(a.times[a.next])
+		}
+	}
+	if Table.autosave.period != 0 {
+		add(Table.autosave.next())
+	}
+	return next
+}
+
+// keeps the last value of a save condition
+type saveIf struct {
+	cond func() bool
+	last bool
+}
+
+// returns true if the condition changed value since the last call.
+func (a *saveIf) needSave() bool {
+	c := a.cond()
+	changed := c != a.last
+	a.last = c
+	return changed
+}
+
+// keeps the last saved value of a quantity saved on change
+type saveOnChange struct {
+	threshold float64
+	last      *data.Slice // nil until first saved
+}
+
+// returns true, and keeps the new value, if q differs from the last saved value
+// by more than the threshold.
+func (a *saveOnChange) needSave(q Quantity) bool {
+	v := ValueOf(q)
+	defer cuda.Recycle(v)
+	if a.last == nil || a.last.Size() != v.Size() {
+		a.free()
+		a.last = cuda.NewSlice(v.NComp(), v.Size())
+	} else {
+		diff := cuda.Buffer(v.NComp(), v.Size())
+		defer cuda.Recycle(diff)
+		cuda.Madd2(diff, v, a.last, 1, -1)
+		rms := math.Sqrt(float64(cuda.Dot(diff, diff)) / float64(v.Len()))
+		if rms <= a.threshold {
+			return false
+		}
+	}
+	data.Copy(a.last, v)
+	return true
+}
+
+func (a *saveOnChange) free() {
+	if a.last != nil {
+		a.last.Free()
+		a.last = nil
+	}
 }
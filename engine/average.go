@@ -7,6 +7,24 @@ import (
 	"github.com/mumax/3/data"
 )
 
+func init() {
+	DeclFunc("Average", Average, "Average of a quantity per component, reduced on the GPU")
+	DeclFunc("AverageRegion", AverageRegion, "Average of a quantity per component in a region, reduced on the GPU")
+}
+
+// Average returns the average of q per component.
+// The reduction is done on the GPU, only the averages are copied back.
+// Magnetization-like quantities are averaged over the geometry, others over the box.
+func Average(q Quantity) []float64 {
+	return AverageOf(q)
+}
+
+// AverageRegion returns the average of q per component, over the given region.
+func AverageRegion(q Quantity, region int) []float64 {
+	defRegionId(region)
+	return (&oneReg{q, region}).average()
+}
+
 // average of quantity over universe
 func qAverageUniverse(q Quantity) []float64 {
 	s := ValueOf(q)
@@ -0,0 +1,45 @@
+package engine
+
+// Backward integration: the magnetization follows its trajectory back in time,
+// dm/dt = -torque, so that damping pumps energy in. Starting near an
+// equilibrium, this climbs towards saddle points, and running forward
+// and then backward tests the reversibility of the solver.
+
+import (
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/util"
+)
+
+var backward bool // integrate dm/dt = -torque
+
+func init() {
+	DeclFunc("RunBackward", RunBackward, "Run the dynamics backward in time for a number of seconds, t decreases by that amount")
+}
+
+// Integrates the LLG equation backward over the given time,
+// after which t is seconds less than before.
+// Time-dependent excitations are evaluated at the time of the forward
+// integration, t0 + (t0 - t), so they should be constant for a true reversal.
+// No output is scheduled during the run, use e.g. a loop over shorter
+// RunBackward calls with TableSave() to record the trajectory.
+func RunBackward(seconds float64) {
+	util.Argument(seconds >= 0)
+	t0 := Time
+	backward = true
+	defer func() { backward = false }()
+	stop := Time + seconds
+	alarm = stop
+	SanityCheck()
+	pause = false
+	runWhile(func() bool { return Time < stop }, false)
+	pause = true
+	Time = t0 - (Time - t0)
+}
+
+// reverses the torque when running backward.
+func reverseTorque(dst *data.Slice) {
+	if backward {
+		cuda.Madd2(dst, dst, dst, -1, 0)
+	}
+}
@@ -38,19 +38,19 @@ func (s *BackwardEuler) Step() {
 	Time = t0 + 0.5*Dt_si // 0.5 dt makes it implicit midpoint method
 
 	// with temperature, previous torque cannot be used as predictor
-	if Temp.isZero() {
+	if !thermalNoise() {
 		cuda.Madd2(y, y0, dy1, 1, dt) // predictor euler step with previous torque
-		M.normalize()
+		M.stepNormalize()
 	}
 
 	torqueFn(dy0)
 	cuda.Madd2(y, y0, dy0, 1, dt) // y = y0 + dt * dy
-	M.normalize()
+	M.stepNormalize()
 
 	// One iteration
 	torqueFn(dy1)
 	cuda.Madd2(y, y0, dy1, 1, dt) // y = y0 + dt * dy1
-	M.normalize()
+	M.stepNormalize()
 
 	Time = t0 + Dt_si
 
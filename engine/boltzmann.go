@@ -0,0 +1,139 @@
+package engine
+
+// Self-test of the thermal field on the actual GPU:
+// an ensemble of independent macrospins with uniaxial anisotropy (no exchange, no demag)
+// at finite temperature should sample the Boltzmann distribution
+//
+//	p(θ) ∝ sin θ exp(σ cos²θ),  σ = Ku1 V / kB T
+//
+// BoltzmannTest sets up its own simulation, so it should be run in a script of its own:
+//
+//	expect("boltzmann", BoltzmannTest(), 0, 0.02)
+//
+// It returns the relative error of the sampled <mz²> and writes the sampled and
+// analytic histograms of mz to boltzmann.txt. The largest relative deviation of
+// a histogram bin is returned by BoltzmannHistogramError() afterwards:
+//
+//	expect("histogram", BoltzmannHistogramError(), 0, 0.1)
+//
+// Successive samples are correlated over the relaxation time of the macrospins
+// (about 0.4 ns), so the effective number of samples is much smaller than the number
+// of snapshots times the number of cells, and the bins need a generous tolerance.
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/mag"
+	"github.com/mumax/3/util"
+)
+
+var (
+	boltzmannSigma   = 2.0    // Ku1 V / kB T of the test
+	boltzmannSamples = 200    // number of sampled snapshots of the ensemble
+	boltzmannBins    = 20     // histogram bins of mz
+	boltzmannEquil   = 2e-9   // equilibration time (s)
+	boltzmannEvery   = 50e-12 // time between samples (s)
+	boltzmannHistErr = 0.     // largest relative deviation of a histogram bin in the last test
+)
+
+func init() {
+	DeclFunc("BoltzmannHistogramError", BoltzmannHistogramError, "Largest relative deviation of a bin of the mz histogram from the Boltzmann distribution, in the last BoltzmannTest")
+	DeclFunc("BoltzmannTest", BoltzmannTest, "Thermal noise self-test: sample an ensemble of macrospins, compare with the Boltzmann distribution. Sets up its own simulation. Returns the relative error of <mz²>")
+}
+
+func BoltzmannTest() float64 {
+	const (
+		n    = 64    // n x n ensemble
+		c    = 5e-9  // cell size (m)
+		Ms   = 1e6   // A/m
+		T    = 300.  // K
+		dt   = 1e-13 // s
+		damp = 0.1
+	)
+	V := c * c * c
+	Ku1 := boltzmannSigma * mag.Kb * T / V
+	LogOut(fmt.Sprintf("BoltzmannTest: %dx%d macrospins, Ku1 V / kB T = %g", n, n, boltzmannSigma))
+	for _, cmd := range []string{
+		fmt.Sprintf("SetGridSize(%d, %d, 1)", n, n),
+		fmt.Sprintf("SetCellSize(%g, %g, %g)", c, c, c),
+		fmt.Sprint("Msat = ", Ms),
+		"Aex = 0",
+		"EnableDemag = false",
+		fmt.Sprint("Ku1 = ", Ku1),
+		"AnisU = vector(0, 0, 1)",
+		"B_ext = vector(0, 0, 0)",
+		fmt.Sprint("alpha = ", damp),
+		fmt.Sprint("Temp = ", T),
+		"m = uniform(0, 0, 1)",
+		fmt.Sprint("SetSolver(", HEUN, ")"),
+		fmt.Sprint("FixDt = ", dt),
+	} {
+		Eval(cmd)
+	}
+	run(boltzmannEquil)
+
+	hist := make([]float64, boltzmannBins) // of mz in [-1, 1]
+	mz2 := 0.
+	for s := 0; s < boltzmannSamples; s++ {
+		run(boltzmannEvery)
+		m := ValueOf(&M)
+		mz := m.Comp(Z).HostCopy().Host()[0]
+		cuda.Recycle(m)
+		for _, v := range mz {
+			mz2 += float64(v * v)
+			b := int((float64(v) + 1) / 2 * float64(boltzmannBins))
+			if b >= boltzmannBins {
+				b = boltzmannBins - 1
+			}
+			if b < 0 {
+				b = 0
+			}
+			hist[b]++
+		}
+	}
+	N := float64(boltzmannSamples * n * n)
+	mz2 /= N
+
+	want, analytic := boltzmannDistribution(boltzmannSigma, boltzmannBins)
+	err := math.Abs(mz2-want) / want
+
+	out, e := httpfs.Create(OD() + "boltzmann.txt")
+	util.FatalErr(e)
+	defer out.Close()
+	fmt.Fprintln(out, "# mz\tsampled ()\tanalytic ()")
+	boltzmannHistErr = 0
+	for b := range hist {
+		mz := -1 + (float64(b)+0.5)*2/float64(boltzmannBins)
+		fmt.Fprintf(out, "%g\t%g\t%g\n", mz, hist[b]/N, analytic[b])
+		boltzmannHistErr = math.Max(boltzmannHistErr, math.Abs(hist[b]/N-analytic[b])/analytic[b])
+	}
+
+	LogOut(fmt.Sprintf("BoltzmannTest: <mz²> = %.4f, Boltzmann: %.4f, relative error %.3g, histogram error %.3g", mz2, want, err, boltzmannHistErr))
+	return err
+}
+
+func BoltzmannHistogramError() float64 {
+	return boltzmannHistErr
+}
+
+// Analytic <mz²> and probability of mz in each of nbins bins over [-1, 1]
+// for p(mz) ∝ exp(σ mz²) (the sin θ of p(θ) is the Jacobian of mz = cos θ).
+func boltzmannDistribution(sigma float64, nbins int) (mz2 float64, bins []float64) {
+	const N = 100000 // integration points
+	bins = make([]float64, nbins)
+	Z := 0.
+	for i := 0; i < N; i++ {
+		mz := -1 + (float64(i)+0.5)*2/N
+		p := math.Exp(sigma * mz * mz)
+		Z += p
+		mz2 += mz * mz * p
+		bins[i*nbins/N] += p
+	}
+	for b := range bins {
+		bins[b] /= Z
+	}
+	return mz2 / Z, bins
+}
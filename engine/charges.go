@@ -0,0 +1,102 @@
+package engine
+
+// Magnetic charge densities, the sources of the demag field.
+// Computed on the CPU, intended for output rather than for every time step.
+
+import (
+	"github.com/mumax/3/data"
+)
+
+var (
+	Ext_VolumeCharge  = NewScalarField("ext_volumecharge", "A/m2", "Volume magnetic charge density -∇·M (without surface charges)", SetVolumeCharge)
+	Ext_SurfaceCharge = NewScalarField("ext_surfacecharge", "A/m", "Surface magnetic charge density M·n in the cells at the magnet's surface", SetSurfaceCharge)
+)
+
+// Sets dst to -∇·M inside the magnet, by central differences,
+// or one-sided differences next to the surface so that surface charges are not included.
+func SetVolumeCharge(dst *data.Slice) {
+	M := Download(&M_full).Vectors()
+	c := Mesh().CellSize()
+	rho := data.NewSlice(1, Mesh().Size())
+	r := rho.Scalars()
+	forEachMagnetCell(M, func(i [3]int) {
+		div := 0.
+		for comp := 0; comp < 3; comp++ {
+			Mi := valueAt(M[comp], i)
+			p, okp := magnetNeighbor(M, i, comp, 1)
+			m, okm := magnetNeighbor(M, i, comp, -1)
+			switch {
+			case okp && okm:
+				div += (valueAt(M[comp], p) - valueAt(M[comp], m)) / (2 * c[comp])
+			case okp:
+				div += (valueAt(M[comp], p) - Mi) / c[comp]
+			case okm:
+				div += (Mi - valueAt(M[comp], m)) / c[comp]
+			}
+		}
+		r[i[Z]][i[Y]][i[X]] = float32(-div)
+	})
+	data.Copy(dst, rho)
+}
+
+// Sets dst to M·n in cells with a non-magnetic neighbor (or the edge of the mesh without PBC),
+// with n the outward normal estimated from the exposed cell faces. Zero inside the magnet.
+// In a single-layer mesh the top and bottom faces cancel, so only edge charges remain.
+func SetSurfaceCharge(dst *data.Slice) {
+	M := Download(&M_full).Vectors()
+	sigma := data.NewSlice(1, Mesh().Size())
+	s := sigma.Scalars()
+	forEachMagnetCell(M, func(i [3]int) {
+		var n data.Vector
+		for comp := 0; comp < 3; comp++ {
+			for _, d := range []int{-1, 1} {
+				if _, ok := magnetNeighbor(M, i, comp, d); !ok {
+					n[comp] += float64(d)
+				}
+			}
+		}
+		if l := n.Len(); l != 0 {
+			Mi := vectorAt(M, i)
+			s[i[Z]][i[Y]][i[X]] = float32(Mi.Dot(n) / l)
+		}
+	})
+	data.Copy(dst, sigma)
+}
+
+// calls f for the index of each cell with nonzero M.
+func forEachMagnetCell(M [3][][][]float32, f func(i [3]int)) {
+	n := Mesh().Size()
+	for iz := 0; iz < n[Z]; iz++ {
+		for iy := 0; iy < n[Y]; iy++ {
+			for ix := 0; ix < n[X]; ix++ {
+				i := [3]int{ix, iy, iz}
+				if isMagnet(M, i) {
+					f(i)
+				}
+			}
+		}
+	}
+}
+
+// index of the neighbor of cell i in direction d (±1) along comp, taking into account PBC,
+// ok is false if there is no neighbor or it is not magnetic.
+func magnetNeighbor(M [3][][][]float32, i [3]int, comp, d int) (j [3]int, ok bool) {
+	n := Mesh().Size()
+	j = i
+	j[comp] += d
+	if j[comp] < 0 || j[comp] >= n[comp] {
+		if Mesh().PBC()[comp] == 0 {
+			return j, false
+		}
+		j[comp] = (j[comp] + n[comp]) % n[comp]
+	}
+	return j, isMagnet(M, j)
+}
+
+func isMagnet(M [3][][][]float32, i [3]int) bool {
+	return valueAt(M[X], i) != 0 || valueAt(M[Y], i) != 0 || valueAt(M[Z], i) != 0
+}
+
+func valueAt(a [][][]float32, i [3]int) float64 {
+	return float64(a[i[Z]][i[Y]][i[X]])
+}
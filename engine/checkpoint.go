@@ -0,0 +1,90 @@
+package engine
+
+// Checkpoints for recovery from GPU failure.
+// With AutoCheckpoint, m is periodically saved to checkpoint.ovf in the output directory.
+// When mumax3 runs with -failover and the GPU fails, it restarts itself on the next GPU
+// with -resume, re-runs the input script, and sets m and t from the checkpoint
+// at the start of the script's first Run, Steps or RunWhile (not inside Relax or other commands
+// that run the solver). Scripts with a single Run, or that run until
+// an absolute time with RunWhile(t < ...), thus continue where the failed run stopped.
+// CUDA errors from kernel launches, copies and synchronization are *cu.Error values
+// naming the failed driver call.
+
+import (
+	"flag"
+	"path"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/util"
+)
+
+var (
+	Flag_failover = flag.String("failover", "", "On GPU failure, continue from the last checkpoint on the next GPU of this comma-separated list (e.g. 1,2)")
+	Flag_recover  = flag.String("recover", "", "Set m and t from this checkpoint file at the start of the first run (used by -failover)")
+)
+
+var checkpoint struct {
+	period    float64 // simulation time between checkpoints, 0 = disabled
+	last      float64 // time of the last checkpoint
+	recovered bool    // -recover checkpoint has been loaded
+}
+
+func init() {
+	DeclFunc("AutoCheckpoint", AutoCheckpoint, "Save m to checkpoint.ovf every period (s), to continue from after a GPU failure with -failover (0 disables)")
+	PostStep(autoCheckpoint)
+}
+
+func AutoCheckpoint(period float64) {
+	util.Argument(period >= 0)
+	checkpoint.period = period
+	checkpoint.last = Time
+}
+
+// Checkpoint file in the output directory.
+func CheckpointFile() string {
+	return OD() + "checkpoint.ovf"
+}
+
+func autoCheckpoint() {
+	if checkpoint.period == 0 || Time < checkpoint.last+checkpoint.period {
+		return
+	}
+	checkpoint.last = Time
+	info := metaOf(&M)
+	dl := cuda.DownloadAsync(M.Buffer())
+	fname := CheckpointFile()
+	queOutput(func() { saveAs_sync(fname, dl.HostCopy(), info, OVF2_BINARY) })
+}
+
+// with -recover, sets m and t from the checkpoint, once.
+func recoverCheckpoint() {
+	if *Flag_recover == "" || checkpoint.recovered {
+		return
+	}
+	checkpoint.recovered = true
+	s, info := loadFile(*Flag_recover)
+	M.SetArray(s)
+	Time = info.Time
+	checkpoint.last = Time
+	skipOutput()
+	LogOut("recovered m and t=", Time, " s from ", path.Base(*Flag_recover))
+}
+
+// skips the periodic and timed output before the current time,
+// it was written by the failed run.
+func skipOutput() {
+	skip := func(a *autosave) {
+		if a.period != 0 && Time > a.start {
+			a.count = int((Time - a.start) / a.period)
+		}
+	}
+	for _, a := range output {
+		skip(a)
+	}
+	skip(&Table.autosave)
+	for _, a := range outputAt {
+		for a.next < len(a.times) && a.times[a.next] <= Time {
+			a.next++
+		}
+	}
+}
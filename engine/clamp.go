@@ -0,0 +1,69 @@
+package engine
+
+// Magnetization clamped to a fixed direction inside chosen regions,
+// e.g. idealized injector or detector contacts. Clamped spins are frozen
+// (zero torque) and their direction is re-imposed before every time step,
+// so that setting m afterwards does not undo the clamp.
+
+import (
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/util"
+)
+
+var (
+	clamped  RegionwiseScalar // 1 in clamped regions
+	clampDir = NewVectorParam("_clampdir", "", "Clamped magnetization direction")
+
+	// FrozenSpins of each region before it was clamped, restored by Unclamp
+	unclampedFrozen [NREGION]struct {
+		value []float64
+		f     func() []float64
+	}
+)
+
+func init() {
+	clamped.regionwise.init(SCALAR, "_clamped", "", nil) // not exported
+	DeclFunc("ClampMagnetization", ClampMagnetization, "Fix the magnetization in a region to a given direction, excluded from the time evolution")
+	DeclFunc("Unclamp", Unclamp, "Release the magnetization in a region clamped by ClampMagnetization, restoring its previous FrozenSpins value")
+}
+
+// Fixes m in region to the direction dir. The spins are frozen.
+func ClampMagnetization(region int, dir data.Vector) {
+	defRegionId(region)
+	if dir.Len() == 0 {
+		util.Fatal("ClampMagnetization: direction should not be zero")
+	}
+	clampDir.setRegions(region, region+1, slice(dir.Div(dir.Len())))
+	if clamped.getRegion(region)[0] == 0 {
+		unclampedFrozen[region].value = FrozenSpins.getRegion(region)
+		unclampedFrozen[region].f = FrozenSpins.upd_reg[region]
+	}
+	clamped.setRegions(region, region+1, []float64{1})
+	FrozenSpins.setRegions(region, region+1, []float64{1})
+	applyClamps()
+}
+
+// Releases the clamp in region, FrozenSpins gets back its value from before the clamp.
+func Unclamp(region int) {
+	defRegionId(region)
+	if clamped.getRegion(region)[0] == 0 {
+		return
+	}
+	clamped.setRegions(region, region+1, []float64{0})
+	prev := unclampedFrozen[region]
+	FrozenSpins.setRegions(region, region+1, prev.value)
+	if prev.f != nil {
+		FrozenSpins.setFunc(region, region+1, prev.f)
+	}
+}
+
+// sets m to the clamp direction in clamped regions.
+func applyClamps() {
+	if clamped.isZero() {
+		return
+	}
+	m := M.Buffer()
+	cuda.ZeroMask(m, clamped.gpuLUT1(), regions.Gpu())
+	cuda.RegionAddV(m, clampDir.gpuLUT(), regions.Gpu())
+}
@@ -0,0 +1,78 @@
+package engine
+
+// Metastable states of disks, rings and rectangles, as starting points
+// for relaxation. Elements are assumed centered at the origin,
+// use Transl() otherwise. All states have their net magnetization along +x,
+// use RotZ() to change that.
+
+import (
+	"math"
+
+	"github.com/mumax/3/data"
+)
+
+func init() {
+	DeclFunc("OnionState", OnionState, "Onion state of a disk or ring: m follows the edge, with head-to-head and tail-to-tail walls on the x axis")
+	DeclFunc("CState", CState, "C-state of a rectangle with given length along x and width: end domains tilted in opposite directions")
+	DeclFunc("SState", SState, "S-state of a rectangle with given length along x and width: end domains tilted in the same direction")
+	DeclFunc("FlowerState", FlowerState, "Flower state of a rectangle with given size: m along x, converging at the -x end and spreading out at the +x end")
+	DeclFunc("LeafState", LeafState, "Leaf state of a rectangle with given size: m along x, tilted away from the x axis at both ends")
+}
+
+// Onion state: m tangential to circles around the origin,
+// pointing along +x at the top and bottom of the disk or ring,
+// with a head-to-head wall at +x and a tail-to-tail wall at -x (on the x axis).
+func OnionState() Config {
+	return func(x, y, z float64) data.Vector {
+		r := math.Sqrt(x*x + y*y)
+		s := 1.0
+		if y < 0 {
+			s = -1
+		}
+		return noNaN(data.Vector{s * y / r, -s * x / r, 0}, 0)
+	}
+}
+
+// C-state of a length x width rectangle: m along x,
+// rotating towards -y at the left end and +y at the right end
+// over a distance equal to the width.
+func CState(length, width float64) Config {
+	return endDomains(length, width, func(x float64) float64 { return math.Copysign(1, x) })
+}
+
+// S-state of a length x width rectangle: m along x,
+// rotating towards +y at both ends over a distance equal to the width.
+func SState(length, width float64) Config {
+	return endDomains(length, width, func(x float64) float64 { return 1 })
+}
+
+// m along x, rotated by up to ±90° (sign given by dir(x))
+// within a distance width from the ends.
+func endDomains(length, width float64, dir func(x float64) float64) Config {
+	return func(x, y, z float64) data.Vector {
+		g := (math.Abs(x) - (length/2 - width)) / width
+		g = math.Min(math.Max(g, 0), 1)
+		theta := dir(x) * g * math.Pi / 2
+		return data.Vector{math.Cos(theta), math.Sin(theta), 0}
+	}
+}
+
+// Flower state of a sizeX x sizeY rectangle:
+// m = (1, X*Y, 0) with X, Y the coordinates scaled to [-1, 1],
+// i.e. the magnetization converges at the -x end and spreads out at the +x end.
+func FlowerState(sizeX, sizeY float64) Config {
+	return func(x, y, z float64) data.Vector {
+		X, Y := 2*x/sizeX, 2*y/sizeY
+		return data.Vector{1, X * Y, 0}
+	}
+}
+
+// Leaf state of a sizeX x sizeY rectangle:
+// m = (1, |X|*Y, 0) with X, Y the coordinates scaled to [-1, 1],
+// i.e. the magnetization is tilted away from the x axis at both ends.
+func LeafState(sizeX, sizeY float64) Config {
+	return func(x, y, z float64) data.Vector {
+		X, Y := 2*x/sizeX, 2*y/sizeY
+		return data.Vector{1, math.Abs(X) * Y, 0}
+	}
+}
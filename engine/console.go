@@ -0,0 +1,161 @@
+package engine
+
+// Line-based console to control a running simulation without the web GUI,
+// e.g. over ssh or netcat on a remote machine. Each line is evaluated
+// as script code between time steps, like commands from the web GUI.
+// The value of expressions (e.g. "m.average()") is printed back.
+// A TCP console listens on localhost unless a host is given explicitly,
+// and each connection must first send the token written to console_token
+// in the output directory, so that other users cannot run code.
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mumax/3/httpfs"
+)
+
+// Start a console on stdin/stdout (addr "-") or on a TCP address (e.g. "localhost:35368",
+// or ":35368" for localhost). While a console is connected, an interactive session (-i) does not time out.
+func GoServeConsole(addr string) {
+	if addr == "-" {
+		go serveConsole(os.Stdin, os.Stdout)
+		return
+	}
+	if !strings.Contains(addr, ":") {
+		addr = ":" + addr // port only
+	}
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Println("//console: ", err)
+		return
+	}
+	token := consoleToken()
+	if err := writeToken(OD()+"console_token", token); err != nil {
+		log.Println("//console: ", err)
+		l.Close()
+		return
+	}
+	httpfs.Put(OD()+"console", []byte(l.Addr().String()))
+	fmt.Print("//starting console at ", l.Addr(), ", token in ", OD()+"console_token", "\n")
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				log.Println("//console: ", err)
+				continue
+			}
+			go func() {
+				defer conn.Close()
+				in := bufio.NewReader(conn)
+				fmt.Fprint(conn, "token: ")
+				line, _ := in.ReadString('\n')
+				if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(line)), []byte(token)) != 1 {
+					fmt.Fprintln(conn, "invalid token")
+					log.Println("//console: rejected connection from", conn.RemoteAddr())
+					return
+				}
+				serveConsole(in, conn)
+			}()
+		}
+	}()
+}
+
+// random secret that TCP console clients have to send first.
+func consoleToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// writes the token to a file only readable by the user,
+// also when the file exists from an earlier run.
+func writeToken(fname, token string) error {
+	f, err := os.OpenFile(fname, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := fmt.Fprintln(f, token); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// read-eval-print loop until end of input or "exit".
+func serveConsole(in io.Reader, out io.Writer) {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				gui_.UpdateKeepAlive()
+				time.Sleep(1 * time.Second)
+			}
+		}
+	}()
+
+	scanner := bufio.NewScanner(in)
+	fmt.Fprint(out, "> ")
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch line {
+		case "":
+		case "exit", "quit":
+			return
+		default:
+			InjectAndWait(func() { evalConsole(line, out) })
+		}
+		fmt.Fprint(out, "> ")
+	}
+}
+
+// evaluates code and prints the value of each statement that has one.
+// Errors are reported to out instead of stopping the simulation.
+func evalConsole(code string, out io.Writer) {
+	defer func() {
+		if err := recover(); err != nil {
+			if userErr, ok := err.(UserErr); ok {
+				LogErr(userErr)
+				fmt.Fprintln(out, "error:", userErr)
+			} else {
+				panic(err)
+			}
+		}
+	}()
+	tree, err := World.Compile(code)
+	if err != nil {
+		LogIn(code)
+		LogErr(err.Error())
+		fmt.Fprintln(out, "error:", err)
+		return
+	}
+	LogIn(rmln(tree.Format()))
+	for _, stmt := range tree.Children {
+		if v := stmt.Eval(); v != nil {
+			fmt.Fprintln(out, myFmt([]interface{}{v})...)
+		}
+	}
+	gui_.UpdateKeepAlive()
+}
@@ -121,8 +121,14 @@ func (q *cropped) Unit() string           { return UnitOf(q.parent) }
 func (q *cropped) EvalTo(dst *data.Slice) { EvalTo(q, dst) }
 
 func (q *cropped) Mesh() *data.Mesh {
-	c := MeshOf(q.parent).CellSize()
-	return data.NewMesh(q.x2-q.x1, q.y2-q.y1, q.z2-q.z1, c[X], c[Y], c[Z])
+	p := MeshOf(q.parent)
+	c := p.CellSize()
+	m := data.NewMesh(q.x2-q.x1, q.y2-q.y1, q.z2-q.z1, c[X], c[Y], c[Z])
+	if p.HasOrigin() {
+		o := p.Origin()
+		m.SetOrigin(o[X]+float64(q.x1)*c[X], o[Y]+float64(q.y1)*c[Y], o[Z]+float64(q.z1)*c[Z])
+	}
+	return m
 }
 
 func (q *cropped) average() []float64 { return qAverageUniverse(q) } // needed for table
@@ -56,8 +56,16 @@ func AddEdensTerm(e Quantity) {
 // AddCustomField evaluates the user-defined custom field terms
 // and adds the result to dst.
 func AddCustomField(dst *data.Slice) {
-	for _, term := range customTerms {
-		buf := ValueOf(term)
+	// add terms two at a time, saving a pass over dst per pair
+	terms := customTerms
+	for ; len(terms) >= 2; terms = terms[2:] {
+		a, b := ValueOf(terms[0]), ValueOf(terms[1])
+		cuda.Madd3(dst, dst, a, b, 1, 1, 1)
+		cuda.Recycle(a)
+		cuda.Recycle(b)
+	}
+	if len(terms) == 1 {
+		buf := ValueOf(terms[0])
 		cuda.Add(dst, dst, buf)
 		cuda.Recycle(buf)
 	}
@@ -0,0 +1,80 @@
+package engine
+
+// Delta-encoded time-series output:
+//
+//	OutputFormat = DELTA
+//
+// makes Save and AutoSave append each quantity to a single file per quantity, e.g. m.delta,
+// storing the lossless, compressed difference with the previously saved frame
+// and every DeltaKeyframe'th frame in full. For high-rate output of slowly varying fields.
+// mumax3-convert reconstructs the frames, e.g.: mumax3-convert -ovf2 binary m.delta
+
+import (
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/delta"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+var DeltaKeyframe = 100 // store every DeltaKeyframe'th frame in full
+
+func init() {
+	DeclROnly("DELTA", DELTA, "OutputFormat = DELTA appends all saves of a quantity as compressed differences to one file (name.delta)")
+	DeclVar("DeltaKeyframe", &DeltaKeyframe, "With OutputFormat = DELTA, store every DeltaKeyframe'th frame in full (default 100)")
+}
+
+// open delta files, only accessed by the output goroutine
+var deltaFiles = make(map[string]*deltaFile)
+
+type deltaFile struct {
+	ncomp int
+	size  [3]int
+	enc   delta.Encoder
+}
+
+// file name of the time series of quantity name
+func deltaFname(name string) string {
+	return seriesFname(name, "delta")
+}
+
+// appends s as a frame to the delta file fname, creating the file on first use.
+func appendDelta(fname string, s *data.Slice, info data.Meta) {
+	f := deltaFiles[fname]
+	if f != nil && (f.ncomp != s.NComp() || f.size != s.Size()) {
+		util.Fatal("Delta output ", fname, ": mesh or number of components changed, use another file name")
+	}
+	if f == nil && *Flag_resume {
+		f = resumeDelta(fname, s)
+	}
+	if f == nil {
+		f = &deltaFile{ncomp: s.NComp(), size: s.Size()}
+		util.FatalErr(httpfs.Put(fname, delta.Header(info, f.ncomp, f.size)))
+		deltaFiles[fname] = f
+	}
+	f.enc.Keyframe = DeltaKeyframe
+	util.FatalErr(httpfs.Append(fname, f.enc.Frame(info.Time, s)))
+}
+
+// with -resume, continues an existing delta file after its last complete frame.
+// The fresh encoder has no previous frame, so the first appended frame is a keyframe.
+// Returns nil if there is no file to continue.
+func resumeDelta(fname string, s *data.Slice) *deltaFile {
+	file, err := httpfs.Read(fname)
+	if err != nil {
+		return nil
+	}
+	l, err := delta.ReadLayout(file)
+	if err != nil {
+		util.Fatal("resume delta output ", fname, ": ", err)
+	}
+	if l.NComp != s.NComp() || l.Size != s.Size() {
+		util.Fatal("resume delta output ", fname, ": mesh or number of components changed, use another file name")
+	}
+	if l.End < len(file) { // drop a partly written frame
+		util.FatalErr(httpfs.Put(fname, file[:l.End]))
+	}
+	LogOut("resume: appending to", fname, "after", l.Frames, "frames")
+	f := &deltaFile{ncomp: s.NComp(), size: s.Size()}
+	deltaFiles[fname] = f
+	return f
+}
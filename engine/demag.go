@@ -5,12 +5,11 @@ package engine
 import (
 	"github.com/mumax/3/cuda"
 	"github.com/mumax/3/data"
-	"github.com/mumax/3/mag"
 )
 
 // Demag variables
 var (
-	Msat        = NewScalarParam("Msat", "A/m", "Saturation magnetization")
+	Msat        = NewScalarParam("Msat", "A/m", "Saturation magnetization", &lex2, &din2, &dbulk2, &vacuum)
 	M_full      = NewVectorField("m_full", "A/m", "Unnormalized magnetization", SetMFull)
 	B_demag     = NewVectorField("B_demag", "T", "Magnetostatic field", SetDemagField)
 	Edens_demag = NewScalarField("Edens_demag", "J/m3", "Magnetostatic energy density", AddEdens_demag)
@@ -33,15 +32,22 @@ func init() {
 
 // Sets dst to the current demag field
 func SetDemagField(dst *data.Slice) {
-	if EnableDemag {
+	if EnableDemag && DemagMultigrid {
+		setMultigridDemag(dst)
+	} else if EnableDemag && isMacrospin() {
+		setMacrospinDemag(dst)
+	} else if EnableDemag {
 		msat := Msat.MSlice()
 		defer msat.Recycle()
 		if NoDemagSpins.isZero() {
 			// Normal demag, everywhere
-			demagConv().Exec(dst, M.Buffer(), geometry.Gpu(), msat)
+			execDemag(dst, M.Buffer(), geometry.Gpu(), msat)
 		} else {
 			setMaskedDemagField(dst, msat)
 		}
+		if haveSUL() {
+			addSULField(dst, msat)
+		}
 	} else {
 		cuda.Zero(dst) // will ADD other terms to it
 	}
@@ -66,7 +72,7 @@ func setMaskedDemagField(dst *data.Slice, msat cuda.MSlice) {
 	cuda.ZeroMask(buf, NoDemagSpins.gpuLUT1(), regions.Gpu())
 
 	// convolution with masked-out cells.
-	demagConv().Exec(dst, M.Buffer(), buf, msat)
+	execDemag(dst, M.Buffer(), buf, msat)
 
 	// After convolution, mask-out the field in the NoDemagSpins cells
 	// so they don't feel the field generated by others.
@@ -101,8 +107,11 @@ func demagConv() *cuda.DemagConvolution {
 	if conv_ == nil {
 		SetBusy(true)
 		defer SetBusy(false)
-		kernel := mag.DemagKernel(Mesh().Size(), Mesh().PBC(), Mesh().CellSize(), DemagAccuracy, *Flag_cachedir)
-		conv_ = cuda.NewDemag(Mesh().Size(), Mesh().PBC(), kernel, *Flag_selftest)
+		if Mesh().Size()[Z] == 1 {
+			// single layer: 2D FFTs, no xz, yz kernel components
+			LogOut("single-layer mesh: using 2D demag convolution (compare with -bench)")
+		}
+		conv_ = cuda.NewDemag(Mesh().Size(), Mesh().PBC(), demagKernel(), *Flag_selftest)
 	}
 	return conv_
 }
@@ -0,0 +1,169 @@
+package engine
+
+// Runtime check of the FFT demag convolution against direct summation
+// with the same kernel, in a few random cells inside the magnet.
+// Catches regressions in FFT libraries, drivers or GPU hardware.
+// The check is on the convolution over the full mesh: the bounding-box crop
+// (DemagCropToGeom) and the soft underlayer image field are not part of it.
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/mag"
+	"github.com/mumax/3/util"
+)
+
+var (
+	demagCheckKernel [3][3]*data.Slice // host kernel, cached for direct summation
+	demagCheckSize   [3]int            // mesh size demagCheckKernel was made for
+	demagCheckEvery  int               // check every N steps, 0 = disabled
+	demagCheckCells  int               // number of random cells to check
+	demagCheckTol    float64           // maximum relative error
+)
+
+func init() {
+	DeclFunc("CheckDemag", CheckDemag, "Compare B_demag to direct summation in N random cells, returns max error relative to max |B_demag|")
+	DeclFunc("AutoCheckDemag", AutoCheckDemag, "Every N steps, check B_demag in a number of random cells against direct summation, stop if the relative error exceeds tol (N=0 disables)")
+	PostStep(autoCheckDemag)
+}
+
+// Every nsteps, compare the demag field in ncells random cells to direct summation.
+// Exits with an error if the error relative to max |B_demag| exceeds tol.
+func AutoCheckDemag(nsteps, ncells int, tol float64) {
+	demagCheckEvery = nsteps
+	demagCheckCells = ncells
+	demagCheckTol = tol
+}
+
+func autoCheckDemag() {
+	if demagCheckEvery == 0 || NSteps%demagCheckEvery != 0 {
+		return
+	}
+	if err := CheckDemag(demagCheckCells); err > demagCheckTol {
+		util.Fatal(fmt.Sprintf("demag check failed at step %v: relative error %.3g exceeds %v", NSteps, err, demagCheckTol))
+	}
+}
+
+// CheckDemag computes the demag field in ncells random cells inside the geometry
+// by direct summation over all cells, with the same kernel as the FFT convolution.
+// Returns the maximum error relative to the maximum demag field.
+func CheckDemag(ncells int) float64 {
+	checkMesh()
+	if !EnableDemag || DemagMultigrid || isMacrospin() {
+		LogErr("CheckDemag: FFT demag not in use")
+		return 0
+	}
+	n := Mesh().Size()
+	if demagCheckSize != n || demagCheckKernel[X][X] == nil {
+		demagCheckKernel = demagKernel()
+		demagCheckSize = n
+	}
+	K := demagCheckKernel
+	ksize := K[X][X].Size()
+
+	Bgpu := checkDemagField().Vectors()
+	src := Download(&M_full).Vectors() // Msat * m * volume fraction
+	vol := Download(&geometry).Scalars()
+	reg := regions.HostArray()
+	noDemag := NoDemagSpins.cpuLUT()[0]
+
+	// sources: cells that generate a field
+	for iz := 0; iz < n[Z]; iz++ {
+		for iy := 0; iy < n[Y]; iy++ {
+			for ix := 0; ix < n[X]; ix++ {
+				if noDemag[reg[iz][iy][ix]] != 0 {
+					for c := 0; c < 3; c++ {
+						src[c][iz][iy][ix] = 0
+					}
+				}
+			}
+		}
+	}
+
+	// destinations: random cells inside the magnet
+	var cells [][3]int
+	rng := rand.New(rand.NewSource(int64(NSteps)))
+	for tries := 0; len(cells) < ncells && tries < 100*ncells; tries++ {
+		i := [3]int{rng.Intn(n[X]), rng.Intn(n[Y]), rng.Intn(n[Z])}
+		if vol[i[Z]][i[Y]][i[X]] != 0 && noDemag[reg[i[Z]][i[Y]][i[X]]] == 0 {
+			cells = append(cells, i)
+		}
+	}
+
+	var k [3][3][][][]float32
+	for s := 0; s < 3; s++ {
+		for d := 0; d < 3; d++ {
+			if K[s][d] != nil {
+				k[s][d] = K[s][d].Scalars()
+			}
+		}
+	}
+
+	maxB, maxErr := 0.0, 0.0
+	for _, c := range cells {
+		var B [3]float64
+		for iz := 0; iz < n[Z]; iz++ {
+			dz := wrapIndex(c[Z]-iz, ksize[Z])
+			for iy := 0; iy < n[Y]; iy++ {
+				dy := wrapIndex(c[Y]-iy, ksize[Y])
+				for ix := 0; ix < n[X]; ix++ {
+					dx := wrapIndex(c[X]-ix, ksize[X])
+					for s := 0; s < 3; s++ {
+						Ms := float64(src[s][iz][iy][ix])
+						if Ms == 0 {
+							continue
+						}
+						for d := 0; d < 3; d++ {
+							if k[s][d] != nil {
+								B[d] += float64(k[s][d][dz][dy][dx]) * Ms
+							}
+						}
+					}
+				}
+			}
+		}
+		for d := 0; d < 3; d++ {
+			B[d] *= mag.Mu0
+			maxB = math.Max(maxB, math.Abs(B[d]))
+			maxErr = math.Max(maxErr, math.Abs(B[d]-float64(Bgpu[d][c[Z]][c[Y]][c[X]])))
+		}
+	}
+	if maxB == 0 {
+		return maxErr
+	}
+	return maxErr / maxB
+}
+
+// the demag field to check, on the host: the FFT convolution of the same sources
+// as SetDemagField, over the full mesh, without crop or soft underlayer.
+func checkDemagField() *data.Slice {
+	msat := Msat.MSlice()
+	defer msat.Recycle()
+	vol := cuda.Buffer(1, Mesh().Size())
+	defer cuda.Recycle(vol)
+	geom, r := geometry.Slice()
+	if r {
+		defer cuda.Recycle(geom)
+	}
+	data.Copy(vol, geom)
+	if !NoDemagSpins.isZero() {
+		cuda.ZeroMask(vol, NoDemagSpins.gpuLUT1(), regions.Gpu())
+	}
+	B := cuda.Buffer(3, Mesh().Size())
+	defer cuda.Recycle(B)
+	demagConv().Exec(B, M.Buffer(), vol, msat)
+	return B.HostCopy()
+}
+
+// wrap displacement i into kernel index range [0, n).
+func wrapIndex(i, n int) int {
+	i %= n
+	if i < 0 {
+		i += n
+	}
+	return i
+}
@@ -0,0 +1,166 @@
+package engine
+
+// Demag convolution restricted to the bounding box of the geometry.
+// For geometries that fill only part of the simulation box, the FFTs then
+// only need to cover that part. Without PBC, the field inside the magnet is
+// not affected by this, but the stray field outside the bounding box is not computed.
+// A kernel loaded with LoadDemagKernel is cropped to the bounding box as well.
+
+import (
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/mag"
+)
+
+var (
+	DemagCropToGeom = false                // restrict demag to geometry bounding box
+	cropConv_       *cuda.DemagConvolution // convolution on the bounding box
+	cropConvSize_   [3]int                 // size cropConv_ was made for
+	cropOff_        [3]int                 // bounding box offset (cells)
+	cropSize_       [3]int                 // bounding box size (cells), zero if the geometry is empty
+	cropKnown_      bool                   // cropOff_, cropSize_ are up to date
+)
+
+func init() {
+	DeclVar("DemagCropToGeom", &DemagCropToGeom, "Restrict demag convolution to the geometry's bounding box (default=false)")
+}
+
+// calculate the demag field of m*vol*msat, on the geometry bounding box if enabled.
+func execDemag(dst, m, vol *data.Slice, msat cuda.MSlice) {
+	if DemagCropToGeom && !vol.IsNil() && Mesh().PBC() == [3]int{0, 0, 0} {
+		off, size := geomBoundingBox()
+		if size == [3]int{} { // no magnet, no field
+			cuda.Zero(dst)
+			return
+		}
+		if size != Mesh().Size() {
+			execCroppedDemag(dst, m, vol, msat, off, size)
+			return
+		}
+	}
+	demagConv().Exec(dst, m, vol, msat)
+}
+
+func execCroppedDemag(dst, m, vol *data.Slice, msat cuda.MSlice, off, size [3]int) {
+	mc := cuda.Buffer(3, size)
+	defer cuda.Recycle(mc)
+	cuda.Crop(mc, m, off[X], off[Y], off[Z])
+
+	vc := cuda.Buffer(1, size)
+	defer cuda.Recycle(vc)
+	cuda.Crop(vc, vol, off[X], off[Y], off[Z])
+
+	msatc := cuda.MakeMSlice(data.NilSlice(1, size), []float64{float64(msat.Mul(0))})
+	if msat.DevPtr(0) != nil {
+		buf := cuda.Buffer(1, size)
+		defer cuda.Recycle(buf)
+		cuda.Crop(buf, msat.Arr(), off[X], off[Y], off[Z])
+		msatc = cuda.ToMSlice(buf)
+	}
+
+	B := cuda.Buffer(3, size)
+	defer cuda.Recycle(B)
+	croppedDemagConv(size).Exec(B, mc, vc, msatc)
+	cuda.Paste(dst, B, off[X], off[Y], off[Z])
+}
+
+// returns demag convolution for the bounding box size, making sure it's initialized.
+// when the geometry only moves (e.g. shifted with the magnetization), the convolution is re-used.
+func croppedDemagConv(size [3]int) *cuda.DemagConvolution {
+	if cropConv_ != nil && cropConvSize_ != size {
+		freeCroppedDemag()
+	}
+	if cropConv_ == nil {
+		SetBusy(true)
+		defer SetBusy(false)
+		LogOut("demag restricted to", size, "cells")
+		var kernel [3][3]*data.Slice
+		if userKernel_[X][X] != nil {
+			kernel = cropKernel(demagKernel(), size)
+		} else {
+			kernel = mag.DemagKernel(size, [3]int{}, Mesh().CellSize(), DemagAccuracy, *Flag_cachedir)
+		}
+		cropConv_ = cuda.NewDemag(size, [3]int{}, kernel, *Flag_selftest)
+		cropConvSize_ = size
+	}
+	return cropConv_
+}
+
+// cropKernel returns the part of the (full mesh) kernel needed for a bounding box of given size:
+// the offsets up to size-1 cells, stored wrapped around in the smaller zero-padded kernel.
+func cropKernel(kernel [3][3]*data.Slice, size [3]int) [3][3]*data.Slice {
+	var cropped [3][3]*data.Slice
+	kSize := mag.KernelSize(size, [3]int{})
+	for i := range kernel {
+		for j := range kernel[i] {
+			if kernel[i][j] == nil {
+				continue
+			}
+			if j < i {
+				cropped[i][j] = cropped[j][i] // symmetric
+				continue
+			}
+			src := kernel[i][j].Scalars()
+			full := kernel[i][j].Size()
+			k := data.NewSlice(1, kSize)
+			dst := k.Scalars()
+			for z := -(size[Z] - 1); z < size[Z]; z++ {
+				for y := -(size[Y] - 1); y < size[Y]; y++ {
+					for x := -(size[X] - 1); x < size[X]; x++ {
+						dst[wrapIndex(z, kSize[Z])][wrapIndex(y, kSize[Y])][wrapIndex(x, kSize[X])] =
+							src[wrapIndex(z, full[Z])][wrapIndex(y, full[Y])][wrapIndex(x, full[X])]
+					}
+				}
+			}
+			cropped[i][j] = k
+		}
+	}
+	return cropped
+}
+
+// bounding box of the non-empty geometry cells, size 0 if there are none
+func geomBoundingBox() (off, size [3]int) {
+	if !cropKnown_ {
+		vol := geometry.Gpu().HostCopy().Scalars()
+		n := Mesh().Size()
+		min := n
+		max := [3]int{-1, -1, -1}
+		for iz := 0; iz < n[Z]; iz++ {
+			for iy := 0; iy < n[Y]; iy++ {
+				for ix := 0; ix < n[X]; ix++ {
+					if vol[iz][iy][ix] != 0 {
+						i := [3]int{ix, iy, iz}
+						for c := range i {
+							if i[c] < min[c] {
+								min[c] = i[c]
+							}
+							if i[c] > max[c] {
+								max[c] = i[c]
+							}
+						}
+					}
+				}
+			}
+		}
+		cropOff_, cropSize_ = [3]int{}, [3]int{}
+		if max[X] >= 0 { // non-empty
+			for c := range min {
+				cropOff_[c] = min[c]
+				cropSize_[c] = max[c] - min[c] + 1
+			}
+		}
+		cropKnown_ = true
+	}
+	return cropOff_, cropSize_
+}
+
+// geometry changed: bounding box needs to be re-computed.
+func invalidateDemagCrop() {
+	cropKnown_ = false
+}
+
+func freeCroppedDemag() {
+	cropConv_.Free()
+	cropConv_ = nil
+	cropConvSize_ = [3]int{}
+}
@@ -0,0 +1,146 @@
+package engine
+
+// Export and import of the demag kernel.
+
+import (
+	"fmt"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/mag"
+	"github.com/mumax/3/oommf"
+	"github.com/mumax/3/util"
+	"math"
+)
+
+var (
+	userKernel_     [3][3]*data.Slice // user-supplied kernel, nil if none
+	userKernelMesh_ *data.Mesh        // mesh the user-supplied kernel was made for
+)
+
+func init() {
+	DeclFunc("SaveDemagKernel", SaveDemagKernel, "Save the demag kernel components as OVF files with given prefix (in output directory)")
+	DeclFunc("LoadDemagKernel", LoadDemagKernel, "Use the demag kernel stored in OVF files with given prefix (as written by SaveDemagKernel)")
+}
+
+// kernel component file suffixes, upper diagonal only
+var kernelComp = map[[2]int]string{
+	{X, X}: "Kxx", {Y, Y}: "Kyy", {Z, Z}: "Kzz",
+	{Y, Z}: "Kyz", {X, Z}: "Kxz", {X, Y}: "Kxy"}
+
+// returns the demag kernel for the current mesh: the user-supplied one if present
+func demagKernel() [3][3]*data.Slice {
+	if userKernel_[X][X] != nil {
+		if checkKernelMesh(userKernelMesh_) == nil {
+			return userKernel_
+		}
+		LogErr("user-supplied demag kernel does not fit the mesh anymore, calculating new kernel")
+		userKernel_ = [3][3]*data.Slice{}
+	}
+	return mag.DemagKernel(Mesh().Size(), Mesh().PBC(), Mesh().CellSize(), DemagAccuracy, *Flag_cachedir)
+}
+
+// SaveDemagKernel writes the (real-space, zero-padded) demag kernel components to
+// OD()+prefix+"Kxx.ovf", ... . Components that are not needed in 2D are not written.
+// The mesh size, cell size and PBC the kernel is made for go to OD()+prefix+"kernel.txt".
+func SaveDemagKernel(prefix string) {
+	checkMesh()
+	kernel := demagKernel()
+	saveKernelMesh(OD()+prefix+"kernel.txt", Mesh())
+	for ij, name := range kernelComp {
+		k := kernel[ij[0]][ij[1]]
+		if k == nil {
+			continue
+		}
+		fname := OD() + prefix + name + ".ovf"
+		f, err := httpfs.Create(fname)
+		util.FatalErr(err)
+		oommf.WriteOVF2(f, k, data.Meta{Name: name, CellSize: Mesh().CellSize(), MeshUnit: "m"}, "binary 4")
+		util.FatalErr(f.Close())
+		LogOut("saved kernel", fname)
+	}
+}
+
+// LoadDemagKernel reads the demag kernel components from prefix+"Kxx.ovf", ...
+// and uses them instead of the calculated kernel.
+// The mesh size, cell size and PBC in prefix+"kernel.txt" must match the current mesh,
+// otherwise a UserErr is raised and the current kernel is kept.
+func LoadDemagKernel(prefix string) {
+	checkMesh()
+	kmesh := loadKernelMesh(prefix + "kernel.txt")
+	CheckRecoverable(checkKernelMesh(kmesh))
+	var kernel [3][3]*data.Slice
+	for ij, name := range kernelComp {
+		if Mesh().Size()[Z] == 1 && ij[1] == Z && ij[0] != Z {
+			continue // not needed in 2D
+		}
+		k := LoadFile(prefix + name + ".ovf")
+		if k.NComp() != 1 {
+			util.Fatal("LoadDemagKernel: ", name, " should have 1 component, have ", k.NComp())
+		}
+		kernel[ij[0]][ij[1]] = k
+	}
+	kernel[Y][X] = kernel[X][Y]
+	kernel[Z][X] = kernel[X][Z]
+	kernel[Z][Y] = kernel[Y][Z]
+	CheckRecoverable(checkKernelSize(kernel))
+
+	userKernel_ = kernel
+	userKernelMesh_ = kmesh
+	conv_.Free() // re-initialized with new kernel when needed
+	conv_ = nil
+	if cropConv_ != nil {
+		freeCroppedDemag()
+	}
+	LogOut("using demag kernel", prefix)
+}
+
+// error if the kernel made for mesh kmesh does not fit the current mesh.
+func checkKernelMesh(kmesh *data.Mesh) error {
+	m := Mesh()
+	if kmesh.Size() != m.Size() || kmesh.PBC() != m.PBC() {
+		return fmt.Errorf("demag kernel is for %v cells with PBC %v, mesh has %v cells with PBC %v",
+			kmesh.Size(), kmesh.PBC(), m.Size(), m.PBC())
+	}
+	for c := range m.CellSize() {
+		if math.Abs(kmesh.CellSize()[c]-m.CellSize()[c]) > 1e-9*m.CellSize()[c] {
+			return fmt.Errorf("demag kernel is for cell size %v, mesh has %v", kmesh.CellSize(), m.CellSize())
+		}
+	}
+	return nil
+}
+
+// writes the mesh a kernel is made for.
+func saveKernelMesh(fname string, m *data.Mesh) {
+	n, c, p := m.Size(), m.CellSize(), m.PBC()
+	txt := fmt.Sprintf("# mumax3 demag kernel\nsize: %v %v %v\ncellsize: %v %v %v\npbc: %v %v %v\n",
+		n[X], n[Y], n[Z], c[X], c[Y], c[Z], p[X], p[Y], p[Z])
+	util.FatalErr(httpfs.Put(fname, []byte(txt)))
+}
+
+// reads the mesh written by saveKernelMesh.
+func loadKernelMesh(fname string) *data.Mesh {
+	b, err := httpfs.Read(fname)
+	if err != nil {
+		util.Fatal("LoadDemagKernel: ", err, " (need the mesh file written by SaveDemagKernel)")
+	}
+	var n, p [3]int
+	var c [3]float64
+	_, err = fmt.Sscanf(string(b), "# mumax3 demag kernel\nsize: %d %d %d\ncellsize: %g %g %g\npbc: %d %d %d\n",
+		&n[X], &n[Y], &n[Z], &c[X], &c[Y], &c[Z], &p[X], &p[Y], &p[Z])
+	if err != nil {
+		util.Fatal("LoadDemagKernel: bad ", fname, ": ", err)
+	}
+	return data.NewMesh(n[X], n[Y], n[Z], c[X], c[Y], c[Z], p[X], p[Y], p[Z])
+}
+
+func checkKernelSize(kernel [3][3]*data.Slice) error {
+	want := mag.KernelSize(Mesh().Size(), Mesh().PBC())
+	for _, row := range kernel {
+		for _, k := range row {
+			if k != nil && k.Size() != want {
+				return fmt.Errorf("demag kernel size %v does not match mesh, need %v", k.Size(), want)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package engine
+
+// Magnetostatic field by multigrid Poisson solver, as alternative to the FFT convolution.
+
+import (
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/util"
+)
+
+var (
+	DemagMultigrid = false         // use multigrid instead of FFT convolution
+	multigrid_     *cuda.Multigrid // initialized when needed
+)
+
+func init() {
+	DeclVar("DemagMultigrid", &DemagMultigrid, "Use the (slower) multigrid Poisson solver instead of FFT convolution for demag (default=false)")
+}
+
+// Sets dst to the demag field calculated by the multigrid solver.
+func setMultigridDemag(dst *data.Slice) {
+	if Mesh().PBC() != [3]int{0, 0, 0} {
+		util.Fatal("DemagMultigrid does not support PBC")
+	}
+	if !NoDemagSpins.isZero() {
+		util.Fatal("DemagMultigrid does not support NoDemagSpins")
+	}
+	if multigrid_ == nil {
+		multigrid_ = cuda.NewMultigrid(Mesh().Size(), Mesh().CellSize(), DemagAccuracy)
+	}
+	msat := Msat.MSlice()
+	defer msat.Recycle()
+	multigrid_.Exec(dst, M.Buffer(), geometry.Gpu(), msat)
+}
@@ -0,0 +1,183 @@
+package engine
+
+// Exchange-coupled composite (ECC) recording media: Voronoi grains
+// of a hard layer at the bottom with a soft layer on top.
+// Grain region i (0 <= i < N) of the hard layer has region i,
+// the soft layer on top of it region N+i. The exchange between the hard
+// and soft part of each grain is scaled by the interlayer coupling,
+// the lateral exchange between grains by the intergranular coupling. E.g.:
+//
+//	SetGridSize(128, 128, 4)
+//	SetCellSize(1e-9, 1e-9, 2e-9)
+//	ECCMedia(8e-9, 100, 1, 4e-9, 0.1, 0)
+//	for i:=0; i<100; i++{
+//		Ku1.SetRegion(i, 1e6)      // hard
+//		Ku1.SetRegion(100+i, 1e4)  // soft
+//	}
+//	m = uniform(0, 0, 1)
+//	ECCSwitchingFields(vector(0, 0, -1), 2, 40)
+//
+// Regions are shared by all grains with the same random region number,
+// as with ext_makegrains. Exchange scales are set per pair of regions, so
+// neighboring grains that happen to share a region stay fully coupled:
+// use many regions (N up to 128) for well separated grains.
+// Switching fields are reported per grain, not per region.
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+// media set by ECCMedia
+var ecc struct {
+	grains int          // number of grain regions
+	t      *tesselation // grain shapes
+	top    float64      // z coordinate of the top of the hard layer (m)
+}
+
+func init() {
+	DeclFunc("ECCMedia", ECCMedia, "Voronoi grains (grain size, N regions, seed) with a hard layer of given thickness (m) in regions 0..N-1 and a soft layer on top in regions N..2N-1, with given interlayer and intergranular exchange scales")
+	DeclFunc("ECCSwitchingFields", ECCSwitchingFields, "Ramp B_ext along dir to Bmax (T) in N steps, minimizing at each step, and write the switching field of the hard layer of each ECCMedia grain to ecc_switching.txt")
+}
+
+func ECCMedia(grainsize float64, nGrains, seed int, hardThickness, interlayer, intergranular float64) {
+	util.Argument(nGrains > 0 && 2*nGrains <= NREGION && hardThickness > 0)
+	checkMesh()
+	SetBusy(true)
+	defer SetBusy(false)
+	Refer("Lel2014")
+
+	bottom := Index2Coord(0, 0, 0)[Z] - Mesh().CellSize()[Z]/2
+	t := newTesselation(grainsize, nGrains, int64(seed))
+	top := bottom + hardThickness
+	f := func(x, y, z float64) int {
+		r := t.RegionOf(x, y, z)
+		if z > top {
+			r += nGrains
+		}
+		return r
+	}
+	regions.hist = append(regions.hist, f)
+	regions.render(f)
+
+	for i := 0; i < nGrains; i++ {
+		for j := 0; j < nGrains; j++ {
+			if j != i {
+				ScaleInterExchange(i, nGrains+j, intergranular)
+				if j > i {
+					ScaleInterExchange(i, j, intergranular)
+					ScaleInterExchange(nGrains+i, nGrains+j, intergranular)
+				}
+			}
+		}
+		ScaleInterExchange(i, nGrains+i, interlayer)
+	}
+	ecc.grains, ecc.t, ecc.top = nGrains, t, top
+}
+
+// Ramps B_ext along dir in nfield steps up to Bmax, minimizing the energy at each step,
+// starting from the current magnetization. For each grain, the switching field is the
+// first field at which the average magnetization of its hard layer along dir becomes positive.
+// Grains already along dir at the start get NaN.
+// The magnetization and B_ext are restored afterwards.
+func ECCSwitchingFields(dir data.Vector, Bmax float64, nfield int) {
+	if ecc.grains == 0 {
+		util.Fatal("ECCSwitchingFields: need ECCMedia first")
+	}
+	util.Argument(nfield > 0 && dir.Len() != 0)
+	dir = dir.Div(dir.Len())
+
+	m0 := cuda.Buffer(3, Mesh().Size())
+	defer cuda.Recycle(m0)
+	data.Copy(m0, M.Buffer())
+	defer M.SetArray(m0)
+	defer restoreExcitation(B_ext, saveExcitation(B_ext))
+
+	grainOf, grains := eccGrains()
+	Bsw := make([]float64, len(grains))
+	for i := range Bsw {
+		Bsw[i] = math.NaN()
+	}
+	switched := make([]bool, len(grains))
+	left := 0
+	for i, m := range eccGrainAverages(grainOf, len(grains), dir) {
+		switched[i] = m > 0
+		if !switched[i] {
+			left++
+		}
+	}
+
+	for s := 1; s <= nfield && left > 0; s++ {
+		B := Bmax * float64(s) / float64(nfield)
+		B_ext.Set(dir.Mul(B))
+		Minimize()
+		for i, m := range eccGrainAverages(grainOf, len(grains), dir) {
+			if !switched[i] && m > 0 {
+				switched[i] = true
+				Bsw[i] = B
+				left--
+			}
+		}
+	}
+
+	out, err := httpfs.Create(OD() + "ecc_switching.txt")
+	util.FatalErr(err)
+	defer out.Close()
+	fmt.Fprintln(out, "# grain\tx (m)\ty (m)\tregion\tBsw (T)")
+	for i, g := range grains {
+		fmt.Fprintf(out, "%d\t%g\t%g\t%d\t%g\n", i, g.x, g.y, g.region, Bsw[i])
+	}
+	LogOut("ECCSwitchingFields: ", len(grains), " grains, wrote ", OD()+"ecc_switching.txt")
+}
+
+// grain index of each cell of the hard layer (-1 elsewhere), and the grain centers.
+func eccGrains() ([]int, []center) {
+	n := Mesh().Size()
+	grainOf := make([]int, Mesh().NCell())
+	var grains []center
+	index := make(map[center]int)
+	for iz := 0; iz < n[Z]; iz++ {
+		for iy := 0; iy < n[Y]; iy++ {
+			for ix := 0; ix < n[X]; ix++ {
+				i := (iz*n[Y]+iy)*n[X] + ix
+				r := Index2Coord(ix, iy, iz)
+				if r[Z] > ecc.top {
+					grainOf[i] = -1
+					continue
+				}
+				c, _, _, _ := ecc.t.nearest(r[X], r[Y])
+				g, ok := index[c]
+				if !ok {
+					g = len(grains)
+					index[c] = g
+					grains = append(grains, c)
+				}
+				grainOf[i] = g
+			}
+		}
+	}
+	return grainOf, grains
+}
+
+// average m along dir over the hard layer of each grain.
+// Downloads m, done once per field step.
+func eccGrainAverages(grainOf []int, ngrains int, dir data.Vector) []float64 {
+	m := M.Buffer().HostCopy().Host()
+	sum := make([]float64, ngrains)
+	count := make([]float64, ngrains)
+	for i, g := range grainOf {
+		if g >= 0 {
+			sum[g] += dir[X]*float64(m[X][i]) + dir[Y]*float64(m[Y][i]) + dir[Z]*float64(m[Z][i])
+			count[g]++
+		}
+	}
+	for g := range sum {
+		sum[g] /= count[g]
+	}
+	return sum
+}
@@ -0,0 +1,115 @@
+package engine
+
+// Emergent electromagnetic fields and Thiele equation coefficients
+// of magnetic textures like skyrmions.
+// With the solid angle density Ω_i = ½ ε_ijk m·(∂_j m × ∂_k m):
+//
+//	emergent magnetic field:  B_i = ħ/2e Ω_i
+//	emergent electric field:  E_i = ħ/2e m·(∂_i m × ∂_t m)
+//	gyrovector:               G_i = ∫ Msat/γ Ω_i dV
+//	dissipation tensor:       D_ij = ∫ Msat/γ ∂_i m·∂_j m dV
+//
+// Signs are for a conduction electron spin parallel to m.
+// Computed on the CPU, intended for output rather than for every time step.
+
+import (
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/mag"
+)
+
+var (
+	Ext_EmergentField         = NewVectorField("ext_emergentfield", "T", "Emergent magnetic field ħ/2e m·(∂_j m × ∂_k m)", SetEmergentField)
+	Ext_EmergentElectricField = NewVectorField("ext_emergentelectricfield", "V/m", "Emergent electric field ħ/2e m·(∂_i m × ∂_t m)", SetEmergentElectricField)
+	Ext_Gyrovector            = NewVectorValue("ext_gyrovector", "kg/s", "Thiele gyrovector ∫ Msat/γ m·(∂_j m × ∂_k m) dV", GetGyrovector)
+	Ext_Dissipation           = NewVectorValue("ext_dissipation", "kg/s", "Thiele dissipation tensor components (Dxx, Dyy, Dxy), D_ij = ∫ Msat/γ ∂_i m·∂_j m dV", GetDissipation)
+)
+
+func SetEmergentField(dst *data.Slice) {
+	m := Download(&M).Vectors()
+	B := data.NewSlice(3, Mesh().Size())
+	b := B.Vectors()
+	forEachMagnetCell(m, func(i [3]int) {
+		omega := solidAngleDensity(m, i)
+		for c := 0; c < 3; c++ {
+			b[c][i[Z]][i[Y]][i[X]] = float32(mag.Hbar / (2 * mag.Qe) * omega[c])
+		}
+	})
+	data.Copy(dst, B)
+}
+
+func SetEmergentElectricField(dst *data.Slice) {
+	m := Download(&M).Vectors()
+	torque := Download(&Torque).Vectors()
+	E := data.NewSlice(3, Mesh().Size())
+	e := E.Vectors()
+	forEachMagnetCell(m, func(i [3]int) {
+		mi := vectorAt(m, i)
+		dmdt := vectorAt(torque, i).Mul(GammaLL)
+		for c := 0; c < 3; c++ {
+			e[c][i[Z]][i[Y]][i[X]] = float32(mag.Hbar / (2 * mag.Qe) * mi.Dot(gradM(m, i, c).Cross(dmdt)))
+		}
+	})
+	data.Copy(dst, E)
+}
+
+func GetGyrovector() []float64 {
+	var G data.Vector
+	thieleIntegral(func(m [3][][][]float32, i [3]int, w float64) {
+		G = G.MAdd(w, solidAngleDensity(m, i))
+	})
+	return G[:]
+}
+
+func GetDissipation() []float64 {
+	var D [3]float64
+	thieleIntegral(func(m [3][][][]float32, i [3]int, w float64) {
+		dx, dy := gradM(m, i, X), gradM(m, i, Y)
+		D[0] += w * dx.Dot(dx)
+		D[1] += w * dy.Dot(dy)
+		D[2] += w * dx.Dot(dy)
+	})
+	return D[:]
+}
+
+// calls f for each magnetic cell with the weight Msat/γ dV of that cell.
+func thieleIntegral(f func(m [3][][][]float32, i [3]int, w float64)) {
+	m := Download(&M).Vectors()
+	Ms := Download(&M_full).Vectors()
+	dV := cellVolume()
+	forEachMagnetCell(m, func(i [3]int) {
+		f(m, i, vectorAt(Ms, i).Len()/GammaLL*dV)
+	})
+}
+
+// Ω_i = ½ ε_ijk m·(∂_j m × ∂_k m) at cell i.
+func solidAngleDensity(m [3][][][]float32, i [3]int) data.Vector {
+	mi := vectorAt(m, i)
+	d := [3]data.Vector{gradM(m, i, X), gradM(m, i, Y), gradM(m, i, Z)}
+	return data.Vector{
+		mi.Dot(d[Y].Cross(d[Z])),
+		mi.Dot(d[Z].Cross(d[X])),
+		mi.Dot(d[X].Cross(d[Y])),
+	}
+}
+
+// ∂m/∂x_comp at cell i: central differences between magnetic cells,
+// one-sided next to a surface, zero without magnetic neighbors.
+func gradM(m [3][][][]float32, i [3]int, comp int) data.Vector {
+	c := Mesh().CellSize()[comp]
+	p, okp := magnetNeighbor(m, i, comp, 1)
+	n, okn := magnetNeighbor(m, i, comp, -1)
+	switch {
+	case okp && okn:
+		return vectorAt(m, p).Sub(vectorAt(m, n)).Div(2 * c)
+	case okp:
+		return vectorAt(m, p).Sub(vectorAt(m, i)).Div(c)
+	case okn:
+		return vectorAt(m, i).Sub(vectorAt(m, n)).Div(c)
+	default:
+		return data.Vector{}
+	}
+}
+
+func vectorAt(v [3][][][]float32, i [3]int) data.Vector {
+	return data.Vector{valueAt(v[X], i), valueAt(v[Y], i), valueAt(v[Z], i)}
+}
@@ -0,0 +1,161 @@
+package engine
+
+// Energy conservation check of the time integrator.
+// Without damping, drive, noise or currents, the LLG equation conserves the total energy,
+// so the energy drift measures the integration error, e.g.:
+//
+//	expect("drift", CheckEnergyConservation(10), 0, 1e-4)
+//
+// The check runs from the current state with alpha = 0, Temp = 0, J = 0
+// and all other time-dependent parameters (e.g. B_ext) frozen at their current value,
+// for the given number of precession periods 2π/(γ B), with B the rms effective field.
+// The drift is the slope of a linear fit of the energy after each period, relative to |E|. Afterwards m, t and all changed parameters
+// are restored, so the simulation continues as if the check had not been done.
+// The energy after each period is written to energyconservation.txt.
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+func init() {
+	DeclFunc("CheckEnergyConservation", CheckEnergyConservation, "Run N precession periods without damping or drive and return the relative total energy drift per period, see energyconservation.txt")
+}
+
+func CheckEnergyConservation(periods int) float64 {
+	util.Argument(periods > 0)
+	SanityCheck()
+
+	// keep the state...
+	m0 := cuda.Buffer(3, Mesh().Size())
+	defer cuda.Recycle(m0)
+	data.Copy(m0, M.Buffer())
+	t0, dt0, precess := Time, Dt_si, Precess
+	alpha, temp := saveParam(&Alpha.regionwise), saveParam(&Temp.regionwise)
+	j, jTerms := saveExcitation(J), J.extraTerms
+	unfreeze := freezeParams()
+
+	// ...to restore it later
+	defer func() {
+		data.Copy(M.Buffer(), m0)
+		Time, Dt_si, Precess = t0, dt0, precess
+		unfreeze()
+		restoreParam(&Alpha.regionwise, alpha)
+		restoreParam(&Temp.regionwise, temp)
+		restoreExcitation(J, j)
+		J.extraTerms = jTerms
+		stepper.Free() // the solver state belongs to the check
+	}()
+
+	// no damping, noise or currents, static parameters
+	Alpha.setUniform([]float64{0})
+	Temp.setUniform([]float64{0})
+	J.perRegion.setUniform([]float64{0, 0, 0})
+	J.extraTerms = nil
+	Precess = true
+	stepper.Free()
+
+	// precession period in the rms effective field
+	B := ValueOf(&B_eff)
+	Brms := math.Sqrt(float64(cuda.Dot(B, B)) / float64(Mesh().NCell()))
+	cuda.Recycle(B)
+	if Brms == 0 {
+		util.Fatal("CheckEnergyConservation: effective field is zero, there is no precession")
+	}
+	T := 2 * math.Pi / (GammaLL * Brms)
+
+	out, err := httpfs.Create(OD() + "energyconservation.txt")
+	util.FatalErr(err)
+	defer out.Close()
+	fmt.Fprintln(out, "# period\tt (s)\tE_total (J)\tdE (J)")
+
+	E0 := GetTotalEnergy()
+	fmt.Fprintf(out, "%d\t%g\t%g\t%g\n", 0, 0., E0, 0.)
+	steps0 := NSteps
+	maxDev := 0.
+	E := []float64{E0}
+	for p := 1; p <= periods; p++ {
+		stop := t0 + float64(p)*T
+		alarm = stop
+		runWhile(func() bool { return Time < stop }, false)
+		Ep := GetTotalEnergy()
+		E = append(E, Ep)
+		maxDev = math.Max(maxDev, math.Abs(Ep-E0))
+		fmt.Fprintf(out, "%d\t%g\t%g\t%g\n", p, Time-t0, Ep, Ep-E0)
+	}
+	NSteps = steps0
+
+	scale := math.Abs(E0)
+	if scale == 0 {
+		scale = 1 // absolute drift
+	}
+	drift := math.Abs(fitSlope(E)) / scale
+	LogOut(fmt.Sprintf("CheckEnergyConservation: %d periods of %.4g s, relative energy drift %.3g per period, max deviation %.4g J (E_total = %.6g J)",
+		periods, T, drift, maxDev, E0))
+	return drift
+}
+
+// slope of the least-squares line through (i, y[i]).
+func fitSlope(y []float64) float64 {
+	n := float64(len(y))
+	xm, ym := (n-1)/2, 0.
+	for _, y := range y {
+		ym += y / n
+	}
+	sxy, sxx := 0., 0.
+	for i, y := range y {
+		dx := float64(i) - xm
+		sxy += dx * (y - ym)
+		sxx += dx * dx
+	}
+	return sxy / sxx
+}
+
+// Freezes all time-dependent parameters and excitation terms at their current value,
+// returns a function that restores them.
+func freezeParams() (restore func()) {
+	var undo []func()
+	freeze := func(p *regionwise) {
+		timedep := false
+		for _, f := range p.upd_reg {
+			timedep = timedep || f != nil
+		}
+		if !timedep {
+			return
+		}
+		s := saveParam(p)
+		for r, f := range p.upd_reg {
+			if f != nil {
+				p.setRegion(r, s.values[r])
+			}
+		}
+		undo = append(undo, func() { restoreParam(p, s) })
+	}
+	for _, p := range gui_.Params {
+		switch p := p.(type) {
+		case *RegionwiseScalar:
+			freeze(&p.regionwise)
+		case *RegionwiseVector:
+			freeze(&p.regionwise)
+		case *Excitation:
+			freeze(&p.perRegion.regionwise)
+			terms := p.extraTerms
+			p.extraTerms = make([]mulmask, len(terms))
+			for i, t := range terms {
+				mul := float64(t.multiplier())
+				p.extraTerms[i] = mulmask{func() float64 { return mul }, t.mask, t.avg}
+			}
+			undo = append(undo, func() { p.extraTerms = terms })
+		}
+	}
+	return func() {
+		for _, f := range undo {
+			f()
+		}
+	}
+}
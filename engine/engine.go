@@ -46,8 +46,12 @@ func GetBusy() bool {
 
 // Cleanly exits the simulation, assuring all output is flushed.
 func Close() {
+	if SummaryReport && globalmesh_.Size() != [3]int{0, 0, 0} {
+		WriteSummary()
+	}
 	drainOutput()
 	Table.flush()
+	closeFeatures()
 	if logfile != nil {
 		logfile.Close()
 	}
@@ -30,7 +30,7 @@ func (_ *Euler) Step() {
 	setLastErr(float64(dt) * LastTorque)
 
 	cuda.Madd2(y, y, dy0, 1, dt) // y = y + dt * dy
-	M.normalize()
+	M.stepNormalize()
 	Time += Dt_si
 	NSteps++
 }
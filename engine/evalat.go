@@ -0,0 +1,54 @@
+package engine
+
+// Evaluation of quantities for a user-supplied magnetization,
+// without stepping the solver.
+
+import (
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+)
+
+func init() {
+	DeclFunc("EvalAt", EvalAt, "Evaluates a quantity for the given magnetization, without advancing time (returns host copy)")
+	DeclFunc("EnergyAt", EnergyAt, "Total energy (J) for the given magnetization, without advancing time")
+}
+
+// EvalAt evaluates quantity q as if the magnetization were m,
+// and returns the result as a host copy.
+// m is resampled to the current mesh if needed and normalized.
+// Time, steps and the magnetization itself are left untouched,
+// so this can be used to inspect imported states or NEB images.
+func EvalAt(q Quantity, m *data.Slice) *data.Slice {
+	var result *data.Slice
+	withMagnetization(m, func() {
+		v := ValueOf(q)
+		defer cuda.Recycle(v)
+		result = v.HostCopy()
+	})
+	return result
+}
+
+// EvalFieldAt returns the effective field for magnetization m.
+func EvalFieldAt(m *data.Slice) *data.Slice {
+	return EvalAt(&B_eff, m)
+}
+
+// EnergyAt returns the total energy for magnetization m.
+func EnergyAt(m *data.Slice) float64 {
+	var E float64
+	withMagnetization(m, func() { E = GetTotalEnergy() })
+	return E
+}
+
+// temporarily replace the magnetization by m while executing f,
+// restore the original magnetization afterwards.
+func withMagnetization(m *data.Slice, f func()) {
+	checkMesh()
+	backup := cuda.Buffer(M.NComp(), M.Buffer().Size())
+	defer cuda.Recycle(backup)
+	data.Copy(backup, M.Buffer())
+	defer data.Copy(M.Buffer(), backup)
+
+	M.SetArray(m)
+	f()
+}
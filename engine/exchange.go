@@ -43,18 +43,27 @@ func init() {
 
 // Adds the current exchange field to dst
 func AddExchangeField(dst *data.Slice) {
+	if thermalSpot.curie() {
+		thermalSpot.addExchangeScaled(dst)
+	} else {
+		ms := Msat.MSlice()
+		defer ms.Recycle()
+		addExchangeField(dst, M.Buffer(), ms)
+	}
+}
+
+// Adds the exchange field of magnetization m with saturation magnetization ms to dst.
+func addExchangeField(dst, m *data.Slice, ms cuda.MSlice) {
 	inter := !Dind.isZero()
 	bulk := !Dbulk.isZero()
-	ms := Msat.MSlice()
-	defer ms.Recycle()
 	switch {
 	case !inter && !bulk:
-		cuda.AddExchange(dst, M.Buffer(), lex2.Gpu(), ms, regions.Gpu(), M.Mesh())
+		cuda.AddExchange(dst, m, lex2.Gpu(), ms, regions.Gpu(), M.Mesh())
 	case inter && !bulk:
 		Refer("mulkers2017")
-		cuda.AddDMI(dst, M.Buffer(), lex2.Gpu(), din2.Gpu(), ms, regions.Gpu(), M.Mesh()) // dmi+exchange
+		cuda.AddDMI(dst, m, lex2.Gpu(), din2.Gpu(), ms, regions.Gpu(), M.Mesh()) // dmi+exchange
 	case bulk && !inter:
-		cuda.AddDMIBulk(dst, M.Buffer(), lex2.Gpu(), dbulk2.Gpu(), ms, regions.Gpu(), M.Mesh()) // dmi+exchange
+		cuda.AddDMIBulk(dst, m, lex2.Gpu(), dbulk2.Gpu(), ms, regions.Gpu(), M.Mesh()) // dmi+exchange
 		// TODO: add ScaleInterDbulk and InterDbulk
 	case inter && bulk:
 		util.Fatal("Cannot have induced and interfacial DMI at the same time")
@@ -146,14 +155,22 @@ func (p *exchParam) setScale(region1, region2 int, scale float64) {
 	p.invalidate()
 }
 
+// no coupling between different regions when one has Msat = 0, which would otherwise
+// act on its neighbors with an arbitrary magnetization. The coupling within a region
+// is kept: the DMI boundary condition divides by it.
 func (p *exchParam) update() {
 	if !p.cpu_ok {
 		ex := p.parent.cpuLUT()
+		ms := Msat.cpuLUT()
 		for i := 0; i < NREGION; i++ {
 			exi := ex[0][i]
 			for j := i; j < NREGION; j++ {
 				exj := ex[0][j]
 				I := symmidx(i, j)
+				if i != j && (ms[0][i] == 0 || ms[0][j] == 0) {
+					p.lut[I] = 0
+					continue
+				}
 				p.lut[I] = p.scale[I]*2/(1/exi+1/exj) + p.inter[I]
 			}
 		}
@@ -0,0 +1,240 @@
+package engine
+
+// Exchange bias from an antiferromagnetic (AF) layer in contact with the magnet,
+// without simulating the AF spins (after the grain model of O'Grady et al.).
+// Each grain region of the magnet sits on an AF grain with orientation n,
+// which exerts a unidirectional field
+//
+//	B_exbias = J n / (Msat t)
+//
+// with J the interfacial exchange energy (J/m²) and t the thickness of the mesh.
+// The AF grains have anisotropy energy K V, with volumes V = area tAF lognormally
+// distributed with standard deviation sigma (of ln V), and blocking temperature
+// TB = K V / (25 kB). Above TB (the cell temperature averaged over the region), an AF grain follows the
+// magnetization of its region with time constant ExchangeBiasTau (rotatable anisotropy).
+// Below TB it is frozen, but reverses thermally with rate f0 exp(-ΔE/kB T), where the
+// coupling to the magnet lowers the barrier ΔE = K V (1 + J area n·<m>/2KV)² of grains
+// opposing the magnetization (training effect). Field cooling from Tset along dir
+// sets all grains with TB < Tset. E.g.:
+//
+//	ext_makegrains(10e-9, 100, 1)
+//	ExchangeBias(0, 99, 1e-4, 1e5, 5e-9, 0.4, 1)
+//	ExchangeBiasSet(vector(1, 0, 0), 500)
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/mag"
+	"github.com/mumax/3/util"
+)
+
+var (
+	B_exbias        = NewVectorField("B_exbias", "T", "Exchange bias field", AddExchangeBiasField)
+	Edens_exbias    = NewScalarField("Edens_exbias", "J/m3", "Exchange bias energy density", AddEdens_exbias)
+	E_exbias        = NewScalarValue("E_exbias", "J", "Exchange bias energy", GetExchangeBiasEnergy)
+	ExchangeBiasTau = 1e-9 // time constant of AF grains above their blocking temperature (s)
+	ExchangeBiasF0  = 1e9  // attempt frequency of AF grain reversal (Hz)
+
+	afGrains []afGrain
+	afJ      float64 // interfacial exchange energy (J/m²)
+	afLUT    biasLUT // B_exbias per region
+	afRnd    *rand.Rand
+	afTime   = math.NaN() // time of the last AF update
+)
+
+var AddEdens_exbias = makeEdensAdder(B_exbias, -1)
+
+func init() {
+	registerEnergy(GetExchangeBiasEnergy, AddEdens_exbias)
+	DeclFunc("ExchangeBias", ExchangeBias, "Exchange bias from AF grains under regions r1..r2 (inclusive): interfacial exchange J (J/m²), AF anisotropy K (J/m3), AF thickness (m), lognormal volume spread sigma, random seed")
+	DeclFunc("ExchangeBiasSet", ExchangeBiasSet, "Field cool from Tset (K): set AF grains with blocking temperature below Tset along dir. Returns the fraction of grains set")
+	DeclVar("ExchangeBiasTau", &ExchangeBiasTau, "Time constant for AF grains above their blocking temperature to follow the magnetization (s)")
+	DeclVar("ExchangeBiasF0", &ExchangeBiasF0, "Attempt frequency of thermal AF grain reversal (Hz)")
+	afLUT.init(3, &afLUT)
+	PostStep(updateAFGrains)
+}
+
+type afGrain struct {
+	region int
+	KV     float64     // anisotropy energy barrier (J)
+	area   float64     // interface area (m²)
+	n      data.Vector // AF orientation at the interface
+}
+
+// blocking temperature
+func (g *afGrain) TB() float64 { return g.KV / (25 * mag.Kb) }
+
+// look-up table of B_exbias, re-computed when the AF grains or Msat have changed
+type biasLUT struct {
+	lut
+	dirty bool
+	msat  [NREGION]float32 // Msat the table was computed for
+}
+
+func (b *biasLUT) update() {
+	if b.dirty || b.msat != Msat.cpuLUT()[0] {
+		b.compute()
+	}
+}
+
+func (b *biasLUT) compute() {
+	b.msat = Msat.cpuLUT()[0]
+	t := Mesh().WorldSize()[Z]
+	for c := range b.cpu_buf {
+		for r := range b.cpu_buf[c] {
+			b.cpu_buf[c][r] = 0
+		}
+	}
+	for _, g := range afGrains {
+		Ms := float64(b.msat[g.region])
+		if Ms == 0 {
+			continue
+		}
+		B := g.n.Mul(afJ / (Ms * t))
+		for c := range B {
+			b.cpu_buf[c][g.region] = float32(B[c])
+		}
+	}
+	b.dirty = false
+	b.gpu_ok = false
+}
+
+func ExchangeBias(r1, r2 int, J, Kaf, tAF, sigma float64, seed int) {
+	checkRegionRange(r1, r2)
+	util.Argument(Kaf > 0 && tAF > 0 && sigma >= 0)
+	t := Mesh().WorldSize()[Z]
+	afRnd = rand.New(rand.NewSource(int64(seed)))
+	afJ = J
+	afGrains = afGrains[:0]
+	for r := r1; r <= r2; r++ {
+		area := regionVolume(r) / t
+		if area == 0 {
+			continue
+		}
+		V := area * tAF * math.Exp(sigma*afRnd.NormFloat64()-sigma*sigma/2)
+		n := randomCone(afRnd, data.Vector{0, 0, 1}, math.Pi) // as deposited: random
+		afGrains = append(afGrains, afGrain{region: r, KV: Kaf * V, area: area, n: n})
+	}
+	afTime = math.NaN()
+	invalidateAFLUT()
+	LogOut("ExchangeBias: ", len(afGrains), " AF grains")
+}
+
+func ExchangeBiasSet(dir data.Vector, Tset float64) float64 {
+	f, err := exchangeBiasSet(dir, Tset)
+	util.FatalErr(err)
+	return f
+}
+
+// sets the AF grains and returns the fraction set, an error if there are none.
+func exchangeBiasSet(dir data.Vector, Tset float64) (float64, error) {
+	if len(afGrains) == 0 {
+		return 0, fmt.Errorf("ExchangeBiasSet: no AF grains, need ExchangeBias on regions with cells first")
+	}
+	util.Argument(dir.Len() != 0)
+	dir = dir.Div(dir.Len())
+	set := 0
+	for i := range afGrains {
+		if afGrains[i].TB() < Tset {
+			afGrains[i].n = dir
+			set++
+		}
+	}
+	invalidateAFLUT()
+	f := float64(set) / float64(len(afGrains))
+	LogOut("ExchangeBiasSet: set ", set, " of ", len(afGrains), " AF grains")
+	return f, nil
+}
+
+// Rotates the unblocked AF grains towards the magnetization of their region
+// and reverses blocked grains with the thermal switching probability over the last step.
+// The temperature of a grain is the average cell temperature of its region,
+// which includes a ThermalSpot.
+func updateAFGrains() {
+	if len(afGrains) == 0 {
+		return
+	}
+	dt := Time - afTime
+	afTime = Time
+	if !(dt > 0) || !thermalNoise() {
+		return
+	}
+
+	rs := make([]int, len(afGrains))
+	for i := range afGrains {
+		rs[i] = afGrains[i].region
+	}
+	temp := ValueOf(&Temp_cell)
+	T := regionAverages(temp, rs)
+	cuda.Recycle(temp)
+	var m [][]float64 // only needed at T != 0
+
+	changed := false
+	for i := range afGrains {
+		g := &afGrains[i]
+		T := T[g.region][0]
+		if T == 0 {
+			continue
+		}
+		if m == nil {
+			m = regionAverages(M.Buffer(), rs)
+		}
+		mg := data.Vector(unslice(m[g.region]))
+		if l := mg.Len(); l != 0 {
+			mg = mg.Div(l)
+		}
+		if T < g.TB() {
+			h := afJ * g.area * g.n.Dot(mg) / (2 * g.KV)
+			dE := g.KV * sqr(math.Max(0, 1+h))
+			if afRnd.Float64() < -math.Expm1(-dt*ExchangeBiasF0*math.Exp(-dE/(mag.Kb*T))) {
+				g.n = g.n.Mul(-1)
+				changed = true
+			}
+			continue
+		}
+		n := g.n.MAdd(-math.Expm1(-dt/ExchangeBiasTau), mg.Sub(g.n))
+		if n.Len() != 0 {
+			g.n = n.Div(n.Len())
+		}
+		changed = true
+	}
+	if changed {
+		invalidateAFLUT()
+	}
+}
+
+// average of src over each of the regions rs, indexed by region,
+// by a reduction on the GPU per region.
+func regionAverages(src *data.Slice, rs []int) [][]float64 {
+	buf := cuda.Buffer(src.NComp(), src.Size())
+	defer cuda.Recycle(buf)
+	avg := make([][]float64, NREGION)
+	for _, r := range rs {
+		if avg[r] != nil {
+			continue
+		}
+		cuda.RegionSelect(buf, src, regions.Gpu(), byte(r))
+		avg[r] = sAverageUniverse(buf)
+		sDiv(avg[r], regions.volume(r))
+	}
+	return avg
+}
+
+func invalidateAFLUT() { afLUT.dirty = true }
+
+func AddExchangeBiasField(dst *data.Slice) {
+	if len(afGrains) != 0 {
+		cuda.RegionAddV(dst, afLUT.gpuLUT(), regions.Gpu())
+	}
+}
+
+func GetExchangeBiasEnergy() float64 {
+	if len(afGrains) == 0 {
+		return 0
+	}
+	return -1 * cellVolume() * dot(&M_full, B_exbias)
+}
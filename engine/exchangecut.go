@@ -0,0 +1,69 @@
+package engine
+
+// Exchange-decoupled surfaces, e.g. cracks, oxidized grain boundaries
+// or patterned media. The cells inside a shape are moved to new regions,
+// copies of their original ones, whose exchange and DMI coupling
+// with all other regions is cut. E.g., a crack along the plane x=0:
+//
+//	ext_CutExchange(xrange(0, inf))
+
+import (
+	"github.com/mumax/3/util"
+)
+
+func init() {
+	DeclFunc("ext_CutExchange", CutExchange, "Cut the exchange and DMI coupling across the surface of a shape, by moving the cells inside to new regions")
+}
+
+// Moves the cells inside s to copies of their regions,
+// and sets the exchange and DMI coupling between these copies
+// and all other regions to zero. Coupling among the copies is unchanged.
+func CutExchange(s Shape) {
+	checkMesh()
+	util.Argument(s != nil)
+	n := Mesh().Size()
+	l := regions.HostList()
+	arr := reshapeBytes(l, n)
+
+	clone := make(map[int]int) // original region -> copy inside s
+	for iz := 0; iz < n[Z]; iz++ {
+		for iy := 0; iy < n[Y]; iy++ {
+			for ix := 0; ix < n[X]; ix++ {
+				r := Index2Coord(ix, iy, iz)
+				if !s(r[X], r[Y], r[Z]) {
+					continue
+				}
+				src := int(arr[iz][iy][ix])
+				c, ok := clone[src]
+				if !ok {
+					c = freeRegion()
+					layerRegions = append(layerRegions, c)
+					copyRegionParams(src, c)
+					clone[src] = c
+				}
+				arr[iz][iy][ix] = byte(c)
+			}
+		}
+	}
+	if len(clone) == 0 {
+		LogErr("ext_CutExchange: shape contains no cells")
+		return
+	}
+	regions.gpuCache.Upload(l)
+	regions.frac = nil
+
+	inside := make([]int, 0, len(clone))
+	for _, c := range clone {
+		inside = append(inside, c)
+	}
+	for _, c := range inside {
+		for r := 0; r < NREGION; r++ {
+			if !containsInt(inside, r) {
+				lex2.setInter(c, r, 0)
+				din2.setInter(c, r, 0)
+				dbulk2.setInter(c, r, 0)
+			}
+		}
+	}
+	LogOut("ext_CutExchange: ", len(clone), " new regions")
+}
@@ -21,6 +21,7 @@ type Excitation struct {
 type mulmask struct {
 	mul  func() float64
 	mask *data.Slice
+	avg  []float64 // average of mask, so the excitation can be averaged without rendering it
 }
 
 func NewExcitation(name, unit, desc string) *Excitation {
@@ -42,13 +43,21 @@ func (e *Excitation) AddTo(dst *data.Slice) {
 		cuda.RegionAddV(dst, e.perRegion.gpuLUT(), regions.Gpu())
 	}
 
-	for _, t := range e.extraTerms {
-		var mul float32 = 1
-		if t.mul != nil {
-			mul = float32(t.mul())
-		}
-		cuda.Madd2(dst, dst, t.mask, 1, mul)
+	// add extra terms two at a time, saving a pass over dst per pair
+	terms := e.extraTerms
+	for ; len(terms) >= 2; terms = terms[2:] {
+		cuda.Madd3(dst, dst, terms[0].mask, terms[1].mask, 1, terms[0].multiplier(), terms[1].multiplier())
+	}
+	if len(terms) == 1 {
+		cuda.Madd2(dst, dst, terms[0].mask, 1, terms[0].multiplier())
+	}
+}
+
+func (t *mulmask) multiplier() float32 {
+	if t.mul == nil {
+		return 1
 	}
+	return float32(t.mul())
 }
 
 func (e *Excitation) isZero() bool {
@@ -102,7 +111,7 @@ func (e *Excitation) AddGo(mask *data.Slice, mul func() float64) {
 		mask = data.Resample(mask, e.Mesh().Size())
 		mask = assureGPU(mask)
 	}
-	e.extraTerms = append(e.extraTerms, mulmask{mul, mask})
+	e.extraTerms = append(e.extraTerms, mulmask{mul, mask, sAverageUniverse(mask)})
 }
 
 func (e *Excitation) SetRegion(region int, f script.VectorFunction) { e.perRegion.SetRegion(region, f) }
@@ -116,8 +125,8 @@ func (e *Excitation) SetRegionFn(region int, f func() [3]float64) {
 	})
 }
 
-func (e *Excitation) average() []float64      { return qAverageUniverse(e) }
-func (e *Excitation) Average() data.Vector    { return unslice(qAverageUniverse(e)) }
+func (e *Excitation) average() []float64      { return e.averageUniverse() }
+func (e *Excitation) Average() data.Vector    { return unslice(e.average()) }
 func (e *Excitation) IsUniform() bool         { return e.perRegion.IsUniform() }
 func (e *Excitation) Name() string            { return e.name }
 func (e *Excitation) Unit() string            { return e.perRegion.Unit() }
@@ -140,3 +149,16 @@ func checkNaN(s *data.Slice, name string) {
 		}
 	}
 }
+
+// average over the universe from the region values and mask averages,
+// without rendering the excitation to a field.
+func (e *Excitation) averageUniverse() []float64 {
+	avg := e.perRegion.average()
+	for _, t := range e.extraTerms {
+		mul := float64(t.multiplier())
+		for c := range avg {
+			avg[c] += mul * t.avg[c]
+		}
+	}
+	return avg
+}
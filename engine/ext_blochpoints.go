@@ -0,0 +1,143 @@
+package engine
+
+// Bloch point detection: the topological index of the magnetization on the surface
+// of each cube of 2x2x2 neighboring cells, i.e. the number of times m covers the unit sphere,
+//
+//	q = 1/4π ∮ m·(∂m/∂u × ∂m/∂v) du dv,
+//
+// is ±1 for a cube enclosing a Bloch point and 0 otherwise. Each face is split in
+// two triangles with solid angles Ω = 2 atan2(a·(b×c), 1 + a·b + b·c + c·a).
+// Cubes with cells outside the geometry are skipped. E.g.:
+//
+//	TableAdd(ext_nblochpoints)
+//	ext_TrackBlochPoints(10e-12)
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+var (
+	Ext_NBlochPoints = NewScalarValue("ext_nblochpoints", "", "Number of Bloch points", func() float64 { return float64(len(blochPoints())) })
+
+	bpTrack struct {
+		period, next float64
+		out          httpfs.WriteCloseFlusher
+		n            int // number of Bloch points at the last check
+	}
+)
+
+func init() {
+	DeclFunc("ext_BlochPoints", BlochPoints, "Log the positions and indices of all Bloch points, returns their number")
+	DeclFunc("ext_TrackBlochPoints", TrackBlochPoints, "Write the Bloch point positions to blochpoints.txt every period (s), and log when they are created or annihilated")
+	PostStep(trackBlochPoints)
+}
+
+type blochPoint struct {
+	r     data.Vector // center of the cube
+	index int         // topological index, ±1
+}
+
+func BlochPoints() int {
+	bp := blochPoints()
+	for _, p := range bp {
+		LogOut(fmt.Sprintf("Bloch point at (%g, %g, %g) m, index %+d", p.r[X], p.r[Y], p.r[Z], p.index))
+	}
+	return len(bp)
+}
+
+func TrackBlochPoints(period float64) {
+	util.Argument(period > 0)
+	if bpTrack.out == nil {
+		var err error
+		bpTrack.out, err = httpfs.Create(OD() + "blochpoints.txt")
+		util.FatalErr(err)
+		fmt.Fprintln(bpTrack.out, "# t (s)\tx (m)\ty (m)\tz (m)\tindex ()")
+	}
+	bpTrack.period = period
+	bpTrack.next = Time
+	bpTrack.n = -1
+}
+
+func trackBlochPoints() {
+	t := &bpTrack
+	if t.period == 0 || Time < t.next {
+		return
+	}
+	t.next += t.period * math.Ceil((Time-t.next+t.period/2)/t.period)
+	bp := blochPoints()
+	for _, p := range bp {
+		fmt.Fprintf(t.out, "%g\t%g\t%g\t%g\t%d\n", Time, p.r[X], p.r[Y], p.r[Z], p.index)
+	}
+	t.out.Flush()
+	if t.n >= 0 && len(bp) != t.n {
+		LogOut(fmt.Sprintf("t = %g s: number of Bloch points changed from %d to %d", Time, t.n, len(bp)))
+	}
+	t.n = len(bp)
+}
+
+func blochPoints() []blochPoint {
+	m := M.Buffer().HostCopy().Vectors()
+	n := Mesh().Size()
+	c := Mesh().CellSize()
+	var bp []blochPoint
+	var corner [2][2][2]data.Vector
+	for iz := 0; iz+1 < n[Z]; iz++ {
+		for iy := 0; iy+1 < n[Y]; iy++ {
+		cube:
+			for ix := 0; ix+1 < n[X]; ix++ {
+				for dz := 0; dz < 2; dz++ {
+					for dy := 0; dy < 2; dy++ {
+						for dx := 0; dx < 2; dx++ {
+							x, y, z := ix+dx, iy+dy, iz+dz
+							v := data.Vector{float64(m[X][z][y][x]), float64(m[Y][z][y][x]), float64(m[Z][z][y][x])}
+							if v == (data.Vector{}) {
+								continue cube
+							}
+							corner[dx][dy][dz] = v
+						}
+					}
+				}
+				q := int(math.Round(cubeIndex(&corner)))
+				if q != 0 {
+					r := Index2Coord(ix, iy, iz).Add(data.Vector{c[X] / 2, c[Y] / 2, c[Z] / 2})
+					bp = append(bp, blochPoint{r, q})
+				}
+			}
+		}
+	}
+	return bp
+}
+
+// faces of the unit cube, counter-clockwise seen from outside
+var cubeFaces = [6][4][3]int{
+	{{0, 0, 0}, {0, 0, 1}, {0, 1, 1}, {0, 1, 0}}, // -x
+	{{1, 0, 0}, {1, 1, 0}, {1, 1, 1}, {1, 0, 1}}, // +x
+	{{0, 0, 0}, {1, 0, 0}, {1, 0, 1}, {0, 0, 1}}, // -y
+	{{0, 1, 0}, {0, 1, 1}, {1, 1, 1}, {1, 1, 0}}, // +y
+	{{0, 0, 0}, {0, 1, 0}, {1, 1, 0}, {1, 0, 0}}, // -z
+	{{0, 0, 1}, {1, 0, 1}, {1, 1, 1}, {0, 1, 1}}, // +z
+}
+
+// topological index of the magnetization on the surface of a cube with the given corner values.
+func cubeIndex(m *[2][2][2]data.Vector) float64 {
+	at := func(p [3]int) data.Vector {
+		v := m[p[0]][p[1]][p[2]]
+		return v.Div(v.Len())
+	}
+	omega := 0.
+	for _, f := range cubeFaces {
+		a, b, c, d := at(f[0]), at(f[1]), at(f[2]), at(f[3])
+		omega += solidAngle(a, b, c) + solidAngle(a, c, d)
+	}
+	return omega / (4 * math.Pi)
+}
+
+// signed solid angle of the spherical triangle a, b, c (unit vectors)
+func solidAngle(a, b, c data.Vector) float64 {
+	return 2 * math.Atan2(a.Dot(b.Cross(c)), 1+a.Dot(b)+b.Dot(c)+c.Dot(a))
+}
@@ -45,7 +45,8 @@ func bubblePos() []float64 {
 		posx = float64(weightedsum / magsum)
 	}
 
-	return []float64{(posx-float64(n[X]/2))*c[X] + GetShiftPos(), (posy-float64(n[Y]/2))*c[Y] + GetShiftYPos(), 0}
+	o := meshOffset()
+	return []float64{(posx-float64(n[X]/2))*c[X] + GetShiftPos() + o[X], (posy-float64(n[Y]/2))*c[Y] + GetShiftYPos() + o[Y], 0}
 }
 
 var (
@@ -14,9 +14,10 @@ func centerBubble() {
 	c := Mesh().CellSize()
 
 	position := bubblePos()
+	o := meshOffset()
 	var centerIdx [2]int
-	centerIdx[X] = int(math.Floor((position[X] - GetShiftPos()) / c[X]))
-	centerIdx[Y] = int(math.Floor((position[Y] - GetShiftYPos()) / c[Y]))
+	centerIdx[X] = int(math.Floor((position[X] - GetShiftPos() - o[X]) / c[X]))
+	centerIdx[Y] = int(math.Floor((position[Y] - GetShiftYPos() - o[Y]) / c[Y]))
 
 	sign := magsign(M.GetCell(0, n[Y]/2, n[Z]/2)[Z]) //TODO make more robust with temperature?
 	zero := data.Vector{0, 0, 0}
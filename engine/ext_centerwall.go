@@ -6,7 +6,7 @@ import (
 )
 
 var (
-	DWPos   = NewScalarValue("ext_dwpos", "m", "Position of the simulation window while following a domain wall", GetShiftPos) // TODO: make more accurate
+	DWPos   = NewScalarValue("ext_dwpos", "m", "Position of the simulation window while following a domain wall", getDWPos) // TODO: make more accurate
 	DWSpeed = NewScalarValue("ext_dwspeed", "m/s", "Speed of the simulation window while following a domain wall", getShiftSpeed)
 )
 
@@ -62,6 +62,11 @@ var (
 	lastV     float64 // speed the last time we queried speed
 )
 
+// window position in world coordinates, including the origin set with SetOrigin
+func getDWPos() float64 {
+	return GetShiftPos() + meshOffset()[X]
+}
+
 func getShiftSpeed() float64 {
 	if lastShift != GetShiftPos() {
 		lastV = (GetShiftPos() - lastShift) / (Time - lastT)
@@ -0,0 +1,166 @@
+package engine
+
+// Lumped circuit driving a magnetoresistive device (MTJ, spin valve).
+// The device resistance depends on the angle between m and the fixed layer,
+// and feeds back into the current through the circuit, which drives
+// the spin-transfer torque as J along z (current perpendicular to plane).
+
+import (
+	"math"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/script"
+	"github.com/mumax/3/util"
+)
+
+var (
+	CircuitRP, CircuitRAP float64 // device resistance in parallel and antiparallel state (Ohm)
+	CircuitArea           float64 // device cross section (m2), 0 means the device volume over the mesh thickness
+	circuitRegions        []int   // regions forming the device, none means the whole geometry
+	circuitCurrent        func() float64
+	circuitVoltage        func() float64
+)
+
+var (
+	DeviceResistance = NewScalarValue("ext_resistance", "Ohm", "Magnetoresistive device resistance", deviceResistance)
+	DeviceCurrent    = NewScalarValue("ext_devicecurrent", "A", "Current through the device", func() float64 { return circuit(circuitCurrent) })
+	DeviceVoltage    = NewScalarValue("ext_devicevoltage", "V", "Voltage over the device", func() float64 { return circuit(circuitVoltage) })
+)
+
+func init() {
+	DeclVar("ext_RP", &CircuitRP, "Device resistance in parallel state (Ohm)")
+	DeclVar("ext_RAP", &CircuitRAP, "Device resistance in antiparallel state (Ohm)")
+	DeclVar("ext_CircuitArea", &CircuitArea, "Device cross section for the current density (m2), 0 means the device volume over the mesh thickness")
+	DeclFunc("ext_CircuitRegion", CircuitRegion, "Add a region to the magnetoresistive device driven by the circuit (default: the whole geometry). Call before defining the circuit.")
+	DeclFunc("ext_RLCircuit", RLCircuit, "Drive J by a voltage source (V) with series resistance Rs (Ohm) and inductance L (H) over the device")
+	DeclFunc("ext_RCCircuit", RCCircuit, "Drive J by a voltage source (V) with series resistance Rs (Ohm), capacitance C (F) parallel to the device")
+}
+
+// Add region to the device: the resistance is averaged over, and the current flows through
+// the device regions only. Without device regions, the device is the whole geometry.
+func CircuitRegion(region int) {
+	defRegionId(region)
+	if circuitCurrent != nil {
+		util.Fatal("ext_CircuitRegion: circuit already defined")
+	}
+	for _, r := range circuitRegions {
+		if r == region {
+			return
+		}
+	}
+	circuitRegions = append(circuitRegions, region)
+}
+
+// Voltage source V, series resistor Rs and inductor L:
+// L dI/dt = V - (Rs + R) I.
+func RLCircuit(V script.ScalarFunction, Rs, L float64) {
+	checkCircuit()
+	util.Argument(L > 0)
+	V = V.Fix().(script.ScalarFunction)
+	I := NewODEVar("ext_circuitI", "A", 0)
+	I.rhs = func() float64 {
+		return (V.Float() - (Rs+deviceResistance())*I.value) / L
+	}
+	circuitCurrent = I.Get
+	circuitVoltage = func() float64 { return I.value * deviceResistance() }
+	driveCurrent()
+}
+
+// Voltage source V, series resistor Rs and capacitor C parallel to the device:
+// C dVc/dt = (V - Vc)/Rs - Vc/R.
+func RCCircuit(V script.ScalarFunction, Rs, C float64) {
+	checkCircuit()
+	util.Argument(Rs > 0 && C > 0)
+	V = V.Fix().(script.ScalarFunction)
+	Vc := NewODEVar("ext_circuitVc", "V", 0)
+	Vc.rhs = func() float64 {
+		return ((V.Float()-Vc.value)/Rs - Vc.value/deviceResistance()) / C
+	}
+	circuitCurrent = func() float64 { return Vc.value / deviceResistance() }
+	circuitVoltage = Vc.Get
+	driveCurrent()
+}
+
+func checkCircuit() {
+	if circuitCurrent != nil {
+		util.Fatal("circuit already defined")
+	}
+	if CircuitRP <= 0 || CircuitRAP <= 0 {
+		util.Fatal("circuit: need to set ext_RP and ext_RAP first")
+	}
+	if FixedLayer.isZero() {
+		util.Fatal("circuit: need to set FixedLayer first")
+	}
+}
+
+// set J to follow the circuit current, along z, in the device regions.
+func driveCurrent() {
+	Jz := func() []float64 {
+		area := CircuitArea
+		if area == 0 {
+			c := Mesh().CellSize()
+			area = deviceNCell() * c[X] * c[Y] / float64(Mesh().Size()[Z])
+		}
+		return []float64{0, 0, circuitCurrent() / area}
+	}
+	if len(circuitRegions) == 0 {
+		J.perRegion.setFunc(0, NREGION, Jz)
+		return
+	}
+	for _, r := range circuitRegions {
+		J.perRegion.setFunc(r, r+1, Jz)
+	}
+}
+
+// resistance from the angle between m and the fixed layer polarization,
+// averaged over the device cells inside the geometry:
+// R = RP + (RAP-RP)(1-mp)/2
+func deviceResistance() float64 {
+	if CircuitRP == 0 && CircuitRAP == 0 {
+		return math.NaN()
+	}
+	mp := ValueOf(Dot(&M, FixedLayer))
+	defer cuda.Recycle(mp)
+	mask := deviceMask()
+	defer cuda.Recycle(mask)
+	cos := float64(cuda.Dot(mp, mask) / cuda.Sum(mask))
+	return CircuitRP + (CircuitRAP-CircuitRP)*(1-cos)/2
+}
+
+// number of device cells in the geometry, fractional cells counting partially.
+func deviceNCell() float64 {
+	mask := deviceMask()
+	defer cuda.Recycle(mask)
+	return float64(cuda.Sum(mask))
+}
+
+// returns a buffer with the geometry volume fraction in the device cells, 0 elsewhere.
+func deviceMask() *data.Slice {
+	mask := cuda.Buffer(1, Mesh().Size())
+	if geometry.Gpu().IsNil() {
+		cuda.Memset(mask, 1)
+	} else {
+		data.Copy(mask, geometry.Gpu())
+	}
+	if len(circuitRegions) == 0 {
+		return mask
+	}
+	dev := cuda.Buffer(1, Mesh().Size())
+	cuda.Zero(dev)
+	buf := cuda.Buffer(1, Mesh().Size())
+	defer cuda.Recycle(buf)
+	for _, r := range circuitRegions {
+		cuda.RegionSelect(buf, mask, regions.Gpu(), byte(r))
+		cuda.Madd2(dev, dev, buf, 1, 1)
+	}
+	cuda.Recycle(mask)
+	return dev
+}
+
+func circuit(f func() float64) float64 {
+	if f == nil {
+		return 0
+	}
+	return f()
+}
@@ -41,6 +41,9 @@ func corePos() []float64 {
 	pos[Z] = float64(m_z[maxZ][maxY][maxX]) // 3rd coordinate is core polarization
 
 	pos[X] += GetShiftPos() // add simulation window shift
+	o := meshOffset()
+	pos[X] += o[X]
+	pos[Y] += o[Y]
 	return pos
 }
 
@@ -0,0 +1,128 @@
+package engine
+
+// Quick thermal stability estimates based on coherent rotation:
+// energy barrier from the anisotropy and demag energy of a region,
+// attempt frequency from Brown's high-barrier formula.
+// They are table quantities for the region selected with ext_BarrierRegion, e.g.:
+//
+//	ext_BarrierRegion = 1
+//	TableAdd(ext_energybarrier)
+//	TableAdd(ext_arrheniustime)
+
+import (
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/mag"
+	"github.com/mumax/3/util"
+	"math"
+)
+
+var (
+	BarrierRegion = 0 // region of the thermal stability estimates
+
+	Ext_EnergyBarrier    = NewScalarValue("ext_energybarrier", "J", "Coherent-rotation energy barrier of region ext_BarrierRegion, from anisotropy and demag energy", func() float64 { return energyBarrier(BarrierRegion) })
+	Ext_StabilityFactor  = NewScalarValue("ext_stabilityfactor", "", "Thermal stability factor Δ = E_barrier/(kB T) of region ext_BarrierRegion", func() float64 { return stabilityFactor(BarrierRegion, energyBarrier(BarrierRegion)) })
+	Ext_AttemptFrequency = NewScalarValue("ext_attemptfrequency", "1/s", "Temperature-independent part α γ B_K/(1+α²) of Brown's attempt frequency of region ext_BarrierRegion", func() float64 { return attemptFrequency(BarrierRegion, energyBarrier(BarrierRegion)) })
+	Ext_ArrheniusTime    = NewScalarValue("ext_arrheniustime", "s", "Néel-Arrhenius mean switching time of region ext_BarrierRegion (Brown's high-barrier formula)", func() float64 { return arrheniusTime(BarrierRegion) })
+)
+
+func init() {
+	DeclVar("ext_BarrierRegion", &BarrierRegion, "Region of ext_energybarrier, ext_stabilityfactor, ext_attemptfrequency and ext_arrheniustime (default 0)")
+}
+
+// number of trial directions in the hard plane
+const nBarrierTrials = 4
+
+// energyBarrier estimates the energy barrier for coherent reversal of a region.
+// The region is magnetized uniformly along its easy axis (AnisU, or the current
+// average magnetization of the region if there is none) and along several
+// directions perpendicular to it. The barrier is the lowest anisotropy + demag
+// energy increase found. The magnetization outside the region is kept fixed,
+// the current magnetization is restored afterwards.
+func energyBarrier(region int) float64 {
+	defRegionId(region)
+	u := easyAxis(region)
+	e1 := perpendicular(u)
+	e2 := u.Cross(e1)
+
+	E0 := regionUniformEnergy(region, u)
+	barrier := math.Inf(1)
+	for i := 0; i < nBarrierTrials; i++ {
+		phi := float64(i) * math.Pi / nBarrierTrials
+		dir := e1.Mul(math.Cos(phi)).MAdd(math.Sin(phi), e2)
+		barrier = math.Min(barrier, regionUniformEnergy(region, dir)-E0)
+	}
+	return barrier
+}
+
+// Δ = E_barrier / (kB T), using the temperature of the region (+Inf at T = 0).
+func stabilityFactor(region int, Eb float64) float64 {
+	return Eb / (mag.Kb * Temp.GetRegion(region))
+}
+
+// f0 = α γ B_K / (1+α²), with the anisotropy field B_K = 2 E_barrier / (Msat V) of the region.
+// Brown's high-barrier attempt frequency is f0 sqrt(Δ/π): the part that depends
+// on temperature is left out, so f0 is also defined at T = 0.
+func attemptFrequency(region int, Eb float64) float64 {
+	alpha := Alpha.GetRegion(region)
+	Ms := Msat.GetRegion(region)
+	BK := 2 * Eb / (Ms * regionVolume(region))
+	return alpha * GammaLL * BK / (1 + alpha*alpha)
+}
+
+// mean switching time τ = exp(Δ) / (f0 sqrt(Δ/π)), from a single barrier evaluation.
+func arrheniusTime(region int) float64 {
+	Eb := energyBarrier(region)
+	delta := stabilityFactor(region, Eb)
+	if Temp.GetRegion(region) == 0 {
+		return math.Inf(1)
+	}
+	return math.Exp(delta) / (attemptFrequency(region, Eb) * math.Sqrt(delta/math.Pi))
+}
+
+// anisotropy + demag energy with the region uniformly magnetized along dir.
+func regionUniformEnergy(region int, dir data.Vector) float64 {
+	m := M.Buffer().HostCopy()
+	v := m.Vectors()
+	reg := regions.HostArray()
+	for iz := range reg {
+		for iy := range reg[iz] {
+			for ix := range reg[iz][iy] {
+				if int(reg[iz][iy][ix]) == region {
+					for c := 0; c < 3; c++ {
+						v[c][iz][iy][ix] = float32(dir[c])
+					}
+				}
+			}
+		}
+	}
+	var E float64
+	withMagnetization(m, func() { E = GetAnisotropyEnergy() + GetDemagEnergy() })
+	return E
+}
+
+// uniaxial anisotropy axis of the region, or its average magnetization direction.
+func easyAxis(region int) data.Vector {
+	u := data.Vector(AnisU.GetRegion(region))
+	if (Ku1.GetRegion(region) == 0 && Ku2.GetRegion(region) == 0) || u.Len() == 0 {
+		u = M.Region(region).Average()
+	}
+	if u.Len() == 0 {
+		util.Fatal("energy barrier: no easy axis or magnetization in region ", region)
+	}
+	return u.Div(u.Len())
+}
+
+// a unit vector perpendicular to unit vector u.
+func perpendicular(u data.Vector) data.Vector {
+	a := data.Vector{1, 0, 0}
+	if math.Abs(u[X]) > 0.9 {
+		a = data.Vector{0, 1, 0}
+	}
+	p := u.Cross(a)
+	return p.Div(p.Len())
+}
+
+// volume of a region in m3
+func regionVolume(region int) float64 {
+	return regions.volume(region) * float64(Mesh().NCell()) * cellVolume()
+}
@@ -0,0 +1,33 @@
+package engine
+
+// Field cooling: run the thermal dynamics while the temperature is ramped
+// down under an applied field, to prepare field-cooled initial states.
+
+import (
+	"math"
+
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/util"
+)
+
+func init() {
+	DeclFunc("ext_fieldcool", FieldCool, "Run while ramping Temp linearly from Tstart to Tend (K) at rate (K/s) under field B (T). B_ext is restored afterwards")
+}
+
+// Runs the dynamics while Temp decreases linearly from Tstart to Tend
+// at the given rate (K/s) in all regions, with B_ext set to B.
+// Afterwards Temp = Tend and B_ext has its previous value.
+func FieldCool(Tstart, Tend, rate float64, B data.Vector) {
+	if rate <= 0 || Tend < 0 || Tstart < Tend {
+		util.Fatal("ext_fieldcool: need Tstart >= Tend >= 0 and rate > 0, have: ", Tstart, ", ", Tend, ", ", rate)
+	}
+	defer restoreExcitation(B_ext, saveExcitation(B_ext))
+	B_ext.Set(B)
+
+	t0 := Time
+	Temp.setFunc(0, NREGION, func() []float64 {
+		return []float64{math.Max(Tstart-rate*(Time-t0), Tend)}
+	})
+	run((Tstart - Tend) / rate)
+	Temp.setRegions(0, NREGION, []float64{Tend})
+}
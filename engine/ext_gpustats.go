@@ -0,0 +1,81 @@
+package engine
+
+// GPU health and throughput, to be added to the table in long runs.
+
+import (
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/cuda/cu"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	GPUMem     = NewScalarValue("ext_gpumem", "B", "GPU memory in use", gpuMemUsed)
+	GPUTemp    = NewScalarValue("ext_gputemp", "C", "GPU temperature (NaN if nvidia-smi is not available)", gpuTemp)
+	Throughput = NewScalarValue("ext_throughput", "1/s", "Cell-steps per second, averaged over the last second of stepping", throughput)
+)
+
+// GPU memory in use on the current device, by any process.
+func gpuMemUsed() float64 {
+	free, total := cu.MemGetInfo()
+	return float64(total - free)
+}
+
+// GPU temperature as reported by nvidia-smi, NaN if unavailable.
+func gpuTemp() float64 {
+	out, err := exec.Command("nvidia-smi", "--query-gpu=temperature.gpu", "--format=csv,noheader,nounits",
+		"--id="+cuda.PCIBusID).Output()
+	if err != nil {
+		return math.NaN()
+	}
+	T, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return T
+}
+
+// throughput bookkeeping, updated after every step so that evaluating
+// ext_throughput (e.g. both by the table and by the user) has no side effects.
+var throughputWindow struct {
+	start time.Time // wall time at the start of the current window
+	last  time.Time // wall time of the last step
+	steps int       // steps taken in the current window
+	rate  float64   // cell-steps per second over the last full window
+}
+
+const throughputPeriod = time.Second // averaging window, in wall time
+
+func init() {
+	PostStep(countThroughput)
+}
+
+func countThroughput() {
+	w := &throughputWindow
+	if w.start.IsZero() {
+		w.start = time.Now()
+		return
+	}
+	w.steps++
+	w.last = time.Now()
+	if dt := w.last.Sub(w.start); dt >= throughputPeriod {
+		w.rate = float64(w.steps) * float64(Mesh().NCell()) / dt.Seconds()
+		w.start, w.steps = w.last, 0
+	}
+}
+
+// cell-steps per second of wall time, averaged over the last second of stepping.
+// Before the first full second, averaged over the steps so far,
+// and 0 until some wall time has passed.
+func throughput() float64 {
+	w := &throughputWindow
+	if w.rate == 0 && w.steps > 0 {
+		if dt := w.last.Sub(w.start).Seconds(); dt > 0 {
+			return float64(w.steps) * float64(Mesh().NCell()) / dt
+		}
+	}
+	return w.rate
+}
@@ -3,10 +3,13 @@ package engine
 import (
 	"math"
 	"math/rand"
+
+	"github.com/mumax/3/util"
 )
 
 func init() {
 	DeclFunc("ext_makegrains", Voronoi, "Voronoi tesselation (grain size, num regions)")
+	DeclFunc("ext_makegrainboundaries", VoronoiBoundaries, "Voronoi tesselation (grain size, num regions, seed) with grain boundaries of given width (m) in region gbRegion")
 }
 
 func Voronoi(grainsize float64, numRegions, seed int) {
@@ -19,7 +22,29 @@ func Voronoi(grainsize float64, numRegions, seed int) {
 	regions.render(t.RegionOf)
 }
 
+// Voronoi tesselation with a grain boundary phase, e.g. for segregated-oxide media:
+// cells within width/2 of the boundary between two grains are set to region gbRegion,
+// which should not be one of the grain regions (gbRegion >= numRegions). E.g.:
+//
+//	ext_makegrainboundaries(8e-9, 255, 1, 1e-9, 255)
+//	Msat.SetRegion(255, 100e3)
+//	Aex.SetRegion(255, 1e-12)
+func VoronoiBoundaries(grainsize float64, numRegions, seed int, width float64, gbRegion int) {
+	util.Argument(width >= 0 && gbRegion >= numRegions && gbRegion < NREGION)
+	Refer("Lel2014")
+	SetBusy(true)
+	defer SetBusy(false)
+
+	t := newTesselation(grainsize, numRegions, int64(seed))
+	t.gbWidth = width
+	t.gbRegion = gbRegion
+	regions.hist = append(regions.hist, t.RegionOf)
+	regions.render(t.RegionOf)
+}
+
 type tesselation struct {
+	gbWidth   float64 // grain boundary width (m), 0 = none
+	gbRegion  int     // region of the grain boundaries
 	grainsize float64
 	tilesize  float64
 	maxRegion int
@@ -39,7 +64,7 @@ type center struct {
 
 // nRegion exclusive
 func newTesselation(grainsize float64, nRegion int, seed int64) *tesselation {
-	return &tesselation{grainsize,
+	return &tesselation{0, 0, grainsize,
 		float64(float32(grainsize * TILE)), // expect 4 grains/block, 36 per 3x3 blocks = safe, relatively round number
 		nRegion,
 		make(map[int2][]center),
@@ -54,26 +79,45 @@ const (
 
 // Returns the region of the grain where cell at x,y,z belongs to
 func (t *tesselation) RegionOf(x, y, z float64) int {
+	nearest, second, mindist, mindist2 := t.nearest(x, y)
+
+	// distance to the bisector of the nearest two centers
+	if t.gbWidth > 0 && !math.IsInf(mindist2, 1) {
+		d := (mindist2 - mindist) / (2 * math.Sqrt(sqr(second.x-nearest.x)+sqr(second.y-nearest.y)))
+		if d < t.gbWidth/2 {
+			return t.gbRegion
+		}
+	}
+
+	//fmt.Println("nearest", x, y, ":", nearest)
+	return int(nearest.region)
+}
+
+// Returns the nearest and second nearest Voronoi center to x,y, and their squared distances.
+// The nearest center identifies the grain.
+func (t *tesselation) nearest(x, y float64) (nearest, second center, mindist, mindist2 float64) {
 	tile := t.tileOf(x, y) // tile containing x,y
 
 	// look for nearest center in tile + neighbors
-	nearest := center{x, y, 0} // dummy initial value, but safe should the infinite impossibility strike.
-	mindist := math.Inf(1)
+	nearest = center{x, y, 0} // dummy initial value, but safe should the infinite impossibility strike.
+	second = nearest          // second nearest, for grain boundaries
+	mindist, mindist2 = math.Inf(1), math.Inf(1)
 	for tx := tile.x - 1; tx <= tile.x+1; tx++ {
 		for ty := tile.y - 1; ty <= tile.y+1; ty++ {
 			centers := t.centersInTile(tx, ty)
 			for _, c := range centers {
 				dist := sqr(x-c.x) + sqr(y-c.y)
 				if dist < mindist {
+					second, mindist2 = nearest, mindist
 					nearest = c
 					mindist = dist
+				} else if dist < mindist2 {
+					second, mindist2 = c, dist
 				}
 			}
 		}
 	}
-
-	//fmt.Println("nearest", x, y, ":", nearest)
-	return int(nearest.region)
+	return
 }
 
 // Returns the list of Voronoi centers in tile(ix, iy), using only ix,iy to seed the random generator
@@ -0,0 +1,154 @@
+package engine
+
+// Spin wave transmission measurements: a broadband field pulse in an antenna region
+// excites spin waves, the response is recorded in a detector region and
+// compared with the response under the antenna as a function of frequency.
+// Damping ramps at the ends of the mesh absorb outgoing waves.
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+func init() {
+	DeclFunc("ext_AbsorbingBoundaries", AbsorbingBoundaries, "Increase alpha quadratically up to alphaMax within width (m) of the x ends of the mesh, in nsteps new regions per region")
+	DeclFunc("ext_SpinWaveTransmission", SpinWaveTransmission, "Excite a sinc field pulse B (T) in antenna region, write the response in detector region relative to antenna vs frequency to transmission.txt")
+}
+
+// Moves the cells within width of the -x and +x ends of the mesh to new regions,
+// with parameters copied from their region but alpha ramped up quadratically
+// to alphaMax at the ends, in nsteps steps.
+// Spin waves reaching the ends are then absorbed instead of reflected.
+func AbsorbingBoundaries(width, alphaMax float64, nsteps int) {
+	checkMesh()
+	util.Argument(width > 0 && nsteps > 0)
+	n := Mesh().Size()
+	c := Mesh().CellSize()[X]
+	l := regions.HostList()
+	arr := reshapeBytes(l, n)
+
+	ramp := make(map[[2]int]int) // (region, step) -> new region
+	for ix := 0; ix < n[X]; ix++ {
+		d := math.Min(float64(ix)+0.5, float64(n[X]-ix)-0.5) * c // distance from the end
+		if d >= width {
+			continue
+		}
+		step := int((1 - d/width) * float64(nsteps))
+		if step >= nsteps {
+			step = nsteps - 1
+		}
+		for iz := 0; iz < n[Z]; iz++ {
+			for iy := 0; iy < n[Y]; iy++ {
+				key := [2]int{int(arr[iz][iy][ix]), step}
+				r, ok := ramp[key]
+				if !ok {
+					r = newRampRegion(key[0], alphaMax, float64(step+1)/float64(nsteps))
+					ramp[key] = r
+				}
+				arr[iz][iy][ix] = byte(r)
+			}
+		}
+	}
+	regions.gpuCache.Upload(l)
+	regions.frac = nil
+	LogOut("ext_AbsorbingBoundaries: ", len(ramp), " damping regions")
+}
+
+// new region with the parameters of src, and alpha increased
+// by a fraction s² of the way to alphaMax.
+func newRampRegion(src int, alphaMax, s float64) int {
+	r := freeRegion()
+	layerRegions = append(layerRegions, r)
+	copyRegionParams(src, r)
+	a := Alpha.getRegion(src)[0]
+	Alpha.setRegions(r, r+1, []float64{a + (alphaMax-a)*s*s})
+	return r
+}
+
+// Adds a field pulse B sinc(2π fmax (t-t0)) in the antenna region, whose spectrum is flat
+// up to fmax, and records the average magnetization in the antenna and detector regions
+// long enough to resolve nf frequencies between fmin and fmax.
+// Writes the amplitude spectra of the change in magnetization in both regions and their ratio
+// (the transmission) to transmission.txt in the output directory.
+// The magnetization, normally relaxed beforehand, and B_ext are restored afterwards.
+func SpinWaveTransmission(antenna, detector int, B data.Vector, fmin, fmax float64, nf int) {
+	checkMesh()
+	defRegionId(antenna)
+	defRegionId(detector)
+	if !(fmin >= 0 && fmax > fmin && nf > 1) {
+		util.Fatal("ext_SpinWaveTransmission: need 0 <= fmin < fmax and nf > 1, have: ", fmin, ", ", fmax, ", ", nf)
+	}
+
+	m0 := cuda.Buffer(3, Mesh().Size())
+	defer cuda.Recycle(m0)
+	data.Copy(m0, M.Buffer())
+	defer M.SetArray(m0)
+	defer restoreExcitation(B_ext, saveExcitation(B_ext))
+
+	ma0 := M.Region(antenna).Average()
+	md0 := M.Region(detector).Average()
+
+	// pulse, delayed so that most of its leading tail is included
+	tstart := Time
+	delay := 5 / fmax
+	B0 := data.Vector(unslice(B_ext.perRegion.getRegion(antenna)))
+	B_ext.SetRegionFn(antenna, func() [3]float64 {
+		x := 2 * math.Pi * fmax * (Time - tstart - delay)
+		s := 1.0
+		if x != 0 {
+			s = math.Sin(x) / x
+		}
+		return B0.Add(B.Mul(s))
+	})
+
+	// record long enough for the frequency resolution, sampling well above 2 fmax
+	df := (fmax - fmin) / float64(nf-1)
+	dt := 1 / (4 * fmax)
+	nt := int(delay/dt + 1/(df*dt) + 1)
+	t := make([]float64, nt)
+	ma := make([]data.Vector, nt)
+	md := make([]data.Vector, nt)
+	for i := 0; i < nt; i++ {
+		if target := tstart + float64(i)*dt; Time < target {
+			run(target - Time)
+		}
+		t[i] = Time - tstart
+		ma[i] = M.Region(antenna).Average().Sub(ma0)
+		md[i] = M.Region(detector).Average().Sub(md0)
+	}
+
+	out, err := httpfs.Create(OD() + "transmission.txt")
+	util.FatalErr(err)
+	defer out.Close()
+	fmt.Fprintln(out, "# f (Hz)\tantenna ()\tdetector ()\ttransmission ()")
+	for k := 0; k < nf; k++ {
+		f := fmin + float64(k)*df
+		a := spectralAmplitude(t, ma, f)
+		d := spectralAmplitude(t, md, f)
+		fmt.Fprintf(out, "%g\t%g\t%g\t%g\n", f, a, d, d/a)
+	}
+	LogOut("ext_SpinWaveTransmission: wrote ", OD()+"transmission.txt")
+}
+
+// |∫ v(t) exp(-i2πft) dt| of a sampled vector signal, summed in quadrature over the components.
+func spectralAmplitude(t []float64, v []data.Vector, f float64) float64 {
+	var sum [3]complex128
+	for i := 1; i < len(t); i++ {
+		dt := t[i] - t[i-1]
+		e := cmplx.Exp(complex(0, -2*math.Pi*f*t[i])) * complex(dt, 0)
+		for c := range sum {
+			sum[c] += complex(v[i][c], 0) * e
+		}
+	}
+	amp := 0.0
+	for _, s := range sum {
+		amp += real(s)*real(s) + imag(s)*imag(s)
+	}
+	return math.Sqrt(amp)
+}
@@ -0,0 +1,109 @@
+package engine
+
+// Vortex core detection, less sensitive to noise than ext_corepos:
+// the core polarity is given by the sign of ∑ mz³, which is dominated by the core,
+// and the position is the centroid of the cells where mz (averaged over the thickness)
+// exceeds half its peak value with that sign.
+
+import (
+	"math"
+
+	"github.com/mumax/3/data"
+)
+
+var (
+	Ext_VortexCore        = NewVectorValue("ext_vortexcore", "m", "Vortex core position (x,y,0), centroid of the core cells", getVortexCore)
+	Ext_VortexPolarity    = NewScalarValue("ext_vortexpolarity", "", "Vortex core polarity: +1, -1 or 0 if there is no core", getVortexPolarity)
+	Ext_VortexCirculation = NewScalarValue("ext_vortexcirculation", "", "Vortex circulation around the core: +1 (counter-clockwise), -1 (clockwise) or 0 if the magnetization does not curl", getVortexCirculation)
+)
+
+func getVortexCore() []float64 {
+	mz := layerAverageMz()
+	pos, _ := vortexCore(mz)
+	return []float64{pos[X], pos[Y], 0}
+}
+
+func getVortexPolarity() float64 {
+	_, p := vortexCore(layerAverageMz())
+	return p
+}
+
+// Normalized circulation ∑ (r × m)_z / r with r measured from the core
+// (or the center of the mesh if there is none), averaged over the magnet,
+// is ±1 for an ideal vortex.
+// Returns its sign if it exceeds 0.5 in absolute value, 0 otherwise.
+func getVortexCirculation() float64 {
+	m := Download(&M).Vectors()
+	core, pol := vortexCore(layerAverageMz())
+	if pol == 0 {
+		core = data.Vector{GetShiftPos(), GetShiftYPos(), 0}.Add(meshOffset())
+	}
+	circ, n := 0.0, 0
+	forEachMagnetCell(m, func(i [3]int) {
+		r := Index2Coord(i[X], i[Y], i[Z]).Sub(core)
+		r[Z] = 0
+		l := r.Len()
+		if l == 0 {
+			return
+		}
+		circ += (r[X]*valueAt(m[Y], i) - r[Y]*valueAt(m[X], i)) / l
+		n++
+	})
+	if n == 0 {
+		return 0
+	}
+	circ /= float64(n)
+	if math.Abs(circ) < 0.5 {
+		return 0
+	}
+	return math.Copysign(1, circ)
+}
+
+// core position and polarity (±1, or 0 if there is no core) from the layer-averaged mz.
+func vortexCore(mz [][]float64) (pos data.Vector, pol float64) {
+	sum3 := 0.0
+	peak := 0.0
+	for iy := range mz {
+		for ix := range mz[iy] {
+			sum3 += mz[iy][ix] * mz[iy][ix] * mz[iy][ix]
+			peak = math.Max(peak, math.Abs(mz[iy][ix]))
+		}
+	}
+	pol = math.Copysign(1, sum3)
+	if sum3 == 0 || peak < 0.5 {
+		return data.Vector{math.NaN(), math.NaN(), 0}, 0
+	}
+
+	var w, x, y float64
+	for iy := range mz {
+		for ix := range mz[iy] {
+			if pol*mz[iy][ix] > peak/2 {
+				wi := pol * mz[iy][ix]
+				r := Index2Coord(ix, iy, 0)
+				x += wi * r[X]
+				y += wi * r[Y]
+				w += wi
+			}
+		}
+	}
+	if w == 0 { // peak has the other sign
+		return data.Vector{math.NaN(), math.NaN(), 0}, 0
+	}
+	return data.Vector{x / w, y / w, 0}, pol
+}
+
+// mz averaged over the thickness, indexed [iy][ix].
+func layerAverageMz() [][]float64 {
+	mz := Download(&M).Comp(Z).Scalars()
+	n := Mesh().Size()
+	avg := make([][]float64, n[Y])
+	for iy := range avg {
+		avg[iy] = make([]float64, n[X])
+		for ix := range avg[iy] {
+			for iz := 0; iz < n[Z]; iz++ {
+				avg[iy][ix] += float64(mz[iz][iy][ix]) / float64(n[Z])
+			}
+		}
+	}
+	return avg
+}
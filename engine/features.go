@@ -0,0 +1,163 @@
+package engine
+
+// Feature vectors for machine-learning surrogate models of the dynamics:
+// the averages of a list of quantities, streamed every N steps
+// to a file or a TCP socket, one tab-separated line per vector, e.g.:
+//
+//	FeatureAdd(m)
+//	FeatureAdd(E_total)
+//	FeatureAdd(ext_topologicalcharge)
+//	FeatureAdd(FFTPeaks(m, 2, 4))
+//	AutoFeatures(10, "tcp://localhost:9000")
+//
+// The first line is a header with the column names, like the table.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+var features struct {
+	quants []Quantity
+	every  int // stream every N steps, 0 = disabled
+	out    *bufio.Writer
+	closer io.Closer
+	socket bool // flush every line
+}
+
+func init() {
+	DeclFunc("FeatureAdd", FeatureAdd, "Add the average of a quantity to the feature vector streamed by AutoFeatures")
+	DeclFunc("AutoFeatures", AutoFeatures, `Every N steps, stream the feature vector to dest: a file in the output directory, or "tcp://host:port" (N=0 disables)`)
+	DeclFunc("FFTPeaks", FFTPeaks, "The n largest amplitudes of the 2D Fourier transform of component comp of a quantity in layer 0")
+	PostStep(autoFeatures)
+}
+
+func FeatureAdd(q Quantity) {
+	if features.out != nil {
+		util.Fatal("FeatureAdd ", NameOf(q), ": need to add features before AutoFeatures")
+	}
+	features.quants = append(features.quants, q)
+}
+
+// Streams the feature vector every nsteps to dest, which is a file name
+// relative to the output directory or a TCP address "tcp://host:port".
+func AutoFeatures(nsteps int, dest string) {
+	util.Argument(nsteps >= 0)
+	closeFeatures()
+	features.every = nsteps
+	if nsteps == 0 {
+		return
+	}
+	if len(features.quants) == 0 {
+		util.Fatal("AutoFeatures: need FeatureAdd first")
+	}
+	var w io.WriteCloser
+	if strings.HasPrefix(dest, "tcp://") {
+		conn, err := net.Dial("tcp", strings.TrimPrefix(dest, "tcp://"))
+		util.FatalErr(err)
+		w = conn
+		features.socket = true
+	} else {
+		f, err := httpfs.Create(OD() + dest)
+		util.FatalErr(err)
+		w = f
+		features.socket = false
+	}
+	features.out = bufio.NewWriter(w)
+	features.closer = w
+
+	fmt.Fprint(features.out, "# t (s)\tstep")
+	for _, q := range features.quants {
+		for c := 0; c < q.NComp(); c++ {
+			name := NameOf(q)
+			if q.NComp() > 1 {
+				name += fmt.Sprint("_", c)
+			}
+			fmt.Fprint(features.out, "\t", name, " (", UnitOf(q), ")")
+		}
+	}
+	fmt.Fprintln(features.out)
+	writeFeatures()
+}
+
+func autoFeatures() {
+	if features.every == 0 || features.out == nil || NSteps%features.every != 0 {
+		return
+	}
+	writeFeatures()
+}
+
+func writeFeatures() {
+	fmt.Fprint(features.out, Time, "\t", NSteps)
+	for _, q := range features.quants {
+		for _, v := range AverageOf(q) {
+			fmt.Fprint(features.out, "\t", float32(v))
+		}
+	}
+	fmt.Fprintln(features.out)
+	if features.socket {
+		if err := features.out.Flush(); err != nil {
+			LogErr("AutoFeatures: ", err, ", stopping feature stream")
+			closeFeatures()
+			features.every = 0
+		}
+	}
+}
+
+// flushes and closes the feature stream, if any.
+func closeFeatures() {
+	if features.out == nil {
+		return
+	}
+	features.out.Flush()
+	features.closer.Close()
+	features.out, features.closer = nil, nil
+}
+
+// The n largest amplitudes of the spatial Fourier transform of one component
+// of a quantity in layer 0, sorted in decreasing order (see FFT2D).
+type fftPeaks struct {
+	fft  *fftLayer
+	comp int
+	n    int
+}
+
+func FFTPeaks(q Quantity, comp, n int) *fftPeaks {
+	util.Argument(comp >= 0 && comp < q.NComp() && n > 0)
+	return &fftPeaks{fft: FFT2D(q, 0), comp: comp, n: n}
+}
+
+func (p *fftPeaks) Name() string       { return fmt.Sprint(NameOf(p.fft.parent), "_FFTpeaks", p.comp) }
+func (p *fftPeaks) NComp() int         { return p.n }
+func (p *fftPeaks) Unit() string       { return UnitOf(p.fft.parent) }
+func (p *fftPeaks) Average() []float64 { return p.average() }
+
+func (p *fftPeaks) average() []float64 {
+	s, _ := p.fft.Slice()
+	amp := s.Comp(p.comp).HostCopy().Host()[0]
+	cuda.Recycle(s)
+	sort.Slice(amp, func(i, j int) bool { return amp[i] > amp[j] })
+	peaks := make([]float64, p.n)
+	for i := range peaks {
+		if i < len(amp) {
+			peaks[i] = float64(amp[i])
+		}
+	}
+	return peaks
+}
+
+func (p *fftPeaks) EvalTo(dst *data.Slice) {
+	v := p.average()
+	for c := range v {
+		cuda.Memset(dst.Comp(c), float32(v[c]))
+	}
+}
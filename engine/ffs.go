@@ -0,0 +1,186 @@
+package engine
+
+// Rates of rare thermally activated transitions by forward flux sampling (FFS),
+// and committor probabilities, with the average of a scalar quantity as order parameter λ.
+// E.g. for reversal from state A: mz < -0.8 to state B: mz ≥ 0.8:
+//
+//	Temp = 300
+//	m = uniform(0, 0, -1)
+//	FFS(m.comp(2), -0.8, 0.8, 8, 50, 1e-12)
+//
+// With equally spaced interfaces λA = λ_0 < λ_1 < ... < λ_n = λB, FFS first runs in A and
+// stores ntrials configurations where λ_0 is crossed coming from A, which gives the flux Φ
+// out of A. From random configurations stored at interface i it then starts ntrials trajectories
+// each, which either reach λ_i+1 (and are stored) or return to A, giving the probabilities
+// P(λ_i+1|λ_i) and the rate k_AB = Φ Π P(λ_i+1|λ_i).
+// The order parameter is evaluated every dt. Trajectories taking longer than FFSMaxTime
+// are left out of the probabilities and reported separately as timeouts. When all trajectories
+// of an interface (or of Committor) time out, there is no probability and the run is aborted.
+// The magnetization is restored afterwards, time keeps running.
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+var (
+	FFSMaxTime = 1e-6 // maximum duration of one FFS trajectory (s)
+
+	ffsRate  = 0.                          // rate of the last FFS
+	ffsRnd   = rand.New(rand.NewSource(0)) // picks starting configurations
+	FFS_rate = NewScalarValue("FFS_rate", "1/s", "Transition rate from the last FFS", func() float64 { return ffsRate })
+)
+
+func init() {
+	DeclFunc("FFS", FFS, "Forward flux sampling of the transition from order parameter (average of q) < lambdaA to >= lambdaB, with n interfaces, ntrials per interface, checked every dt (s). Writes ffs.txt and returns the rate (1/s). Trajectories reaching neither interface within FFSMaxTime are left out, aborts if all of an interface time out")
+	DeclFunc("Committor", Committor, "Fraction of n thermal trajectories from the current state that reach order parameter (average of q) >= lambdaB before < lambdaA, checked every dt (s). Trajectories reaching neither within FFSMaxTime are left out, aborts if all time out")
+	DeclVar("FFSMaxTime", &FFSMaxTime, "Maximum duration of one FFS or Committor trajectory (s)")
+}
+
+// order parameter: average of a scalar quantity
+type orderParam struct {
+	q      Quantity
+	lA, lB float64
+}
+
+func newOrderParam(q Quantity, lambdaA, lambdaB float64) orderParam {
+	util.Argument(q.NComp() == 1 && lambdaA < lambdaB)
+	return orderParam{q, lambdaA, lambdaB}
+}
+
+func (o orderParam) get() float64 { return AverageOf(o.q)[0] }
+
+// outcome of a trajectory
+const (
+	ffsReturned = iota // back in A
+	ffsReached         // reached the target
+	ffsTimeout         // neither within FFSMaxTime
+)
+
+// Runs from the current state in steps of dt until λ < λA or λ ≥ target, at most FFSMaxTime.
+func (o orderParam) runUntil(target, dt float64) int {
+	t0 := Time
+	for Time-t0 < FFSMaxTime {
+		run(dt)
+		switch l := o.get(); {
+		case l >= target:
+			return ffsReached
+		case l < o.lA:
+			return ffsReturned
+		}
+	}
+	return ffsTimeout
+}
+
+func FFS(q Quantity, lambdaA, lambdaB float64, n, ntrials int, dt float64) float64 {
+	checkMesh()
+	o := newOrderParam(q, lambdaA, lambdaB)
+	util.Argument(n > 0 && ntrials > 0 && dt > 0)
+	if o.get() >= lambdaA {
+		util.Fatal("FFS: should start in state A (order parameter < ", lambdaA, "), have: ", o.get())
+	}
+	m0 := M.Buffer().HostCopy()
+	defer M.SetArray(m0)
+
+	out, err := httpfs.Create(OD() + "ffs.txt")
+	util.FatalErr(err)
+	defer out.Close()
+
+	// flux through λ_0 out of A
+	configs := ffsFlux(o, m0, ntrials, dt)
+	fmt.Fprintf(out, "# flux out of A: %g 1/s\n", ffsRate)
+	fmt.Fprintln(out, "# interface ()\tlambda ()\tlambda next ()\tP(next|this) ()\tsuccesses ()\ttrials ()\ttimeouts ()")
+	out.Flush()
+
+	for i := 0; i < n; i++ {
+		next := lambdaA + (lambdaB-lambdaA)*float64(i+1)/float64(n)
+		var reached []*data.Slice
+		timeouts := 0
+		for k := 0; k < ntrials; k++ {
+			M.SetArray(configs[ffsRnd.Intn(len(configs))])
+			switch o.runUntil(next, dt) {
+			case ffsReached:
+				reached = append(reached, M.Buffer().HostCopy())
+			case ffsTimeout:
+				timeouts++
+			}
+		}
+		if timeouts == ntrials {
+			util.Fatal("FFS: all ", ntrials, " trajectories from interface ", i, " timed out, increase FFSMaxTime")
+		}
+		P := float64(len(reached)) / float64(ntrials-timeouts)
+		ffsRate *= P
+		this := lambdaA + (lambdaB-lambdaA)*float64(i)/float64(n)
+		fmt.Fprintf(out, "%d\t%g\t%g\t%g\t%d\t%d\t%d\n", i, this, next, P, len(reached), ntrials, timeouts)
+		out.Flush()
+		LogOut(fmt.Sprintf("FFS: P(%g|%g) = %g", next, this, P))
+		if timeouts > 0 {
+			LogErr("FFS: ", timeouts, " of ", ntrials, " trajectories did not reach an interface within FFSMaxTime, left out")
+		}
+		if len(reached) == 0 {
+			LogOut("FFS: no trajectories reached the next interface, rate is below sampling resolution")
+			break
+		}
+		configs = reached
+	}
+	LogOut("FFS: rate: ", ffsRate, " 1/s")
+	return ffsRate
+}
+
+// Runs in A, starting from m0, until ntrials crossings of λA coming from A have been stored.
+// Sets ffsRate to the flux out of A.
+func ffsFlux(o orderParam, m0 *data.Slice, ntrials int, dt float64) []*data.Slice {
+	var configs []*data.Slice
+	t0 := Time
+	inA := true
+	for len(configs) < ntrials {
+		run(dt)
+		l := o.get()
+		switch {
+		case l >= o.lB: // spontaneous transition: start over in A
+			M.SetArray(m0)
+			inA = true
+		case l < o.lA:
+			inA = true
+		case inA:
+			configs = append(configs, M.Buffer().HostCopy())
+			inA = false
+		}
+		if Time-t0 > FFSMaxTime*float64(ntrials) {
+			util.Fatal("FFS: only ", len(configs), " crossings of the first interface after ", Time-t0, " s, lower lambdaA or increase FFSMaxTime")
+		}
+	}
+	ffsRate = float64(len(configs)) / (Time - t0)
+	return configs
+}
+
+func Committor(q Quantity, lambdaA, lambdaB float64, n int, dt float64) float64 {
+	checkMesh()
+	o := newOrderParam(q, lambdaA, lambdaB)
+	util.Argument(n > 0 && dt > 0)
+	m0 := M.Buffer().HostCopy()
+	defer M.SetArray(m0)
+	reached, timeouts := 0, 0
+	for i := 0; i < n; i++ {
+		M.SetArray(m0)
+		switch o.runUntil(lambdaB, dt) {
+		case ffsReached:
+			reached++
+		case ffsTimeout:
+			timeouts++
+		}
+	}
+	if timeouts == n {
+		util.Fatal("Committor: all ", n, " trajectories timed out, increase FFSMaxTime")
+	}
+	pB := float64(reached) / float64(n-timeouts)
+	LogOut("Committor: ", reached, " of ", n-timeouts, " trajectories reached B")
+	if timeouts > 0 {
+		LogErr("Committor: ", timeouts, " of ", n, " trajectories did not reach A or B within FFSMaxTime, left out")
+	}
+	return pB
+}
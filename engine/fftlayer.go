@@ -0,0 +1,187 @@
+package engine
+
+// Spatial Fourier transform of a single layer, e.g. to monitor
+// spin wave modes in one layer of a multilayer.
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/util"
+)
+
+var (
+	FFTLayer   = 0        // layer transformed by m_FFTlayer
+	FFTWindow  = "boxcar" // spatial window applied before the FFT
+	FFTTukey   = 0.5      // tapered fraction of the tukey window
+	M_FFTLayer = &fftLayer{parent: &M, name: "m_FFTlayer", layer: func() int { return FFTLayer }}
+)
+
+// FFT plans and window, shared by all layer transforms (which may be made in a script loop)
+// and freed when the mesh changes.
+var fft2D struct {
+	plans  map[[2]int]*cuda.FFT2DR2CPlan // by layer size
+	window *data.Slice                   // FFTWindow on GPU, nil for boxcar
+	winKey string                        // window name, parameter and size for which window was made
+}
+
+func init() {
+	DeclVar("FFTLayer", &FFTLayer, "Layer (z index) transformed by m_FFTlayer")
+	DeclVar("FFTWindow", &FFTWindow, `Window applied along x and y before FFT2D and m_FFTlayer: "boxcar" (none), "hann", "hamming" or "tukey"`)
+	DeclVar("FFTTukey", &FFTTukey, "Fraction of the tukey FFTWindow that is tapered (default=0.5)")
+	DeclROnly("m_FFTlayer", M_FFTLayer, "Amplitude of the 2D Fourier transform of m in layer FFTLayer (kx along x, ky=0 at the center along y)")
+	DeclFunc("FFT2D", FFT2D, "Amplitude of the 2D Fourier transform of a quantity in one layer (kx along x, ky=0 at the center along y)")
+}
+
+// Amplitude of the in-plane Fourier transform of layer iz of a quantity,
+// divided by the number of cells so that a uniform value v gives amplitude |v| at k=0.
+// The result has Nx/2+1 cells along x (kx = 0 ... Nyquist) and Ny cells along y,
+// with ky = 0 at index Ny/2.
+type fftLayer struct {
+	parent Quantity
+	name   string
+	layer  func() int
+}
+
+func FFT2D(parent Quantity, layer int) *fftLayer {
+	util.Argument(layer >= 0 && layer < MeshOf(parent).Size()[Z])
+	name := fmt.Sprint(NameOf(parent), "_FFTlayer", layer, "_")
+	return &fftLayer{parent: parent, name: name, layer: func() int { return layer }}
+}
+
+func (q *fftLayer) NComp() int             { return q.parent.NComp() }
+func (q *fftLayer) Name() string           { return q.name }
+func (q *fftLayer) Unit() string           { return UnitOf(q.parent) }
+func (q *fftLayer) EvalTo(dst *data.Slice) { EvalTo(q, dst) }
+func (q *fftLayer) average() []float64     { return qAverageUniverse(q) }
+func (q *fftLayer) Average() []float64     { return q.average() }
+
+// reciprocal space mesh, cell size is the wave number resolution 1/L (1/m).
+func (q *fftLayer) Mesh() *data.Mesh {
+	m := MeshOf(q.parent)
+	n := m.Size()
+	w := m.WorldSize()
+	return data.NewMesh(n[X]/2+1, n[Y], 1, 1/w[X], 1/w[Y], m.CellSize()[Z])
+}
+
+func (q *fftLayer) Slice() (*data.Slice, bool) {
+	n := MeshOf(q.parent).Size()
+	layer := q.layer()
+	if layer < 0 || layer >= n[Z] {
+		util.Fatal(q.name, ": layer ", layer, " out of range [0, ", n[Z], "[")
+	}
+	plan := fft2DPlan(n[X], n[Y])
+
+	src := ValueOf(q.parent)
+	defer cuda.Recycle(src)
+	in := cuda.Buffer(1, [3]int{n[X], n[Y], 1})
+	defer cuda.Recycle(in)
+	nxo, nyo, _ := plan.OutputSizeFloats()
+	out := cuda.Buffer(1, [3]int{nxo, nyo, 1})
+	defer cuda.Recycle(out)
+
+	size := q.Mesh().Size()
+	amp := data.NewSlice(q.NComp(), size)
+	norm := 1 / float64(n[X]*n[Y])
+	for c := 0; c < q.NComp(); c++ {
+		cuda.Crop(in, src.Comp(c), 0, 0, layer)
+		if w := fft2DWindow(n); w != nil {
+			cuda.Mul(in, in, w)
+		}
+		plan.ExecAsync(in, out)
+		F := out.HostCopy().Host()[0]
+		a := amp.Host()[c]
+		for iy := 0; iy < n[Y]; iy++ {
+			ky := (iy + n[Y]/2) % n[Y] // ky=0 at the center
+			for ix := 0; ix < size[X]; ix++ {
+				re, im := float64(F[iy*nxo+2*ix]), float64(F[iy*nxo+2*ix+1])
+				a[ky*size[X]+ix] = float32(norm * math.Sqrt(re*re+im*im))
+			}
+		}
+	}
+	dst := cuda.Buffer(q.NComp(), size)
+	data.Copy(dst, amp)
+	return dst, true
+}
+
+// shared plan for Nx x Ny layers
+func fft2DPlan(Nx, Ny int) *cuda.FFT2DR2CPlan {
+	if fft2D.plans == nil {
+		fft2D.plans = make(map[[2]int]*cuda.FFT2DR2CPlan)
+	}
+	p := fft2D.plans[[2]int{Nx, Ny}]
+	if p == nil {
+		p = cuda.NewFFT2DR2C(Nx, Ny)
+		fft2D.plans[[2]int{Nx, Ny}] = p
+	}
+	return p
+}
+
+// frees the plans and window of the layer transforms, e.g. when the mesh changes.
+func freeFFT2D() {
+	for _, p := range fft2D.plans {
+		p.Free()
+	}
+	fft2D.plans = nil
+	fft2D.window.Free()
+	fft2D.window = nil
+	fft2D.winKey = ""
+}
+
+// the product of FFTWindow along x and y, for an n[X] x n[Y] layer.
+func fft2DWindow(n [3]int) *data.Slice {
+	key := fmt.Sprint(FFTWindow, FFTTukey, n[X], n[Y])
+	if key == fft2D.winKey {
+		return fft2D.window
+	}
+	fft2D.window.Free()
+	fft2D.window = nil
+	if FFTWindow == "boxcar" {
+		fft2D.winKey = key
+		return nil
+	}
+	w := data.NewSlice(1, [3]int{n[X], n[Y], 1})
+	wxy := w.Scalars()[0]
+	for iy := 0; iy < n[Y]; iy++ {
+		for ix := 0; ix < n[X]; ix++ {
+			wxy[iy][ix] = float32(window1D(ix, n[X]) * window1D(iy, n[Y]))
+		}
+	}
+	fft2D.window = cuda.NewSlice(1, w.Size())
+	data.Copy(fft2D.window, w)
+	fft2D.winKey = key
+	return fft2D.window
+}
+
+// weight of sample i out of n for FFTWindow.
+func window1D(i, n int) float64 {
+	if n == 1 {
+		return 1
+	}
+	x := float64(i) / float64(n-1) // 0..1
+	switch FFTWindow {
+	default:
+		util.Fatal(`FFTWindow: unknown window "`, FFTWindow, `", options: boxcar, hann, hamming, tukey`)
+		return 0
+	case "boxcar":
+		return 1
+	case "hann":
+		return 0.5 * (1 - math.Cos(2*math.Pi*x))
+	case "hamming":
+		return 0.54 - 0.46*math.Cos(2*math.Pi*x)
+	case "tukey":
+		a := FFTTukey
+		switch {
+		case a <= 0:
+			return 1
+		case x < a/2:
+			return 0.5 * (1 - math.Cos(2*math.Pi*x/a))
+		case x > 1-a/2:
+			return 0.5 * (1 - math.Cos(2*math.Pi*(1-x)/a))
+		default:
+			return 1
+		}
+	}
+}
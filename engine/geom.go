@@ -151,6 +151,7 @@ func (geometry *geom) setGeom(s Shape) {
 	}
 
 	data.Copy(geometry.buffer, V)
+	invalidateDemagCrop()
 
 	// M inside geom but previously outside needs to be re-inited
 	needupload := false
@@ -217,6 +218,7 @@ func (g *geom) shift(dx int) {
 	newv := float32(1) // initially fill edges with 1's
 	cuda.ShiftX(s2, s, dx, newv, newv)
 	data.Copy(s, s2)
+	invalidateDemagCrop()
 
 	n := Mesh().Size()
 	x1, x2 := shiftDirtyRange(dx)
@@ -247,6 +249,7 @@ func (g *geom) shiftY(dy int) {
 	newv := float32(1) // initially fill edges with 1's
 	cuda.ShiftY(s2, s, dy, newv, newv)
 	data.Copy(s, s2)
+	invalidateDemagCrop()
 
 	n := Mesh().Size()
 	y1, y2 := shiftDirtyRange(dy)
@@ -0,0 +1,49 @@
+package engine
+
+// Host array access to quantities, for programs embedding the engine.
+
+import (
+	"fmt"
+	"github.com/mumax/3/data"
+)
+
+// GetAsArray returns a host copy of the quantity with given name,
+// as used in input scripts (e.g. "m", "B_eff", "Edens_total").
+// The result is indexed as [component][iz][iy][ix].
+func GetAsArray(name string) [][][][]float32 {
+	return Download(QuantityByName(name)).Tensors()
+}
+
+// GetVector returns a host copy of the named 3-component quantity,
+// indexed as [component][iz][iy][ix].
+func GetVector(name string) [3][][][]float32 {
+	return getNComp(name, 3).Vectors()
+}
+
+// GetScalar returns a host copy of the named 1-component quantity,
+// indexed as [iz][iy][ix].
+func GetScalar(name string) [][][]float32 {
+	return getNComp(name, 1).Scalars()
+}
+
+// QuantityByName looks up a quantity by its script identifier (case-insensitive).
+// It panics with a user error if the name does not refer to a quantity.
+func QuantityByName(name string) Quantity {
+	e := World.Resolve(name)
+	if e == nil {
+		panic(UserErr(fmt.Sprint("undefined quantity: ", name)))
+	}
+	q, ok := e.Eval().(Quantity)
+	if !ok {
+		panic(UserErr(fmt.Sprint(name, " is not a quantity")))
+	}
+	return q
+}
+
+func getNComp(name string, ncomp int) *data.Slice {
+	q := QuantityByName(name)
+	if q.NComp() != ncomp {
+		panic(UserErr(fmt.Sprint(name, " has ", q.NComp(), " components, need ", ncomp)))
+	}
+	return Download(q)
+}
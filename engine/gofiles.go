@@ -21,6 +21,9 @@ var (
 	Flag_silent      = flag.Bool("s", false, "Silent") // provided for backwards compatibility
 	Flag_sync        = flag.Bool("sync", false, "Synchronize all CUDA calls (debug)")
 	Flag_forceclean  = flag.Bool("f", false, "Force start, clean existing output directory")
+	Flag_console     = flag.String("console", "", `Start a script console on stdin ("-") or a TCP address (e.g. ":35368", on localhost unless a host is given). TCP clients must first send the token from console_token in the output directory`)
+	Flag_resume      = flag.Bool("resume", false, "Continue numbering of existing output files and append to the existing table")
+	Flag_autotune    = flag.Bool("autotune", false, "Benchmark the launch configuration of the madd and exchange kernels at startup (cached per GPU model)")
 )
 
 // Usage: in every Go input file, write:
@@ -38,6 +41,9 @@ func InitAndClose() func() {
 
 	cuda.Init(*Flag_gpu)
 	cuda.Synchronous = *Flag_sync
+	if *Flag_autotune {
+		cuda.Autotune(*Flag_cachedir)
+	}
 
 	od := *Flag_od
 	if od == "" {
@@ -105,6 +105,7 @@ func (g *guistate) PrepareServer() {
 	http.Handle("/", g)
 	http.HandleFunc("/render/", g.ServeRender)
 	http.HandleFunc("/plot/", g.servePlot)
+	http.HandleFunc("/save", serveSave)
 
 	g.Set("title", util.NoExt(OD()[:len(OD())-1]))
 	g.prepareConsole()
@@ -308,6 +309,14 @@ func (g *guistate) prepareParam() {
 			}
 		})
 	}
+	// show buttons: display the parameter's map, to check the setup
+	for _, p := range g.Params {
+		n := p.Name()
+		g.OnEvent("show_"+n, func() {
+			g.Set("renderQuant", n)
+			g.showQuant(n)
+		})
+	}
 	// overwrite handler for temperature
 	// do not crash when we enter bogus values (see temperature.go)
 	g.OnEvent("Temp", func() {
@@ -320,6 +329,19 @@ func (g *guistate) prepareParam() {
 	})
 }
 
+// displays the named quantity
+func (g *guistate) showQuant(name string) {
+	g.render.mutex.Lock()
+	defer g.render.mutex.Unlock()
+	q := g.Quants[name]
+	if q == nil {
+		LogErr("display: unknown quantity:", name)
+		return
+	}
+	g.render.quant = q
+	g.Set("renderDoc", g.Doc(name))
+}
+
 // see prepareServer
 func (g *guistate) prepareDisplay() {
 	// plot
@@ -331,16 +353,7 @@ func (g *guistate) prepareDisplay() {
 
 	// render
 	g.OnEvent("renderQuant", func() {
-		g.render.mutex.Lock()
-		defer g.render.mutex.Unlock()
-		name := g.StringValue("renderQuant")
-		q := g.Quants[name]
-		if q == nil {
-			LogErr("display: unknown quantity:", name)
-			return
-		}
-		g.render.quant = q
-		g.Set("renderDoc", g.Doc(g.StringValue("renderQuant")))
+		g.showQuant(g.StringValue("renderQuant"))
 	})
 	g.OnEvent("renderComp", func() {
 		g.render.mutex.Lock()
@@ -0,0 +1,131 @@
+package engine
+
+// Write head field moving over the medium, e.g. a field map computed
+// with a finite element tool, sampled on a regular grid and saved as OVF.
+// The map's center starts at position r0 and moves with velocity v (m/s):
+//
+//	AddFieldTerm(HeadField("head.ovf", vector(-200e-9, 0, 10e-9), vector(20, 0, 0)))
+//
+// The map is interpolated trilinearly onto the mesh once, outside it the field is zero.
+// While it moves, it is translated on the GPU with linear interpolation between cells.
+// Values in A/m are converted to T, other units are taken as T.
+// The map can be scaled in time, e.g. to reverse the write current, with
+//
+//	h := HeadField(...)
+//	h.SetCurrent(sin(...))
+
+import (
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/mag"
+	"github.com/mumax/3/script"
+	"github.com/mumax/3/util"
+)
+
+func init() {
+	DeclFunc("HeadField", HeadField, "Field map (T or A/m) from file, centered at r0 (m) and moving with velocity v (m/s). Use with AddFieldTerm")
+}
+
+type headField struct {
+	host    [3][][][]float32 // field map (T)
+	size    [3]int
+	cell    data.Vector           // cell size of the map
+	current script.ScalarFunction // time-dependent multiplier, nil = 1
+	stamp   *stamp                // map on the mesh, translated on the GPU
+}
+
+func HeadField(fname string, r0, v data.Vector) *headField {
+	s, info := loadFile(fname)
+	if s.NComp() != 3 {
+		util.Fatal("HeadField ", fname, ": need a vector field, have ", s.NComp(), " components")
+	}
+	h := &headField{size: s.Size(), cell: data.Vector(info.CellSize)}
+	vec := s.Vectors()
+	for c := range h.host {
+		h.host[c] = vec[c]
+	}
+	if info.Unit == "A/m" {
+		for _, v := range s.Host() {
+			for i := range v {
+				v[i] *= float32(mag.Mu0)
+			}
+		}
+	}
+	var hw data.Vector // half width, 0 along axes with a single cell, where the map is uniform
+	for c := range hw {
+		if h.size[c] > 1 {
+			hw[c] = float64(h.size[c]) * h.cell[c] / 2
+		}
+	}
+	h.stamp = newStamp(3, r0, v, hw, func(r data.Vector, dst []float64) {
+		B := h.interpolate(r)
+		copy(dst, B[:])
+	})
+	return h
+}
+
+// Scales the field by a (time-dependent) factor, e.g. the write current.
+func (h *headField) SetCurrent(f script.ScalarFunction) {
+	h.current = f
+}
+
+func (h *headField) NComp() int       { return 3 }
+func (h *headField) Name() string     { return "B_head" }
+func (h *headField) Unit() string     { return "T" }
+func (h *headField) Mesh() *data.Mesh { return Mesh() }
+func (h *headField) average() []float64 {
+	return qAverageUniverse(h)
+}
+func (h *headField) Average() data.Vector { return unslice(h.average()) }
+
+func (h *headField) EvalTo(dst *data.Slice) {
+	B := h.stamp.at(Time)
+	if h.current == nil {
+		data.Copy(dst, B)
+		return
+	}
+	cuda.Madd2(dst, B, B, float32(h.current.Float()), 0)
+}
+
+// trilinear interpolation of the map at position r relative to its center, zero outside.
+func (h *headField) interpolate(r data.Vector) data.Vector {
+	var i [3]int
+	var f [3]float64
+	for c := 0; c < 3; c++ {
+		u := r[c]/h.cell[c] + float64(h.size[c])/2 - 0.5 // index coordinate
+		if h.size[c] == 1 {
+			u = 0
+		}
+		if u < 0 || u > float64(h.size[c]-1) {
+			return data.Vector{}
+		}
+		i[c] = int(u)
+		if i[c] == h.size[c]-1 && h.size[c] > 1 {
+			i[c]--
+		}
+		f[c] = u - float64(i[c])
+	}
+	var B data.Vector
+	for dz := 0; dz < 2; dz++ {
+		for dy := 0; dy < 2; dy++ {
+			for dx := 0; dx < 2; dx++ {
+				w := lerpWeight(f[X], dx) * lerpWeight(f[Y], dy) * lerpWeight(f[Z], dz)
+				if w == 0 {
+					continue
+				}
+				x, y, z := i[X]+dx, i[Y]+dy, i[Z]+dz
+				for c := 0; c < 3; c++ {
+					B[c] += w * float64(h.host[c][z][y][x])
+				}
+			}
+		}
+	}
+	return B
+}
+
+func lerpWeight(f float64, d int) float64 {
+	if d == 0 {
+		return 1 - f
+	}
+	return f
+}
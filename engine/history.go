@@ -0,0 +1,156 @@
+package engine
+
+// Recording of the last steps of m in host memory, dumped when an event occurs,
+// to capture the dynamics leading up to rare events without continuous output. E.g.:
+//
+//	RecordHistory(1000)
+//	HistoryTrigger(m.comp(2), 0)   // dump when mz crosses 0
+//
+// keeps m of the last 1000 steps and writes them to history0_000000.ovf ... (oldest first),
+// with their times in history0.txt, when <mz> changes sign. Dumps are numbered.
+// Selecting cells with HistoryCell records only m in those cells, written as columns of the
+// history table, which also saves memory on large meshes. Full frames are downloaded
+// asynchronously into pinned host buffers. When m becomes NaN, the history is dumped
+// before the run is aborted.
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+var history struct {
+	frames  []histFrame
+	next    int      // ring buffer index of the next frame
+	full    bool     // ring buffer has wrapped
+	cells   [][3]int // recorded cells, all if empty
+	trigger Quantity // dump when its average crosses threshold
+	thresh  float64  // trigger threshold
+	side    float64  // sign of trigger - threshold at the last step
+	size    [3]int   // mesh size of the full-field frames
+	ndump   int      // number of dumps so far
+}
+
+// m at one step
+type histFrame struct {
+	t     float64
+	step  int
+	m     *data.Slice   // full field, in pinned host memory
+	cells []data.Vector // m in selected cells
+}
+
+func init() {
+	DeclFunc("RecordHistory", RecordHistory, "Keep m of the last n steps in host memory, for DumpHistory (n=0 stops)")
+	DeclFunc("HistoryCell", HistoryCell, "Record only m in the given cells (repeat to add cells) in RecordHistory")
+	DeclFunc("HistoryTrigger", HistoryTrigger, "DumpHistory when the average of scalar quantity q crosses threshold")
+	DeclFunc("DumpHistory", DumpHistory, "Write the steps recorded by RecordHistory")
+	PostStep(recordHistory)
+}
+
+func RecordHistory(n int) {
+	util.Argument(n >= 0)
+	for _, f := range history.frames {
+		cuda.FreePinned(f.m)
+	}
+	history.frames = make([]histFrame, n)
+	history.next = 0
+	history.full = false
+}
+
+func HistoryCell(ix, iy, iz int) {
+	n := Mesh().Size()
+	util.Argument(ix >= 0 && ix < n[X] && iy >= 0 && iy < n[Y] && iz >= 0 && iz < n[Z])
+	history.cells = append(history.cells, [3]int{ix, iy, iz})
+	RecordHistory(len(history.frames)) // drop frames of the other kind
+}
+
+func HistoryTrigger(q Quantity, threshold float64) {
+	util.Argument(q.NComp() == 1)
+	history.trigger = q
+	history.thresh = threshold
+	history.side = 0
+}
+
+func recordHistory() {
+	h := &history
+	if len(h.frames) == 0 {
+		return
+	}
+	if h.size != Mesh().Size() { // frames of another mesh
+		h.size = Mesh().Size()
+		RecordHistory(len(h.frames))
+	}
+
+	f := &h.frames[h.next]
+	f.t, f.step = Time, NSteps
+	if len(h.cells) == 0 {
+		if f.m == nil {
+			f.m = cuda.NewPinnedSlice(3, h.size)
+		}
+		cuda.DownloadPinnedAsync(f.m, M.Buffer()) // no sync
+	} else {
+		f.cells = f.cells[:0]
+		for _, c := range h.cells {
+			f.cells = append(f.cells, M.GetCell(c[X], c[Y], c[Z]))
+		}
+	}
+	h.next = (h.next + 1) % len(h.frames)
+	h.full = h.full || h.next == 0
+
+	if avg := M.Average(); math.IsNaN(avg[X]) || math.IsNaN(avg[Y]) || math.IsNaN(avg[Z]) {
+		DumpHistory()
+		util.Fatal("m became NaN at t=", Time, " s, dumped history")
+	}
+	if h.trigger != nil {
+		side := math.Copysign(1, AverageOf(h.trigger)[0]-h.thresh)
+		if h.side != 0 && side != h.side {
+			LogOut(fmt.Sprintf("HistoryTrigger: %s crossed %g at t=%g s", NameOf(h.trigger), h.thresh, Time))
+			DumpHistory()
+		}
+		h.side = side
+	}
+}
+
+func DumpHistory() {
+	h := &history
+	frames := h.frames[:h.next] // oldest first
+	if h.full {
+		frames = append(append([]histFrame{}, h.frames[h.next:]...), h.frames[:h.next]...)
+	}
+	if len(frames) == 0 {
+		LogErr("DumpHistory: nothing recorded, use RecordHistory first")
+		return
+	}
+	cuda.Sync() // pending downloads
+	base := fmt.Sprint(OD(), "history", h.ndump)
+	h.ndump++
+
+	out, err := httpfs.Create(base + ".txt")
+	util.FatalErr(err)
+	defer out.Close()
+	fmt.Fprint(out, "# frame ()\tt (s)\tstep ()")
+	for _, c := range h.cells {
+		for _, comp := range []string{"mx", "my", "mz"} {
+			fmt.Fprintf(out, "\t%s[%d,%d,%d] ()", comp, c[X], c[Y], c[Z])
+		}
+	}
+	fmt.Fprintln(out)
+
+	info := metaOf(&M)
+	for i, f := range frames {
+		fmt.Fprintf(out, "%d\t%g\t%d", i, f.t, f.step)
+		for _, v := range f.cells {
+			fmt.Fprintf(out, "\t%g\t%g\t%g", v[X], v[Y], v[Z])
+		}
+		fmt.Fprintln(out)
+		if f.m != nil && len(h.cells) == 0 {
+			info.Time = f.t
+			saveAs_sync(fmt.Sprintf("%s_%06d.%s", base, i, StringFromOutputFormat[outputFormat]), f.m, info, outputFormat)
+		}
+	}
+	LogOut("DumpHistory: wrote ", len(frames), " steps to ", base)
+}
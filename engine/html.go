@@ -206,7 +206,7 @@ Region: {{.Number "region" -1 255 -1}} </br>
 
 <table>
 {{range .Data.Parameters}}
-<tr title="{{$.Data.Doc .}}"> <td>{{.}}</td> <td> {{$.TextBox . ""}} {{$.Data.UnitOf . }}</td> </tr>
+<tr title="{{$.Data.Doc .}}"> <td>{{.}}</td> <td> {{$.TextBox . ""}} {{$.Data.UnitOf . }}</td> <td> {{$.Button (print "show_" .) "show"}} </td> </tr>
 {{end}}
 </table>
 
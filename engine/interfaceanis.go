@@ -0,0 +1,52 @@
+package engine
+
+// Interface anisotropy given as surface energy density Ks (J/m2),
+// as usually found in literature, converted to an effective
+// volume anisotropy Ku1 = Ks / t for a layer of thickness t.
+
+import (
+	"github.com/mumax/3/util"
+)
+
+func init() {
+	DeclFunc("SetKs", SetKs, "Set Ku1 in a region from interface anisotropy Ks (J/m2) divided by the region's thickness along z")
+}
+
+// Sets Ku1 = Ks / t in region, with t the thickness of the region along z
+// (number of cell layers containing the region times the cell size).
+// For a layer with two interfaces, Ks is the sum of both contributions.
+// If anisU is not set in the region, it is set perpendicular to the layer.
+// The conversion uses the current geometry and is not updated when it changes.
+func SetKs(region int, Ks float64) {
+	checkMesh()
+	defRegionId(region)
+	t := layerThickness(region)
+	if t == 0 {
+		util.Fatal("SetKs: region ", region, " is empty")
+	}
+	Ku1.setRegions(region, region+1, []float64{Ks / t})
+	if AnisU.GetRegion(region) == [3]float64{} {
+		AnisU.setRegions(region, region+1, []float64{0, 0, 1})
+	}
+	LogOut("SetKs: region ", region, " thickness ", t, " m, Ku1 = ", Ks/t, " J/m3")
+}
+
+// thickness along z of the cell layers that contain region (inside the geometry).
+func layerThickness(region int) float64 {
+	reg := regions.HostArray()
+	vol := Download(&geometry).Scalars()
+	n := Mesh().Size()
+	nlayers := 0
+	for iz := 0; iz < n[Z]; iz++ {
+	layer:
+		for iy := 0; iy < n[Y]; iy++ {
+			for ix := 0; ix < n[X]; ix++ {
+				if int(reg[iz][iy][ix]) == region && vol[iz][iy][ix] != 0 {
+					nlayers++
+					break layer
+				}
+			}
+		}
+	}
+	return float64(nlayers) * Mesh().CellSize()[Z]
+}
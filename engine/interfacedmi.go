@@ -0,0 +1,101 @@
+package engine
+
+// Interfacial DMI restricted to the cell layers next to an interface.
+// The cells of a region within a few layers (along z) of a neighboring region
+// are moved to a new region with the same parameters and couplings,
+// except for Dind. Since interfacial DMI only couples in-plane neighbors,
+// splitting a region along z does not change the exchange nor DMI
+// between the other cells.
+
+import (
+	"github.com/mumax/3/util"
+)
+
+func init() {
+	DeclFunc("ext_InterfaceDind", InterfaceDind, "Set Dind only in the cells of region within nlayers (along z) of neighbor region. Returns the new region number for these cells")
+}
+
+// Moves the cells of region within nlayers cells (along z) of neighbor
+// to a new region, with all parameters copied from region, and sets Dind = D there.
+// Returns the new region number.
+func InterfaceDind(region, neighbor int, D float64, nlayers int) int {
+	checkMesh()
+	defRegionId(region)
+	defRegionId(neighbor)
+	util.Argument(nlayers > 0)
+
+	l := regions.HostList()
+	n := Mesh().Size()
+	arr := reshapeBytes(l, n)
+	iface := freeRegion()
+	count := 0
+	for iy := 0; iy < n[Y]; iy++ {
+		for ix := 0; ix < n[X]; ix++ {
+			// mark on a copy of the column, so new cells do not count as region
+			var col []byte
+			for iz := 0; iz < n[Z]; iz++ {
+				col = append(col, arr[iz][iy][ix])
+			}
+			for iz := 0; iz < n[Z]; iz++ {
+				if int(col[iz]) == region && nearRegion(col, iz, neighbor, nlayers) {
+					arr[iz][iy][ix] = byte(iface)
+					count++
+				}
+			}
+		}
+	}
+	if count == 0 {
+		util.Fatal("ext_InterfaceDind: region ", region, " has no cells within ", nlayers, " layers of region ", neighbor)
+	}
+
+	copyRegionParams(region, iface)
+	regions.gpuCache.Upload(l)
+	regions.frac = nil
+	layerRegions = append(layerRegions, iface)
+	Dind.setRegions(iface, iface+1, []float64{D})
+	LogOut("ext_InterfaceDind: ", count, " cells of region ", region, " moved to region ", iface)
+	return iface
+}
+
+// is there a cell of region within n cells of index i in col?
+func nearRegion(col []byte, i, region, n int) bool {
+	for j := i - n; j <= i+n; j++ {
+		if j >= 0 && j < len(col) && int(col[j]) == region {
+			return true
+		}
+	}
+	return false
+}
+
+// copies all parameter values and inter-region couplings from region src to dst.
+func copyRegionParams(src, dst int) {
+	for _, p := range gui_.Params {
+		var rw *regionwise
+		switch p := p.(type) {
+		default:
+			continue
+		case *RegionwiseScalar:
+			rw = &p.regionwise
+		case *RegionwiseVector:
+			rw = &p.regionwise
+		case *Excitation:
+			rw = &p.perRegion.regionwise
+		}
+		rw.bufset_(dst, rw.getRegion(src))
+		rw.upd_reg[dst] = rw.upd_reg[src]
+		rw.invalidate()
+	}
+	for _, x := range []*exchParam{&lex2, &din2, &dbulk2} {
+		for r := 0; r < NREGION; r++ {
+			if r == dst {
+				continue
+			}
+			x.scale[symmidx(dst, r)] = x.scale[symmidx(src, r)]
+			x.inter[symmidx(dst, r)] = x.inter[symmidx(src, r)]
+		}
+		// src and dst are the same material
+		x.scale[symmidx(dst, src)] = 1
+		x.inter[symmidx(dst, src)] = 0
+		x.invalidate()
+	}
+}
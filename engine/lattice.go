@@ -0,0 +1,61 @@
+package engine
+
+// Periodic lattices of shapes (dots, or holes when subtracted),
+// e.g. for magnonic crystals:
+//
+//	antidots := Circle(50e-9).HexLattice(200e-9)
+//	setGeom(Universe().Sub(antidots))
+
+import (
+	"math"
+
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/util"
+)
+
+// Repeats the shape at all points n*a1 + m*a2 (integer n, m) of the
+// in-plane lattice spanned by a1, a2. The shape should not extend
+// further than about one lattice cell from the origin.
+func (s Shape) Lattice(a1, a2 data.Vector) Shape {
+	det := a1[X]*a2[Y] - a1[Y]*a2[X]
+	if det == 0 {
+		util.Fatal("Lattice: lattice vectors should be non-zero and not parallel")
+	}
+	return func(x, y, z float64) bool {
+		// lattice coordinates of (x, y)
+		u := math.Floor((x*a2[Y] - y*a2[X]) / det)
+		v := math.Floor((a1[X]*y - a1[Y]*x) / det)
+		for n := u - 2; n <= u+2; n++ {
+			for m := v - 2; m <= v+2; m++ {
+				if s(x-n*a1[X]-m*a2[X], y-n*a1[Y]-m*a2[Y], z-n*a1[Z]-m*a2[Z]) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// Repeats the shape on a square lattice with lattice constant a.
+func (s Shape) SquareLattice(a float64) Shape {
+	return s.Lattice(data.Vector{a, 0, 0}, data.Vector{0, a, 0})
+}
+
+// Repeats the shape on a hexagonal lattice with lattice constant a,
+// one lattice vector along x.
+func (s Shape) HexLattice(a float64) Shape {
+	return s.Lattice(data.Vector{a, 0, 0}, data.Vector{a / 2, a * math.Sqrt(3) / 2, 0})
+}
+
+// Returns the union of copies of the shape translated by each basis vector,
+// to be repeated with Lattice for a lattice with a basis.
+func (s Shape) Basis(basis ...data.Vector) Shape {
+	return func(x, y, z float64) bool {
+		for _, b := range basis {
+			if s(x-b[X], y-b[Y], z-b[Z]) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,57 @@
+package engine
+
+// Sugar for the common multilayer setup: one region per z-range,
+// with the parameters of a named material (see materials.go).
+
+import (
+	"github.com/mumax/3/util"
+)
+
+var layerRegions []int // regions handed out by SetLayer
+
+func init() {
+	DeclFunc("SetLayer", SetLayer, "Define a new region for zmin <= z < zmax (m), set the parameters of the named material there, returns the region number")
+}
+
+// Defines the cells with zmin <= z < zmax as a new region and sets the
+// parameters of the named material in it. Returns the region number,
+// so that more parameters can be set with e.g. alpha.SetRegion().
+func SetLayer(zmin, zmax float64, material string) int {
+	checkMesh()
+	if zmax <= zmin {
+		util.Fatal("SetLayer: need zmin < zmax, have: ", zmin, ", ", zmax)
+	}
+	if _, ok := lookupMaterial(material); !ok {
+		util.Fatal("SetLayer: unknown material ", material, ", have: ", materialNames())
+	}
+	r := freeRegion()
+	DefRegion(r, ZRange(zmin, zmax))
+	if regions.fractions()[r] == 0 {
+		LogErr("SetLayer: no cells between z = ", zmin, " and ", zmax)
+	}
+	layerRegions = append(layerRegions, r)
+	UseMaterial(r, material)
+	LogOut("SetLayer: region ", r, " = ", material, " for z in [", zmin, ", ", zmax, ")")
+	return r
+}
+
+// lowest non-zero region number not used by any cell nor by a previous layer.
+func freeRegion() int {
+	frac := regions.fractions()
+	for r := 1; r < NREGION; r++ {
+		if frac[r] == 0 && !containsInt(layerRegions, r) {
+			return r
+		}
+	}
+	util.Fatal("SetLayer: no free region left")
+	return -1
+}
+
+func containsInt(s []int, x int) bool {
+	for _, y := range s {
+		if y == x {
+			return true
+		}
+	}
+	return false
+}
@@ -43,6 +43,7 @@ func LogErr(msg ...interface{}) {
 	str := "//" + sprint(msg...)
 	log2GUI(str)
 	log2File(str)
+	addWarning(str)
 	fprintln(os.Stderr, str)
 }
 
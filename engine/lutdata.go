@@ -77,6 +77,17 @@ func (p *lut) assureAlloc() {
 	}
 }
 
+// frees the GPU copy, for tables that are not parameters
+func (p *lut) free() {
+	for i, b := range p.gpu_buf {
+		if b != nil {
+			cu.MemFree(cu.DevicePtr(uintptr(b)))
+		}
+		p.gpu_buf[i] = nil
+	}
+	p.gpu_ok = false
+}
+
 func (b *lut) NComp() int { return len(b.cpu_buf) }
 
 // uncompress the table to a full array with parameter values per cell.
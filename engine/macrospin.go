@@ -0,0 +1,37 @@
+package engine
+
+// Single-cell (macrospin) simulations: the demag field of a uniformly
+// magnetized cuboid follows from its demagnetizing factors, so the
+// demag kernel and FFT convolution are not set up at all.
+
+import (
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/mag"
+)
+
+var (
+	macrospinN    [3]float64 // demag factors of the cell...
+	macrospinCell [3]float64 // ...with this size
+)
+
+// single cell without PBC: demag field from demag factors.
+func isMacrospin() bool {
+	return Mesh().NCell() == 1 && Mesh().PBC() == [3]int{0, 0, 0}
+}
+
+// B_demag = -mu0 N M, for a single cell.
+func setMacrospinDemag(dst *data.Slice) {
+	if c := Mesh().CellSize(); c != macrospinCell {
+		macrospinN = mag.DemagFactors(c[X], c[Y], c[Z])
+		macrospinCell = c
+		LogOut("macrospin: demag factors", macrospinN)
+	}
+	SetMFull(dst)
+	for c := 0; c < 3; c++ {
+		cuda.Madd2(dst.Comp(c), dst.Comp(c), dst.Comp(c), float32(-mag.Mu0*macrospinN[c]), 0)
+	}
+	if !NoDemagSpins.isZero() {
+		cuda.ZeroMask(dst, NoDemagSpins.gpuLUT1(), regions.Gpu())
+	}
+}
@@ -11,6 +11,11 @@ var M magnetization // reduced magnetization (unit length)
 
 func init() { DeclLValue("m", &M, `Reduced magnetization (unit length)`) }
 
+// TODO: atomistic embedding, a region resolved on a finer (atomic) lattice with its own
+// spins and coupling cells to the surrounding mesh. The solver, the exchange stencils and
+// the FFT demag all assume a single uniform grid. Note that with the cell size set to the
+// lattice constant, the exchange is already a simple cubic Heisenberg model, J = 2 Aex a.
+
 // Special buffered quantity to store magnetization
 // makes sure it's normalized etc.
 type magnetization struct {
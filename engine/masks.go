@@ -0,0 +1,61 @@
+package engine
+
+// Smooth masks for use with excitations, e.g.:
+//
+//	B_ext.Add(GaussianMask(vector(0, 0, 1), 0, 0, 0, 20e-9), sin(2*pi*f*t))
+//
+// Sharp (binary) masks excite spin waves of all wave vectors,
+// smooth profiles limit the bandwidth of the excitation.
+
+import (
+	"math"
+
+	"github.com/mumax/3/data"
+)
+
+func init() {
+	DeclFunc("GaussianMask", GaussianMask, "Vector mask dir*exp(-r²/2sigma²), with r the distance to (x0, y0, z0) in meter")
+	DeclFunc("TanhMask", TanhMask, "Vector mask dir*(1+tanh((r.n-pos)/width))/2, a smooth step across the plane r.n = pos (unit normal n)")
+	DeclFunc("LinearMask", LinearMask, "Vector mask dir*(r.grad), a linear gradient (grad in 1/m)")
+}
+
+// Gaussian spot centered at (x0, y0, z0) with standard deviation sigma (m).
+func GaussianMask(dir data.Vector, x0, y0, z0, sigma float64) *data.Slice {
+	return renderMask(dir, func(r data.Vector) float64 {
+		r2 := sqr64(r[X]-x0) + sqr64(r[Y]-y0) + sqr64(r[Z]-z0)
+		return math.Exp(-r2 / (2 * sigma * sigma))
+	})
+}
+
+// Smooth step from 0 to 1 along normal, centered at distance pos from the origin.
+func TanhMask(dir, normal data.Vector, pos, width float64) *data.Slice {
+	n := normal.Div(normal.Len())
+	return renderMask(dir, func(r data.Vector) float64 {
+		return 0.5 * (1 + math.Tanh((r.Dot(n)-pos)/width))
+	})
+}
+
+// Linear profile r.grad, zero at the origin.
+func LinearMask(dir, grad data.Vector) *data.Slice {
+	return renderMask(dir, func(r data.Vector) float64 {
+		return r.Dot(grad)
+	})
+}
+
+// host mask dir*profile(r) with r the cell center.
+func renderMask(dir data.Vector, profile func(r data.Vector) float64) *data.Slice {
+	n := Mesh().Size()
+	mask := data.NewSlice(3, n)
+	v := mask.Vectors()
+	for iz := 0; iz < n[Z]; iz++ {
+		for iy := 0; iy < n[Y]; iy++ {
+			for ix := 0; ix < n[X]; ix++ {
+				p := profile(Index2Coord(ix, iy, iz))
+				for c := 0; c < 3; c++ {
+					v[c][iz][iy][ix] = float32(p * dir[c])
+				}
+			}
+		}
+	}
+	return mask
+}
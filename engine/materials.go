@@ -0,0 +1,167 @@
+package engine
+
+// Named material parameter sets, applied to a region with UseMaterial.
+// Built-in values are typical room-temperature literature values,
+// users can add or override materials with a JSON file:
+//
+//	{"MyAlloy": {"Msat": 1e6, "Aex": 15e-12, "anisU": [0, 0, 1]}}
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+// material: parameter name -> value (1 or 3 components)
+type material map[string]values
+
+// parameter value, in JSON a number or a list of numbers
+type values []float64
+
+func (v *values) UnmarshalJSON(b []byte) error {
+	var x float64
+	if err := json.Unmarshal(b, &x); err == nil {
+		*v = values{x}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]float64)(v))
+}
+
+var materials = map[string]material{
+	"Permalloy": {"Msat": {860e3}, "Aex": {13e-12}, "alpha": {0.01}},
+	"CoFeB":     {"Msat": {1.1e6}, "Aex": {15e-12}, "alpha": {0.01}},
+	"YIG":       {"Msat": {140e3}, "Aex": {3.65e-12}, "alpha": {1e-4}},
+	"FePt":      {"Msat": {1.14e6}, "Aex": {10e-12}, "Ku1": {6.6e6}, "anisU": {0, 0, 1}, "alpha": {0.1}},
+	"Co":        {"Msat": {1.4e6}, "Aex": {30e-12}, "Ku1": {520e3}, "anisU": {0, 0, 1}, "alpha": {0.01}},
+	"Fe":        {"Msat": {1.7e6}, "Aex": {21e-12}, "Kc1": {48e3}, "anisC1": {1, 0, 0}, "anisC2": {0, 1, 0}, "alpha": {0.002}},
+	"Ni":        {"Msat": {490e3}, "Aex": {9e-12}, "Kc1": {-5.7e3}, "anisC1": {1, 0, 0}, "anisC2": {0, 1, 0}, "alpha": {0.045}},
+}
+
+func init() {
+	DeclFunc("UseMaterial", UseMaterial, "Set the parameters of a named material (e.g. \"Permalloy\") in a region (-1 = all regions)")
+	DeclFunc("LoadMaterials", LoadMaterials, "Add materials from a JSON file: {\"name\": {\"Msat\": 800e3, \"anisU\": [0,0,1], ...}}")
+	DeclFunc("ListMaterials", ListMaterials, "Print the known materials and their parameters")
+}
+
+// Sets the parameters of the named material in region (-1 = all regions).
+// Parameters not specified by the material are left untouched.
+func UseMaterial(region int, name string) {
+	mat, ok := lookupMaterial(name)
+	if !ok {
+		util.Fatal("UseMaterial: unknown material ", name, ", have: ", materialNames())
+	}
+	for param, v := range mat {
+		setParamRegion(param, region, v)
+	}
+}
+
+func LoadMaterials(fname string) {
+	in, err := httpfs.Read(fname)
+	util.FatalErr(err)
+	var m map[string]material
+	if err := json.Unmarshal(in, &m); err != nil {
+		util.Fatal("LoadMaterials ", fname, ": ", err)
+	}
+	for name, mat := range m {
+		for param, v := range mat {
+			checkMaterialParam(name, param, v)
+		}
+		for k := range materials {
+			if strings.EqualFold(k, name) {
+				delete(materials, k) // override
+			}
+		}
+		materials[name] = mat
+	}
+}
+
+func ListMaterials() {
+	for _, name := range materialNames() {
+		mat := materials[name]
+		params := make([]string, 0, len(mat))
+		for p := range mat {
+			params = append(params, p)
+		}
+		sort.Strings(params)
+		s := name + ":"
+		for _, p := range params {
+			s += fmt.Sprint(" ", p, "=", unwrap1(mat[p]))
+		}
+		LogOut(s)
+	}
+}
+
+// sets a scalar or vector parameter in a region (-1 = all), given by its script name.
+func setParamRegion(param string, region int, v []float64) {
+	if region < -1 || region >= NREGION {
+		util.Fatal("region number should be -1 or 0-", NREGION-1, ", have: ", region)
+	}
+	r1, r2 := region, region+1
+	if region == -1 {
+		r1, r2 = 0, NREGION
+	}
+	switch p := paramByName(param).(type) {
+	case *RegionwiseScalar:
+		util.Argument(len(v) == 1)
+		p.setRegions(r1, r2, v)
+	case *RegionwiseVector:
+		util.Argument(len(v) == 3)
+		p.setRegions(r1, r2, v)
+	default:
+		util.Fatal("not a material parameter: ", param)
+	}
+}
+
+func checkMaterialParam(name, param string, v []float64) {
+	switch paramByName(param).(type) {
+	case *RegionwiseScalar:
+		if len(v) != 1 {
+			util.Fatal("material ", name, ": ", param, " needs 1 value, have: ", v)
+		}
+	case *RegionwiseVector:
+		if len(v) != 3 {
+			util.Fatal("material ", name, ": ", param, " needs 3 values, have: ", v)
+		}
+	default:
+		util.Fatal("material ", name, ": unknown parameter ", param)
+	}
+}
+
+// the parameter with given (case-insensitive) script name, nil if not found.
+func paramByName(name string) interface{} {
+	e := World.Resolve(name)
+	if e == nil {
+		return nil
+	}
+	return e.Eval()
+}
+
+// case-insensitive material lookup.
+func lookupMaterial(name string) (material, bool) {
+	for k, m := range materials {
+		if strings.EqualFold(k, name) {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+func materialNames() []string {
+	names := make([]string, 0, len(materials))
+	for k := range materials {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func unwrap1(v []float64) interface{} {
+	if len(v) == 1 {
+		return v[0]
+	}
+	return v
+}
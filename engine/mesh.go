@@ -12,6 +12,7 @@ func init() {
 	DeclFunc("SetCellSize", SetCellSize, `Sets the X,Y,Z cell size in meters`)
 	DeclFunc("SetPBC", SetPBC, `Sets number of repetitions in X,Y,Z`)
 	DeclFunc("SetMesh", SetMesh, `Sets GridSize, CellSize and PBC in once`)
+	DeclFunc("SetOrigin", SetOrigin, `Sets the world position (m) of the lower corner of the mesh, making all coordinates absolute`)
 }
 
 func Mesh() *data.Mesh {
@@ -42,6 +43,9 @@ func SetMesh(Nx, Ny, Nz int, cellSizeX, cellSizeY, cellSizeZ float64, pbcx, pbcy
 	if globalmesh_.Size() == [3]int{0, 0, 0} {
 		// first time mesh is set
 		globalmesh_ = *data.NewMesh(Nx, Ny, Nz, cellSizeX, cellSizeY, cellSizeZ, pbc...)
+		if lazy_origin != nil {
+			globalmesh_.SetOrigin(lazy_origin[X], lazy_origin[Y], lazy_origin[Z])
+		}
 		M.alloc()
 		regions.alloc()
 	} else {
@@ -51,12 +55,19 @@ func SetMesh(Nx, Ny, Nz int, cellSizeX, cellSizeY, cellSizeZ float64, pbcx, pbcy
 		// free everything
 		conv_.Free()
 		conv_ = nil
+		freeCroppedDemag()
+		freeSUL()
+		multigrid_.Free()
+		multigrid_ = nil
 		mfmconv_.Free()
 		mfmconv_ = nil
 		cuda.FreeBuffers()
 
 		// resize everything
 		globalmesh_ = *data.NewMesh(Nx, Ny, Nz, cellSizeX, cellSizeY, cellSizeZ, pbc...)
+		if lazy_origin != nil {
+			globalmesh_.SetOrigin(lazy_origin[X], lazy_origin[Y], lazy_origin[Z])
+		}
 		M.resize()
 		regions.resize()
 		geometry.buffer.Free()
@@ -68,11 +79,14 @@ func SetMesh(Nx, Ny, Nz int, cellSizeX, cellSizeY, cellSizeZ float64, pbcx, pbcy
 		if Mesh().Size() != prevSize {
 			B_ext.RemoveExtraTerms()
 			J.RemoveExtraTerms()
+			SpinCurrentX.RemoveExtraTerms()
+			SpinCurrentY.RemoveExtraTerms()
+			SpinCurrentZ.RemoveExtraTerms()
 		}
 
 		if Mesh().Size() != prevSize {
-			B_therm.noise.Free()
-			B_therm.noise = nil
+			B_therm.free()
+			freeFFT2D()
 		}
 	}
 	lazy_gridsize = []int{Nx, Ny, Nz}
@@ -80,6 +94,21 @@ func SetMesh(Nx, Ny, Nz int, cellSizeX, cellSizeY, cellSizeZ float64, pbcx, pbcy
 	lazy_pbc = []int{pbcx, pbcy, pbcz}
 }
 
+// Sets the world position of the lower corner of the mesh.
+// Coordinates (shapes, configs, regions, positions, output headers) are then absolute
+// instead of relative to the center of the mesh, so that simulations of different parts
+// of a study share one frame. Best set before defining the geometry:
+// an existing geometry is re-evaluated, regions and m are not.
+func SetOrigin(x, y, z float64) {
+	lazy_origin = []float64{x, y, z}
+	if globalmesh_.Size() != [3]int{0, 0, 0} {
+		globalmesh_.SetOrigin(x, y, z)
+		if geometry.shape != nil {
+			geometry.setGeom(geometry.shape)
+		}
+	}
+}
+
 func printf(f float64) float32 {
 	return float32(f)
 }
@@ -89,6 +118,7 @@ var (
 	lazy_gridsize []int
 	lazy_cellsize []float64
 	lazy_pbc      = []int{0, 0, 0}
+	lazy_origin   []float64 // nil: not set
 )
 
 func SetGridSize(Nx, Ny, Nz int) {
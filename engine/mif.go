@@ -0,0 +1,245 @@
+package engine
+
+// Import of OOMMF MIF 2 problem files, for a practical subset:
+// box atlas and rectangular mesh, uniform exchange, uniaxial and cubic anisotropy,
+// demag, fixed and staged (UZeeman) applied fields, Euler/RK/CG evolvers
+// and time or minimization drivers with uniform or file-based m0.
+// The problem is translated into script statements, which end up in the log.
+// The field stages are run with RunMIF().
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"strings"
+
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/mag"
+	"github.com/mumax/3/oommf"
+	"github.com/mumax/3/util"
+)
+
+func init() {
+	DeclFunc("LoadMIF", LoadMIF, "Set up the simulation from an OOMMF MIF 2 file (subset)")
+	DeclFunc("RunMIF", RunMIF, "Run the field stages of the MIF file loaded with LoadMIF, saving m and a table row after each stage")
+}
+
+// stages and stopping criteria of the last loaded MIF file
+var mif struct {
+	stages   []data.Vector // UZeeman field per stage (T)
+	bias     data.Vector   // FixedZeeman field (T)
+	minimize bool          // MinDriver: minimize each stage instead of time evolution
+	stopDmDt float64       // TimeDriver stopping_dm_dt (deg/ns)
+	stopTime float64       // TimeDriver stopping_time (s)
+	loaded   bool
+}
+
+func LoadMIF(fname string) {
+	in, err := httpfs.Open(fname)
+	util.FatalErr(err)
+	defer in.Close()
+	m, err := oommf.ReadMIF(in)
+	if err != nil {
+		util.Fatal("LoadMIF ", fname, ": ", err)
+	}
+	for _, s := range m.Skipped {
+		LogErr("LoadMIF: ignoring unsupported command ", s)
+	}
+	check := func(err error) {
+		if err != nil {
+			util.Fatal("LoadMIF ", fname, ": ", err)
+		}
+	}
+	// numbers of key, n of them or any number if n < 0
+	floats := func(b *oommf.MIFBlock, key string, n int) []float64 {
+		v, err := b.Floats(key)
+		check(err)
+		if n > 0 && len(v) != n {
+			util.Fatal("LoadMIF ", fname, ": ", b.Type, " ", key, ": need ", n, " numbers, have ", b.Values[key])
+		}
+		return v
+	}
+	float := func(b *oommf.MIFBlock, key string, def float64) float64 {
+		v, err := b.Float(key, def)
+		check(err)
+		return v
+	}
+	vec := func(v []float64) string { return fmt.Sprintf("vector(%g, %g, %g)", v[X], v[Y], v[Z]) }
+
+	// mesh
+	atlas := m.Block("Oxs_BoxAtlas")
+	mesh := m.Block("Oxs_RectangularMesh")
+	if atlas == nil || mesh == nil {
+		util.Fatal("LoadMIF ", fname, ": need Oxs_BoxAtlas and Oxs_RectangularMesh")
+	}
+	c := floats(mesh, "cellsize", 3)
+	var n [3]int
+	for i, key := range []string{"xrange", "yrange", "zrange"} {
+		r := floats(atlas, key, 2)
+		n[i] = int(math.Abs(r[1]-r[0])/c[i] + 0.5)
+	}
+	Eval(fmt.Sprintf("SetGridSize(%d, %d, %d)", n[X], n[Y], n[Z]))
+	Eval(fmt.Sprintf("SetCellSize(%g, %g, %g)", c[X], c[Y], c[Z]))
+
+	// energies
+	Eval(fmt.Sprint("EnableDemag = ", m.Block("Oxs_Demag") != nil))
+	if b := m.Block("Oxs_UniformExchange"); b != nil {
+		Eval(fmt.Sprint("Aex = ", float(b, "A", 0)))
+	}
+	if b := m.Block("Oxs_UniaxialAnisotropy"); b != nil {
+		Eval(fmt.Sprint("Ku1 = ", float(b, "K1", 0)))
+		Eval("AnisU = " + vec(floats(b, "axis", 3)))
+	}
+	if b := m.Block("Oxs_CubicAnisotropy"); b != nil {
+		Eval(fmt.Sprint("Kc1 = ", float(b, "K1", 0)))
+		Eval("AnisC1 = " + vec(floats(b, "axis1", 3)))
+		Eval("AnisC2 = " + vec(floats(b, "axis2", 3)))
+	}
+	mif.bias = data.Vector{}
+	if b := m.Block("Oxs_FixedZeeman"); b != nil {
+		mif.bias = data.Vector(unslice(floats(b, "field", 3))).Mul(mag.Mu0 * float(b, "multiplier", 1))
+	}
+	mif.stages = nil
+	if b := m.Block("Oxs_UZeeman"); b != nil {
+		mif.stages = fieldStages(floats(b, "Hrange", -1), mag.Mu0*float(b, "multiplier", 1))
+	}
+	if mif.stages == nil {
+		mif.stages = []data.Vector{{}}
+	}
+	B0 := mif.bias.Add(mif.stages[0])
+	Eval("B_ext = " + vec(B0[:]))
+
+	// evolver
+	// OOMMF gyromagnetic ratios are in m/(A s). gamma_G is the Gilbert form, like mumax's GammaLL,
+	// gamma_LL the Landau-Lifshitz form, which is gamma_G/(1+alpha²).
+	// Oxs_EulerEvolve defaults to gamma_LL = 2.211e5, Oxs_RungeKuttaEvolve to gamma_G = 2.211e5.
+	const oxsGamma = 2.211e5
+	for _, typ := range []string{"Oxs_EulerEvolve", "Oxs_RungeKuttaEvolve"} {
+		if b := m.Block(typ); b != nil {
+			alpha := float(b, "alpha", 0.5)
+			Eval(fmt.Sprint("alpha = ", alpha))
+			gammaG := oxsGamma
+			switch {
+			case b.Has("gamma_G"):
+				gammaG = float(b, "gamma_G", 0)
+			case b.Has("gamma_LL"):
+				gammaG = float(b, "gamma_LL", 0) * (1 + alpha*alpha)
+			case typ == "Oxs_EulerEvolve":
+				gammaG = oxsGamma * (1 + alpha*alpha)
+			}
+			Eval(fmt.Sprint("GammaLL = ", gammaG/mag.Mu0))
+		}
+	}
+
+	// driver
+	d := m.Block("Oxs_TimeDriver")
+	mif.minimize = false
+	if d == nil {
+		d = m.Block("Oxs_MinDriver")
+		mif.minimize = true
+	}
+	if d == nil {
+		util.Fatal("LoadMIF ", fname, ": need Oxs_TimeDriver or Oxs_MinDriver")
+	}
+	Eval(fmt.Sprint("Msat = ", float(d, "Ms", 0)))
+	mif.stopDmDt = float(d, "stopping_dm_dt", 0)
+	mif.stopTime = float(d, "stopping_time", 0)
+	if !mif.minimize && mif.stopDmDt == 0 && mif.stopTime == 0 {
+		util.Fatal("LoadMIF ", fname, ": Oxs_TimeDriver needs stopping_dm_dt or stopping_time")
+	}
+	if d.Has("m0") {
+		Eval(mifM0(d.Values["m0"], path.Dir(fname), check))
+	}
+	mif.loaded = true
+	LogOut("LoadMIF: ", len(mif.stages), " stages, run them with RunMIF()")
+}
+
+// translates m0 into a statement setting m.
+func mifM0(v string, dir string, check func(error)) string {
+	w, err := oommf.MIFList(v)
+	check(err)
+	if len(w) == 3 {
+		f, err := oommf.MIFFloats(v)
+		check(err)
+		return fmt.Sprintf("m = uniform(%g, %g, %g)", f[X], f[Y], f[Z])
+	}
+	if len(w) == 2 {
+		spec, err := oommf.MIFList(w[1])
+		check(err)
+		b := &oommf.MIFBlock{Type: w[0], Values: map[string]string{}}
+		for i := 0; i+1 < len(spec); i += 2 {
+			b.Values[spec[i]] = spec[i+1]
+		}
+		switch w[0] {
+		case "Oxs_UniformVectorField":
+			f, err := b.Floats("vector")
+			check(err)
+			if len(f) == 3 {
+				return fmt.Sprintf("m = uniform(%g, %g, %g)", f[X], f[Y], f[Z])
+			}
+		case "Oxs_FileVectorField":
+			if file, ok := b.Values["file"]; ok {
+				if !path.IsAbs(file) && !strings.Contains(file, "://") {
+					file = path.Join(dir, file)
+				}
+				return fmt.Sprintf("m.LoadFile(%q)", file)
+			}
+		}
+	}
+	util.Fatal("LoadMIF: unsupported m0: ", v)
+	return ""
+}
+
+// fields (T) of the stages of an Hrange list {x0 y0 z0 x1 y1 z1 n} ...
+// Each range has n+1 stages from H0 to H1, its first stage is skipped
+// when it equals the last stage of the previous range.
+func fieldStages(hrange []float64, multiplier float64) []data.Vector {
+	if len(hrange)%7 != 0 {
+		util.Fatal("LoadMIF: Hrange needs 7 numbers per range, have ", len(hrange))
+	}
+	var stages []data.Vector
+	for i := 0; i < len(hrange); i += 7 {
+		h0 := data.Vector{hrange[i], hrange[i+1], hrange[i+2]}.Mul(multiplier)
+		h1 := data.Vector{hrange[i+3], hrange[i+4], hrange[i+5]}.Mul(multiplier)
+		steps := int(hrange[i+6])
+		for s := 0; s <= steps; s++ {
+			h := h0
+			if steps > 0 {
+				h = h0.MAdd(float64(s)/float64(steps), h1.Sub(h0))
+			}
+			if s == 0 && len(stages) > 0 && stages[len(stages)-1] == h {
+				continue
+			}
+			stages = append(stages, h)
+		}
+	}
+	return stages
+}
+
+// Runs all field stages of the loaded MIF file, with the driver's stopping criterion,
+// saving m and a table row at the end of each stage.
+func RunMIF() {
+	if !mif.loaded {
+		util.Fatal("RunMIF: need LoadMIF first")
+	}
+	for _, h := range mif.stages {
+		B := mif.bias.Add(h)
+		Eval(fmt.Sprintf("B_ext = vector(%g, %g, %g)", B[X], B[Y], B[Z]))
+		switch {
+		case mif.minimize:
+			Minimize()
+		case mif.stopDmDt > 0:
+			// stopping_dm_dt as torque/γ (T)
+			stop := mif.stopDmDt * math.Pi / 180 * 1e9 / GammaLL
+			start, t0 := NSteps, Time
+			runOutput(func() bool {
+				return NSteps == start || LastTorque > stop && (mif.stopTime == 0 || Time < t0+mif.stopTime)
+			})
+		default:
+			run(mif.stopTime)
+		}
+		TableSave()
+		Save(&M)
+	}
+}
@@ -155,6 +155,6 @@ func Minimize() {
 		return (mini.lastDm.count < DmSamples || mini.lastDm.Max() > StopMaxDm)
 	}
 
-	RunWhile(cond)
+	runOutput(cond)
 	pause = true
 }
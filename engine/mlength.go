@@ -0,0 +1,58 @@
+package engine
+
+// Control over the renormalization of m during time integration,
+// and monitoring of the resulting drift of |m| away from 1.
+// By default m is renormalized after every stage of every time step.
+// Renormalizing less often, e.g.
+//
+//	NormalizeEvery = 0
+//
+// allows studying the integrator's norm conservation with ext_mlengthdrift.
+//
+// Separate projective or norm-preserving (e.g. Cayley transform) integrators are
+// not offered: renormalizing after every stage, the default, already projects
+// each stage onto |m| = 1, and the error estimates of the adaptive solvers
+// assume this.
+
+import (
+	"math"
+
+	"github.com/mumax/3/data"
+)
+
+var (
+	NormalizeEvery = 1 // renormalize m in time steps that are a multiple of NormalizeEvery, 0 = never
+
+	Ext_MLength      = NewScalarField("ext_mlength", "", "Length of the reduced magnetization |m|", SetMLength)
+	Ext_MLengthDrift = NewScalarValue("ext_mlengthdrift", "", "Maximum deviation of |m| from 1 over the magnet", GetMLengthDrift)
+)
+
+func init() {
+	DeclVar("NormalizeEvery", &NormalizeEvery, "Renormalize m during time steps that are a multiple of NormalizeEvery (default 1, every step, which projects every stage onto |m|=1), 0 = never. There are no separate norm-preserving integrators")
+}
+
+// renormalizes m during a time step, according to NormalizeEvery.
+func (m *magnetization) stepNormalize() {
+	if NormalizeEvery > 0 && NSteps%NormalizeEvery == 0 {
+		m.normalize()
+	}
+}
+
+func SetMLength(dst *data.Slice) {
+	m := Download(&M).Vectors()
+	l := data.NewSlice(1, Mesh().Size())
+	s := l.Scalars()
+	forEachMagnetCell(m, func(i [3]int) {
+		s[i[Z]][i[Y]][i[X]] = float32(vectorAt(m, i).Len())
+	})
+	data.Copy(dst, l)
+}
+
+func GetMLengthDrift() float64 {
+	m := Download(&M).Vectors()
+	drift := 0.0
+	forEachMagnetCell(m, func(i [3]int) {
+		drift = math.Max(drift, math.Abs(vectorAt(m, i).Len()-1))
+	})
+	return drift
+}
@@ -0,0 +1,52 @@
+package engine
+
+// Loading mumax2 output, to continue mumax2 simulations
+// or compare their results with mumax3.
+
+import (
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/dump"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/oommf"
+	"github.com/mumax/3/util"
+)
+
+func init() {
+	DeclFunc("LoadMumax2File", LoadMumax2File, "Load a mumax2 output file (dump or OVF), with vector components in mumax3 (XYZ) order")
+	DeclFunc("ContinueMumax2", ContinueMumax2, "Set m and t from a mumax2 magnetization file (dump or OVF)")
+}
+
+// Reads a mumax2 output file: dump files have their vector components
+// converted from mumax2's ZYX order, OVF files are read as-is.
+func LoadMumax2File(fname string) *data.Slice {
+	s, _ := loadMumax2(fname)
+	return s
+}
+
+func loadMumax2(fname string) (*data.Slice, data.Meta) {
+	format, err := dump.Mumax2Format(fname)
+	util.FatalErr(err)
+	in, err := httpfs.Open(fname)
+	util.FatalErr(err)
+	defer in.Close()
+	var s *data.Slice
+	var info data.Meta
+	if format == "dump" {
+		s, info, err = dump.ReadMumax2(in)
+	} else {
+		s, info, err = oommf.Read(in)
+	}
+	util.FatalErr(err)
+	return s, info
+}
+
+// Continues from a mumax2 magnetization state: sets m (resampled if needed) and the time.
+func ContinueMumax2(fname string) {
+	s, info := loadMumax2(fname)
+	if s.NComp() != 3 {
+		util.Fatal("ContinueMumax2 ", fname, ": need vector data, have ", s.NComp(), " components")
+	}
+	M.SetArray(s)
+	Time = info.Time
+	LogOut("ContinueMumax2: loaded ", fname, " at t=", Time, " s")
+}
@@ -0,0 +1,83 @@
+package engine
+
+// NetCDF time-series output:
+//
+//	OutputFormat = NETCDF
+//
+// makes Save and AutoSave append each quantity to a single file per quantity, e.g. m.nc,
+// with time, x, y and z axes and units, instead of writing a new file per save.
+// With -resume, the records are appended to the existing files.
+
+import (
+	"time"
+
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/netcdf"
+	"github.com/mumax/3/util"
+)
+
+func init() {
+	DeclROnly("NETCDF", NETCDF, "OutputFormat = NETCDF appends all saves of a quantity to one NetCDF file (name.nc)")
+}
+
+// open NetCDF files, only accessed by the output goroutine
+var ncFiles = make(map[string]*ncFile)
+
+type ncFile struct {
+	ncomp   int
+	size    [3]int
+	records int
+}
+
+// file name of the time series of quantity name
+func ncFname(name string) string {
+	return seriesFname(name, "nc")
+}
+
+// appends s as a record to the NetCDF file fname, creating the file on first use.
+func appendNetCDF(fname string, s *data.Slice, info data.Meta) {
+	f := ncFiles[fname]
+	if f != nil && (f.ncomp != s.NComp() || f.size != s.Size()) {
+		util.Fatal("NetCDF output ", fname, ": mesh or number of components changed, use another file name")
+	}
+	if f == nil && *Flag_resume {
+		f = resumeNetCDF(fname, s)
+	}
+	if f == nil {
+		f = &ncFile{ncomp: s.NComp(), size: s.Size()}
+		header := netcdf.Header(info, f.ncomp, f.size,
+			"title", info.Name, "source", UNAME, "history", "created "+time.Now().Format(time.RFC3339))
+		util.FatalErr(httpfs.Put(fname, header))
+		ncFiles[fname] = f
+	}
+	util.FatalErr(httpfs.Append(fname, netcdf.Record(info.Time, s)))
+	f.records++
+	// keep the record count in the header up to date,
+	// remote files stay "streaming": their length determines the count
+	p, pos := netcdf.NumRecs(f.records)
+	_ = httpfs.WriteAt(fname, p, pos)
+}
+
+// with -resume, continues an existing file after its last complete record,
+// returns nil if there is no file to continue.
+func resumeNetCDF(fname string, s *data.Slice) *ncFile {
+	file, err := httpfs.Read(fname)
+	if err != nil {
+		return nil
+	}
+	l, err := netcdf.ReadLayout(file)
+	if err != nil {
+		util.Fatal("resume NetCDF output ", fname, ": ", err)
+	}
+	if l.RecordSize != 8+4*s.NComp()*s.Len() {
+		util.Fatal("resume NetCDF output ", fname, ": mesh or number of components changed, use another file name")
+	}
+	if l.End < len(file) { // drop a partly written record
+		util.FatalErr(httpfs.Put(fname, file[:l.End]))
+	}
+	LogOut("resume: appending to", fname, "after", l.Records, "records")
+	f := &ncFile{ncomp: s.NComp(), size: s.Size(), records: l.Records}
+	ncFiles[fname] = f
+	return f
+}
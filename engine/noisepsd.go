@@ -0,0 +1,103 @@
+package engine
+
+// Random waveforms with a given one-sided power spectral density S(f),
+// e.g. measured field noise, for use as a time dependence like LoadWaveform:
+//
+//	noise := NoiseFromPSD("psd.csv", 1e-12, 100e-9, 1)
+//	B_ext = vector(0, 0, 0.1).Add(vector(0, 0, noise.At(t)))
+//
+// or 1/f noise of 1e-10 T²/Hz at 1 Hz:
+//
+//	noise := PowerLawNoise(1e-10, 1, 1e-12, 100e-9, 1)
+//
+// The PSD file has a frequency (Hz) and S (unit²/Hz) per line, as for LoadWaveform,
+// and is linearly interpolated, zero outside the listed frequencies.
+// The waveform is sampled every dt from the current time on, for the given duration,
+// and has variance ∫ S(f) df over 1/duration < f < 1/2dt.
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+
+	"github.com/mumax/3/util"
+)
+
+func init() {
+	DeclFunc("NoiseFromPSD", NoiseFromPSD, "Random waveform with the power spectral density from a file (f (Hz), S (unit²/Hz)), sampled every dt (s) for duration (s), with random seed. Use .At(t) as time dependence")
+	DeclFunc("PowerLawNoise", PowerLawNoise, "Random waveform with power spectral density S1 (unit²/Hz) (1 Hz / f)^exponent, sampled every dt (s) for duration (s), with random seed. Use .At(t) as time dependence")
+}
+
+func NoiseFromPSD(fname string, dt, duration float64, seed int) *waveform {
+	psd := LoadWaveform(fname)
+	n := len(psd.t)
+	return noiseWaveform(func(f float64) float64 {
+		if f < psd.t[0] || f > psd.t[n-1] {
+			return 0
+		}
+		return psd.At(f)
+	}, dt, duration, seed)
+}
+
+func PowerLawNoise(S1, exponent, dt, duration float64, seed int) *waveform {
+	util.Argument(S1 >= 0)
+	return noiseWaveform(func(f float64) float64 {
+		return S1 * math.Pow(f, -exponent)
+	}, dt, duration, seed)
+}
+
+// Sum of harmonics a_k cos(2π f_k t) + b_k sin(2π f_k t) at f_k = k df, with
+// a_k, b_k normally distributed with variance S(f_k) df, evaluated with an FFT.
+func noiseWaveform(S func(f float64) float64, dt, duration float64, seed int) *waveform {
+	util.Argument(dt > 0 && duration > dt)
+	N := 1
+	for float64(N)*dt < duration {
+		N *= 2
+	}
+	df := 1 / (float64(N) * dt)
+	rnd := rand.New(rand.NewSource(int64(seed)))
+
+	c := make([]complex128, N)
+	for k := 1; k < N/2; k++ {
+		sigma := math.Sqrt(S(float64(k)*df) * df)
+		a, b := sigma*rnd.NormFloat64(), sigma*rnd.NormFloat64()
+		c[k] = complex(a, -b) / 2
+		c[N-k] = cmplx.Conj(c[k])
+	}
+	fft(c, +1)
+
+	w := &waveform{t: make([]float64, N), v: make([]float64, N)}
+	for i := range c {
+		w.t[i] = Time + float64(i)*dt
+		w.v[i] = real(c[i])
+	}
+	return w
+}
+
+// In-place radix-2 FFT, x_n = Σ_k c_k exp(sign i2πkn/N), len(c) a power of two.
+func fft(c []complex128, sign float64) {
+	N := len(c)
+	for i, j := 1, 0; i < N; i++ {
+		bit := N >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			c[i], c[j] = c[j], c[i]
+		}
+	}
+	for size := 2; size <= N; size *= 2 {
+		w := cmplx.Exp(complex(0, sign*2*math.Pi/float64(size)))
+		for start := 0; start < N; start += size {
+			wk := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				u := c[start+k]
+				v := c[start+k+size/2] * wk
+				c[start+k] = u + v
+				c[start+k+size/2] = u - v
+				wk *= w
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package engine
+
+// Auxiliary scalar ODE variables, integrated alongside the magnetization.
+// E.g. the current in an RL circuit driving an excitation:
+//
+//	I := NewODEVar("I", "A", 0)
+//	I.SetRHS((V - R*I.Get()) / L)
+//	J = vector(I.Get()/area, 0, 0)
+//
+// Unlike regular script variables, which are fixed to their current value
+// when used in a parameter expression, I.Get() is re-evaluated each time.
+
+import (
+	"reflect"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/script"
+)
+
+var (
+	odeVars  []*ODEVar // all auxiliary variables, integrated after each time step
+	odeStamp int       // incremented when auxiliary variables change, forces parameter update
+)
+
+func init() {
+	DeclFunc("NewODEVar", NewODEVar, "Make a new scalar variable (name, unit, initial value), integrated in time alongside m. Set the time derivative with SetRHS().")
+}
+
+// Scalar variable x obeying dx/dt = rhs, where rhs may depend on
+// time, other ODE variables and output quantities.
+type ODEVar struct {
+	value      float64
+	rhs        func() float64 // time derivative, nil means constant
+	name, unit string
+}
+
+func NewODEVar(name, unit string, initial float64) *ODEVar {
+	x := &ODEVar{value: initial, name: name, unit: unit}
+	odeVars = append(odeVars, x)
+	return x
+}
+
+// Set the time derivative dx/dt.
+func (x *ODEVar) SetRHS(rhs script.ScalarFunction) {
+	f := rhs.Fix().(script.ScalarFunction) // fix values of all variables except t
+	x.rhs = f.Float
+}
+
+func (x *ODEVar) Get() float64 { return x.value }
+func (x *ODEVar) Set(v float64) {
+	x.value = v
+	odeStamp++
+}
+
+func (x *ODEVar) Name() string       { return x.name }
+func (x *ODEVar) Unit() string       { return x.unit }
+func (x *ODEVar) NComp() int         { return 1 }
+func (x *ODEVar) average() []float64 { return []float64{x.value} }
+func (x *ODEVar) EvalTo(dst *data.Slice) {
+	cuda.Memset(dst, float32(x.value))
+}
+
+// does the expression use an ODE variable, so that it can not be treated as constant?
+func usesODEVar(e script.Expr) bool {
+	if e.Type() == reflect.TypeOf(new(ODEVar)) {
+		return true
+	}
+	for _, c := range e.Child() {
+		if usesODEVar(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// advance all ODE variables from time t0 to the current time with one RK4 step.
+// The magnetization is kept at its value at the end of the time step.
+func stepODEVars(t0 float64) {
+	if len(odeVars) == 0 || Time == t0 {
+		return
+	}
+	t1 := Time
+	dt := t1 - t0
+	defer func() { Time = t1 }()
+
+	x0 := make([]float64, len(odeVars))
+	for i, x := range odeVars {
+		x0[i] = x.value
+	}
+	k := make([][]float64, 4)
+	for s := range k {
+		k[s] = make([]float64, len(odeVars))
+	}
+
+	// stage s at t0+c*dt with x = x0 + c*dt*k[s-1]
+	c := []float64{0, 0.5, 0.5, 1}
+	for s := range k {
+		Time = t0 + c[s]*dt
+		for i, x := range odeVars {
+			if s > 0 {
+				x.value = x0[i] + c[s]*dt*k[s-1][i]
+			}
+		}
+		odeStamp++
+		for i, x := range odeVars {
+			if x.rhs != nil {
+				k[s][i] = x.rhs()
+			}
+		}
+	}
+	for i, x := range odeVars {
+		x.value = x0[i] + dt/6*(k[0][i]+2*k[1][i]+2*k[2][i]+k[3][i])
+		checkNaN1(x.value)
+	}
+	odeStamp++
+}
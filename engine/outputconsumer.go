@@ -0,0 +1,50 @@
+package engine
+
+// In-memory output for programs embedding mumax3, e.g.:
+//
+//	AddOutputConsumer(func(fname string, s *data.Slice, info data.Meta) {
+//		losses = append(losses, loss(s))
+//	})
+//	OutputFiles = false
+//
+// Every Save, SaveAs or AutoSave then passes the data to the consumers,
+// without writing files.
+
+import (
+	"github.com/mumax/3/data"
+)
+
+// Receives saved data on the host, with its metadata and the file name it would have been saved under.
+// Consumers are called one at a time on the output goroutine, in the order the data was saved,
+// while the simulation keeps running. They may keep s, but must not modify it (it is shared
+// with the other consumers and analyses, see analysis.go) nor call into the engine.
+// Call Flush (drainOutput) to wait until all saved data has been consumed.
+type OutputConsumer func(fname string, s *data.Slice, info data.Meta)
+
+var (
+	OutputFiles     = true // write saved quantities to files, in addition to passing them to output consumers
+	outputConsumers []OutputConsumer
+)
+
+func init() {
+	DeclVar("OutputFiles", &OutputFiles, "Write saved quantities to files (default true). When false, they are only passed to output consumers registered from Go")
+}
+
+// Registers c to receive all data saved from now on.
+func AddOutputConsumer(c OutputConsumer) {
+	drainOutput()
+	outputConsumers = append(outputConsumers, c)
+}
+
+// Removes all output consumers.
+func ClearOutputConsumers() {
+	drainOutput()
+	outputConsumers = nil
+}
+
+// passes saved data to the consumers, after it has been written to a file (if at all).
+func consumeOutput(fname string, s *data.Slice, info data.Meta) {
+	for _, c := range outputConsumers {
+		c(fname, s, info)
+	}
+}
@@ -0,0 +1,75 @@
+package engine
+
+// Index of the saved output files: with
+//
+//	OutputIndex = true
+//
+// every saved file gets a line in index.csv in the output directory:
+//
+//	file,t,step,quantity,unit
+//	m000000.ovf,0,0,m,
+//	m000001.ovf,1e-10,112,m,
+//
+// so the time axis can be reconstructed without parsing file names or headers.
+// Lines are appended whole and only after the file has been written,
+// so a reader never sees a partial line or a file that is not complete.
+// NetCDF and Zarr outputs get one line per record.
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+var (
+	OutputIndex = false // write index.csv listing all saved files
+	outIndex    struct {
+		sync.Mutex // output closures may run on the main and output goroutine
+		inited     bool
+	}
+)
+
+func init() {
+	DeclVar("OutputIndex", &OutputIndex, "Write index.csv with file name, time, step and quantity of every saved file (default false)")
+}
+
+// entry for a saved file, to be called on the output goroutine after fname has been written.
+type indexEntry struct {
+	fname, quant, unit string
+	t                  float64
+	step               int
+}
+
+// returns the index entry for a file saved now, or nil if there is no index.
+func newIndexEntry(fname, quant, unit string) *indexEntry {
+	if !OutputIndex {
+		return nil
+	}
+	return &indexEntry{fname: fname, quant: quant, unit: unit, t: Time, step: NSteps}
+}
+
+// appends the entry to index.csv, if not nil.
+func (e *indexEntry) write() {
+	if e == nil {
+		return
+	}
+	outIndex.Lock()
+	defer outIndex.Unlock()
+	fname := OD() + "index.csv"
+	if !outIndex.inited {
+		outIndex.inited = true
+		if _, err := httpfs.Read(fname); !(*Flag_resume && err == nil) {
+			util.FatalErr(httpfs.Put(fname, []byte("file,t,step,quantity,unit\n")))
+		}
+	}
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	w.Write([]string{strings.TrimPrefix(e.fname, OD()), fmt.Sprint(e.t), fmt.Sprint(e.step), e.quant, e.unit})
+	w.Flush()
+	util.FatalErr(httpfs.Append(fname, b.Bytes()))
+}
@@ -21,6 +21,7 @@ type regionwise struct {
 	lut
 	upd_reg    [NREGION]func() []float64 // time-dependent values
 	timestamp  float64                   // used not to double-evaluate f(t)
+	odestamp   int                       // odeStamp at last evaluation, ODE variables may change at fixed t
 	children   []derived                 // derived parameters
 	name, unit string
 }
@@ -34,7 +35,7 @@ func (p *regionwise) init(nComp int, name, unit string, children []derived) {
 }
 
 func (p *regionwise) MSlice() cuda.MSlice {
-	if p.IsUniform() {
+	if p.IsUniform() && thermalSpot.scaleOf(p) == nil {
 		return cuda.MakeMSlice(data.NilSlice(p.NComp(), Mesh().Size()), p.getRegion(0))
 	} else {
 		buf, r := p.Slice()
@@ -43,6 +44,17 @@ func (p *regionwise) MSlice() cuda.MSlice {
 	}
 }
 
+// uncompress the table to a full array, scaled by the temperature dependence if any (see ThermalSpotCurie).
+func (p *regionwise) Slice() (*data.Slice, bool) {
+	buf, r := p.lut.Slice()
+	if scale := thermalSpot.scaleOf(p); scale != nil {
+		for c := 0; c < buf.NComp(); c++ {
+			cuda.Mul(buf.Comp(c), buf.Comp(c), scale)
+		}
+	}
+	return buf, r
+}
+
 func (p *regionwise) Name() string     { return p.name }
 func (p *regionwise) Unit() string     { return p.unit }
 func (p *regionwise) Mesh() *data.Mesh { return Mesh() }
@@ -67,7 +79,7 @@ func contains(s []derived, x derived) bool {
 }
 
 func (p *regionwise) update() {
-	if p.timestamp != Time {
+	if p.timestamp != Time || p.odestamp != odeStamp {
 		changed := false
 		// update functions of time
 		for r := 0; r < NREGION; r++ {
@@ -78,6 +90,7 @@ func (p *regionwise) update() {
 			}
 		}
 		p.timestamp = Time
+		p.odestamp = odeStamp
 		if changed {
 			p.invalidate()
 		}
@@ -153,9 +166,29 @@ func (p *regionwise) IsUniform() bool {
 	return true
 }
 
-func (p *regionwise) average() []float64     { return qAverageUniverse(p) }
 func (p *regionwise) EvalTo(dst *data.Slice) { EvalTo(p, dst) }
 
+// average over the universe, computed from the region values
+// weighted by the region volumes, without rendering a field.
+func (p *regionwise) average() []float64 {
+	if thermalSpot.scaleOf(p) != nil {
+		return qAverageUniverse(p)
+	}
+	if p.IsUniform() {
+		return p.getRegion(0)
+	}
+	cpu := p.cpuLUT()
+	avg := make([]float64, p.NComp())
+	for r, f := range regions.fractions() {
+		if f != 0 {
+			for c := range avg {
+				avg[c] += f * float64(cpu[c][r])
+			}
+		}
+	}
+	return avg
+}
+
 // parameter derived from others (not directly settable). E.g.: Bsat derived from Msat
 type DerivedParam struct {
 	lut                          // GPU storage
@@ -271,14 +304,14 @@ func (p *RegionwiseScalar) GetRegion(region int) float64 {
 func (p *RegionwiseScalar) Eval() interface{}       { return p }
 func (p *RegionwiseScalar) Type() reflect.Type      { return reflect.TypeOf(new(RegionwiseScalar)) }
 func (p *RegionwiseScalar) InputType() reflect.Type { return script.ScalarFunction_t }
-func (p *RegionwiseScalar) Average() float64        { return qAverageUniverse(p)[0] }
+func (p *RegionwiseScalar) Average() float64        { return p.average()[0] }
 func (p *RegionwiseScalar) Region(r int) *sOneReg   { return sOneRegion(p, r) }
 func (p *RegionwiseScalar) EvalTo(dst *data.Slice)  { EvalTo(p, dst) }
 
-// checks if a script expression contains t (time)
+// checks if a script expression contains t (time) or ODE variables
 func IsConst(e script.Expr) bool {
 	t := World.Resolve("t")
-	return !script.Contains(e, t)
+	return !script.Contains(e, t) && !usesODEVar(e)
 }
 
 func cat(desc, unit string) string {
@@ -364,6 +397,6 @@ func (p *RegionwiseVector) Eval() interface{}       { return p }
 func (p *RegionwiseVector) Type() reflect.Type      { return reflect.TypeOf(new(RegionwiseVector)) }
 func (p *RegionwiseVector) InputType() reflect.Type { return script.VectorFunction_t }
 func (p *RegionwiseVector) Region(r int) *vOneReg   { return vOneRegion(p, r) }
-func (p *RegionwiseVector) Average() data.Vector    { return unslice(qAverageUniverse(p)) }
+func (p *RegionwiseVector) Average() data.Vector    { return unslice(p.average()) }
 func (p *RegionwiseVector) Comp(c int) ScalarField  { return Comp(p, c) }
 func (p *RegionwiseVector) EvalTo(dst *data.Slice)  { EvalTo(p, dst) }
@@ -0,0 +1,173 @@
+package engine
+
+// Export/import of all material parameters and physics toggles as JSON,
+// for reusing a parameter set across runs and for provenance.
+// Loading replays the settings as script statements, so they end up in the log.
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+// toggles and constants saved along with the parameters
+var paramToggles = []string{"EnableDemag", "DemagAccuracy", "DemagCropToGeom", "DemagMultigrid",
+	"DoPrecess", "DisableZhangLiTorque", "DisableSlonczewskiTorque", "GammaLL", "ThermCorrTime"}
+
+func init() {
+	DeclFunc("SaveParams", SaveParams, "Save all material parameters and physics toggles to a JSON file")
+	DeclFunc("LoadParams", LoadParams, "Load material parameters and physics toggles from a JSON file written by SaveParams")
+}
+
+type paramFile struct {
+	Version string                 `json:"version"`
+	Date    string                 `json:"date"`
+	Time    float64                `json:"t"` // simulation time of the saved values
+	Params  map[string]paramEntry  `json:"params"`
+	Toggles map[string]interface{} `json:"toggles"`
+}
+
+type paramEntry struct {
+	Unit          string               `json:"unit"`
+	Value         []float32            `json:"value"`             // value in region 0, default for all regions
+	Regions       map[string][]float32 `json:"regions,omitempty"` // regions where the value differs from region 0
+	TimeDependent bool                 `json:"timedependent,omitempty"`
+	Masks         int                  `json:"masks,omitempty"` // number of mask terms added with Add(), not saved
+}
+
+func SaveParams(fname string) {
+	if !strings.HasPrefix(fname, OD()) {
+		fname = OD() + fname
+	}
+	f := paramFile{
+		Version: UNAME,
+		Date:    time.Now().Format(time.RFC3339),
+		Time:    Time,
+		Params:  make(map[string]paramEntry),
+		Toggles: make(map[string]interface{}),
+	}
+	for name, p := range gui_.Params {
+		var rw *regionwise
+		e := paramEntry{Unit: p.Unit()}
+		switch p := p.(type) {
+		default:
+			continue // not user-settable
+		case *RegionwiseScalar:
+			rw = &p.regionwise
+		case *RegionwiseVector:
+			rw = &p.regionwise
+		case *Excitation:
+			rw = &p.perRegion.regionwise
+			e.Masks = len(p.extraTerms)
+		}
+		e.Value = float32s(rw.getRegion(0))
+		for r := 0; r < NREGION; r++ {
+			if rw.upd_reg[r] != nil {
+				e.TimeDependent = true
+			}
+			if v := float32s(rw.getRegion(r)); r > 0 && !equal32(v, e.Value) {
+				if e.Regions == nil {
+					e.Regions = make(map[string][]float32)
+				}
+				e.Regions[strconv.Itoa(r)] = v
+			}
+		}
+		f.Params[name] = e
+	}
+	for _, name := range paramToggles {
+		f.Toggles[name] = World.Resolve(name).Eval()
+	}
+
+	out, err := json.MarshalIndent(f, "", "\t")
+	util.FatalErr(err)
+	util.FatalErr(httpfs.Put(fname, out))
+}
+
+func LoadParams(fname string) {
+	in, err := httpfs.Read(fname)
+	util.FatalErr(err)
+	var f paramFile
+	if err := json.Unmarshal(in, &f); err != nil {
+		util.Fatal("LoadParams ", fname, ": ", err)
+	}
+
+	names := make([]string, 0, len(f.Params))
+	for name := range f.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		e := f.Params[name]
+		if _, ok := gui_.Params[name]; !ok {
+			LogErr("LoadParams: unknown parameter ", name, ", ignored")
+			continue
+		}
+		Eval(fmt.Sprint(name, " = ", paramLiteral(e.Value)))
+		regs := make([]int, 0, len(e.Regions))
+		for r := range e.Regions {
+			i, err := strconv.Atoi(r)
+			if err != nil || i < 0 || i >= NREGION {
+				util.Fatal("LoadParams: ", name, ": invalid region ", r)
+			}
+			regs = append(regs, i)
+		}
+		sort.Ints(regs)
+		for _, r := range regs {
+			Eval(fmt.Sprint(name, ".SetRegion(", r, ", ", paramLiteral(e.Regions[strconv.Itoa(r)]), ")"))
+		}
+		if e.TimeDependent {
+			LogErr("LoadParams: ", name, " was time-dependent, loaded its value at t=", f.Time)
+		}
+		if e.Masks != 0 {
+			LogErr("LoadParams: ", name, " had ", e.Masks, " mask term(s), these need to be added again")
+		}
+	}
+
+	names = names[:0]
+	for name := range f.Toggles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if World.Resolve(name) == nil {
+			LogErr("LoadParams: unknown variable ", name, ", ignored")
+			continue
+		}
+		Eval(fmt.Sprint(name, " = ", f.Toggles[name]))
+	}
+}
+
+// script literal for a scalar or vector value
+func paramLiteral(v []float32) string {
+	switch len(v) {
+	case 1:
+		return fmt.Sprint(v[0])
+	case 3:
+		return fmt.Sprint("vector(", v[0], ", ", v[1], ", ", v[2], ")")
+	default:
+		panic(fmt.Sprint("paramLiteral: invalid number of components: ", len(v)))
+	}
+}
+
+func float32s(v []float64) []float32 {
+	f := make([]float32, len(v))
+	for i := range v {
+		f[i] = float32(v[i])
+	}
+	return f
+}
+
+func equal32(a, b []float32) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
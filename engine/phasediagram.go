@@ -0,0 +1,280 @@
+package engine
+
+// Phase diagrams of magnetic textures, e.g. of chiral magnets as a function of field and DMI:
+// at each point of a 2D parameter grid, the magnetization is relaxed from the current state
+// and nrandom random states, and the lowest energy state is classified as
+//
+//	uniform:          no modulation, all Fourier amplitudes of m (k≠0) below PhaseUniformTol
+//	stripe:           modulation along one direction, collinear (domains)
+//	helix:            modulation along one direction, rotating (helix, cycloid, cone)
+//	skyrmion lattice: |Q| ≥ 2 and modulation along 3 or more directions
+//	skyrmions:        |Q| ≥ 1, otherwise
+//	other:            e.g. labyrinths, vortices
+//
+// from the topological charge Q and the peaks of the in-plane Fourier transform
+// of m in the middle layer. Collinear and rotating modulations are told apart
+// by the ratio of the two largest eigenvalues of the covariance <m_i m_j> - <m_i><m_j>.
+// E.g.:
+//
+//	PhaseDiagram(ScanExcitation(B_ext, vector(0, 0, 0), vector(0, 0, 0.5), 11), ScanParam(Dind, 1e-3, 4e-3, 7), 3, 1)
+//
+// writes the parameter values, state and its topological charge and energy to phasediagram.txt
+// and saves the lowest energy state of each point as phase_i_j.
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+var (
+	PhaseUniformTol = 0.02 // largest Fourier amplitude of m (k≠0) of a uniform state
+	PhasePeakTol    = 0.25 // Fourier peaks above this fraction of the largest peak count as modulation
+
+	phaseFFT   = &fftLayer{parent: &M, name: "m_phaseFFT", layer: func() int { return Mesh().Size()[Z] / 2 }}
+	phaseNames = []string{"uniform", "stripe", "helix", "skyrmion lattice", "skyrmions", "other"}
+)
+
+const (
+	PHASE_UNIFORM = iota
+	PHASE_STRIPE
+	PHASE_HELIX
+	PHASE_SKYRMION_LATTICE
+	PHASE_SKYRMIONS
+	PHASE_OTHER
+)
+
+func init() {
+	DeclFunc("PhaseDiagram", PhaseDiagram, "Relax from the current and nrandom random states (with random seed) at each point of two parameter scans, classify the lowest energy state. Writes phasediagram.txt")
+	DeclFunc("ScanParam", ScanParam, "Scan of a scalar parameter over n values from..to, for PhaseDiagram")
+	DeclFunc("ScanExcitation", ScanExcitation, "Scan of an excitation over n vectors from..to, for PhaseDiagram")
+	DeclFunc("ClassifyState", ClassifyState, "Classify the magnetization as 0: uniform, 1: stripe, 2: helix, 3: skyrmion lattice, 4: skyrmions, 5: other")
+	DeclVar("PhaseUniformTol", &PhaseUniformTol, "Largest Fourier amplitude of m (k≠0) of a uniform state, for ClassifyState")
+	DeclVar("PhasePeakTol", &PhasePeakTol, "Fourier peaks above this fraction of the largest one count as modulation, for ClassifyState")
+}
+
+// linear scan of a region-wise parameter (all regions) over n values
+type scanAxis struct {
+	p        *regionwise
+	from, to []float64
+	n        int
+}
+
+func ScanParam(p *RegionwiseScalar, from, to float64, n int) *scanAxis {
+	util.Argument(n > 0)
+	return &scanAxis{&p.regionwise, []float64{from}, []float64{to}, n}
+}
+
+func ScanExcitation(e *Excitation, from, to data.Vector, n int) *scanAxis {
+	util.Argument(n > 0)
+	return &scanAxis{&e.perRegion.regionwise, from[:], to[:], n}
+}
+
+// i'th value of the scan
+func (a *scanAxis) value(i int) []float64 {
+	f := 0.
+	if a.n > 1 {
+		f = float64(i) / float64(a.n-1)
+	}
+	v := make([]float64, len(a.from))
+	for c := range v {
+		v[c] = a.from[c] + f*(a.to[c]-a.from[c])
+	}
+	return v
+}
+
+// table header for the scanned values
+func (a *scanAxis) header() string {
+	if len(a.from) == 1 {
+		return fmt.Sprintf("%s (%s)\t", a.p.Name(), a.p.Unit())
+	}
+	h := ""
+	for _, c := range []string{"x", "y", "z"} {
+		h += fmt.Sprintf("%s%s (%s)\t", a.p.Name(), c, a.p.Unit())
+	}
+	return h
+}
+
+func PhaseDiagram(a1, a2 *scanAxis, nrandom, seed int) {
+	checkMesh()
+	util.Argument(nrandom >= 0)
+	defer restoreParam(a1.p, saveParam(a1.p))
+	defer restoreParam(a2.p, saveParam(a2.p))
+
+	m0 := cuda.Buffer(3, Mesh().Size())
+	defer cuda.Recycle(m0)
+	data.Copy(m0, M.Buffer())
+	defer M.SetArray(m0)
+
+	out, err := httpfs.Create(OD() + "phasediagram.txt")
+	util.FatalErr(err)
+	defer out.Close()
+	fmt.Fprintln(out, "# "+a1.header()+a2.header()+"state ()\tQ ()\tE (J)\tname")
+
+	for i := 0; i < a1.n; i++ {
+		for j := 0; j < a2.n; j++ {
+			v1, v2 := a1.value(i), a2.value(j)
+			a1.p.setRegions(0, NREGION, v1)
+			a2.p.setRegions(0, NREGION, v2)
+
+			set := []func(){func() { M.SetArray(m0) }}
+			for s := 1; s <= nrandom; s++ {
+				s := s
+				set = append(set, func() { M.Set(RandomMagSeed(seed + s)) })
+			}
+			best, E := relaxLowest(set)
+			state := ClassifyState()
+			Q := GetTopologicalCharge()
+			SaveAs(&M, fmt.Sprintf("phase_%d_%d", i, j))
+
+			for _, v := range append(v1, v2...) {
+				fmt.Fprintf(out, "%g\t", v)
+			}
+			fmt.Fprintf(out, "%d\t%g\t%g\t%s\n", state, Q, E[best], phaseNames[state])
+			out.Flush()
+			LogOut(fmt.Sprintf("PhaseDiagram: %s=%v, %s=%v: %s", a1.p.Name(), v1, a2.p.Name(), v2, phaseNames[state]))
+		}
+	}
+}
+
+func ClassifyState() int {
+	checkMesh()
+	amax, dirs := fourierPeaks()
+	Q := math.Abs(GetTopologicalCharge())
+	switch {
+	case amax < PhaseUniformTol:
+		return PHASE_UNIFORM
+	case Q >= 0.5:
+		if Q >= 1.5 && dirs >= 3 {
+			return PHASE_SKYRMION_LATTICE
+		}
+		return PHASE_SKYRMIONS
+	case dirs == 1:
+		if l := covarianceEigenvalues(); l[1] < 0.3*l[0] {
+			return PHASE_STRIPE
+		}
+		return PHASE_HELIX
+	default:
+		return PHASE_OTHER
+	}
+}
+
+// Largest Fourier amplitude of m (k≠0) and the number of distinct directions
+// (within 10°) of the wave vectors of the peaks above PhasePeakTol times that amplitude.
+func fourierPeaks() (amax float64, ndir int) {
+	F := ValueOf(phaseFFT)
+	defer cuda.Recycle(F)
+	a := F.HostCopy().Vectors()
+	n := F.Size()
+	dk := phaseFFT.Mesh().CellSize()
+
+	amp := func(ix, iy int) float64 {
+		return math.Sqrt(sqr64(float64(a[X][0][iy][ix])) + sqr64(float64(a[Y][0][iy][ix])) + sqr64(float64(a[Z][0][iy][ix])))
+	}
+	isZero := func(ix, iy int) bool { return ix == 0 && iy == n[Y]/2 }
+	wrap := func(iy int) int { return (iy + n[Y]) % n[Y] } // ky is periodic
+
+	for iy := 0; iy < n[Y]; iy++ {
+		for ix := 0; ix < n[X]; ix++ {
+			if !isZero(ix, iy) {
+				amax = math.Max(amax, amp(ix, iy))
+			}
+		}
+	}
+	if amax == 0 {
+		return 0, 0
+	}
+
+	var angles []float64
+	for iy := 0; iy < n[Y]; iy++ {
+		for ix := 0; ix < n[X]; ix++ {
+			A := amp(ix, iy)
+			ky := iy - n[Y]/2
+			if isZero(ix, iy) || A < PhasePeakTol*amax || (ix == 0 && ky < 0) { // (0, -ky) is the conjugate of (0, ky)
+				continue
+			}
+			peak := true
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					jx, jy := ix+dx, wrap(iy+dy)
+					if (dx != 0 || dy != 0) && jx >= 0 && jx < n[X] && !isZero(jx, jy) && amp(jx, jy) > A {
+						peak = false
+					}
+				}
+			}
+			if !peak {
+				continue
+			}
+			phi := math.Atan2(float64(ky)*dk[Y], float64(ix)*dk[X]) // -π/2..π/2
+			distinct := true
+			for _, p := range angles {
+				d := math.Abs(phi - p)
+				if math.Min(d, math.Pi-d) < 10*math.Pi/180 {
+					distinct = false
+				}
+			}
+			if distinct {
+				angles = append(angles, phi)
+			}
+		}
+	}
+	return amax, len(angles)
+}
+
+// eigenvalues, largest first, of the covariance matrix <m_i m_j> - <m_i><m_j> over the magnet.
+func covarianceEigenvalues() [3]float64 {
+	m := Download(&M).Vectors()
+	var C [3][3]float64
+	var mean data.Vector
+	N := 0.
+	forEachMagnetCell(m, func(i [3]int) {
+		mi := vectorAt(m, i)
+		mean = mean.Add(mi)
+		for a := 0; a < 3; a++ {
+			for b := 0; b < 3; b++ {
+				C[a][b] += mi[a] * mi[b]
+			}
+		}
+		N++
+	})
+	for a := 0; a < 3; a++ {
+		for b := 0; b < 3; b++ {
+			C[a][b] = C[a][b]/N - mean[a]*mean[b]/(N*N)
+		}
+	}
+	return symEigenvalues(C)
+}
+
+// eigenvalues, largest first, of a symmetric 3x3 matrix (trigonometric solution).
+func symEigenvalues(A [3][3]float64) [3]float64 {
+	p1 := sqr64(A[0][1]) + sqr64(A[0][2]) + sqr64(A[1][2])
+	q := (A[0][0] + A[1][1] + A[2][2]) / 3
+	if p1 == 0 {
+		l := []float64{A[0][0], A[1][1], A[2][2]}
+		sort.Sort(sort.Reverse(sort.Float64Slice(l)))
+		return [3]float64{l[0], l[1], l[2]}
+	}
+	p2 := sqr64(A[0][0]-q) + sqr64(A[1][1]-q) + sqr64(A[2][2]-q) + 2*p1
+	p := math.Sqrt(p2 / 6)
+	var B [3][3]float64
+	for i := range B {
+		for j := range B[i] {
+			B[i][j] = A[i][j] / p
+			if i == j {
+				B[i][j] -= q / p
+			}
+		}
+	}
+	r := (B[0][0]*(B[1][1]*B[2][2]-B[1][2]*B[2][1]) -
+		B[0][1]*(B[1][0]*B[2][2]-B[1][2]*B[2][0]) +
+		B[0][2]*(B[1][0]*B[2][1]-B[1][1]*B[2][0])) / 2
+	phi := math.Acos(math.Max(-1, math.Min(1, r))) / 3
+	l1 := q + 2*p*math.Cos(phi)
+	l3 := q + 2*p*math.Cos(phi+2*math.Pi/3)
+	return [3]float64{l1, 3*q - l1 - l3, l3}
+}
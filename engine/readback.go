@@ -0,0 +1,150 @@
+package engine
+
+// Readback of a written track by reciprocity: the signal of a reader
+// at down-track position x0 is
+//
+//	V(x0) ∝ ∫ M(r) · h(r - x0) dV
+//
+// with h the reader's sensitivity function (its field per unit current).
+// The reader is moved along x over all cell positions and the waveform
+// V(x0) is written to readback.txt. The sensitivity is either
+// a Karlqvist gap field above the top surface, limited to the track width:
+//
+//	ReadbackKarlqvist(20e-9, 50e-9, 5e-9)
+//
+// or a map from a file, with the cell size and y, z size of the mesh
+// and the reader at the center along x:
+//
+//	Readback("sensitivity.ovf")
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+func init() {
+	DeclFunc("Readback", Readback, "Write the reciprocity readback waveform along x to readback.txt, for the reader sensitivity map in a file")
+	DeclFunc("ReadbackKarlqvist", ReadbackKarlqvist, "Write the reciprocity readback waveform along x to readback.txt, for a Karlqvist reader with gap, track width and spacing above the top surface (m)")
+}
+
+func Readback(sensFile string) {
+	checkMesh()
+	h, _ := loadFile(sensFile)
+	n := Mesh().Size()
+	hs := h.Size()
+	if h.NComp() != 3 || hs[Y] != n[Y] || hs[Z] != n[Z] {
+		util.Fatal("Readback: sensitivity map ", sensFile, " should be a vector field of ", n[Y], " x ", n[Z], " cells in y and z, have ", h.NComp(), " components of ", hs)
+	}
+	hv := h.Vectors()
+	readback(func(iy, iz int) []data.Vector {
+		k := make([]data.Vector, 2*n[X]-1)
+		for d := range k {
+			i := d - (n[X] - 1) + hs[X]/2
+			if i >= 0 && i < hs[X] {
+				k[d] = data.Vector{float64(hv[X][iz][iy][i]), float64(hv[Y][iz][iy][i]), float64(hv[Z][iz][iy][i])}
+			}
+		}
+		return k
+	})
+}
+
+// Karlqvist head field per unit deep-gap field, at depth y below the air bearing surface
+// and down-track distance x from the gap center.
+func karlqvist(gap, x, y float64) (hx, hz float64) {
+	g := gap / 2
+	hx = (math.Atan((x+g)/y) - math.Atan((x-g)/y)) / math.Pi
+	hz = -math.Log((sqr(x+g)+sqr(y))/(sqr(x-g)+sqr(y))) / (2 * math.Pi)
+	return
+}
+
+func ReadbackKarlqvist(gap, width, spacing float64) {
+	checkMesh()
+	util.Argument(gap > 0 && width > 0 && spacing > 0)
+	n := Mesh().Size()
+	c := Mesh().CellSize()
+	top := Index2Coord(0, 0, n[Z]-1)[Z] + c[Z]/2
+
+	// the sensitivity only depends on the distance to the reader and the depth,
+	// evaluate it once per layer and share it by all cells within the track width.
+	layer := make([][]data.Vector, n[Z])
+	for iz := range layer {
+		k := make([]data.Vector, 2*n[X]-1)
+		depth := spacing + top - Index2Coord(0, 0, iz)[Z]
+		for d := range k {
+			hx, hz := karlqvist(gap, float64(d-(n[X]-1))*c[X], depth)
+			k[d] = data.Vector{hx, 0, hz}
+		}
+		layer[iz] = k
+	}
+	readback(func(iy, iz int) []data.Vector {
+		if math.Abs(Index2Coord(0, iy, 0)[Y]) > width/2 {
+			return nil
+		}
+		return layer[iz]
+	})
+}
+
+// Writes V(x0) = Σ M·h dV for the reader above each cell column ix0.
+// kernel(iy, iz) returns the sensitivity h of the cells in row (iy, iz)
+// at distance d = ix - ix0 from the reader, at index d + Nx - 1,
+// or nil if it is zero. The sum over x is a correlation of each row with
+// its kernel, done by FFT. Kernels shared between rows are transformed once.
+func readback(kernel func(iy, iz int) []data.Vector) {
+	M := Download(&M_full).Vectors()
+	n := Mesh().Size()
+	dV := cellVolume()
+
+	L := 1 // FFT length without wrap-around for distances up to ±(Nx-1)
+	for L < 2*n[X]-1 {
+		L *= 2
+	}
+	V := make([]complex128, L)
+	transformed := make(map[*data.Vector][3][]complex128)
+	row := make([]complex128, L)
+	for iz := 0; iz < n[Z]; iz++ {
+		for iy := 0; iy < n[Y]; iy++ {
+			k := kernel(iy, iz)
+			if k == nil {
+				continue
+			}
+			K, ok := transformed[&k[0]]
+			if !ok {
+				// stored as h(-d) at index d mod L, so that V = M * h(-d) is a convolution
+				for c := range K {
+					K[c] = make([]complex128, L)
+					for d := range k {
+						K[c][(L-(d-(n[X]-1)))%L] = complex(k[d][c], 0)
+					}
+					fft(K[c], -1)
+				}
+				transformed[&k[0]] = K
+			}
+			for c := range K {
+				for ix := range row {
+					row[ix] = 0
+					if ix < n[X] {
+						row[ix] = complex(float64(M[c][iz][iy][ix]), 0)
+					}
+				}
+				fft(row, -1)
+				for i := range V {
+					V[i] += row[i] * K[c][i]
+				}
+			}
+		}
+	}
+	fft(V, 1)
+
+	out, err := httpfs.Create(OD() + "readback.txt")
+	util.FatalErr(err)
+	defer out.Close()
+	fmt.Fprintln(out, "# x (m)\tV (arb.)")
+	for ix0 := 0; ix0 < n[X]; ix0++ {
+		fmt.Fprintf(out, "%g\t%g\n", Index2Coord(ix0, 0, 0)[X], real(V[ix0])/float64(L)*dV)
+	}
+	LogOut("Readback: wrote ", OD()+"readback.txt")
+}
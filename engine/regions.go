@@ -20,6 +20,8 @@ func init() {
 type Regions struct {
 	gpuCache *cuda.Bytes                 // TODO: rename: buffer
 	hist     []func(x, y, z float64) int // history of region set operations
+	frac     []float64                   // cached fraction of cells per region, nil if out of date
+	defined  [NREGION]bool               // regions assigned to cells by the user
 	info
 }
 
@@ -27,15 +29,19 @@ func (r *Regions) alloc() {
 	mesh := r.Mesh()
 	r.gpuCache = cuda.NewBytes(mesh.NCell())
 	DefRegion(0, universe)
+	r.defined[0] = false // default, not set by the user
 }
 
 func (r *Regions) resize() {
 	newSize := Mesh().Size()
 	r.gpuCache.Free()
 	r.gpuCache = cuda.NewBytes(prod(newSize))
+	r.frac = nil
+	defined := r.defined
 	for _, f := range r.hist {
 		r.render(f)
 	}
+	r.defined = defined
 }
 
 // Define a region with id (0-255) to be inside the Shape.
@@ -62,16 +68,18 @@ func (r *Regions) render(f func(x, y, z float64) int) {
 	for iz := 0; iz < n[Z]; iz++ {
 		for iy := 0; iy < n[Y]; iy++ {
 			for ix := 0; ix < n[X]; ix++ {
-				r := Index2Coord(ix, iy, iz)
-				region := f(r[X], r[Y], r[Z])
+				R := Index2Coord(ix, iy, iz)
+				region := f(R[X], R[Y], R[Z])
 				if region >= 0 {
 					arr[iz][iy][ix] = byte(region)
+					r.defined[region] = true
 				}
 			}
 		}
 	}
 	//log.Print("regions.upload")
 	r.gpuCache.Upload(l)
+	r.frac = nil
 }
 
 // get the region for position R based on the history
@@ -101,6 +109,8 @@ func DefRegionCell(id int, x, y, z int) {
 	defRegionId(id)
 	index := data.Index(Mesh().Size(), x, y, z)
 	regions.gpuCache.Set(index, byte(id))
+	regions.defined[id] = true
+	regions.frac = nil
 }
 
 // Load regions from ovf file, use first component.
@@ -121,10 +131,12 @@ func (r *Regions) LoadFile(fname string) {
 					util.Fatal("regions.LoadFile(", fname, "): all values should be between 0 & 256, have: ", val)
 				}
 				arr[iz][iy][ix] = byte(val)
+				r.defined[byte(val)] = true
 			}
 		}
 	}
 	r.gpuCache.Upload(l)
+	r.frac = nil
 }
 
 func (r *Regions) average() []float64 {
@@ -142,6 +154,7 @@ func (r *Regions) SetCell(ix, iy, iz int, region int) {
 	size := Mesh().Size()
 	i := data.Index(size, ix, iy, iz)
 	r.gpuCache.Set(i, byte(region))
+	r.frac = nil
 }
 
 func (r *Regions) GetCell(ix, iy, iz int) int {
@@ -151,25 +164,33 @@ func (r *Regions) GetCell(ix, iy, iz int) int {
 }
 
 func defRegionId(id int) {
-	if id < 0 || id > NREGION {
-		util.Fatalf("region id should be 0 -%v, have: %v", NREGION, id)
+	if id < 0 || id >= NREGION {
+		util.Fatalf("region id should be 0 -%v, have: %v", NREGION-1, id)
 	}
 	checkMesh()
 }
 
 // normalized volume (0..1) of region.
-// TODO: a tidbit too expensive
+// counted exactly, also on meshes with more cells than a float32 sum can count.
 func (r *Regions) volume(region_ int) float64 {
-	region := byte(region_)
-	vol := 0
-	list := r.HostList()
-	for _, reg := range list {
-		if reg == region {
-			vol++
+	return r.fractions()[region_]
+}
+
+// fraction of cells in each region, cached until the regions change.
+// allows averaging region-wise quantities without rendering them to a field.
+func (r *Regions) fractions() []float64 {
+	if r.frac == nil {
+		frac := make([]float64, NREGION)
+		l := r.HostList()
+		for _, reg := range l {
+			frac[reg]++
+		}
+		for i := range frac {
+			frac[i] /= float64(len(l))
 		}
+		r.frac = frac
 	}
-	V := float64(vol) / float64(r.Mesh().NCell())
-	return V
+	return r.frac
 }
 
 // Get the region data on GPU
@@ -221,6 +242,7 @@ func (b *Regions) shift(dx int) {
 	newreg := byte(0) // new region at edge
 	cuda.ShiftBytes(r2, r1, b.Mesh(), dx, newreg)
 	r1.Copy(r2)
+	b.frac = nil
 
 	n := Mesh().Size()
 	x1, x2 := shiftDirtyRange(dx)
@@ -246,6 +268,7 @@ func (b *Regions) shiftY(dy int) {
 	newreg := byte(0) // new region at edge
 	cuda.ShiftBytesY(r2, r1, b.Mesh(), dy, newreg)
 	r1.Copy(r2)
+	b.frac = nil
 
 	n := Mesh().Size()
 	y1, y2 := shiftDirtyRange(dy)
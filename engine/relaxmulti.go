@@ -0,0 +1,54 @@
+package engine
+
+// Ground state search from several initial states, e.g.:
+//
+//	RelaxMulti(uniform(0, 0, 1), NeelSkyrmion(1, -1), RandomMagSeed(1), RandomMagSeed(2))
+//
+// relaxes from each state, logs their total energies and keeps the lowest energy state.
+
+import (
+	"fmt"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/util"
+)
+
+func init() {
+	DeclFunc("RelaxMulti", RelaxMulti, "Relax from each of the given initial magnetizations (e.g. RandomMagSeed(i)), keep the lowest energy state. Returns its total energy (J)")
+}
+
+func RelaxMulti(seeds ...Config) float64 {
+	checkMesh()
+	util.Argument(len(seeds) > 0)
+	set := make([]func(), len(seeds))
+	for i, c := range seeds {
+		c := c
+		set[i] = func() { M.Set(c) }
+	}
+	best, E := relaxLowest(set)
+	for i := range E {
+		LogOut(fmt.Sprintf("RelaxMulti: initial state %d: E = %g J", i, E[i]))
+	}
+	LogOut("RelaxMulti: keeping state ", best)
+	return E[best]
+}
+
+// Relaxes from the initial states set by each of set, leaves the lowest energy state in M.
+// Returns its index and the total energies of all relaxed states.
+func relaxLowest(set []func()) (best int, E []float64) {
+	buf := cuda.Buffer(3, Mesh().Size())
+	defer cuda.Recycle(buf)
+	E = make([]float64, len(set))
+	for i, s := range set {
+		s()
+		Relax()
+		E[i] = GetTotalEnergy()
+		if i == 0 || E[i] < E[best] {
+			best = i
+			data.Copy(buf, M.Buffer())
+		}
+	}
+	M.SetArray(buf)
+	return best, E
+}
@@ -0,0 +1,51 @@
+package engine
+
+// Resuming an interrupted run in an existing output directory (-resume flag):
+// auto-saved files continue their numbering and the table is appended to.
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/mumax/3/httpfs"
+)
+
+var resumed = make(map[interface{}]bool) // auto numbers already continued from existing files
+
+// with -resume, the first auto number of q continues after the existing files.
+func resumeNumber(q Quantity, ext string) {
+	if !*Flag_resume || resumed[q] {
+		return
+	}
+	resumed[q] = true
+	name := NameOf(q)
+	dir := path.Dir(autoFname(name, ext, 0))
+	ls, _ := httpfs.ReadDir(dir)
+	exists := make(map[string]bool, len(ls))
+	for _, f := range ls {
+		exists[path.Base(f)] = true
+	}
+	n := autonum[q]
+	for exists[path.Base(autoFname(name, ext, n))] {
+		n++
+	}
+	if n != autonum[q] {
+		LogOut("resume:", name, "output continues at number", n)
+	}
+	autonum[q] = n
+}
+
+// open the existing table for appending and write a resume marker.
+// returns false if there is no table to append to.
+func (t *DataTable) resume() bool {
+	f, err := httpfs.OpenAppend(OD() + t.name + ".txt")
+	if err != nil {
+		return false
+	}
+	t.output = f
+	LogOut("resume: appending to", t.name+".txt")
+	fprintln(t, fmt.Sprintf("# resumed at t = %v s", Time))
+	t.Flush()
+	t.startAutoflush()
+	return true
+}
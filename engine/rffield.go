@@ -0,0 +1,86 @@
+package engine
+
+// Circularly and elliptically polarized RF fields, e.g.:
+//
+//	B_ext = CircularField(1e-3, 10e9, 0, vector(0, 0, 1)).Add(vector(0, 0, 0.1))
+
+import (
+	"math"
+	"reflect"
+
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/script"
+	"github.com/mumax/3/util"
+)
+
+func init() {
+	DeclFunc("CircularField", CircularField, "Field with amplitude (T) rotating counter-clockwise around normal at frequency f (Hz), starting at phase (rad). Use .Add() for a bias field")
+	DeclFunc("EllipticalField", EllipticalField, "Field amp1 cos(2πft+phase) u + amp2 sin(2πft+phase) v (T), rotating from u towards v. Use .Add() for a bias field")
+}
+
+// Rotating field amp (cos(ωt+φ) u + sin(ωt+φ) v) with u, v perpendicular to normal
+// and u × v = normal, so it rotates counter-clockwise when seen from the tip of normal.
+// A negative amplitude rotates clockwise.
+func CircularField(amp, f, phase float64, normal data.Vector) *rfField {
+	util.Argument(normal.Len() != 0)
+	n := normal.Div(normal.Len())
+	a := data.Vector{1, 0, 0}
+	if math.Abs(n[X]) > 0.9 {
+		a = data.Vector{0, 1, 0}
+	}
+	u := a.Sub(n.Mul(a.Dot(n)))
+	u = u.Div(u.Len())
+	v := n.Cross(u)
+	return &rfField{cos: u.Mul(math.Abs(amp)), sin: v.Mul(amp), freq: f, phase: phase}
+}
+
+// Elliptical field amp1 cos(ωt+φ) u + amp2 sin(ωt+φ) v, with u and v normalized.
+func EllipticalField(amp1, amp2, f, phase float64, u, v data.Vector) *rfField {
+	util.Argument(u.Len() != 0 && v.Len() != 0)
+	return &rfField{cos: u.Mul(amp1 / u.Len()), sin: v.Mul(amp2 / v.Len()), freq: f, phase: phase}
+}
+
+// Harmonic vector function of time, plus other (e.g. static) terms.
+// Implements script.VectorFunction so it can be assigned to excitations.
+type rfField struct {
+	cos, sin    data.Vector // amplitude of the cos and sin parts
+	freq, phase float64
+	terms       []script.VectorFunction // added with Add()
+}
+
+// Returns the sum of the field and b, which may be a vector or another function of time.
+func (f *rfField) Add(b script.VectorFunction) *rfField {
+	sum := *f
+	sum.terms = append(append([]script.VectorFunction{}, f.terms...), b)
+	return &sum
+}
+
+func (f *rfField) Float3() data.Vector {
+	wt := 2*math.Pi*f.freq*Time + f.phase
+	B := f.cos.Mul(math.Cos(wt)).Add(f.sin.Mul(math.Sin(wt)))
+	for _, t := range f.terms {
+		B = B.Add(t.Float3())
+	}
+	return B
+}
+
+func (f *rfField) Eval() interface{}  { return f }
+func (f *rfField) Type() reflect.Type { return script.VectorFunction_t }
+
+// depends on time, and on the added terms
+func (f *rfField) Child() []script.Expr {
+	c := []script.Expr{World.Resolve("t")}
+	for _, t := range f.terms {
+		c = append(c, t)
+	}
+	return c
+}
+
+func (f *rfField) Fix() script.Expr {
+	fixed := *f
+	fixed.terms = make([]script.VectorFunction, len(f.terms))
+	for i, t := range f.terms {
+		fixed.terms[i] = t.Fix().(script.VectorFunction)
+	}
+	return &fixed
+}
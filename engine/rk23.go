@@ -40,7 +40,7 @@ func (rk *RK23) Step() {
 	}
 
 	// FSAL cannot be used with temperature
-	if !Temp.isZero() {
+	if thermalNoise() {
 		torqueFn(rk.k1)
 	}
 
@@ -62,18 +62,18 @@ func (rk *RK23) Step() {
 	// stage 2
 	Time = t0 + (1./2.)*Dt_si
 	cuda.Madd2(m, m, rk.k1, 1, (1./2.)*h) // m = m*1 + k1*h/2
-	M.normalize()
+	M.stepNormalize()
 	torqueFn(k2)
 
 	// stage 3
 	Time = t0 + (3./4.)*Dt_si
 	cuda.Madd2(m, m0, k2, 1, (3./4.)*h) // m = m0*1 + k2*3/4
-	M.normalize()
+	M.stepNormalize()
 	torqueFn(k3)
 
 	// 3rd order solution
 	madd4(m, m0, rk.k1, k2, k3, 1, (2./9.)*h, (1./3.)*h, (4./9.)*h)
-	M.normalize()
+	M.stepNormalize()
 
 	// error estimate
 	Time = t0 + Dt_si
@@ -151,25 +151,54 @@ func adaptDt(corr float64) {
 		Dt_si = alarm - Time
 	}
 
+	// do not cross the next output time
+	if ExactOutput {
+		if next := nextOutputTime(); Time+Dt_si > next {
+			Dt_si = next - Time
+			for Time+Dt_si < next { // rounding, make sure the output is due after the step
+				Dt_si = math.Nextafter(Dt_si, math.Inf(1))
+			}
+		}
+	}
+
 	util.AssertMsg(Dt_si > 0, fmt.Sprint("Time step too small: ", Dt_si))
 }
 
 // Run the simulation for a number of seconds.
 func Run(seconds float64) {
 	stop := Time + seconds
-	alarm = stop // don't have dt adapt to go over alarm
-	RunWhile(func() bool { return Time < stop })
+	recoverCheckpoint() // a recovered run continues to the same stop time
+	runUntil(stop)
 }
 
 // Run the simulation for a number of steps.
 func Steps(n int) {
 	stop := NSteps + n
-	RunWhile(func() bool { return NSteps < stop })
+	recoverCheckpoint()
+	runOutput(func() bool { return NSteps < stop })
 }
 
 // Runs as long as condition returns true, saves output.
 func RunWhile(condition func() bool) {
+	recoverCheckpoint()
+	runOutput(condition)
+}
+
+// Run, for use inside other commands:
+// only the script's own Run, Steps and RunWhile recover a checkpoint.
+func run(seconds float64) {
+	runUntil(Time + seconds)
+}
+
+func runUntil(stop float64) {
+	alarm = stop // don't have dt adapt to go over alarm
+	runOutput(func() bool { return Time < stop })
+}
+
+// RunWhile without checkpoint recovery, for use inside other commands.
+func runOutput(condition func() bool) {
 	SanityCheck()
+	strictVerify()
 	pause = false // may be set by <-Inject
 	const output = true
 	runWhile(condition, output)
@@ -196,7 +225,11 @@ func RunInteractive() {
 
 // take one time step
 func step(output bool) {
+	checkOutputErr()
+	t0 := Time
+	applyClamps()
 	stepper.Step()
+	stepODEVars(t0) // no-op if the step was undone
 	for _, f := range postStep {
 		f()
 	}
@@ -0,0 +1,96 @@
+package engine
+
+// Time-averaged quantities, e.g. the average of a field over one period
+// of a continuous drive.
+
+import (
+	"fmt"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/util"
+)
+
+func init() {
+	DeclFunc("RunningAverage", RunningAverage, "Time average of a quantity over consecutive windows of given duration (s), updated every time step")
+}
+
+// Time average of a quantity over consecutive time windows.
+// Its value is the average over the last completed window,
+// or over the time since it was created if no window has been completed yet.
+// The parent is sampled after every time step, weighted by the step size.
+type runningAverage struct {
+	parent    Quantity
+	name      string
+	window    float64
+	sum, last *data.Slice // ∫ parent dt over the current window, average over the last one
+	tsum      float64     // time integrated in sum
+	tlast     float64     // time of the last update
+	done      bool        // last holds a completed window
+}
+
+func RunningAverage(parent Quantity, window float64) *runningAverage {
+	util.Argument(window > 0)
+	r := &runningAverage{parent: parent, window: window, tlast: Time,
+		name: fmt.Sprint(NameOf(parent), "_avg")}
+	PostStep(r.update)
+	return r
+}
+
+func (r *runningAverage) update() {
+	dt := Time - r.tlast
+	r.tlast = Time
+	if dt <= 0 { // undone step
+		return
+	}
+	v := ValueOf(r.parent)
+	defer cuda.Recycle(v)
+	if r.sum == nil || r.sum.Size() != v.Size() {
+		r.free()
+		r.sum = cuda.NewSlice(v.NComp(), v.Size())
+		r.last = cuda.NewSlice(v.NComp(), v.Size())
+		cuda.Zero(r.sum)
+	}
+	cuda.Madd2(r.sum, r.sum, v, 1, float32(dt))
+	r.tsum += dt
+	if r.tsum >= r.window {
+		cuda.Madd2(r.last, r.sum, r.sum, float32(1/r.tsum), 0)
+		cuda.Zero(r.sum)
+		r.tsum = 0
+		r.done = true
+	}
+}
+
+// release buffers, e.g. after the mesh size has changed
+func (r *runningAverage) free() {
+	if r.sum != nil {
+		r.sum.Free()
+		r.last.Free()
+	}
+	r.sum, r.last = nil, nil
+	r.tsum = 0
+	r.done = false
+}
+
+func (r *runningAverage) NComp() int             { return r.parent.NComp() }
+func (r *runningAverage) Name() string           { return r.name }
+func (r *runningAverage) Unit() string           { return UnitOf(r.parent) }
+func (r *runningAverage) Mesh() *data.Mesh       { return MeshOf(r.parent) }
+func (r *runningAverage) EvalTo(dst *data.Slice) { EvalTo(r, dst) }
+func (r *runningAverage) average() []float64     { return qAverageUniverse(r) }
+func (r *runningAverage) Average() []float64     { return r.average() }
+
+func (r *runningAverage) Slice() (*data.Slice, bool) {
+	switch {
+	case r.done:
+		dst := cuda.Buffer(r.NComp(), r.last.Size())
+		data.Copy(dst, r.last)
+		return dst, true
+	case r.tsum > 0:
+		dst := cuda.Buffer(r.NComp(), r.sum.Size())
+		cuda.Madd2(dst, r.sum, r.sum, float32(1/r.tsum), 0)
+		return dst, true
+	default: // nothing integrated yet
+		return ValueOf(r.parent), true
+	}
+}
@@ -1,7 +1,6 @@
 package engine
 
 import (
-	"fmt"
 	"path"
 	"reflect"
 	"strings"
@@ -20,7 +19,9 @@ func init() {
 	DeclFunc("SaveAs", SaveAs, "Save space-dependent with custom filename")
 
 	DeclLValue("FilenameFormat", &fformat{}, "printf formatting string for output filenames.")
-	DeclLValue("OutputFormat", &oformat{}, "Format for data files: OVF1_TEXT, OVF1_BINARY, OVF2_TEXT or OVF2_BINARY")
+	DeclLValue("FilenameNumber", &fnumber{}, `Number in auto filenames: "count" (default), "step" or "ps" (time in picoseconds)`)
+	DeclVar("OutputSubdirs", &OutputSubdirs, "Auto-save each quantity in its own subdirectory of the output directory")
+	DeclLValue("OutputFormat", &oformat{}, "Format for data files: OVF1_TEXT, OVF1_BINARY, OVF2_TEXT, OVF2_BINARY, DUMP, NETCDF, ZARR or DELTA")
 
 	DeclROnly("OVF1_BINARY", OVF1_BINARY, "OutputFormat = OVF1_BINARY sets binary OVF1 output")
 	DeclROnly("OVF2_BINARY", OVF2_BINARY, "OutputFormat = OVF2_BINARY sets binary OVF2 output")
@@ -28,11 +29,14 @@ func init() {
 	DeclROnly("OVF2_TEXT", OVF2_TEXT, "OutputFormat = OVF2_TEXT sets text OVF2 output")
 	DeclROnly("DUMP", DUMP, "OutputFormat = DUMP sets text DUMP output")
 	DeclFunc("Snapshot", Snapshot, "Save image of quantity")
+	DeclFunc("SnapshotAs", SnapshotAs, "Save image of quantity with custom filename")
 	DeclVar("SnapshotFormat", &SnapshotFormat, "Image format for snapshots: jpg, png or gif.")
 }
 
 var (
 	FilenameFormat = "%s%06d"    // formatting string for auto filenames.
+	FilenameNumber = "count"     // number used in auto filenames: "count", "step" or "ps"
+	OutputSubdirs  = false       // auto-save each quantity in its own subdirectory
 	SnapshotFormat = "jpg"       // user-settable snapshot format
 	outputFormat   = OVF2_BINARY // user-settable output format
 )
@@ -49,9 +53,30 @@ func (*oformat) Eval() interface{}      { return outputFormat }
 func (*oformat) SetValue(v interface{}) { drainOutput(); outputFormat = v.(OutputFormat) }
 func (*oformat) Type() reflect.Type     { return reflect.TypeOf(OutputFormat(OVF2_BINARY)) }
 
+type fnumber struct{}
+
+func (*fnumber) Eval() interface{} { return FilenameNumber }
+func (*fnumber) SetValue(v interface{}) {
+	n := v.(string)
+	if n != "count" && n != "step" && n != "ps" {
+		panic(UserErr(`FilenameNumber should be "count", "step" or "ps", have: "` + n + `"`))
+	}
+	drainOutput()
+	FilenameNumber = n
+}
+func (*fnumber) Type() reflect.Type { return reflect.TypeOf("") }
+
 // Save once, with auto file name
 func Save(q Quantity) {
-	fname := autoFname(NameOf(q), outputFormat, autonum[q])
+	fname := autoFname(NameOf(q), StringFromOutputFormat[outputFormat], autoNumber(q, StringFromOutputFormat[outputFormat]))
+	switch outputFormat {
+	case NETCDF:
+		fname = ncFname(NameOf(q))
+	case ZARR:
+		fname = zarrFname(NameOf(q))
+	case DELTA:
+		fname = deltaFname(NameOf(q))
+	}
 	SaveAs(q, fname)
 	autonum[q]++
 }
@@ -68,19 +93,54 @@ func SaveAs(q Quantity, fname string) {
 	}
 	buffer := ValueOf(q) // TODO: check and optimize for Buffer()
 	defer cuda.Recycle(buffer)
-	info := data.Meta{Time: Time, Name: NameOf(q), Unit: UnitOf(q), CellSize: MeshOf(q).CellSize()}
-	data := buffer.HostCopy() // must be copy (async io)
-	queOutput(func() { saveAs_sync(fname, data, info, outputFormat) })
+	info := metaOf(q)
+	dl := cuda.DownloadAsync(buffer) // must be copy (async io)
+	files := OutputFiles
+	index := newIndexEntry(fname, info.Name, info.Unit)
+	queOutput(func() {
+		data := dl.HostCopy()
+		if files {
+			saveAs_sync(fname, data, info, outputFormat)
+			index.write()
+		}
+		consumeOutput(fname, data, info)
+	})
+}
+
+// Meta data saved with the current value of q:
+// name, unit, time and the mesh it lives on.
+func metaOf(q Quantity) data.Meta {
+	return data.Meta{Time: Time, Name: NameOf(q), Unit: UnitOf(q), CellSize: MeshOf(q).CellSize(), MeshUnit: "m", Origin: outputOrigin(MeshOf(q))}
 }
 
 // Save image once, with auto file name
 func Snapshot(q Quantity) {
-	fname := fmt.Sprintf(OD()+FilenameFormat+"."+SnapshotFormat, NameOf(q), autonum[q])
+	fname := autoFname(NameOf(q), SnapshotFormat, autoNumber(q, SnapshotFormat))
+	snapshotAs(q, fname)
+	autonum[q]++
+}
+
+// Save image once, with given file name.
+// The extension selects the image format, SnapshotFormat is added if there is none.
+func SnapshotAs(q Quantity, fname string) {
+	if !strings.HasPrefix(fname, OD()) {
+		fname = OD() + fname
+	}
+	if path.Ext(fname) == "" {
+		fname += "." + SnapshotFormat
+	}
+	snapshotAs(q, fname)
+}
+
+func snapshotAs(q Quantity, fname string) {
 	s := ValueOf(q)
 	defer cuda.Recycle(s)
-	data := s.HostCopy() // must be copy (asyncio)
-	queOutput(func() { snapshot_sync(fname, data) })
-	autonum[q]++
+	dl := cuda.DownloadAsync(s) // must be copy (asyncio)
+	index := newIndexEntry(fname, NameOf(q), UnitOf(q))
+	queOutput(func() {
+		snapshot_sync(fname, dl.HostCopy())
+		index.write()
+	})
 }
 
 // synchronous snapshot
@@ -93,6 +153,17 @@ func snapshot_sync(fname string, output *data.Slice) {
 
 // synchronous save
 func saveAs_sync(fname string, s *data.Slice, info data.Meta, format OutputFormat) {
+	switch format {
+	case NETCDF:
+		appendNetCDF(fname, s, info)
+		return
+	case ZARR:
+		appendZarr(fname, s, info)
+		return
+	case DELTA:
+		appendDelta(fname, s, info)
+		return
+	}
 	f, err := httpfs.Create(fname)
 	util.FatalErr(err)
 	defer f.Close()
@@ -122,6 +193,9 @@ const (
 	OVF2_TEXT
 	OVF2_BINARY
 	DUMP
+	NETCDF
+	ZARR
+	DELTA
 )
 
 var (
@@ -130,5 +204,8 @@ var (
 		OVF1_BINARY: "ovf",
 		OVF2_TEXT:   "ovf",
 		OVF2_BINARY: "ovf",
-		DUMP:        "dump"}
+		DUMP:        "dump",
+		NETCDF:      "nc",
+		ZARR:        "zarr",
+		DELTA:       "delta"}
 )
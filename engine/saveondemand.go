@@ -0,0 +1,53 @@
+package engine
+
+// Save on demand: peek at a long-running job without waiting for the next autosave.
+// Sending SIGUSR1 to the process, e.g.:
+//
+//	kill -USR1 <pid>
+//
+// or requesting /save from the web GUI port, e.g.:
+//
+//	curl localhost:35367/save
+//
+// saves the quantities selected with SaveOnDemand (default: m) after the current time step,
+// or right away when the simulation is paused.
+// The save is queued onto the run loop, like commands from the GUI.
+
+import (
+	"fmt"
+	"net/http"
+)
+
+var demandQuants []Quantity // saved on demand, m if empty
+
+func init() {
+	DeclFunc("SaveOnDemand", SaveOnDemand, "Select the quantities saved on SIGUSR1 or a request to /save on the GUI port (default: m)")
+	notifySaveSignal(func() { InjectAndWait(saveOnDemand) })
+}
+
+// Selects the quantities to save on demand, m if none.
+func SaveOnDemand(q ...Quantity) {
+	demandQuants = q
+}
+
+// handles /save on the GUI port.
+func serveSave(w http.ResponseWriter, r *http.Request) {
+	var t float64
+	InjectAndWait(func() {
+		saveOnDemand()
+		t = Time
+	})
+	fmt.Fprintln(w, "saved at t =", t, "s")
+}
+
+// runs on the run loop.
+func saveOnDemand() {
+	quants := demandQuants
+	if len(quants) == 0 {
+		quants = []Quantity{&M}
+	}
+	LogOut("saving on demand at t =", Time, "s")
+	for _, q := range quants {
+		Save(q)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+// +build !windows
+
+package engine
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// calls f each time the process receives SIGUSR1.
+func notifySaveSignal(f func()) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGUSR1)
+	go func() {
+		for range c {
+			f()
+		}
+	}()
+}
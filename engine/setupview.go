@@ -0,0 +1,55 @@
+package engine
+
+// Visual check of a setup before running:
+//
+//	SnapshotSetup()
+//
+// writes images of the geometry, the region map, the exchange (and DMI) coupling
+// with neighbors, which reveals cut or rescaled inter-region exchange, and of every
+// parameter or excitation that is not uniform, to setup_<name>.jpg (SnapshotFormat).
+// In the GUI, the same maps can be displayed with the "show" buttons of the parameters.
+
+import (
+	"fmt"
+)
+
+func init() {
+	DeclFunc("SnapshotSetup", SnapshotSetup, "Save images of geom, regions, ExchCoupling and all non-uniform parameters, to verify the setup")
+}
+
+func SnapshotSetup() {
+	checkMesh()
+	qs := setupQuants()
+	for _, q := range qs {
+		SnapshotAs(q, "setup_"+NameOf(q))
+	}
+	LogOut(fmt.Sprint("SnapshotSetup: wrote ", len(qs), " images"))
+}
+
+// geometry, regions, couplings and non-uniform parameters, in that order.
+func setupQuants() []Quantity {
+	qs := []Quantity{&geometry, &regions, ExchCoupling}
+	if !Dind.isZero() {
+		qs = append(qs, DindCoupling)
+	}
+	var names []string
+	for n := range gui_.Params {
+		names = append(names, n)
+	}
+	sortNoCase(names)
+	for _, n := range names {
+		p := gui_.Params[n]
+		q, ok := p.(Quantity)
+		if !ok {
+			continue
+		}
+		if e, ok := p.(*Excitation); ok && len(e.extraTerms) > 0 {
+			qs = append(qs, q)
+			continue
+		}
+		if !p.IsUniform() {
+			qs = append(qs, q)
+		}
+	}
+	return qs
+}
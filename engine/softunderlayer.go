@@ -0,0 +1,138 @@
+package engine
+
+// Soft underlayer (SUL): a perfectly soft (infinite permeability) half-space
+// below the mesh, at a given spacing below its bottom surface.
+// Its effect on the demag field is that of the mirror image of the magnetization
+// in the SUL surface, with opposite in-plane and the same perpendicular components,
+// so that the image charges have the opposite sign of the real ones and the field
+// has no component along the SUL surface. E.g., a perpendicular film on the SUL feels
+// the demag field of a film twice as thick:
+//
+//	SoftUnderlayer(2e-9)
+//
+// The image field is computed by a convolution on a mesh that holds the magnet,
+// the gap and the image, so the spacing should be a multiple of half the cell size in z.
+// Not combined with DemagMultigrid or macrospin demag.
+
+import (
+	"math"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/mag"
+	"github.com/mumax/3/util"
+)
+
+var (
+	sulSpacing  = -1.0                 // spacing between magnet and soft underlayer (m), negative means none
+	sulConv_    *cuda.DemagConvolution // convolution on magnet + gap + image
+	sulConvSize [3]int                 // size sulConv_ was made for
+)
+
+func init() {
+	DeclFunc("SoftUnderlayer", SoftUnderlayer, "Add the demag field of the images in a perfectly soft underlayer at given spacing (m) below the bottom of the mesh")
+	DeclFunc("RemoveSoftUnderlayer", RemoveSoftUnderlayer, "Remove the soft underlayer")
+}
+
+func SoftUnderlayer(spacing float64) {
+	checkMesh()
+	util.Argument(spacing >= 0)
+	sulSpacing = spacing
+	sulGap() // check now
+}
+
+func RemoveSoftUnderlayer() {
+	freeSUL()
+	sulSpacing = -1
+}
+
+func haveSUL() bool { return sulSpacing >= 0 }
+
+// gap between image and magnet, in cells.
+func sulGap() int {
+	if Mesh().PBC()[Z] != 0 {
+		util.Fatal("SoftUnderlayer: not possible with PBC in z")
+	}
+	c := Mesh().CellSize()[Z]
+	gap := int(math.Round(2 * sulSpacing / c))
+	if math.Abs(float64(gap)*c-2*sulSpacing) > 1e-3*c {
+		util.Fatal("SoftUnderlayer: spacing should be a multiple of half the cell size in z (", c/2, " m), have: ", sulSpacing)
+	}
+	return gap
+}
+
+// Adds the field of the image of m*vol*msat to dst.
+// The image layers come first in the extended mesh, in reverse order, followed by the gap and the magnet.
+func addSULField(dst *data.Slice, msat cuda.MSlice) {
+	n := Mesh().Size()
+	gap := sulGap()
+	size := [3]int{n[X], n[Y], 2*n[Z] + gap}
+
+	m := cuda.Buffer(3, size)
+	defer cuda.Recycle(m)
+	cuda.Zero(m)
+	pasteMirrored(m, M.Buffer())
+	for _, c := range []int{X, Y} {
+		cuda.Madd2(m.Comp(c), m.Comp(c), m.Comp(c), -1, 0)
+	}
+
+	vol := data.NilSlice(1, size)
+	if geom := geometry.Gpu(); !geom.IsNil() {
+		vol = cuda.Buffer(1, size)
+		defer cuda.Recycle(vol)
+		cuda.Zero(vol)
+		pasteMirrored(vol, geom)
+	}
+
+	ms := cuda.MakeMSlice(data.NilSlice(1, size), []float64{float64(msat.Mul(0))})
+	if msat.DevPtr(0) != nil {
+		buf := cuda.Buffer(1, size)
+		defer cuda.Recycle(buf)
+		cuda.Zero(buf)
+		pasteMirrored(buf, msat.Arr())
+		ms = cuda.ToMSlice(buf)
+	}
+
+	B := cuda.Buffer(3, size)
+	defer cuda.Recycle(B)
+	sulConv(size).Exec(B, m, vol, ms)
+
+	Bimg := cuda.Buffer(3, n)
+	defer cuda.Recycle(Bimg)
+	cuda.Crop(Bimg, B, 0, 0, n[Z]+gap)
+	cuda.Add(dst, dst, Bimg)
+}
+
+// pastes the layers of src into dst in reverse order, starting at layer 0.
+func pasteMirrored(dst, src *data.Slice) {
+	n := src.Size()
+	layer := cuda.Buffer(src.NComp(), [3]int{n[X], n[Y], 1})
+	defer cuda.Recycle(layer)
+	for iz := 0; iz < n[Z]; iz++ {
+		cuda.Crop(layer, src, 0, 0, iz)
+		cuda.Paste(dst, layer, 0, 0, n[Z]-1-iz)
+	}
+}
+
+// returns the convolution for the extended mesh, making sure it's initialized.
+func sulConv(size [3]int) *cuda.DemagConvolution {
+	if sulConv_ != nil && sulConvSize != size {
+		freeSUL()
+	}
+	if sulConv_ == nil {
+		SetBusy(true)
+		defer SetBusy(false)
+		LogOut("soft underlayer: image demag on", size, "cells")
+		pbc := Mesh().PBC()
+		kernel := mag.DemagKernel(size, pbc, Mesh().CellSize(), DemagAccuracy, *Flag_cachedir)
+		sulConv_ = cuda.NewDemag(size, pbc, kernel, *Flag_selftest)
+		sulConvSize = size
+	}
+	return sulConv_
+}
+
+func freeSUL() {
+	sulConv_.Free()
+	sulConv_ = nil
+	sulConvSize = [3]int{}
+}
@@ -0,0 +1,90 @@
+package engine
+
+// Spin-transfer torque from a user-supplied spin current tensor,
+// e.g. computed by an external transport solver.
+// SpinCurrentX, Y, Z hold the spin current flowing along x, y, z,
+// each a vector giving the spin polarization direction and magnitude (J/m2).
+// The absorbed spin angular momentum -div Q, projected perpendicular
+// to m, acts as a torque on the magnetization:
+// τ = -(div Q)⊥ / Msat = m×(m×div Q) / Msat.
+// The spin current is taken to vanish outside the simulation box,
+// or wraps around along periodic directions.
+
+import (
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+)
+
+var (
+	SpinCurrentX      = NewExcitation("SpinCurrentX", "J/m2", "Spin current flowing along x (spin polarization vector)")
+	SpinCurrentY      = NewExcitation("SpinCurrentY", "J/m2", "Spin current flowing along y (spin polarization vector)")
+	SpinCurrentZ      = NewExcitation("SpinCurrentZ", "J/m2", "Spin current flowing along z (spin polarization vector)")
+	SpinCurrentTorque = NewVectorField("SpinCurrentTorque", "T", "Torque from the spin current tensor", SetSpinCurrentTorque)
+)
+
+func SetSpinCurrentTorque(dst *data.Slice) {
+	cuda.Zero(dst)
+	AddSpinCurrentTorque(dst)
+}
+
+// Adds the torque due to absorption of the spin current tensor to dst.
+func AddSpinCurrentTorque(dst *data.Slice) {
+	Q := [3]*Excitation{SpinCurrentX, SpinCurrentY, SpinCurrentZ}
+	if Q[X].isZero() && Q[Y].isZero() && Q[Z].isZero() {
+		return
+	}
+
+	div := cuda.Buffer(3, Mesh().Size())
+	defer cuda.Recycle(div)
+	cuda.Zero(div)
+	for i := range Q {
+		if !Q[i].isZero() {
+			addDivergence(div, Q[i], i)
+		}
+	}
+
+	// minus the perpendicular component: m×(m×div), divided by Msat
+	m := M.Buffer()
+	mxd := cuda.Buffer(3, Mesh().Size())
+	defer cuda.Recycle(mxd)
+	cuda.CrossProduct(mxd, m, div)
+	cuda.CrossProduct(div, m, mxd)
+
+	msat, rec := Msat.Slice()
+	if rec {
+		defer cuda.Recycle(msat)
+	}
+	for c := 0; c < 3; c++ {
+		cuda.Div(div.Comp(c), div.Comp(c), msat) // zero where Msat = 0
+	}
+	cuda.Madd2(dst, dst, div, 1, 1)
+}
+
+// adds the derivative of spin current q along direction dir to dst,
+// central differences with zero spin current outside the box, wrapped with PBC.
+func addDivergence(dst *data.Slice, q *Excitation, dir int) {
+	n := Mesh().Size()
+	if n[dir] == 1 {
+		return // uniform along dir, with zero outside: in- and outflow cancel
+	}
+	Q, rec := q.Slice()
+	if rec {
+		defer cuda.Recycle(Q)
+	}
+	shift := [3]func(dst, src *data.Slice, shift int, clampL, clampR float32){cuda.ShiftX, cuda.ShiftY, cuda.ShiftZ}[dir]
+	next := cuda.Buffer(1, n)
+	defer cuda.Recycle(next)
+	prev := cuda.Buffer(1, n)
+	defer cuda.Recycle(prev)
+	f := float32(1 / (2 * Mesh().CellSize()[dir]))
+	for c := 0; c < 3; c++ {
+		shift(next, Q.Comp(c), -1, 0, 0) // next[i] = Q[i+1]
+		shift(prev, Q.Comp(c), 1, 0, 0)  // prev[i] = Q[i-1]
+		cuda.Madd3(dst.Comp(c), dst.Comp(c), next, prev, 1, f, -f)
+		if Mesh().PBC()[dir] != 0 {
+			shift(next, Q.Comp(c), n[dir]-1, 0, 0)      // next[N-1] = Q[0], 0 elsewhere
+			shift(prev, Q.Comp(c), -(n[dir] - 1), 0, 0) // prev[0] = Q[N-1], 0 elsewhere
+			cuda.Madd3(dst.Comp(c), dst.Comp(c), next, prev, 1, f, -f)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package engine
+
+// A map moving rigidly over the mesh, like a write head field or a laser spot.
+// The map is sampled on the host once, on the mesh cell size, and kept on the GPU.
+// At each time it is only translated on the GPU, by whole cells,
+// with linear interpolation for the sub-cell part of the displacement.
+// Along axes without motion, the map is sampled on the mesh cells themselves.
+
+import (
+	"math"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+)
+
+type stamp struct {
+	nComp int
+	r0, v data.Vector                        // center position at t = 0 (m), velocity (m/s)
+	hw    data.Vector                        // half width of the support (m), 0 if uniform along the axis
+	f     func(r data.Vector, dst []float64) // value at r relative to the center, zero outside hw
+
+	buf      *data.Slice // samples
+	j0       [3]int      // index of the center sample along moving axes
+	x0       data.Vector // position of cell 0 when sampled
+	out      *data.Slice // translated map
+	rendered float64     // time at which out was rendered
+}
+
+func newStamp(nComp int, r0, v, hw data.Vector, f func(r data.Vector, dst []float64)) *stamp {
+	return &stamp{nComp: nComp, r0: r0, v: v, hw: hw, f: f, rendered: math.NaN()}
+}
+
+// translated along axis c
+func (s *stamp) moving(c int) bool { return s.v[c] != 0 && s.hw[c] > 0 }
+
+// The map at time t on the mesh. Not to be modified or recycled by the caller.
+func (s *stamp) at(t float64) *data.Slice {
+	if s.out == nil || s.out.Size() != Mesh().Size() || s.x0 != Index2Coord(0, 0, 0) {
+		s.sample()
+	}
+	if s.rendered != t {
+		s.translate(t)
+	}
+	return s.out
+}
+
+// samples the map on the host, around its center along moving axes, at the cells otherwise.
+func (s *stamp) sample() {
+	s.free()
+	n := Mesh().Size()
+	cs := Mesh().CellSize()
+	s.x0 = Index2Coord(0, 0, 0)
+	var size [3]int
+	for c := range size {
+		size[c] = n[c]
+		if s.moving(c) {
+			s.j0[c] = int(math.Ceil(s.hw[c]/cs[c])) + 1
+			size[c] = 2*s.j0[c] + 1
+		}
+	}
+	host := data.NewSlice(s.nComp, size)
+	v := make([]float64, s.nComp)
+	for iz := 0; iz < size[Z]; iz++ {
+		for iy := 0; iy < size[Y]; iy++ {
+			for ix := 0; ix < size[X]; ix++ {
+				i := [3]int{ix, iy, iz}
+				var r data.Vector
+				for c := range r {
+					if s.moving(c) {
+						r[c] = float64(i[c]-s.j0[c]) * cs[c]
+					} else {
+						r[c] = s.x0[c] + float64(i[c])*cs[c] - s.r0[c]
+					}
+				}
+				s.f(r, v)
+				for c := range v {
+					host.Set(c, ix, iy, iz, v[c])
+				}
+			}
+		}
+	}
+	s.buf = cuda.NewSlice(s.nComp, size)
+	data.Copy(s.buf, host)
+	s.out = cuda.NewSlice(s.nComp, n)
+	s.rendered = math.NaN()
+}
+
+// renders the samples displaced by v t into out.
+func (s *stamp) translate(t float64) {
+	n := Mesh().Size()
+	cs := Mesh().CellSize()
+	S := s.buf.Size()
+	center := s.r0.MAdd(t, s.v)
+
+	// cell index k + f of the center, samples pasted at k - j0 with weight 1-f and k+1 - j0 with weight f
+	var k, pad, D [3]int
+	var f [3]float64
+	for c := range k {
+		if s.moving(c) {
+			u := (center[c] - s.x0[c]) / cs[c]
+			k[c] = int(math.Floor(u)) - s.j0[c]
+			f[c] = u - math.Floor(u)
+			pad[c] = S[c]
+		}
+		D[c] = n[c] + 2*pad[c]
+	}
+
+	cuda.Zero(s.out)
+	canvas := cuda.Buffer(s.nComp, D)
+	defer cuda.Recycle(canvas)
+	tmp := cuda.Buffer(s.nComp, n)
+	defer cuda.Recycle(tmp)
+	for dz := 0; dz < 2; dz++ {
+		for dy := 0; dy < 2; dy++ {
+			for dx := 0; dx < 2; dx++ {
+				d := [3]int{dx, dy, dz}
+				w := 1.
+				fits := true
+				var off [3]int
+				for c := range d {
+					w *= lerpWeight(f[c], d[c])
+					off[c] = k[c] + d[c] + pad[c]
+					fits = fits && off[c] >= 0 && off[c]+S[c] <= D[c]
+				}
+				if w == 0 || !fits { // no overlap with the mesh if it does not fit the canvas
+					continue
+				}
+				cuda.Paste(canvas, s.buf, off[X], off[Y], off[Z])
+				cuda.Crop(tmp, canvas, pad[X], pad[Y], pad[Z])
+				cuda.Madd2(s.out, s.out, tmp, 1, float32(w))
+			}
+		}
+	}
+	s.rendered = t
+}
+
+func (s *stamp) free() {
+	s.buf.Free()
+	s.out.Free()
+	s.buf, s.out = nil, nil
+}
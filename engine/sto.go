@@ -0,0 +1,215 @@
+package engine
+
+// Spin-torque oscillator (STO) analysis from the average magnetization.
+// The oscillation is the motion of <m> around its mean, projected on the plane
+// perpendicular to the mean. Its power spectral density gives the frequency (peak)
+// and linewidth (full width at half maximum); the power is the mean square
+// of the oscillating part of <m>. E.g.:
+//
+//	STOSteadyState(5e-9, 5e-12, 0.01, 100e-9)
+//	f := STOAnalyze(50e-9, 5e-12)
+//	TableAdd(STO_f)
+//
+// or the tuning curve versus the current density, written to sto_tuning.txt:
+//
+//	STOScan(J, vector(0, 0, 1), 1e11, 1e12, 10, 50e-9, 5e-12)
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+var (
+	STOTolerance = 0.01 // relative change in amplitude between windows for steady state
+	STOMaxSettle = 1e-7 // maximum time to reach steady state in STOScan (s)
+
+	sto stoResult // result of the last STOAnalyze
+
+	STO_f         = NewScalarValue("STO_f", "Hz", "Frequency of the last STOAnalyze", func() float64 { return sto.f })
+	STO_linewidth = NewScalarValue("STO_linewidth", "Hz", "Linewidth (FWHM) of the last STOAnalyze", func() float64 { return sto.linewidth })
+	STO_power     = NewScalarValue("STO_power", "", "Power (mean square oscillation of <m>) of the last STOAnalyze", func() float64 { return sto.power })
+)
+
+type stoResult struct {
+	f, linewidth, power float64
+}
+
+func init() {
+	DeclFunc("STOSteadyState", STOSteadyState, "Run in windows of given duration (s), sampling <m> every dt (s), until the oscillation amplitude and mean change less than tol between windows, at most maxTime (s). Returns whether a steady state was reached")
+	DeclFunc("STOAnalyze", STOAnalyze, "Record <m> for duration (s) every dt (s), write its power spectral density to sto_spectrum.txt and return the oscillation frequency. Also sets STO_f, STO_linewidth and STO_power")
+	DeclFunc("STOScan", STOScan, "Set excitation to dir times n values from..to, reaching steady state and running STOAnalyze(duration, dt) for each. Writes the tuning curves to sto_tuning.txt")
+	DeclVar("STOTolerance", &STOTolerance, "Relative change of the oscillation between windows for STOScan to consider it steady")
+	DeclVar("STOMaxSettle", &STOMaxSettle, "Maximum time for STOScan to reach a steady state (s)")
+}
+
+func STOSteadyState(window, dt, tol, maxTime float64) bool {
+	util.Argument(window > 0 && dt > 0 && window >= 2*dt && tol > 0)
+	t0 := Time
+	prevA, prevMean := math.NaN(), data.Vector{}
+	for Time-t0 < maxTime {
+		m := stoRecord(window, dt)
+		mean, A := stoOscillation(m)
+		if !math.IsNaN(prevA) && math.Abs(A-prevA) <= tol*A && mean.Sub(prevMean).Len() <= tol {
+			LogOut(fmt.Sprintf("STOSteadyState: steady after %g s, amplitude %.4g", Time-t0, A))
+			return true
+		}
+		prevA, prevMean = A, mean
+	}
+	LogErr("STOSteadyState: no steady state after ", Time-t0, " s")
+	return false
+}
+
+func STOAnalyze(duration, dt float64) float64 {
+	util.Argument(duration > 0 && dt > 0 && duration >= 4*dt)
+	m := stoRecord(duration, dt)
+	f, psd := stoSpectrum(m, dt)
+
+	out, err := httpfs.Create(OD() + "sto_spectrum.txt")
+	util.FatalErr(err)
+	defer out.Close()
+	fmt.Fprintln(out, "# f (Hz)\tPSD (1/Hz)")
+	for k := range f {
+		fmt.Fprintf(out, "%g\t%g\n", f[k], psd[k])
+	}
+
+	_, A := stoOscillation(m)
+	sto = stoResult{power: A * A}
+	sto.f, sto.linewidth = stoPeak(f, psd)
+	if df := f[1] - f[0]; sto.linewidth < 2*df {
+		LogOut("STOAnalyze: linewidth limited by the frequency resolution ", df, " Hz, record longer")
+	}
+	LogOut(fmt.Sprintf("STOAnalyze: f = %.6g Hz, linewidth = %.4g Hz, power = %.4g", sto.f, sto.linewidth, sto.power))
+	return sto.f
+}
+
+// Sets the excitation to dir times n values from..to, starting each time from
+// the previous state, and writes frequency, linewidth and power to sto_tuning.txt.
+// The excitation is restored afterwards.
+func STOScan(e *Excitation, dir data.Vector, from, to float64, n int, duration, dt float64) {
+	util.Argument(n > 1 && dir.Len() != 0)
+	dir = dir.Div(dir.Len())
+	defer restoreExcitation(e, saveExcitation(e))
+
+	out, err := httpfs.Create(OD() + "sto_tuning.txt")
+	util.FatalErr(err)
+	defer out.Close()
+	fmt.Fprintf(out, "# %s (%s)\tf (Hz)\tlinewidth (Hz)\tpower ()\tsteady\n", e.Name(), e.Unit())
+	for i := 0; i < n; i++ {
+		v := from + (to-from)*float64(i)/float64(n-1)
+		e.Set(dir.Mul(v))
+		steady := STOSteadyState(duration/4, dt, STOTolerance, STOMaxSettle)
+		STOAnalyze(duration, dt)
+		fmt.Fprintf(out, "%g\t%g\t%g\t%g\t%v\n", v, sto.f, sto.linewidth, sto.power, steady)
+		out.Flush()
+	}
+	LogOut("STOScan: wrote ", OD()+"sto_tuning.txt")
+}
+
+// runs for duration, sampling <m> every dt.
+func stoRecord(duration, dt float64) []data.Vector {
+	n := int(duration/dt + 0.5)
+	m := make([]data.Vector, n)
+	t0 := Time
+	for i := range m {
+		if target := t0 + float64(i+1)*dt; Time < target {
+			run(target - Time)
+		}
+		m[i] = M.Average()
+	}
+	return m
+}
+
+// mean and rms amplitude of the oscillating part of m.
+func stoOscillation(m []data.Vector) (mean data.Vector, A float64) {
+	for _, v := range m {
+		mean = mean.Add(v)
+	}
+	mean = mean.Div(float64(len(m)))
+	for _, v := range m {
+		A += sqr(v.Sub(mean).Len())
+	}
+	return mean, math.Sqrt(A / float64(len(m)))
+}
+
+// One-sided power spectral density of the oscillating part of m, sampled every dt,
+// projected on the plane perpendicular to its mean as a complex signal
+// so that both senses of rotation are included. Hann window, zero padded
+// to a power of two, normalized so that the PSD integrates to the mean square.
+func stoSpectrum(m []data.Vector, dt float64) (f, psd []float64) {
+	N := len(m)
+	mean, _ := stoOscillation(m)
+	axis := data.Vector{0, 0, 1}
+	if mean.Len() > 1e-3 {
+		axis = mean.Div(mean.Len())
+	}
+	u := perpendicular(axis)
+	v := axis.Cross(u)
+
+	L := 1 // zero padded to a power of two for the FFT
+	for L < N {
+		L *= 2
+	}
+	z := make([]complex128, L)
+	w2 := 0.
+	for i := range m {
+		w := 0.5 * (1 - math.Cos(2*math.Pi*float64(i)/float64(N-1)))
+		d := m[i].Sub(mean)
+		z[i] = complex(w*d.Dot(u), w*d.Dot(v))
+		w2 += w * w
+	}
+	fft(z, -1)
+
+	df := 1 / (float64(L) * dt)
+	f = make([]float64, L/2+1)
+	psd = make([]float64, L/2+1)
+	for k := range f {
+		f[k] = float64(k) * df
+		P := sqr(cmplx.Abs(z[k]))
+		if k != 0 && k != L-k {
+			P += sqr(cmplx.Abs(z[L-k]))
+		}
+		psd[k] = P * dt / w2
+	}
+	return f, psd
+}
+
+// Peak frequency, refined by Gaussian interpolation between bins,
+// and full width at half maximum of the PSD, the DC bin excluded.
+func stoPeak(f, psd []float64) (fpeak, fwhm float64) {
+	k := 1
+	for i := 2; i < len(psd); i++ {
+		if psd[i] > psd[k] {
+			k = i
+		}
+	}
+	df := f[1] - f[0]
+	fpeak = f[k]
+	if k+1 < len(psd) && psd[k-1] > 0 && psd[k] > 0 && psd[k+1] > 0 {
+		a, b, c := math.Log(psd[k-1]), math.Log(psd[k]), math.Log(psd[k+1])
+		if d := a - 2*b + c; d != 0 {
+			fpeak += 0.5 * (a - c) / d * df
+		}
+	}
+
+	half := psd[k] / 2
+	lo := f[0]
+	for i := k; i > 0; i-- {
+		if psd[i-1] < half {
+			lo = f[i-1] + (half-psd[i-1])/(psd[i]-psd[i-1])*df
+			break
+		}
+	}
+	hi := f[len(f)-1]
+	for i := k; i+1 < len(psd); i++ {
+		if psd[i+1] < half {
+			hi = f[i] + (psd[i]-half)/(psd[i]-psd[i+1])*df
+			break
+		}
+	}
+	return fpeak, hi - lo
+}
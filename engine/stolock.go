@@ -0,0 +1,164 @@
+package engine
+
+// Injection locking of a spin-torque oscillator to an RF current or field.
+// The drive amp sin(2π f t) is added to an excitation (e.g. J or B_ext) in all regions.
+// The oscillator phase is that of <m> around its mean, and it is locked when
+// its phase difference with the drive does not drift, i.e. when there is less than
+// one cycle slip over the recorded time. E.g., after STOAnalyze for the free-running frequency:
+//
+//	STOLock(J, vector(0, 0, 1e10), STO_f.get(), 20e-9, 5e-12)
+//	STOLockingRange(J, vector(0, 0, 1e10), STO_f.get(), 500e6, 21, 20e-9, 5e-12)
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+var (
+	stoLock stoLockResult // result of the last STOLock
+
+	STO_phase = NewScalarValue("STO_phase", "rad", "Phase of the oscillator relative to the drive of the last STOLock, NaN if not locked", func() float64 { return stoLock.phase })
+	STO_df    = NewScalarValue("STO_df", "Hz", "Oscillator minus drive frequency of the last STOLock", func() float64 { return stoLock.df })
+)
+
+type stoLockResult struct {
+	phase, df float64
+}
+
+func init() {
+	DeclFunc("STOLock", STOLock, "Add amp sin(2π f t) to excitation, settle and record <m> for duration (s) every dt (s). Returns the phase of the oscillator relative to the drive (rad), NaN if not locked")
+	DeclFunc("STOLockingRange", STOLockingRange, "Run STOLock for n drive frequencies f0 ± maxDetuning (Hz), each from the current state. Writes sto_locking.txt and returns the width of the locked frequency range around f0 (Hz)")
+}
+
+// Drives the excitation with amp sin(2π f t) on top of its present value,
+// runs for duration to settle, then records for duration.
+// The excitation is restored afterwards.
+func STOLock(e *Excitation, amp data.Vector, f, duration, dt float64) float64 {
+	util.Argument(f > 0 && duration > 0 && dt > 0 && dt < 1/(2*f))
+	tdrive := Time
+	defer restoreExcitation(e, addDrive(e, amp, f))
+
+	run(duration)
+	t0 := Time - tdrive
+	m := stoRecord(duration, dt)
+	mean, _ := stoOscillation(m)
+	axis := data.Vector{0, 0, 1}
+	if mean.Len() > 1e-3 {
+		axis = mean.Div(mean.Len())
+	}
+	u := perpendicular(axis)
+	v := axis.Cross(u)
+
+	// oscillator phase, unwrapped, in the sense in which it rotates
+	theta := make([]float64, len(m))
+	for i := range m {
+		d := m[i].Sub(mean)
+		theta[i] = math.Atan2(d.Dot(v), d.Dot(u))
+		if i > 0 {
+			theta[i] -= 2 * math.Pi * math.Round((theta[i]-theta[i-1])/(2*math.Pi))
+		}
+	}
+	sense := 1.
+	if theta[len(theta)-1] < theta[0] {
+		sense = -1
+	}
+
+	// phase difference with the drive, and its drift by linear regression
+	dphi := make([]float64, len(m))
+	var st, sp, stt, stp float64
+	for i := range m {
+		x := float64(i+1) * dt
+		dphi[i] = sense*theta[i] - 2*math.Pi*f*(t0+x) // drive phase since it started
+		st += x
+		sp += dphi[i]
+		stt += x * x
+		stp += x * dphi[i]
+	}
+	N := float64(len(m))
+	slope := (N*stp - st*sp) / (N*stt - st*st)
+	stoLock = stoLockResult{df: slope / (2 * math.Pi), phase: math.NaN()}
+
+	if math.Abs(stoLock.df) < 1/duration {
+		var c, s float64
+		for _, p := range dphi {
+			c += math.Cos(p)
+			s += math.Sin(p)
+		}
+		stoLock.phase = math.Atan2(s, c)
+	}
+	LogOut(fmt.Sprintf("STOLock: drive %.6g Hz, oscillator - drive: %.4g Hz, phase: %.4g rad", f, stoLock.df, stoLock.phase))
+	return stoLock.phase
+}
+
+// Adds amp sin(2π f (t-t0)) to the excitation in all regions, keeping the values and
+// time dependences set per region. Returns the state to restore.
+func addDrive(e *Excitation, amp data.Vector, f float64) *paramState {
+	saved := saveExcitation(e)
+	t0 := Time
+	for r := 0; r < NREGION; r++ {
+		base := func(r int) func() []float64 {
+			if fn := saved.funcs[r]; fn != nil {
+				return fn
+			}
+			v := saved.values[r]
+			return func() []float64 { return v }
+		}(r)
+		e.SetRegionFn(r, func() [3]float64 {
+			B := data.Vector(unslice(base()))
+			return B.Add(amp.Mul(math.Sin(2 * math.Pi * f * (Time - t0))))
+		})
+	}
+	return saved
+}
+
+// Measures the lock for n drive frequencies f0-maxDetuning .. f0+maxDetuning (odd n includes f0),
+// each starting from the current magnetization. Writes detuning, oscillator - drive frequency
+// and phase to sto_locking.txt. Returns the width of the locked range around f0, i.e. the one
+// containing the detuning closest to zero (for even n, either of the two closest).
+func STOLockingRange(e *Excitation, amp data.Vector, f0, maxDetuning float64, n int, duration, dt float64) float64 {
+	util.Argument(n > 1 && maxDetuning > 0 && maxDetuning < f0)
+	m0 := cuda.Buffer(3, Mesh().Size())
+	defer cuda.Recycle(m0)
+	data.Copy(m0, M.Buffer())
+	defer M.SetArray(m0)
+
+	out, err := httpfs.Create(OD() + "sto_locking.txt")
+	util.FatalErr(err)
+	defer out.Close()
+	fmt.Fprintln(out, "# detuning (Hz)\tf drive (Hz)\toscillator - drive (Hz)\tphase (rad)")
+
+	detuning := make([]float64, n)
+	locked := make([]bool, n)
+	for i := range detuning {
+		detuning[i] = -maxDetuning + 2*maxDetuning*float64(i)/float64(n-1)
+		M.SetArray(m0)
+		phase := STOLock(e, amp, f0+detuning[i], duration, dt)
+		locked[i] = !math.IsNaN(phase)
+		fmt.Fprintf(out, "%g\t%g\t%g\t%g\n", detuning[i], f0+detuning[i], stoLock.df, phase)
+		out.Flush()
+	}
+
+	// contiguous locked range around the detuning closest to zero
+	c := n / 2
+	if n%2 == 0 && !locked[c] {
+		c-- // the other one closest to zero
+	}
+	if !locked[c] {
+		LogOut("STOLockingRange: not locked at f0")
+		return 0
+	}
+	lo, hi := c, c
+	for lo > 0 && locked[lo-1] {
+		lo--
+	}
+	for hi < n-1 && locked[hi+1] {
+		hi++
+	}
+	LogOut(fmt.Sprintf("STOLockingRange: locked from %.4g to %.4g Hz detuning", detuning[lo], detuning[hi]))
+	return detuning[hi] - detuning[lo]
+}
@@ -0,0 +1,237 @@
+package engine
+
+// Run summary: a Markdown report with the setup, non-zero parameters,
+// plots of the table columns, a snapshot of the final magnetization,
+// timing and warnings, written to summary.md in the output directory.
+// Written at the end of the run with
+//
+//	SummaryReport = true
+//
+// or at any time with WriteSummary().
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/timer"
+	"github.com/mumax/3/util"
+)
+
+var (
+	SummaryReport = false  // write summary.md when the simulation ends
+	warnings      []string // logged errors and warnings, for the summary
+	maxWarnings   = 100    // number of warnings kept
+	maxPlots      = 16     // number of table columns plotted
+)
+
+func init() {
+	DeclFunc("WriteSummary", WriteSummary, "Write a Markdown summary of the run to summary.md in the output directory")
+	DeclVar("SummaryReport", &SummaryReport, "Write summary.md in the output directory when the simulation ends (default=false)")
+}
+
+// keeps a warning for the summary
+func addWarning(msg string) {
+	if len(warnings) < maxWarnings {
+		warnings = append(warnings, msg)
+	}
+}
+
+func WriteSummary() {
+	checkMesh()
+	var b bytes.Buffer
+	p := func(msg ...interface{}) { fmt.Fprint(&b, msg...) }
+	pl := func(msg ...interface{}) { fmt.Fprintln(&b, msg...) }
+
+	pl("# mumax3 run summary")
+	pl()
+	pl("- version:", UNAME)
+	pl("- GPU:", cuda.GPUInfo)
+	pl("- output:", OD())
+	pl("- written:", time.Now().Format(time.RFC3339))
+	pl()
+
+	pl("## Simulation")
+	pl()
+	n, c, pbc := Mesh().Size(), Mesh().CellSize(), Mesh().PBC()
+	pl("- grid:", n[X], "x", n[Y], "x", n[Z], "cells of", c[X], "x", c[Y], "x", c[Z], "m")
+	pl("- PBC:", pbc[X], pbc[Y], pbc[Z])
+	pl("- solver:", solvernames[solvertype])
+	pl("- simulated time:", Time, "s in", NSteps, "steps")
+	wall := time.Since(StartTime)
+	p("- wall time: ", wall.Round(time.Second))
+	if s := wall.Seconds(); s > 0 {
+		p(fmt.Sprintf(" (%.1f steps/s)", float64(NSteps)/s))
+	}
+	pl()
+	pl()
+
+	pl("## Parameters")
+	pl()
+	pl("| name | unit | value | other regions |")
+	pl("|---|---|---|---|")
+	names := make([]string, 0, len(gui_.Params))
+	for name := range gui_.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var rw *regionwise
+		switch p := gui_.Params[name].(type) {
+		default:
+			continue
+		case *RegionwiseScalar:
+			rw = &p.regionwise
+		case *RegionwiseVector:
+			rw = &p.regionwise
+		case *Excitation:
+			rw = &p.perRegion.regionwise
+		}
+		if rw.isZero() {
+			continue
+		}
+		v0 := float32s(rw.getRegion(0))
+		other := 0
+		for r := 1; r < NREGION; r++ {
+			if !equal32(float32s(rw.getRegion(r)), v0) {
+				other++
+			}
+		}
+		pl("|", name, "|", rw.Unit(), "|", fmtValue(v0), "|", other, "|")
+	}
+	pl()
+
+	if Table.inited() {
+		pl("## Table")
+		pl()
+		for _, f := range summaryPlots() {
+			pl("![" + f[0] + "](" + f[1] + ")")
+			pl()
+		}
+	}
+
+	pl("## Final magnetization")
+	pl()
+	const snap = "summary_m.png"
+	s := ValueOf(&M)
+	host := s.HostCopy()
+	cuda.Recycle(s)
+	queOutput(func() { snapshot_sync(OD()+snap, host) })
+	pl("![m](" + snap + ")")
+	pl()
+	pl("average m:", M.Average())
+	pl()
+
+	var t bytes.Buffer
+	timer.Print(&t)
+	if t.Len() > 0 {
+		pl("## Timing")
+		pl()
+		pl("```")
+		p(t.String())
+		pl("```")
+		pl()
+	}
+
+	pl("## Warnings")
+	pl()
+	if len(warnings) == 0 {
+		pl("none")
+	}
+	for _, w := range warnings {
+		pl("-", strings.TrimPrefix(w, "//"))
+	}
+
+	util.FatalErr(httpfs.Put(OD()+"summary.md", b.Bytes()))
+	LogOut("wrote ", OD()+"summary.md")
+}
+
+func fmtValue(v []float32) string {
+	if len(v) == 1 {
+		return fmt.Sprint(v[0])
+	}
+	return fmt.Sprint(v)
+}
+
+// plots the columns of the table against time as SVG images,
+// returns the column names and file names relative to the output directory.
+func summaryPlots() [][2]string {
+	Table.flush()
+	in, err := httpfs.Read(OD() + "table.txt")
+	if err != nil {
+		LogErr("summary: ", err)
+		return nil
+	}
+	var header []string
+	var cols [][]float64
+	sc := bufio.NewScanner(bytes.NewReader(in))
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "#") {
+			header = strings.Split(strings.TrimPrefix(line, "# "), "\t")
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != len(header) {
+			continue
+		}
+		if cols == nil {
+			cols = make([][]float64, len(fields))
+		}
+		for i, f := range fields {
+			v, _ := strconv.ParseFloat(f, 64)
+			cols[i] = append(cols[i], v)
+		}
+	}
+	var files [][2]string
+	for i := 1; i < len(cols) && len(files) < maxPlots; i++ {
+		fname := fmt.Sprintf("summary_%02d.svg", i)
+		util.FatalErr(httpfs.Put(OD()+fname, svgPlot(cols[0], cols[i], header[0], header[i])))
+		files = append(files, [2]string{header[i], fname})
+	}
+	return files
+}
+
+// minimal SVG line plot of y(x), with the data range as axis labels.
+// The labels are column names, which may contain XML special characters.
+func svgPlot(x, y []float64, xlabel, ylabel string) []byte {
+	const w, h, m = 480, 240, 60 // size, margin
+	minmax := func(v []float64) (float64, float64) {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, v := range v {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+		if hi == lo {
+			lo, hi = lo-1, hi+1
+		}
+		return lo, hi
+	}
+	x0, x1 := minmax(x)
+	y0, y1 := minmax(y)
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-size="10">`+"\n", w, h)
+	fmt.Fprintf(&b, `<rect x="%d" y="10" width="%d" height="%d" fill="none" stroke="black"/>`+"\n", m, w-m-10, h-m)
+	fmt.Fprint(&b, `<polyline fill="none" stroke="blue" points="`)
+	for i := range x {
+		px := m + (x[i]-x0)/(x1-x0)*float64(w-m-10)
+		py := 10 + (y1-y[i])/(y1-y0)*float64(h-m)
+		fmt.Fprintf(&b, "%.1f,%.1f ", px, py)
+	}
+	fmt.Fprintln(&b, `"/>`)
+	fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%g</text>`+"\n", m-2, 20, y1)
+	fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%g</text>`+"\n", m-2, h-m+10, y0)
+	fmt.Fprintf(&b, `<text x="%d" y="%d">%g</text>`+"\n", m, h-m+25, x0)
+	fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%g</text>`+"\n", w-10, h-m+25, x1)
+	fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle">%s</text>`+"\n", (w+m)/2, h-m+40, html.EscapeString(xlabel))
+	fmt.Fprintf(&b, `<text x="10" y="%d" transform="rotate(-90 10 %d)" text-anchor="middle">%s</text>`+"\n", h/2, h/2, html.EscapeString(ylabel))
+	fmt.Fprintln(&b, `</svg>`)
+	return b.Bytes()
+}
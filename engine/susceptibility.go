@@ -0,0 +1,297 @@
+package engine
+
+// Frequency-domain linear response around the current (relaxed) magnetization.
+// For a small harmonic field h exp(iωt), the magnetization responds as
+// m0 + δm exp(iωt), with δm = a + ib solving the linearized LLG equation
+//
+//	iω/γ δm = J δm + τ_h
+//
+// where J is the Jacobian of the torque with respect to m and τ_h the torque of h on m0.
+// Split into real and imaginary parts, this is solved with restarted GMRES.
+// J is never stored: its product with a vector is evaluated as a central difference
+// of the full torque, so every effective field term and spin-transfer torque is included.
+// The thermal field should be off (Temp = 0), it would act as a static random field.
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/mag"
+	"github.com/mumax/3/util"
+)
+
+var (
+	Ext_ChiRe  = NewVectorValue("ext_chi_re", "", "Real part of the susceptibility μ0 δM/h found by ext_Susceptibility", func() []float64 { return lr.chiRe[:] })
+	Ext_ChiIm  = NewVectorValue("ext_chi_im", "", "Imaginary part of the susceptibility μ0 δM/h found by ext_Susceptibility", func() []float64 { return lr.chiIm[:] })
+	Ext_ModeRe = NewVectorField("ext_mode_re", "1/T", "Real part of the mode profile δm/h found by ext_Susceptibility", func(dst *data.Slice) { lr.mode(dst, 0) })
+	Ext_ModeIm = NewVectorField("ext_mode_im", "1/T", "Imaginary part of the mode profile δm/h found by ext_Susceptibility", func(dst *data.Slice) { lr.mode(dst, 1) })
+)
+
+var (
+	SusceptibilityTol     = 1e-4 // relative residual of the linear response solver
+	susceptibilityRestart = 30   // GMRES restart length
+	susceptibilityMaxIter = 600  // total GMRES iterations before giving up
+	lrPerturbation        = 1e-3 // rms amplitude of the perturbation of m in Jacobian-vector products
+)
+
+func init() {
+	DeclFunc("ext_Susceptibility", Susceptibility, "Solve the linear response to a field along h at frequency f (Hz), set ext_chi_re, ext_chi_im, ext_mode_re and ext_mode_im")
+	DeclFunc("ext_SusceptibilitySpectrum", SusceptibilitySpectrum, "Solve the linear response to a field along h for nf frequencies between fmin and fmax (Hz), write the susceptibility to susceptibility.txt")
+	DeclVar("ext_SusceptibilityTol", &SusceptibilityTol, "Relative residual of the linear response solver (default 1e-4)")
+}
+
+// last linear response: mode profile per tesla of drive field, and susceptibility
+var lr linearResponse
+
+type linearResponse struct {
+	x            lrVec
+	chiRe, chiIm data.Vector
+}
+
+// Solves the linear response to a unit field along h at frequency f.
+func Susceptibility(f float64, h data.Vector) {
+	solveLinearResponse(f, h, false)
+	LogOut("ext_Susceptibility: f=", f, " chi_re=", lr.chiRe, " chi_im=", lr.chiIm)
+}
+
+// Solves the linear response for nf frequencies between fmin and fmax,
+// starting each solve from the previous solution,
+// and writes f and the real and imaginary parts of the susceptibility to susceptibility.txt.
+// The mode profile of the last frequency is kept.
+func SusceptibilitySpectrum(h data.Vector, fmin, fmax float64, nf int) {
+	if !(fmin >= 0 && fmax > fmin && nf > 1) {
+		util.Fatal("ext_SusceptibilitySpectrum: need 0 <= fmin < fmax and nf > 1, have: ", fmin, ", ", fmax, ", ", nf)
+	}
+	out, err := httpfs.Create(OD() + "susceptibility.txt")
+	util.FatalErr(err)
+	defer out.Close()
+	fmt.Fprintln(out, "# f (Hz)\tchi_re_x ()\tchi_re_y ()\tchi_re_z ()\tchi_im_x ()\tchi_im_y ()\tchi_im_z ()")
+	for k := 0; k < nf; k++ {
+		f := fmin + float64(k)*(fmax-fmin)/float64(nf-1)
+		solveLinearResponse(f, h, k > 0)
+		re, im := lr.chiRe, lr.chiIm
+		fmt.Fprintf(out, "%g\t%g\t%g\t%g\t%g\t%g\t%g\n", f, re[X], re[Y], re[Z], im[X], im[Y], im[Z])
+	}
+	LogOut("ext_SusceptibilitySpectrum: wrote ", OD()+"susceptibility.txt")
+}
+
+// solves for the response lr.x to a 1 T field along h at frequency f,
+// starting from the previous solution if warm, and updates the susceptibility.
+func solveLinearResponse(f float64, h data.Vector, warm bool) {
+	checkMesh()
+	util.Argument(h.Len() != 0)
+	size := Mesh().Size()
+	if lr.x[0] == nil || lr.x[0].Size() != size {
+		lr.x.free()
+		lr.x = lrVec{cuda.NewSlice(3, size), cuda.NewSlice(3, size)}
+		warm = false
+	}
+	if !warm {
+		lr.x.zero()
+	}
+
+	m0 := cuda.Buffer(3, size)
+	defer cuda.Recycle(m0)
+	data.Copy(m0, M.Buffer())
+	defer data.Copy(M.Buffer(), m0)
+
+	op := &lrOperator{m0: m0, w: 2 * math.Pi * f / GammaLL}
+
+	// right-hand side (τ_h, 0)
+	rhs := newLRVec(size)
+	defer rhs.recycle()
+	hs := cuda.Buffer(3, size)
+	defer cuda.Recycle(hs)
+	hu := h.Div(h.Len())
+	cuda.Memset(hs, float32(hu[X]), float32(hu[Y]), float32(hu[Z]))
+	alpha := Alpha.MSlice()
+	defer alpha.Recycle()
+	if Precess {
+		cuda.LLTorque(rhs[0], m0, hs, alpha)
+	} else {
+		cuda.LLNoPrecess(rhs[0], m0, hs)
+	}
+	FreezeSpins(rhs[0])
+	cuda.Zero(rhs[1])
+
+	iter, res := gmres(op, lr.x, rhs, SusceptibilityTol)
+	if res > SusceptibilityTol {
+		LogErr("ext_Susceptibility: f=", f, ": not converged after ", iter, " iterations, relative residual ", res)
+	}
+
+	// χ = μ0 <Msat δm> per tesla
+	ms := ValueOf(Msat)
+	defer cuda.Recycle(ms)
+	buf := cuda.Buffer(3, size)
+	defer cuda.Recycle(buf)
+	for i, chi := range []*data.Vector{&lr.chiRe, &lr.chiIm} {
+		for c := 0; c < 3; c++ {
+			cuda.Mul(buf.Comp(c), lr.x[i].Comp(c), ms)
+		}
+		*chi = data.Vector(unslice(sAverageMagnet(buf))).Mul(mag.Mu0)
+	}
+}
+
+// copies the real (i=0) or imaginary (i=1) part of the last mode profile to dst.
+func (l *linearResponse) mode(dst *data.Slice, i int) {
+	if l.x[i] == nil || l.x[i].Size() != dst.Size() {
+		cuda.Zero(dst)
+		return
+	}
+	data.Copy(dst, l.x[i])
+}
+
+// Linearized LLG operator acting on (a, b):
+//
+//	(-J a - w b, w a - J b),  w = ω/γ
+type lrOperator struct {
+	m0 *data.Slice // magnetization around which the torque is linearized
+	w  float64
+}
+
+func (op *lrOperator) apply(dst, x lrVec) {
+	t := cuda.Buffer(3, x[0].Size())
+	defer cuda.Recycle(t)
+	op.jacobian(dst[0], x[0], t)
+	op.jacobian(dst[1], x[1], t)
+	w := float32(op.w)
+	cuda.Madd2(dst[0], dst[0], x[1], -1, -w)
+	cuda.Madd2(dst[1], x[0], dst[1], w, -1)
+}
+
+// dst = J v, as the central difference of the torque around m0.
+// t is a scratch buffer. Leaves M.Buffer() perturbed.
+func (op *lrOperator) jacobian(dst, v, t *data.Slice) {
+	rms := math.Sqrt(float64(cuda.Dot(v, v)) / float64(Mesh().NCell()))
+	if rms == 0 {
+		cuda.Zero(dst)
+		return
+	}
+	eps := float32(lrPerturbation / rms)
+	cuda.Madd2(M.Buffer(), op.m0, v, 1, eps)
+	SetTorque(dst)
+	cuda.Madd2(M.Buffer(), op.m0, v, 1, -eps)
+	SetTorque(t)
+	cuda.Madd2(dst, dst, t, 1/(2*eps), -1/(2*eps))
+}
+
+// Solves op x = b with restarted GMRES, starting from x.
+// Returns the number of iterations and the final relative residual.
+func gmres(op *lrOperator, x, b lrVec, tol float64) (iter int, res float64) {
+	size := x[0].Size()
+	n := susceptibilityRestart
+	bnorm := math.Sqrt(b.dot(b))
+	if bnorm == 0 {
+		x.zero()
+		return 0, 0
+	}
+
+	V := make([]lrVec, n+1) // Krylov basis
+	for i := range V {
+		V[i] = newLRVec(size)
+		defer V[i].recycle()
+	}
+	w := newLRVec(size)
+	defer w.recycle()
+	H := make([][]float64, n+1) // Hessenberg matrix, rotated to upper triangular
+	for i := range H {
+		H[i] = make([]float64, n)
+	}
+	cs, sn := make([]float64, n), make([]float64, n)
+	g := make([]float64, n+1)
+
+	for iter < susceptibilityMaxIter {
+		// residual r = b - op x
+		op.apply(w, x)
+		lrMadd(V[0], b, w, 1, -1)
+		beta := math.Sqrt(V[0].dot(V[0]))
+		res = beta / bnorm
+		if res <= tol {
+			return iter, res
+		}
+		lrMadd(V[0], V[0], V[0], float32(1/beta), 0)
+		for i := range g {
+			g[i] = 0
+		}
+		g[0] = beta
+
+		k := 0
+		for k < n && iter < susceptibilityMaxIter {
+			op.apply(w, V[k])
+			for i := 0; i <= k; i++ { // modified Gram-Schmidt
+				H[i][k] = w.dot(V[i])
+				lrMadd(w, w, V[i], 1, float32(-H[i][k]))
+			}
+			H[k+1][k] = math.Sqrt(w.dot(w))
+			if H[k+1][k] != 0 {
+				lrMadd(V[k+1], w, w, float32(1/H[k+1][k]), 0)
+			}
+			for i := 0; i < k; i++ { // previous Givens rotations
+				H[i][k], H[i+1][k] = cs[i]*H[i][k]+sn[i]*H[i+1][k], -sn[i]*H[i][k]+cs[i]*H[i+1][k]
+			}
+			r := math.Hypot(H[k][k], H[k+1][k])
+			cs[k], sn[k] = H[k][k]/r, H[k+1][k]/r
+			H[k][k], H[k+1][k] = r, 0
+			g[k], g[k+1] = cs[k]*g[k], -sn[k]*g[k]
+			k++
+			iter++
+			if math.Abs(g[k])/bnorm <= tol {
+				break
+			}
+		}
+
+		// x += V y with H y = g
+		y := make([]float64, k)
+		for i := k - 1; i >= 0; i-- {
+			y[i] = g[i]
+			for j := i + 1; j < k; j++ {
+				y[i] -= H[i][j] * y[j]
+			}
+			y[i] /= H[i][i]
+		}
+		for i := 0; i < k; i++ {
+			lrMadd(x, x, V[i], 1, float32(y[i]))
+		}
+	}
+	op.apply(w, x)
+	lrMadd(w, b, w, 1, -1)
+	return iter, math.Sqrt(w.dot(w)) / bnorm
+}
+
+// real and imaginary part of a complex vector field
+type lrVec [2]*data.Slice
+
+func newLRVec(size [3]int) lrVec {
+	return lrVec{cuda.Buffer(3, size), cuda.Buffer(3, size)}
+}
+
+func (v lrVec) recycle() {
+	cuda.Recycle(v[0])
+	cuda.Recycle(v[1])
+}
+
+func (v lrVec) free() {
+	if v[0] != nil {
+		v[0].Free()
+		v[1].Free()
+	}
+}
+
+func (v lrVec) zero() {
+	cuda.Zero(v[0])
+	cuda.Zero(v[1])
+}
+
+// real inner product
+func (v lrVec) dot(w lrVec) float64 {
+	return float64(cuda.Dot(v[0], w[0])) + float64(cuda.Dot(v[1], w[1]))
+}
+
+// dst = a x + b y
+func lrMadd(dst, x, y lrVec, a, b float32) {
+	cuda.Madd2(dst[0], x[0], y[0], a, b)
+	cuda.Madd2(dst[1], x[1], y[1], a, b)
+}
@@ -145,6 +145,9 @@ func (t *DataTable) init() {
 	if t.inited() {
 		return
 	}
+	if *Flag_resume && t.resume() {
+		return
+	}
 	f, err := httpfs.Create(OD() + t.name + ".txt")
 	util.FatalErr(err)
 	t.output = f
@@ -162,7 +165,10 @@ func (t *DataTable) init() {
 	}
 	fprintln(t)
 	t.Flush()
+	t.startAutoflush()
+}
 
+func (t *DataTable) startAutoflush() {
 	// periodically flush so GUI shows graph,
 	// but don't flush after every output for performance
 	// (httpfs flush is expensive)
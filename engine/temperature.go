@@ -14,6 +14,8 @@ var (
 	E_therm     = NewScalarValue("E_therm", "J", "Thermal energy", GetThermalEnergy)
 	Edens_therm = NewScalarField("Edens_therm", "J/m3", "Thermal energy density", AddThermalEnergyDensity)
 	B_therm     thermField // Thermal effective field (T)
+
+	ThermCorrTime float64 // correlation time of colored thermal noise, 0 means white noise
 )
 
 var AddThermalEnergyDensity = makeEdensAdder(&B_therm, -1)
@@ -25,6 +27,9 @@ type thermField struct {
 	noise     *data.Slice      // noise buffer
 	step      int              // solver step corresponding to noise
 	dt        float64          // solver timestep corresponding to noise
+	prev      *data.Slice      // colored noise: noise of the previous step
+	fresh     *data.Slice      // colored noise: new random part for this step
+	prevOK    bool             // colored noise: prev holds valid noise
 }
 
 func init() {
@@ -32,10 +37,11 @@ func init() {
 	registerEnergy(GetThermalEnergy, AddThermalEnergyDensity)
 	B_therm.step = -1 // invalidate noise cache
 	DeclROnly("B_therm", &B_therm, "Thermal field (T)")
+	DeclVar("ThermCorrTime", &ThermCorrTime, "Correlation time of thermal noise (s), Ornstein-Uhlenbeck process. 0 means white noise (default)")
 }
 
 func (b *thermField) AddTo(dst *data.Slice) {
-	if !Temp.isZero() {
+	if thermalNoise() {
 		b.update()
 		cuda.Add(dst, dst, b.noise)
 	}
@@ -59,10 +65,11 @@ func (b *thermField) update() {
 		B_therm.dt = -1
 	}
 
-	if Temp.isZero() {
+	if !thermalNoise() {
 		cuda.Memset(b.noise, 0, 0, 0)
 		b.step = NSteps
 		b.dt = Dt_si
+		b.prevOK = false
 		return
 	}
 
@@ -73,6 +80,11 @@ func (b *thermField) update() {
 
 	// after a bad step the timestep is rescaled and the noise should be rescaled accordingly, instead of redrawing the random numbers
 	if NSteps == b.step && Dt_si != b.dt {
+		if ThermCorrTime > 0 {
+			b.mixColored() // same random numbers, new decay over dt
+			b.dt = Dt_si
+			return
+		}
 		for c := 0; c < 3; c++ {
 			cuda.Madd2(b.noise.Comp(c), b.noise.Comp(c), b.noise.Comp(c), float32(math.Sqrt(b.dt/Dt_si)), 0.)
 		}
@@ -86,17 +98,31 @@ func (b *thermField) update() {
 		//util.Fatal("Finite temperature requires fixed time step. Set FixDt != 0.")
 	}
 
+	if ThermCorrTime > 0 {
+		b.updateColored()
+		b.step = NSteps
+		b.dt = Dt_si
+		return
+	}
+
+	b.prevOK = false
+	b.generate(b.noise, Dt_si)
+	b.step = NSteps
+	b.dt = Dt_si
+}
+
+// draw uncorrelated noise for a time step dt into dst.
+func (b *thermField) generate(dst *data.Slice, dt float64) {
 	N := Mesh().NCell()
-	k2_VgammaDt := 2 * mag.Kb / (GammaLL * cellVolume() * Dt_si)
+	k2_VgammaDt := 2 * mag.Kb / (GammaLL * cellVolume() * dt)
 	noise := cuda.Buffer(1, Mesh().Size())
 	defer cuda.Recycle(noise)
 
 	const mean = 0
 	const stddev = 1
-	dst := b.noise
 	ms := Msat.MSlice()
 	defer ms.Recycle()
-	temp := Temp.MSlice()
+	temp := cellTemp()
 	defer temp.Recycle()
 	alpha := Alpha.MSlice()
 	defer alpha.Recycle()
@@ -104,13 +130,43 @@ func (b *thermField) update() {
 		b.generator.GenerateNormal(uintptr(noise.DevPtr(0)), int64(N), mean, stddev)
 		cuda.SetTemperature(dst.Comp(i), noise, k2_VgammaDt, ms, temp, alpha)
 	}
+}
 
-	b.step = NSteps
-	b.dt = Dt_si
+// Ornstein-Uhlenbeck noise with correlation time τ = ThermCorrTime:
+// noise = a*prev + sqrt(1-a²)*fresh, with a = exp(-dt/τ).
+// fresh has the stationary variance, which equals that of white noise
+// for a time step 2τ, so that both have the same low-frequency power.
+func (b *thermField) updateColored() {
+	if b.prev == nil {
+		b.prev = cuda.NewSlice(3, Mesh().Size())
+		b.fresh = cuda.NewSlice(3, Mesh().Size())
+		b.prevOK = false
+	}
+	b.generate(b.fresh, 2*ThermCorrTime)
+	if b.prevOK {
+		data.Copy(b.prev, b.noise)
+	} else {
+		data.Copy(b.prev, b.fresh) // start from the stationary distribution
+	}
+	b.prevOK = true
+	b.mixColored()
+}
+
+func (b *thermField) mixColored() {
+	a := math.Exp(-Dt_si / ThermCorrTime)
+	cuda.Madd2(b.noise, b.prev, b.fresh, float32(a), float32(math.Sqrt(1-a*a)))
+}
+
+func (b *thermField) free() {
+	for _, s := range []*data.Slice{b.noise, b.prev, b.fresh} {
+		s.Free()
+	}
+	b.noise, b.prev, b.fresh = nil, nil, nil
+	b.prevOK = false
 }
 
 func GetThermalEnergy() float64 {
-	if Temp.isZero() || relaxing {
+	if !thermalNoise() || relaxing {
 		return 0
 	} else {
 		return -cellVolume() * dot(&M_full, &B_therm)
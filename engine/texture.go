@@ -0,0 +1,106 @@
+package engine
+
+// Crystalline orientation of anisotropy per region (grain),
+// given by Euler angles or drawn from a texture, e.g. after ext_makegrains:
+//
+//	Kc1 = -1e4
+//	RandomCubicTexture(0, 255, 1)
+//
+// or a <001> fiber texture along z with 5° spread:
+//
+//	FiberCubicTexture(0, 255, vector(0, 0, 1), 5*pi/180, 1)
+//
+// or uniaxial easy axes dispersed by up to 3° around z, as for recording media:
+//
+//	AnisUDispersion(0, 255, vector(0, 0, 1), 3*pi/180, 1)
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/util"
+)
+
+func init() {
+	DeclFunc("SetCubicEuler", SetCubicEuler, "Set AnisC1 and AnisC2 in region to the crystal axes of Bunge Euler angles phi1, Phi, phi2 (rad)")
+	DeclFunc("RandomCubicTexture", RandomCubicTexture, "Set uniformly random cubic crystal orientations in regions r1 to r2 (inclusive), with random seed")
+	DeclFunc("AnisUDispersion", AnisUDispersion, "Set AnisU in regions r1 to r2 (inclusive) to random directions within a cone of half-angle (rad) around axis, with random seed")
+	DeclFunc("FiberCubicTexture", FiberCubicTexture, "Set cubic crystal orientations in regions r1 to r2 (inclusive) with [001] within a cone of half-angle spread (rad) around axis, randomly rotated around it, with random seed")
+}
+
+// Sets the cubic anisotropy axes in the region to the [100] and [010] crystal axes,
+// in sample coordinates, of the orientation with Bunge (ZXZ) Euler angles.
+func SetCubicEuler(region int, phi1, Phi, phi2 float64) {
+	c1, c2 := eulerAxes(phi1, Phi, phi2)
+	setCubicAxes(region, c1, c2)
+}
+
+func RandomCubicTexture(r1, r2, seed int) {
+	checkRegionRange(r1, r2)
+	rnd := rand.New(rand.NewSource(int64(seed)))
+	for r := r1; r <= r2; r++ {
+		phi1 := 2 * math.Pi * rnd.Float64()
+		Phi := math.Acos(2*rnd.Float64() - 1) // uniform on the sphere
+		phi2 := 2 * math.Pi * rnd.Float64()
+		SetCubicEuler(r, phi1, Phi, phi2)
+	}
+}
+
+func FiberCubicTexture(r1, r2 int, axis data.Vector, spread float64, seed int) {
+	checkRegionRange(r1, r2)
+	util.Argument(axis.Len() != 0 && spread >= 0)
+	rnd := rand.New(rand.NewSource(int64(seed)))
+	for r := r1; r <= r2; r++ {
+		c3 := randomCone(rnd, axis, spread)
+		u := perpendicular(c3)
+		psi := 2 * math.Pi * rnd.Float64()
+		c1 := u.Mul(math.Cos(psi)).Add(c3.Cross(u).Mul(math.Sin(psi)))
+		setCubicAxes(r, c1, c3.Cross(c1))
+	}
+}
+
+// Sets AnisU in each region to a random direction, uniformly distributed
+// within the cone of given half-angle around axis.
+func AnisUDispersion(r1, r2 int, axis data.Vector, halfAngle float64, seed int) {
+	checkRegionRange(r1, r2)
+	util.Argument(axis.Len() != 0 && halfAngle >= 0)
+	rnd := rand.New(rand.NewSource(int64(seed)))
+	for r := r1; r <= r2; r++ {
+		u := randomCone(rnd, axis, halfAngle)
+		AnisU.setRegion(r, u[:])
+	}
+}
+
+func setCubicAxes(region int, c1, c2 data.Vector) {
+	AnisC1.setRegion(region, c1[:])
+	AnisC2.setRegion(region, c2[:])
+}
+
+// [100] and [010] crystal axes in sample coordinates: the first two rows of
+// the rotation g = Rz(phi2) Rx(Phi) Rz(phi1) from sample to crystal frame.
+func eulerAxes(phi1, Phi, phi2 float64) (c1, c2 data.Vector) {
+	c1_, s1 := math.Cos(phi1), math.Sin(phi1)
+	c, s := math.Cos(Phi), math.Sin(Phi)
+	c2_, s2 := math.Cos(phi2), math.Sin(phi2)
+	c1 = data.Vector{c1_*c2_ - s1*s2*c, s1*c2_ + c1_*s2*c, s2 * s}
+	c2 = data.Vector{-c1_*s2 - s1*c2_*c, -s1*s2 + c1_*c2_*c, c2_ * s}
+	return
+}
+
+// random unit vector, uniformly distributed within the cone of given half-angle (rad) around axis.
+func randomCone(rnd *rand.Rand, axis data.Vector, halfAngle float64) data.Vector {
+	n := axis.Div(axis.Len())
+	u := perpendicular(n)
+	v := n.Cross(u)
+	cosT := 1 - rnd.Float64()*(1-math.Cos(halfAngle)) // uniform on the spherical cap
+	sinT := math.Sqrt(1 - cosT*cosT)
+	phi := 2 * math.Pi * rnd.Float64()
+	return n.Mul(cosT).Add(u.Mul(sinT * math.Cos(phi))).Add(v.Mul(sinT * math.Sin(phi)))
+}
+
+func checkRegionRange(r1, r2 int) {
+	if r1 < 0 || r2 >= NREGION || r1 > r2 {
+		util.Fatal("region range should be 0 <= r1 <= r2 < ", NREGION, ", have: ", r1, ", ", r2)
+	}
+}
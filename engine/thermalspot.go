@@ -0,0 +1,323 @@
+package engine
+
+// Heat-assisted magnetic recording (HAMR): a Gaussian thermal spot
+// moving over the medium, on top of the background temperature Temp:
+//
+//	T(r, t) = Temp + Tpeak exp(-4 ln2 ρ²/FWHM²),  ρ = in-plane distance to r0 + v t
+//
+// The cell temperature drives the thermal noise. With a Curie temperature set,
+// the material parameters follow the reduced magnetization m(T) = (1 - T/Tc)^β:
+//
+//	Msat(T) = Msat m(T),  Ku1,2(T) = Ku1,2 m(T)^n,  Aex, Dind, Dbulk(T) ∝ m(T)²
+//
+// where the parameters set by the user are the values at T = 0. The exchange and DMI
+// are scaled per bond, by m(T) of both cells, so that the exchange energy is conserved.
+// The temperature entering m(T) is limited to 0.99 Tc, so that hot cells keep a small
+// magnetization and strong thermal noise. E.g., for FePt:
+//
+//	ThermalSpot(500, 40e-9, vector(-100e-9, 0, 0), vector(20, 0, 0))
+//	ThermalSpotCurie(700, 0.34, 2.1)
+//	AddFieldTerm(HeadField("head.ovf", vector(-80e-9, 0, 10e-9), vector(20, 0, 0)))
+//	FixDt = 1e-14
+//
+// Temp_cell holds the resulting cell temperature.
+// The spot and the parameter scale factors around it are sampled once,
+// and only translated on the GPU as the spot moves (see stamp.go).
+// They are sampled again for each background temperature Temp,
+// so a time-dependent Temp is evaluated on the host at every change.
+
+import (
+	"math"
+
+	"github.com/mumax/3/cuda"
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/util"
+)
+
+var (
+	thermalSpot thermSpot
+	Temp_cell   = NewScalarField("Temp_cell", "K", "Cell temperature, including the ThermalSpot", SetCellTemp)
+)
+
+func init() {
+	DeclFunc("ThermalSpot", ThermalSpot, "Gaussian thermal spot with peak temperature rise Tpeak (K) above Temp and FWHM (m), centered at r0 (m) and moving with velocity v (m/s)")
+	DeclFunc("ThermalSpotCurie", ThermalSpotCurie, "Make Msat ∝ (1-T/Tc)^beta, Ku1, Ku2 ∝ Msat^n and exchange, DMI ∝ Msat² (per pair of cells) follow the cell temperature, with Curie temperature Tc (K). Tc = 0 disables")
+}
+
+type thermSpot struct {
+	Tpeak, fwhm float64
+	r0, v       data.Vector
+	Tc, beta, n float64
+	temp        *data.Slice      // cell temperature (K)
+	msat, ku    *data.Slice      // parameter scale factors m(T), m(T)^n
+	rendered    float64          // time at which the buffers were rendered
+	tempLUT     [NREGION]float32 // Temp at which the buffers were rendered
+	spot        *stamp           // temperature rise
+	background  fixedLUT         // m(T), m(T)^n at Temp per region
+	groups      []tempGroup      // scale factors around the spot, per background temperature
+}
+
+// Regions at the same background temperature T0.
+type tempGroup struct {
+	T0  float64
+	dev *stamp   // m(T), m(T)^n around the spot minus their background value
+	lut fixedLUT // 1 in the regions of the group, unused if it is the only group
+}
+
+// look-up table set directly
+type fixedLUT struct{ lut }
+
+func (*fixedLUT) update() {}
+
+func ThermalSpot(Tpeak, fwhm float64, r0, v data.Vector) {
+	util.Argument(Tpeak >= 0 && fwhm > 0)
+	s := &thermalSpot
+	s.Tpeak, s.fwhm, s.r0, s.v = Tpeak, fwhm, r0, v
+	s.invalidate()
+}
+
+func ThermalSpotCurie(Tc, beta, n float64) {
+	util.Argument(Tc >= 0 && beta > 0 && n >= 0)
+	s := &thermalSpot
+	s.Tc, s.beta, s.n = Tc, beta, n
+	s.invalidate()
+}
+
+// re-sample the spot and parameter scales at the next update.
+func (s *thermSpot) invalidate() {
+	s.rendered = math.NaN()
+	for i := range s.tempLUT {
+		s.tempLUT[i] = float32(math.NaN())
+	}
+}
+
+// whether the spot adds to the temperature
+func (s *thermSpot) heating() bool { return s.fwhm > 0 && s.Tpeak > 0 }
+
+// whether parameters depend on temperature
+func (s *thermSpot) curie() bool { return s.Tc > 0 }
+
+// whether there is thermal noise, from Temp or a ThermalSpot
+func thermalNoise() bool { return !Temp.isZero() || thermalSpot.heating() }
+
+// Scale factor per cell of parameter p, nil if it does not depend on the temperature.
+func (s *thermSpot) scaleOf(p *regionwise) *data.Slice {
+	if !s.curie() {
+		return nil
+	}
+	var f **data.Slice
+	switch p {
+	default:
+		return nil
+	case &Msat.regionwise:
+		f = &s.msat
+	case &Ku1.regionwise, &Ku2.regionwise:
+		f = &s.ku
+	}
+	s.update()
+	return *f
+}
+
+// Cell temperature to be recycled by the caller.
+func cellTemp() cuda.MSlice {
+	s := &thermalSpot
+	if !s.heating() {
+		return Temp.MSlice()
+	}
+	s.update()
+	buf := cuda.Buffer(1, Mesh().Size())
+	data.Copy(buf, s.temp)
+	return cuda.ToMSlice(buf)
+}
+
+func SetCellTemp(dst *data.Slice) {
+	s := &thermalSpot
+	if !s.heating() && !s.curie() {
+		EvalTo(Temp, dst)
+		return
+	}
+	s.update()
+	data.Copy(dst, s.temp)
+}
+
+// Adds the exchange (and DMI) field to dst, with the coupling of each pair of
+// neighbors i, j scaled by μ_i μ_j, the reduced magnetizations m(T) of both cells.
+// With Msat_i = Msat μ_i, the exchange field is
+//
+//	B_i = 2A/Msat Σ_j μ_j (m_j - m_i)/Δ² = 2A/Msat Σ_j (μ_j m_j - μ_i m_i)/Δ² - m_i 2A/Msat Σ_j (μ_j - μ_i)/Δ²
+//
+// the exchange (and DMI) field of μ m minus m times that of the scalar μ, both at the Msat of T = 0.
+func (s *thermSpot) addExchangeScaled(dst *data.Slice) {
+	s.update()
+	m := M.Buffer()
+	n := Mesh().Size()
+	ms := unscaledMSlice(&Msat.regionwise)
+	defer ms.Recycle()
+
+	u := cuda.Buffer(3, n)
+	defer cuda.Recycle(u)
+	for c := 0; c < 3; c++ {
+		cuda.Mul(u.Comp(c), m.Comp(c), s.msat)
+	}
+	addExchangeField(dst, u, ms)
+
+	// scalar m(T) along x, zero outside the magnet so the missing neighbors are treated alike
+	cuda.Zero(u)
+	cuda.AddDotProduct(u.Comp(X), 1, m, m)
+	cuda.Mul(u.Comp(X), u.Comp(X), s.msat)
+	L := cuda.Buffer(3, n)
+	defer cuda.Recycle(L)
+	cuda.Zero(L)
+	cuda.AddExchange(L, u, lex2.Gpu(), ms, regions.Gpu(), M.Mesh())
+	for c := 0; c < 3; c++ {
+		cuda.Mul(u.Comp(c), m.Comp(c), L.Comp(X))
+	}
+	cuda.Madd2(dst, dst, u, 1, -1)
+}
+
+// Parameter values per cell, without the temperature dependence.
+func unscaledMSlice(p *regionwise) cuda.MSlice {
+	if p.IsUniform() {
+		return cuda.MakeMSlice(data.NilSlice(p.NComp(), Mesh().Size()), p.getRegion(0))
+	}
+	buf, _ := p.lut.Slice()
+	return cuda.ToMSlice(buf)
+}
+
+// reduced magnetization m(T) and m(T)^n
+func (s *thermSpot) scales(T float64) (m, mn float64) {
+	m = math.Pow(1-math.Min(T, 0.99*s.Tc)/s.Tc, s.beta)
+	return m, math.Pow(m, s.n)
+}
+
+// temperature rise at r relative to the center of the spot
+func (s *thermSpot) rise(r data.Vector) float64 {
+	return s.Tpeak * math.Exp(-4*math.Ln2/sqr(s.fwhm)*(sqr(r[X])+sqr(r[Y])))
+}
+
+// re-renders the buffers when the time or Temp has changed.
+func (s *thermSpot) update() {
+	if s.temp == nil || s.temp.Size() != Mesh().Size() {
+		s.free()
+		n := Mesh().Size()
+		s.temp = cuda.NewSlice(1, n)
+		s.msat = cuda.NewSlice(1, n)
+		s.ku = cuda.NewSlice(1, n)
+		s.invalidate()
+	}
+	if T0 := Temp.cpuLUT()[0]; s.tempLUT != T0 {
+		s.tempLUT = T0
+		s.sample()
+		s.rendered = math.NaN()
+	}
+	if s.rendered != Time {
+		s.render()
+	}
+}
+
+// sets up the spot and the scale factors for the current background temperatures.
+func (s *thermSpot) sample() {
+	s.freeSamples()
+	hw := data.Vector{3 * s.fwhm, 3 * s.fwhm, 0} // uniform along z, < 1e-10 Tpeak outside
+	if s.heating() {
+		s.spot = newStamp(1, s.r0, s.v, hw, func(r data.Vector, dst []float64) {
+			dst[0] = s.rise(r)
+		})
+	}
+	if !s.curie() {
+		return
+	}
+
+	if s.background.cpu_buf == nil {
+		s.background.init(2, &s.background)
+	}
+	for r, T := range s.tempLUT {
+		s.background.cpu_buf[0][r], s.background.cpu_buf[1][r] = f32pair(s.scales(float64(T)))
+	}
+	s.background.gpu_ok = false
+	if !s.heating() {
+		return
+	}
+
+	group := make(map[float32]int)
+	for _, T := range s.tempLUT {
+		if _, ok := group[T]; !ok {
+			group[T] = len(s.groups)
+			T0 := float64(T)
+			m0, mn0 := s.scales(T0)
+			s.groups = append(s.groups, tempGroup{T0: T0, dev: newStamp(2, s.r0, s.v, hw, func(r data.Vector, dst []float64) {
+				m, mn := s.scales(T0 + s.rise(r))
+				dst[0], dst[1] = m-m0, mn-mn0
+			})})
+		}
+	}
+	if len(s.groups) > 1 {
+		for i := range s.groups {
+			g := &s.groups[i]
+			g.lut.init(1, &g.lut)
+			for r, T := range s.tempLUT {
+				if group[T] == i {
+					g.lut.cpu_buf[0][r] = 1
+				}
+			}
+		}
+	}
+}
+
+func f32pair(a, b float64) (float32, float32) { return float32(a), float32(b) }
+
+// renders the buffers at the current time on the GPU.
+func (s *thermSpot) render() {
+	t0, r := Temp.Slice()
+	data.Copy(s.temp, t0)
+	if r {
+		cuda.Recycle(t0)
+	}
+	if s.heating() {
+		cuda.Add(s.temp, s.temp, s.spot.at(Time))
+	}
+
+	if s.curie() {
+		bg, _ := s.background.Slice()
+		data.Copy(s.msat, bg.Comp(0))
+		data.Copy(s.ku, bg.Comp(1))
+		cuda.Recycle(bg)
+		for i := range s.groups {
+			g := &s.groups[i]
+			dev := g.dev.at(Time)
+			if len(s.groups) > 1 {
+				mask := cuda.Buffer(1, Mesh().Size()) // regions may have changed
+				defer cuda.Recycle(mask)
+				cuda.RegionDecode(mask, g.lut.gpuLUT1(), regions.Gpu())
+				masked := cuda.Buffer(2, Mesh().Size())
+				defer cuda.Recycle(masked)
+				for c := 0; c < 2; c++ {
+					cuda.Mul(masked.Comp(c), dev.Comp(c), mask)
+				}
+				dev = masked
+			}
+			cuda.Add(s.msat, s.msat, dev.Comp(0))
+			cuda.Add(s.ku, s.ku, dev.Comp(1))
+		}
+	}
+	s.rendered = Time
+}
+
+func (s *thermSpot) freeSamples() {
+	if s.spot != nil {
+		s.spot.free()
+	}
+	for i := range s.groups {
+		s.groups[i].dev.free()
+		s.groups[i].lut.free()
+	}
+	s.spot, s.groups = nil, nil
+}
+
+func (s *thermSpot) free() {
+	for _, b := range []*data.Slice{s.temp, s.msat, s.ku} {
+		b.Free()
+	}
+	s.temp, s.msat, s.ku = nil, nil, nil
+	s.freeSamples()
+}
@@ -27,9 +27,23 @@ var (
 	fixedLayerPosition               = FIXEDLAYER_TOP // instructs mumax3 how free and fixed layers are stacked along +z direction
 )
 
+var vacuum DerivedParam // 1 in regions with Msat = 0: no torque, no exchange
+
 func init() {
 	Pol.setUniform([]float64{1}) // default spin polarization
 	Lambda.Set(1)                // sensible default value (?).
+	// already a child of Msat, see demag.go
+	vacuum.lut.init(SCALAR, &vacuum)
+	vacuum.parents = []updater{Msat}
+	vacuum.updater = func(p *DerivedParam) {
+		ms := Msat.cpuLUT()
+		for r := 0; r < NREGION; r++ {
+			p.cpu_buf[0][r] = 0
+			if ms[0][r] == 0 {
+				p.cpu_buf[0][r] = 1
+			}
+		}
+	}
 	DeclVar("GammaLL", &GammaLL, "Gyromagnetic ratio in rad/Ts")
 	DeclVar("DisableZhangLiTorque", &DisableZhangLiTorque, "Disables Zhang-Li torque (default=false)")
 	DeclVar("DisableSlonczewskiTorque", &DisableSlonczewskiTorque, "Disables Slonczewski torque (default=false)")
@@ -43,7 +57,9 @@ func init() {
 func SetTorque(dst *data.Slice) {
 	SetLLTorque(dst)
 	AddSTTorque(dst)
+	AddSpinCurrentTorque(dst)
 	FreezeSpins(dst)
+	reverseTorque(dst)
 }
 
 // Sets dst to the current Landau-Lifshitz torque
@@ -107,10 +123,14 @@ func AddSTTorque(dst *data.Slice) {
 	}
 }
 
+// Zeroes the torque on frozen spins and in regions without magnetization (Msat = 0).
 func FreezeSpins(dst *data.Slice) {
 	if !FrozenSpins.isZero() {
 		cuda.ZeroMask(dst, FrozenSpins.gpuLUT1(), regions.Gpu())
 	}
+	if !vacuum.isZero() {
+		cuda.ZeroMask(dst, vacuum.gpuLUT1(), regions.Gpu())
+	}
 }
 
 func GetMaxTorque() float64 {
@@ -80,16 +80,23 @@ func Fprintln(filename string, msg ...interface{}) {
 
 // Read a magnetization state from .dump file.
 func LoadFile(fname string) *data.Slice {
+	s, _ := loadFile(fname)
+	return s
+}
+
+// LoadFile, also returning the metadata.
+func loadFile(fname string) (*data.Slice, data.Meta) {
 	in, err := httpfs.Open(fname)
 	util.FatalErr(err)
 	var s *data.Slice
+	var info data.Meta
 	if path.Ext(fname) == ".dump" {
-		s, _, err = dump.Read(in)
+		s, info, err = dump.Read(in)
 	} else {
-		s, _, err = oommf.Read(in)
+		s, info, err = oommf.Read(in)
 	}
 	util.FatalErr(err)
-	return s
+	return s, info
 }
 
 // Download a quantity to host,
@@ -134,7 +141,28 @@ func Index2Coord(ix, iy, iz int) data.Vector {
 	x := c[X]*(float64(ix)-0.5*float64(n[X]-1)) - TotalShift
 	y := c[Y]*(float64(iy)-0.5*float64(n[Y]-1)) - TotalYShift
 	z := c[Z] * (float64(iz) - 0.5*float64(n[Z]-1))
-	return data.Vector{x, y, z}
+	return data.Vector{x, y, z}.Add(meshOffset())
+}
+
+// Position of the center of the mesh in world coordinates:
+// 0,0,0 unless set otherwise with SetOrigin (window shifts not included).
+func meshOffset() data.Vector {
+	m := Mesh()
+	if !m.HasOrigin() {
+		return data.Vector{}
+	}
+	o, w := m.Origin(), m.WorldSize()
+	return data.Vector{o[X] + w[X]/2, o[Y] + w[Y]/2, o[Z] + w[Z]/2}
+}
+
+// Lower corner of mesh m in world coordinates, as written to output files:
+// 0,0,0 unless an origin was set with SetOrigin.
+func outputOrigin(m *data.Mesh) [3]float64 {
+	if !m.HasOrigin() {
+		return [3]float64{}
+	}
+	o := m.Origin()
+	return [3]float64{o[X] - TotalShift, o[Y] - TotalYShift, o[Z]}
 }
 
 func sign(x float64) float64 {
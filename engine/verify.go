@@ -0,0 +1,148 @@
+package engine
+
+// Static check of the setup for common mistakes, before spending time on a run:
+//
+//	Verify()
+//
+// reports, for the regions that contain magnet cells:
+// Msat = 0 (default), Msat set but Aex = 0 in more than one cell, and DMI without exchange;
+// magnet cells left in region 0 when DefRegion was only used for other regions;
+// and saved quantities (AutoSave, AutoSnapshot, SaveAt, ...) that are NaN
+// or zero everywhere, e.g. B_therm with Temp = 0 or torques without current.
+// The latter are only warnings, as they may become nonzero later on.
+// Verify returns the number of errors. With StrictSetup = true it runs before the first
+// Run, Steps or RunWhile, which abort when errors are found.
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/mumax/3/util"
+)
+
+var (
+	StrictSetup = false // Verify before the first run, abort on errors
+	verified    = false // Verify has run because of StrictSetup
+)
+
+func init() {
+	DeclFunc("Verify", Verify, "Report common setup mistakes (default parameters, missing exchange, unassigned cells, undefined outputs), returns the number of errors")
+	DeclVar("StrictSetup", &StrictSetup, "Verify() the setup before the first run, abort if it reports errors")
+}
+
+func Verify() int {
+	checkMesh()
+	nerr := 0
+	report := func(isErr bool, msg ...interface{}) {
+		kind := "warning"
+		if isErr {
+			kind = "error"
+			nerr++
+		}
+		LogOut("Verify: ", kind, ": ", fmt.Sprint(msg...))
+	}
+
+	// magnet cells per region
+	m := Download(&M).Vectors()
+	reg := regions.HostArray()
+	var ncell [NREGION]int
+	total := 0
+	forEachMagnetCell(m, func(i [3]int) {
+		ncell[reg[i[Z]][i[Y]][i[X]]]++
+		total++
+	})
+	if total == 0 {
+		report(true, "no magnet cells: the geometry is empty or m is zero")
+	}
+
+	for r := 0; r < NREGION; r++ {
+		if ncell[r] == 0 {
+			continue
+		}
+		Ms, A := Msat.GetRegion(r), Aex.GetRegion(r)
+		D := Dind.GetRegion(r) != 0 || Dbulk.GetRegion(r) != 0
+		switch {
+		case Ms == 0:
+			report(true, "region ", r, " (", ncell[r], " cells): Msat = 0 (default)")
+		case A == 0 && ncell[r] > 1 && D:
+			report(true, "region ", r, " (", ncell[r], " cells): DMI without exchange (Aex = 0)")
+		case A == 0 && ncell[r] > 1:
+			report(true, "region ", r, " (", ncell[r], " cells): Msat set but Aex = 0")
+		}
+	}
+
+	// cells left over by DefRegion
+	other := false
+	for r := 1; r < NREGION; r++ {
+		other = other || regions.defined[r]
+	}
+	if other && !regions.defined[0] && ncell[0] > 0 {
+		report(true, ncell[0], " magnet cells belong to no region (left in default region 0)")
+	}
+
+	for _, q := range savedQuants() {
+		nan, zero := nanOrZero(q)
+		switch {
+		case nan:
+			report(true, "saved quantity ", NameOf(q), " is NaN")
+		case zero:
+			report(false, "saved quantity ", NameOf(q), " is zero everywhere at t = ", Time, " s")
+		}
+	}
+
+	if nerr == 0 {
+		LogOut("Verify: no errors found")
+	}
+	return nerr
+}
+
+// runs Verify before the first run in strict mode.
+func strictVerify() {
+	if !StrictSetup || verified {
+		return
+	}
+	verified = true
+	if n := Verify(); n > 0 {
+		util.Fatal("StrictSetup: Verify found ", n, " errors, not running")
+	}
+}
+
+// quantities to be saved by AutoSave, AutoSnapshot, SaveAt, AutoSaveIf and AutoSaveOnChange,
+// sorted by name.
+func savedQuants() []Quantity {
+	set := make(map[Quantity]bool)
+	for q := range output {
+		set[q] = true
+	}
+	for q := range outputAt {
+		set[q] = true
+	}
+	for q := range outputIf {
+		set[q] = true
+	}
+	for q := range outputOnChange {
+		set[q] = true
+	}
+	var qs []Quantity
+	for q := range set {
+		qs = append(qs, q)
+	}
+	sort.Slice(qs, func(i, j int) bool { return NameOf(qs[i]) < NameOf(qs[j]) })
+	return qs
+}
+
+// whether q has a NaN value, or is zero in all cells.
+func nanOrZero(q Quantity) (nan, zero bool) {
+	s := Download(q)
+	zero = true
+	for _, c := range s.Host() {
+		for _, v := range c {
+			if math.IsNaN(float64(v)) {
+				return true, false
+			}
+			zero = zero && v == 0
+		}
+	}
+	return false, zero
+}
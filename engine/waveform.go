@@ -0,0 +1,78 @@
+package engine
+
+// Sampled waveforms, e.g. measured pulse shapes, as the time dependence
+// of any parameter or excitation:
+//
+//	pulse := LoadWaveform("pulse.csv")
+//	B_ext = vector(0, 0, 0.1*pulse.At(t))
+//	J = vector(0, 0, 1e12*pulse.At(t-1e-9))
+//
+// The file has a time (s) and a value per line, separated by a comma, tab or spaces.
+// Empty lines, lines starting with # and a header line are ignored.
+// Values are linearly interpolated, before the first and after the last sample
+// the first and last value are used.
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+func init() {
+	DeclFunc("LoadWaveform", LoadWaveform, "Load a sampled waveform (t (s), value) from a CSV file. Use .At(t) as time dependence")
+}
+
+type waveform struct {
+	t, v []float64
+}
+
+func LoadWaveform(fname string) *waveform {
+	in, err := httpfs.Read(fname)
+	util.FatalErr(err)
+	w := new(waveform)
+	for i, line := range strings.Split(string(in), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ';' || r == ' ' || r == '\t' })
+		if len(f) < 2 {
+			util.Fatal("LoadWaveform ", fname, ":", i+1, ": need time and value, have: ", line)
+		}
+		t, err1 := strconv.ParseFloat(f[0], 64)
+		v, err2 := strconv.ParseFloat(f[1], 64)
+		if err1 != nil || err2 != nil {
+			if len(w.t) == 0 {
+				continue // header
+			}
+			util.Fatal("LoadWaveform ", fname, ":", i+1, ": cannot parse: ", line)
+		}
+		if n := len(w.t); n > 0 && t <= w.t[n-1] {
+			util.Fatal("LoadWaveform ", fname, ":", i+1, ": time should increase, have: ", t, " after ", w.t[n-1])
+		}
+		w.t = append(w.t, t)
+		w.v = append(w.v, v)
+	}
+	if len(w.t) == 0 {
+		util.Fatal("LoadWaveform ", fname, ": no samples")
+	}
+	LogOut("LoadWaveform ", fname, ": ", len(w.t), " samples from ", w.t[0], " to ", w.t[len(w.t)-1], " s")
+	return w
+}
+
+// Value at time t, linearly interpolated.
+func (w *waveform) At(t float64) float64 {
+	n := len(w.t)
+	i := sort.SearchFloat64s(w.t, t) // first sample at or after t
+	switch {
+	case i == 0:
+		return w.v[0]
+	case i == n:
+		return w.v[n-1]
+	}
+	f := (t - w.t[i-1]) / (w.t[i] - w.t[i-1])
+	return w.v[i-1] + f*(w.v[i]-w.v[i-1])
+}
@@ -0,0 +1,130 @@
+package engine
+
+// Zarr output:
+//
+//	OutputFormat = ZARR
+//
+// makes Save and AutoSave append each quantity to a Zarr store per quantity, e.g. m.zarr,
+// with time, x, y and z coordinates, one compressed chunk per time step and component.
+// E.g., in python: xarray.open_zarr("out/m.zarr").
+
+import (
+	"math"
+	"strings"
+
+	"github.com/mumax/3/data"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+	"github.com/mumax/3/zarr"
+)
+
+func init() {
+	DeclROnly("ZARR", ZARR, "OutputFormat = ZARR appends all saves of a quantity to one Zarr store (name.zarr)")
+}
+
+const zarrTimeChunk = 1024 // number of time values per chunk of the time array
+
+// open Zarr stores, only accessed by the output goroutine
+var zarrStores = make(map[string]*zarrStore)
+
+type zarrStore struct {
+	name  string
+	ncomp int
+	size  [3]int
+	times []float64
+}
+
+// store name of the time series of quantity name
+func zarrFname(name string) string {
+	return seriesFname(name, "zarr")
+}
+
+// appends s as a time step to the Zarr store fname, creating the store on first use.
+func appendZarr(fname string, s *data.Slice, info data.Meta) {
+	dir := strings.TrimSuffix(fname, "/") + "/"
+	put := func(file string, p []byte) { util.FatalErr(httpfs.Put(dir+file, p)) }
+
+	z := zarrStores[dir]
+	if z != nil && (z.ncomp != s.NComp() || z.size != s.Size()) {
+		util.Fatal("Zarr output ", fname, ": mesh or number of components changed, use another file name")
+	}
+	size := s.Size()
+	if z == nil && *Flag_resume {
+		z = resumeZarr(dir, s, info)
+	}
+	if z == nil {
+		z = &zarrStore{name: info.Name, ncomp: s.NComp(), size: size}
+		put(".zgroup", zarr.Group())
+		put(".zattrs", zarr.Attrs(nil, map[string]interface{}{"title": info.Name, "source": UNAME}))
+		for c, x := range []string{"x", "y", "z"} {
+			coord := make([]float64, size[c])
+			for i := range coord {
+				coord[i] = info.Origin[c] + (float64(i)+0.5)*info.CellSize[c]
+			}
+			put(x+"/.zarray", zarr.Array([]int{size[c]}, []int{size[c]}, "<f8", false))
+			put(x+"/.zattrs", zarr.Attrs([]string{x}, map[string]interface{}{"units": info.MeshUnit}))
+			put(x+"/0", zarr.Chunk64(coord, false))
+		}
+		put(z.name+"/.zattrs", zarr.Attrs([]string{"time", "comp", "z", "y", "x"}, map[string]interface{}{"units": info.Unit, "components": info.Labels(z.ncomp)}))
+		put("time/.zattrs", zarr.Attrs([]string{"time"}, map[string]interface{}{"units": "s"}))
+		zarrStores[dir] = z
+	}
+
+	// chunks first, then the metadata with the new shape
+	t := len(z.times)
+	z.times = append(z.times, info.Time)
+	for c := 0; c < z.ncomp; c++ {
+		put(z.name+"/"+zarr.ChunkName(t, c, 0, 0, 0), zarr.Chunk32(s.Host()[c], true))
+	}
+	put(z.name+"/.zarray", zarr.Array([]int{len(z.times), z.ncomp, size[Z], size[Y], size[X]}, []int{1, 1, size[Z], size[Y], size[X]}, "<f4", true))
+
+	k := t / zarrTimeChunk
+	chunk := make([]float64, zarrTimeChunk)
+	for i := range chunk {
+		chunk[i] = math.NaN()
+	}
+	copy(chunk, z.times[k*zarrTimeChunk:])
+	put("time/"+zarr.ChunkName(k), zarr.Chunk64(chunk, false))
+	put("time/.zarray", zarr.Array([]int{len(z.times)}, []int{zarrTimeChunk}, "<f8", false))
+}
+
+// with -resume, continues an existing store after its last complete time step,
+// keeping its metadata and time values. Returns nil if there is no store to continue.
+func resumeZarr(dir string, s *data.Slice, info data.Meta) *zarrStore {
+	zarray, err := httpfs.Read(dir + info.Name + "/.zarray")
+	if err != nil {
+		return nil
+	}
+	shape, err := zarr.Shape(zarray)
+	util.FatalErr(err)
+	size := s.Size()
+	if len(shape) != 5 || shape[1] != s.NComp() || shape[2] != size[Z] || shape[3] != size[Y] || shape[4] != size[X] {
+		util.Fatal("resume Zarr output ", dir, ": mesh or number of components changed, use another file name")
+	}
+
+	// the time array is written last, it may be one step behind the data
+	tarray, err := httpfs.Read(dir + "time/.zarray")
+	util.FatalErr(err)
+	tshape, err := zarr.Shape(tarray)
+	util.FatalErr(err)
+	n := shape[0]
+	if len(tshape) == 1 && tshape[0] < n {
+		n = tshape[0]
+	}
+	var times []float64
+	for k := 0; k*zarrTimeChunk < n; k++ {
+		p, err := httpfs.Read(dir + "time/" + zarr.ChunkName(k))
+		util.FatalErr(err)
+		chunk, err := zarr.ReadChunk64(p, false)
+		util.FatalErr(err)
+		times = append(times, chunk...)
+	}
+	if len(times) < n {
+		util.Fatal("resume Zarr output ", dir, ": ", len(times), " time values for ", n, " time steps")
+	}
+
+	LogOut("resume: appending to", dir, "after", n, "time steps")
+	z := &zarrStore{name: info.Name, ncomp: s.NComp(), size: size, times: times[:n]}
+	zarrStores[dir] = z
+	return z
+}
@@ -99,6 +99,17 @@ func Append(URL string, p []byte) error {
 	return AppendSize(URL, p, -1)
 }
 
+// Overwrite part of the existing file given by URL with p, starting at offset off.
+// Only supported for local files.
+func WriteAt(URL string, p []byte, off int64) error {
+	URL = addWorkDir(URL)
+	if isRemote(URL) {
+		return fmt.Errorf("httpfs: WriteAt %v: not supported for remote files", URL)
+	} else {
+		return localWriteAt(URL, p, off)
+	}
+}
+
 // Create file given by URL and put data from p there.
 func Put(URL string, p []byte) error {
 	URL = addWorkDir(URL)
@@ -105,6 +105,17 @@ func localPut(fname string, data []byte) error {
 	return err2
 }
 
+func localWriteAt(fname string, data []byte, off int64) error {
+	f, err := os.OpenFile(fname, os.O_WRONLY, FilePerm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err2 := f.WriteAt(data, off)
+	return err2
+}
+
 func localRead(fname string) ([]byte, error) {
 	return ioutil.ReadFile(fname)
 }
@@ -295,3 +295,31 @@ func mustFail(t *testing.T, err error) {
 		t.Fatal("did not get error")
 	}
 }
+
+func TestOpenAppend(t *testing.T) {
+	Remove("testdata")
+	defer Remove("testdata")
+
+	mustPass(t, Mkdir("testdata"))
+
+	// must fail if file does not yet exist
+	if _, err := OpenAppend("testdata/file"); err == nil {
+		t.Fail()
+	}
+
+	out, errC := Create("testdata/file")
+	mustPass(t, errC)
+	fmt.Fprint(out, "hello ")
+	mustPass(t, out.Close())
+
+	out, errA := OpenAppend("testdata/file")
+	mustPass(t, errA)
+	fmt.Fprint(out, "httpfs")
+	mustPass(t, out.Close())
+
+	b, errR := Read("testdata/file")
+	mustPass(t, errR)
+	if string(b) != "hello httpfs" {
+		t.Error("have:", string(b))
+	}
+}
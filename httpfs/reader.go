@@ -21,6 +21,15 @@ func Create(URL string) (WriteCloseFlusher, error) {
 	return &bufWriter{bufio.NewWriterSize(&appendWriter{URL, 0}, BUFSIZE)}, nil
 }
 
+// open an existing file for appending.
+func OpenAppend(URL string) (WriteCloseFlusher, error) {
+	data, err := Read(URL)
+	if err != nil {
+		return nil, err
+	}
+	return &bufWriter{bufio.NewWriterSize(&appendWriter{URL, int64(len(data))}, BUFSIZE)}, nil
+}
+
 func MustCreate(URL string) WriteCloseFlusher {
 	f, err := Create(URL)
 	if err != nil {
@@ -0,0 +1,33 @@
+package mag
+
+import "math"
+
+// DemagFactors returns the demagnetizing factors Nx, Ny, Nz
+// of a uniformly magnetized rectangular prism with edges sx, sy, sz.
+// A. Aharoni, J. Appl. Phys. 83, 3432 (1998).
+func DemagFactors(sx, sy, sz float64) [3]float64 {
+	a, b, c := sx/2, sy/2, sz/2
+	return [3]float64{aharoniDz(b, c, a), aharoniDz(c, a, b), aharoniDz(a, b, c)}
+}
+
+// Demagnetizing factor along c of a prism with half edges a, b, c. Aharoni eq. (1).
+func aharoniDz(a, b, c float64) float64 {
+	r := math.Sqrt(a*a + b*b + c*c)
+	ab := math.Sqrt(a*a + b*b)
+	bc := math.Sqrt(b*b + c*c)
+	ac := math.Sqrt(a*a + c*c)
+	abc := a * b * c
+
+	d := (b*b-c*c)/(2*b*c)*math.Log((r-a)/(r+a)) +
+		(a*a-c*c)/(2*a*c)*math.Log((r-b)/(r+b)) +
+		b/(2*c)*math.Log((ab+a)/(ab-a)) +
+		a/(2*c)*math.Log((ab+b)/(ab-b)) +
+		c/(2*a)*math.Log((bc-b)/(bc+b)) +
+		c/(2*b)*math.Log((ac-a)/(ac+a)) +
+		2*math.Atan(a*b/(c*r)) +
+		(a*a*a+b*b*b-2*c*c*c)/(3*abc) +
+		(a*a+b*b-2*c*c)/(3*abc)*r +
+		c/(a*b)*(ac+bc) -
+		(ab*ab*ab+bc*bc*bc+ac*ac*ac)/(3*abc)
+	return d / math.Pi
+}
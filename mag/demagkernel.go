@@ -376,6 +376,11 @@ func wrap(number, max int) int {
 	return number
 }
 
+// Size of the demag kernel for given input size and PBC, as returned by DemagKernel.
+func KernelSize(inputSize, pbc [3]int) [3]int {
+	return padSize(inputSize, pbc)
+}
+
 const maxAspect = 100.0 // maximum sane cell aspect ratio
 
 func sanityCheck(cellsize [3]float64, pbc [3]int) {
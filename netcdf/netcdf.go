@@ -0,0 +1,197 @@
+// package netcdf writes time series of mumax3 data in the NetCDF classic format
+// (64-bit offset variant), readable with e.g. xarray, netCDF4-python, ncdump or ParaView.
+//
+// A file holds one quantity. Its header is written once with Header,
+// after which each time step is appended as a record with Record.
+// The number of records in the header is set to "streaming" (unknown, follows from the file size)
+// and can be patched with NumRecs after each append.
+package netcdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+
+	"github.com/mumax/3/data"
+)
+
+// NetCDF type and tag codes
+const (
+	ncChar      = 2
+	ncFloat     = 5
+	ncDouble    = 6
+	ncDimension = 10
+	ncVariable  = 11
+	ncAttribute = 12
+	streaming   = 0xFFFFFFFF // numrecs: indeterminate
+	numrecsPos  = 4          // position of numrecs in the file
+)
+
+// Header of a file holding a time series of the quantity info.Name (info.Unit),
+// with ncomp components on a mesh of size cells with cell size info.CellSize and lower corner info.Origin.
+// The data variable has dimensions (time, comp, z, y, x), comp is omitted for scalars.
+// Includes the cell-center coordinate variables x, y, z, in info.MeshUnit (default m).
+// attrs are added as global attributes, in order (key, value, key, value, ...).
+func Header(info data.Meta, ncomp int, size [3]int, attrs ...string) []byte {
+	if len(attrs)%2 != 0 {
+		panic("netcdf: need attribute key-value pairs")
+	}
+	name := varName(info.Name)
+	meshUnit := info.MeshUnit
+	if meshUnit == "" {
+		meshUnit = "m"
+	}
+
+	// dimensions: time (record), x, y, z, comp
+	var h buffer
+	h.bytes([]byte("CDF\x02"))
+	h.uint32(streaming)
+	dims := []struct {
+		name string
+		n    int
+	}{{"time", 0}, {"x", size[data.X]}, {"y", size[data.Y]}, {"z", size[data.Z]}, {"comp", ncomp}}
+	if ncomp == 1 {
+		dims = dims[:4]
+	}
+	h.uint32(ncDimension)
+	h.uint32(uint32(len(dims)))
+	for _, d := range dims {
+		h.name(d.name)
+		h.uint32(uint32(d.n))
+	}
+
+	h.uint32(ncAttribute)
+	h.uint32(uint32(len(attrs) / 2))
+	for i := 0; i < len(attrs); i += 2 {
+		h.textAttr(attrs[i], attrs[i+1])
+	}
+
+	// variables: x, y, z, time, data
+	// begin offsets are patched once the header size is known
+	type variable struct {
+		name  string
+		dims  []int
+		attrs []string
+		typ   int
+		vsize int
+	}
+	n := size[data.X] * size[data.Y] * size[data.Z]
+	dataDims := []int{0, 3, 2, 1}
+	dataAttrs := []string{"units", info.Unit, "long_name", info.Name}
+	if ncomp > 1 {
+		dataDims = []int{0, 4, 3, 2, 1}
+		dataAttrs = append(dataAttrs, "components", strings.Join(info.Labels(ncomp), " "))
+	}
+	vars := []variable{
+		{"x", []int{1}, []string{"units", meshUnit, "long_name", "cell center x"}, ncDouble, 8 * size[data.X]},
+		{"y", []int{2}, []string{"units", meshUnit, "long_name", "cell center y"}, ncDouble, 8 * size[data.Y]},
+		{"z", []int{3}, []string{"units", meshUnit, "long_name", "cell center z"}, ncDouble, 8 * size[data.Z]},
+		{"time", []int{0}, []string{"units", "s", "long_name", "time"}, ncDouble, 8},
+		{name, dataDims, dataAttrs, ncFloat, 4 * ncomp * n},
+	}
+	h.uint32(ncVariable)
+	h.uint32(uint32(len(vars)))
+	beginPos := make([]int, len(vars))
+	for i, v := range vars {
+		h.name(v.name)
+		h.uint32(uint32(len(v.dims)))
+		for _, d := range v.dims {
+			h.uint32(uint32(d))
+		}
+		h.uint32(ncAttribute)
+		h.uint32(uint32(len(v.attrs) / 2))
+		for j := 0; j < len(v.attrs); j += 2 {
+			h.textAttr(v.attrs[j], v.attrs[j+1])
+		}
+		h.uint32(uint32(v.typ))
+		h.uint32(uint32(v.vsize))
+		beginPos[i] = h.Len()
+		h.uint64(0) // begin, patched below
+	}
+
+	// fixed-size coordinate data follows the header, then the records (time, data)
+	begin := h.Len()
+	for i, v := range vars {
+		binary.BigEndian.PutUint64(h.Bytes()[beginPos[i]:], uint64(begin))
+		begin += v.vsize
+	}
+	for c := data.X; c <= data.Z; c++ {
+		for i := 0; i < size[c]; i++ {
+			h.float64(info.Origin[c] + (float64(i)+0.5)*info.CellSize[c])
+		}
+	}
+	return h.Bytes()
+}
+
+// Record encodes the data of one time step, to be appended to the file.
+// s must have the number of components and size given to Header.
+func Record(t float64, s *data.Slice) []byte {
+	var r buffer
+	r.float64(t)
+	for _, c := range s.Host() {
+		for _, v := range c {
+			r.uint32(math.Float32bits(v))
+		}
+	}
+	return r.Bytes()
+}
+
+// NumRecs returns the number of records n, encoded, and its position in the file.
+func NumRecs(n int) (p []byte, pos int64) {
+	var b buffer
+	b.uint32(uint32(n))
+	return b.Bytes(), numrecsPos
+}
+
+// valid NetCDF name: letters, digits and underscores, starting with a letter.
+func varName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 || !(b[0] >= 'a' && b[0] <= 'z' || b[0] >= 'A' && b[0] <= 'Z') {
+		b = append([]byte("q"), b...)
+	}
+	return string(b)
+}
+
+// big-endian encoding
+type buffer struct{ bytes.Buffer }
+
+func (b *buffer) bytes(p []byte) { b.Write(p) }
+
+func (b *buffer) uint32(v uint32) {
+	var p [4]byte
+	binary.BigEndian.PutUint32(p[:], v)
+	b.Write(p[:])
+}
+
+func (b *buffer) uint64(v uint64) {
+	var p [8]byte
+	binary.BigEndian.PutUint64(p[:], v)
+	b.Write(p[:])
+}
+
+func (b *buffer) float64(v float64) { b.uint64(math.Float64bits(v)) }
+
+// string padded to 4 bytes
+func (b *buffer) padded(s string) {
+	b.WriteString(s)
+	for i := len(s); i%4 != 0; i++ {
+		b.WriteByte(0)
+	}
+}
+
+func (b *buffer) name(s string) {
+	b.uint32(uint32(len(s)))
+	b.padded(s)
+}
+
+func (b *buffer) textAttr(key, value string) {
+	b.name(key)
+	b.uint32(ncChar)
+	b.name(value)
+}
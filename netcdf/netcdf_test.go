@@ -0,0 +1,76 @@
+package netcdf
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/mumax/3/data"
+)
+
+func TestRecords(t *testing.T) {
+	s := data.NewSlice(3, [3]int{3, 2, 1})
+	for c := range s.Host() {
+		for i := range s.Host()[c] {
+			s.Host()[c][i] = float32(10*c + i)
+		}
+	}
+	info := data.Meta{Name: "m", CellSize: [3]float64{1e-9, 2e-9, 3e-9}, Origin: [3]float64{-1e-9, 0, 0}}
+	f := Header(info, 3, s.Size(), "title", "m")
+	if string(f[:4]) != "CDF\x02" {
+		t.Fatalf("bad magic: %q", f[:4])
+	}
+	coords := 8 * (3 + 2 + 1) // x, y, z, included in the header
+	h := len(f) - coords
+	f = append(f, Record(1e-12, s)...)
+	f = append(f, Record(2e-12, s)...)
+	p, pos := NumRecs(2)
+	copy(f[pos:], p)
+
+	if n := binary.BigEndian.Uint32(f[4:]); n != 2 {
+		t.Errorf("numrecs: have %v, want 2", n)
+	}
+	// coordinates after the header, then records of time and m
+	rec := 8 + 4*s.Len()*3
+	if len(f) != h+coords+2*rec {
+		t.Fatalf("file size: have %v, want %v", len(f), h+coords+2*rec)
+	}
+	if x := math.Float64frombits(binary.BigEndian.Uint64(f[h+8:])); math.Abs(x-0.5e-9) > 1e-20 {
+		t.Errorf("x[1]: have %v, want 0.5e-9", x)
+	}
+	r := h + coords + rec
+	if tm := math.Float64frombits(binary.BigEndian.Uint64(f[r:])); tm != 2e-12 {
+		t.Errorf("time[1]: have %v, want 2e-12", tm)
+	}
+	if v := math.Float32frombits(binary.BigEndian.Uint32(f[len(f)-4:])); v != 25 {
+		t.Errorf("last value: have %v, want 25", v)
+	}
+}
+
+func TestReadLayout(t *testing.T) {
+	// data.Slice has at most 3 components, but headers are written for any number
+	for _, ncomp := range []int{1, 3, 6, 9} {
+		size := [3]int{4, 3, 2}
+		info := data.Meta{Name: "q", Unit: "T", CellSize: [3]float64{1e-9, 1e-9, 1e-9}}
+		f := Header(info, ncomp, size, "title", "q")
+		l, err := ReadLayout(f)
+		if err != nil {
+			t.Fatalf("ncomp %v: %v", ncomp, err)
+		}
+		if l.Records != 0 || l.End != len(f) {
+			t.Errorf("ncomp %v, empty file: have %+v, want 0 records ending at %v", ncomp, l, len(f))
+		}
+		rec := 8 + 4*ncomp*size[0]*size[1]*size[2]
+		f = append(f, make([]byte, 2*rec+10)...) // 2 records and an interrupted write
+		l, err = ReadLayout(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l.Records != 2 || l.RecordSize != rec || l.End != len(f)-10 {
+			t.Errorf("ncomp %v: have %+v, want 2 records of %v bytes ending at %v", ncomp, l, rec, len(f)-10)
+		}
+	}
+	if _, err := ReadLayout([]byte("CDF\x02\x00")); err == nil {
+		t.Error("truncated header: expected error")
+	}
+}
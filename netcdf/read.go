@@ -0,0 +1,129 @@
+package netcdf
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Layout of an existing file, as needed to append to it.
+type Layout struct {
+	Records    int // number of complete records in the file
+	RecordSize int // bytes per record: time and data
+	End        int // file offset after the last complete record
+}
+
+// ReadLayout parses the header of a file written by Header (and Record)
+// and counts its complete records from the file size,
+// so that new records can be appended after a restart.
+func ReadLayout(file []byte) (Layout, error) {
+	r := &reader{p: file}
+	if magic := r.bytes(4); string(magic) != "CDF\x02" {
+		return Layout{}, fmt.Errorf("netcdf: not a 64-bit offset NetCDF file")
+	}
+	r.uint32() // numrecs, may be streaming
+
+	// dimensions: the record dimension has length 0
+	recDim := -1
+	r.tag(ncDimension)
+	ndims := r.uint32()
+	for i := 0; i < ndims && r.err == nil; i++ {
+		r.name()
+		if r.uint32() == 0 {
+			recDim = i
+		}
+	}
+
+	r.tag(ncAttribute)
+	nattrs := r.uint32()
+	for i := 0; i < nattrs && r.err == nil; i++ {
+		r.attr()
+	}
+
+	// variables: records start at the lowest begin of the record variables
+	var l Layout
+	begin := -1
+	r.tag(ncVariable)
+	nvars := r.uint32()
+	for i := 0; i < nvars && r.err == nil; i++ {
+		r.name()
+		nd := r.uint32()
+		isRec := false
+		for j := 0; j < nd; j++ {
+			if d := r.uint32(); j == 0 && d == recDim {
+				isRec = true
+			}
+		}
+		r.tag(ncAttribute)
+		na := r.uint32()
+		for j := 0; j < na && r.err == nil; j++ {
+			r.attr()
+		}
+		r.uint32() // type
+		vsize := r.uint32()
+		b := int(r.uint64())
+		if isRec {
+			l.RecordSize += vsize
+			if begin < 0 || b < begin {
+				begin = b
+			}
+		}
+	}
+	if r.err != nil {
+		return Layout{}, r.err
+	}
+	if begin < 0 || l.RecordSize == 0 || begin > len(file) {
+		return Layout{}, fmt.Errorf("netcdf: no records in file")
+	}
+	l.Records = (len(file) - begin) / l.RecordSize
+	l.End = begin + l.Records*l.RecordSize
+	return l, nil
+}
+
+// big-endian decoding, the first error sticks
+type reader struct {
+	p   []byte
+	pos int
+	err error
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil || n < 0 || r.pos+n > len(r.p) {
+		if r.err == nil {
+			r.err = fmt.Errorf("netcdf: truncated header")
+		}
+		return make([]byte, 8) // enough to decode a number
+	}
+	b := r.p[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *reader) uint32() int    { return int(binary.BigEndian.Uint32(r.bytes(4))) }
+func (r *reader) uint64() uint64 { return binary.BigEndian.Uint64(r.bytes(8)) }
+
+// list tag, or ABSENT (0, 0) for an empty list, which leaves a 0 count to be read
+func (r *reader) tag(want int) {
+	if t := r.uint32(); t != want && t != 0 && r.err == nil {
+		r.err = fmt.Errorf("netcdf: bad header tag %v, want %v", t, want)
+	}
+}
+
+func (r *reader) name() string {
+	n := r.uint32()
+	b := r.bytes((n + 3) / 4 * 4)
+	if r.err != nil {
+		return ""
+	}
+	return string(b[:n])
+}
+
+func (r *reader) attr() {
+	r.name()
+	typ := r.uint32()
+	n := r.uint32()
+	size := map[int]int{1: 1, ncChar: 1, 3: 2, 4: 4, ncFloat: 4, ncDouble: 8}[typ]
+	if size == 0 && r.err == nil {
+		r.err = fmt.Errorf("netcdf: bad attribute type %v", typ)
+	}
+	r.bytes((n*size + 3) / 4 * 4)
+}
@@ -0,0 +1,446 @@
+package oommf
+
+// Reader for a subset of OOMMF MIF 2 problem files.
+// MIF 2 files are Tcl scripts. Supported are the commands
+// Specify, set and Parameter, with $variable substitution
+// and the [expr ...] and [subst ...] commands.
+// Other commands (Destination, Schedule, ...) are skipped and reported.
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// A MIF 2 problem: its Specify blocks, in order.
+type MIF struct {
+	Blocks  []MIFBlock
+	Vars    map[string]string // set and Parameter variables
+	Skipped []string          // unsupported commands
+}
+
+// One Specify block, e.g.: Specify Oxs_UniformExchange:name { A 13e-12 }
+type MIFBlock struct {
+	Type, Name string
+	Keys       []string          // in order of appearance
+	Values     map[string]string // unparsed values, lists are Tcl lists
+}
+
+// Block of given type, e.g. "Oxs_Demag", or nil.
+func (m *MIF) Block(typ string) *MIFBlock {
+	for i := range m.Blocks {
+		if m.Blocks[i].Type == typ {
+			return &m.Blocks[i]
+		}
+	}
+	return nil
+}
+
+// Has reports whether the block has the key.
+func (b *MIFBlock) Has(key string) bool {
+	_, ok := b.Values[key]
+	return ok
+}
+
+// Floats parses the value of key as a (nested) list of numbers, flattened.
+func (b *MIFBlock) Floats(key string) ([]float64, error) {
+	v, ok := b.Values[key]
+	if !ok {
+		return nil, fmt.Errorf("%v: missing %v", b.Type, key)
+	}
+	return MIFFloats(v)
+}
+
+// Float parses the value of key as a single number, or returns def if the key is absent.
+func (b *MIFBlock) Float(key string, def float64) (float64, error) {
+	if !b.Has(key) {
+		return def, nil
+	}
+	f, err := b.Floats(key)
+	if err == nil && len(f) != 1 {
+		err = fmt.Errorf("%v: %v: need one number, have %v", b.Type, key, b.Values[key])
+	}
+	if err != nil {
+		return 0, err
+	}
+	return f[0], nil
+}
+
+// ReadMIF parses a MIF 2 file.
+func ReadMIF(in io.Reader) (*MIF, error) {
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	text := string(src)
+	if !strings.HasPrefix(text, "# MIF 2") {
+		return nil, fmt.Errorf("not a MIF 2 file")
+	}
+	m := &MIF{Vars: map[string]string{}}
+	p := &tclParser{src: text, vars: m.Vars}
+	for {
+		words, err := p.command()
+		if err != nil {
+			return nil, err
+		}
+		if words == nil {
+			return m, nil
+		}
+		if len(words) == 0 {
+			continue
+		}
+		switch words[0] {
+		default:
+			m.Skipped = append(m.Skipped, words[0])
+		case "set":
+			if len(words) != 3 {
+				return nil, fmt.Errorf("set: need 2 arguments, have %v", words[1:])
+			}
+			m.Vars[words[1]] = words[2]
+		case "Parameter":
+			if len(words) != 3 {
+				return nil, fmt.Errorf("Parameter: need 2 arguments, have %v", words[1:])
+			}
+			m.Vars[words[1]] = words[2]
+		case "Specify":
+			if len(words) != 3 {
+				return nil, fmt.Errorf("Specify: need 2 arguments, have %v", words[1:])
+			}
+			b, err := parseBlock(words[1], words[2])
+			if err != nil {
+				return nil, err
+			}
+			m.Blocks = append(m.Blocks, b)
+		}
+	}
+}
+
+func parseBlock(spec, body string) (MIFBlock, error) {
+	b := MIFBlock{Values: map[string]string{}}
+	b.Type = spec
+	if i := strings.Index(spec, ":"); i >= 0 {
+		b.Type, b.Name = spec[:i], spec[i+1:]
+	}
+	words, err := MIFList(body)
+	if err != nil {
+		return b, fmt.Errorf("%v: %v", spec, err)
+	}
+	if len(words)%2 != 0 {
+		return b, fmt.Errorf("%v: need key-value pairs, have: %v", spec, words)
+	}
+	for i := 0; i < len(words); i += 2 {
+		b.Keys = append(b.Keys, words[i])
+		b.Values[words[i]] = words[i+1]
+	}
+	return b, nil
+}
+
+// MIFList splits a Tcl list into its elements, without substitutions.
+func MIFList(s string) ([]string, error) {
+	p := &tclParser{src: s, raw: true}
+	var words []string
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return words, nil
+		}
+		w, err := p.word()
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, w)
+	}
+}
+
+// MIFFloats parses a (nested) Tcl list of numbers, flattened.
+func MIFFloats(s string) ([]float64, error) {
+	words, err := MIFList(s)
+	if err != nil {
+		return nil, err
+	}
+	var f []float64
+	for _, w := range words {
+		if strings.ContainsAny(w, " \t\n") {
+			sub, err := MIFFloats(w)
+			if err != nil {
+				return nil, err
+			}
+			f = append(f, sub...)
+			continue
+		}
+		v, err := strconv.ParseFloat(w, 64)
+		if err != nil {
+			return nil, fmt.Errorf("not a number: %q", w)
+		}
+		f = append(f, v)
+	}
+	return f, nil
+}
+
+// Minimal Tcl parser: commands, words, {braces}, "quotes", $vars and [commands].
+type tclParser struct {
+	src   string
+	pos   int
+	vars  map[string]string
+	raw   bool // no substitutions, list parsing
+	depth int  // nesting of [commands]
+}
+
+func (p *tclParser) eof() bool  { return p.pos >= len(p.src) }
+func (p *tclParser) peek() byte { return p.src[p.pos] }
+
+// skips white space, including newlines if nl.
+func (p *tclParser) skipSpace(nl bool) {
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || (nl && c == '\n'):
+			p.pos++
+		case c == '\\' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '\n':
+			p.pos += 2
+		default:
+			return
+		}
+	}
+}
+
+// next command as a list of words, empty for a blank line or comment, nil at the end.
+func (p *tclParser) command() ([]string, error) {
+	p.skipSpace(true)
+	if p.eof() {
+		return nil, nil
+	}
+	if p.peek() == '#' {
+		for !p.eof() && p.peek() != '\n' {
+			p.pos++
+		}
+		return []string{}, nil
+	}
+	words := []string{}
+	for {
+		p.skipSpace(false)
+		if p.eof() {
+			return words, nil
+		}
+		switch p.peek() {
+		case '\n', ';':
+			p.pos++
+			return words, nil
+		case ']':
+			if p.depth == 0 {
+				return nil, fmt.Errorf("unexpected close-bracket")
+			}
+			return words, nil
+		}
+		w, err := p.word()
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, w)
+	}
+}
+
+func (p *tclParser) word() (string, error) {
+	switch p.peek() {
+	case '{':
+		return p.braced()
+	case '"':
+		p.pos++
+		return p.until(func(c byte) bool { return c == '"' }, true)
+	default:
+		return p.until(func(c byte) bool {
+			return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == ';' || c == ']'
+		}, false)
+	}
+}
+
+// {...} word, verbatim.
+func (p *tclParser) braced() (string, error) {
+	start := p.pos
+	depth := 0
+	for ; !p.eof(); p.pos++ {
+		switch p.peek() {
+		case '\\':
+			p.pos++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				p.pos++
+				return p.src[start+1 : p.pos-1], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("missing close-brace")
+}
+
+// word up to the stop character, with substitutions.
+// The stop character is consumed if closing.
+func (p *tclParser) until(stop func(byte) bool, closing bool) (string, error) {
+	var b bytes.Buffer
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case stop(c):
+			if closing {
+				p.pos++
+			}
+			return b.String(), nil
+		case c == '$' && !p.raw:
+			p.pos++
+			v, err := p.variable()
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(v)
+		case c == '[' && !p.raw:
+			p.pos++
+			v, err := p.eval()
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(v)
+		case c == '\\' && p.pos+1 < len(p.src):
+			b.WriteByte(p.src[p.pos+1])
+			p.pos += 2
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	if closing {
+		return "", fmt.Errorf("missing close-quote")
+	}
+	return b.String(), nil
+}
+
+func (p *tclParser) variable() (string, error) {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			break
+		}
+		p.pos++
+	}
+	name := p.src[start:p.pos]
+	v, ok := p.vars[name]
+	if !ok {
+		return "", fmt.Errorf("undefined variable $%v", name)
+	}
+	return v, nil
+}
+
+// evaluates a bracketed command: expr or subst.
+func (p *tclParser) eval() (string, error) {
+	p.depth++
+	words, err := p.command()
+	p.depth--
+	if err != nil {
+		return "", err
+	}
+	if p.eof() || p.peek() != ']' {
+		return "", fmt.Errorf("missing close-bracket")
+	}
+	p.pos++
+	if len(words) == 0 {
+		return "", nil
+	}
+	switch words[0] {
+	default:
+		return "", fmt.Errorf("unsupported command [%v ...]", words[0])
+	case "expr":
+		sub := &tclParser{src: strings.Join(words[1:], " "), vars: p.vars}
+		s, err := sub.until(func(byte) bool { return false }, false)
+		if err != nil {
+			return "", err
+		}
+		v, err := evalExpr(s)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case "subst":
+		if len(words) != 2 {
+			return "", fmt.Errorf("subst: need 1 argument")
+		}
+		sub := &tclParser{src: words[1], vars: p.vars}
+		return sub.until(func(byte) bool { return false }, false)
+	}
+}
+
+// evaluates an arithmetic expression with Go syntax, which for numbers
+// and the common math functions is the same as Tcl's expr.
+func evalExpr(s string) (float64, error) {
+	e, err := parser.ParseExpr(s)
+	if err != nil {
+		return 0, fmt.Errorf("expr %v: %v", s, err)
+	}
+	return evalNode(e)
+}
+
+var exprFuncs = map[string]func(float64) float64{
+	"sqrt": math.Sqrt, "sin": math.Sin, "cos": math.Cos, "tan": math.Tan,
+	"asin": math.Asin, "acos": math.Acos, "atan": math.Atan,
+	"exp": math.Exp, "log": math.Log, "abs": math.Abs, "double": func(x float64) float64 { return x },
+}
+
+func evalNode(e ast.Expr) (float64, error) {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		return strconv.ParseFloat(e.Value, 64)
+	case *ast.ParenExpr:
+		return evalNode(e.X)
+	case *ast.UnaryExpr:
+		x, err := evalNode(e.X)
+		switch e.Op {
+		case token.SUB:
+			return -x, err
+		case token.ADD:
+			return x, err
+		}
+	case *ast.BinaryExpr:
+		x, err := evalNode(e.X)
+		if err != nil {
+			return 0, err
+		}
+		y, err := evalNode(e.Y)
+		if err != nil {
+			return 0, err
+		}
+		switch e.Op {
+		case token.ADD:
+			return x + y, nil
+		case token.SUB:
+			return x - y, nil
+		case token.MUL:
+			return x * y, nil
+		case token.QUO:
+			return x / y, nil
+		}
+	case *ast.CallExpr:
+		if id, ok := e.Fun.(*ast.Ident); ok && len(e.Args) == 1 {
+			if f, ok := exprFuncs[id.Name]; ok {
+				x, err := evalNode(e.Args[0])
+				return f(x), err
+			}
+		}
+		if id, ok := e.Fun.(*ast.Ident); ok && id.Name == "pow" && len(e.Args) == 2 {
+			x, err := evalNode(e.Args[0])
+			if err != nil {
+				return 0, err
+			}
+			y, err := evalNode(e.Args[1])
+			return math.Pow(x, y), err
+		}
+	}
+	return 0, fmt.Errorf("expr: unsupported: %T", e)
+}
@@ -0,0 +1,169 @@
+package oommf
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// muMAG standard problem 4, as in the OOMMF distribution (shortened).
+const sp4 = `# MIF 2.1
+# MIF Example File: stdprob4.mif
+# Description: Sample problem description for muMAG Standard Problem #4
+
+set pi [expr {4*atan(1.0)}]
+set mu0 [expr {4*$pi*1e-7}]
+
+Parameter stop 0.1 ;# stopping criterion in deg/ns
+
+Specify Oxs_BoxAtlas:atlas {
+  xrange {0 500e-9}
+  yrange {0 125e-9}
+  zrange {0 3e-9}
+}
+
+Specify Oxs_RectangularMesh:mesh {
+  cellsize {2.5e-9 2.5e-9 3e-9}
+  atlas :atlas
+}
+
+Specify Oxs_UniformExchange {
+  A  13e-12
+}
+
+Specify Oxs_Demag {}
+
+Specify Oxs_UZeeman [subst {
+  multiplier [expr {0.001/$mu0}]
+  Hrange {
+     { 0 0 0   50 50 0   1 }
+     { 50 50 0 \
+       -24.6 4.3 0.0   2 }
+  }
+}]
+
+Specify Oxs_RungeKuttaEvolve:evolve {
+  alpha 0.02
+  gamma_G 2.211e5
+}
+
+Specify Oxs_TimeDriver [subst {
+ basename stdprob4
+ evolver :evolve
+ stopping_dm_dt $stop
+ mesh :mesh
+ Ms 8e5
+ m0 { 1 0.25 0.1 }
+}]
+
+Destination archive mmArchive
+Schedule DataTable archive Stage 1
+`
+
+func readSP4(t *testing.T) *MIF {
+	m, err := ReadMIF(strings.NewReader(sp4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMIFBlocks(t *testing.T) {
+	m := readSP4(t)
+	var types []string
+	for _, b := range m.Blocks {
+		types = append(types, b.Type)
+	}
+	want := "Oxs_BoxAtlas Oxs_RectangularMesh Oxs_UniformExchange Oxs_Demag Oxs_UZeeman Oxs_RungeKuttaEvolve Oxs_TimeDriver"
+	if have := strings.Join(types, " "); have != want {
+		t.Fatalf("blocks: have %v, want %v", have, want)
+	}
+	if n := m.Block("Oxs_BoxAtlas").Name; n != "atlas" {
+		t.Errorf("atlas name: have %q", n)
+	}
+	if n := m.Block("Oxs_UniformExchange").Name; n != "" {
+		t.Errorf("exchange name: have %q, want none", n)
+	}
+	if k := m.Block("Oxs_Demag").Keys; len(k) != 0 {
+		t.Errorf("demag keys: have %v, want none", k)
+	}
+	if m.Block("Oxs_MinDriver") != nil {
+		t.Error("found absent block")
+	}
+	keys := strings.Join(m.Block("Oxs_TimeDriver").Keys, " ")
+	if keys != "basename evolver stopping_dm_dt mesh Ms m0" {
+		t.Errorf("driver keys out of order: %v", keys)
+	}
+	if s := strings.Join(m.Skipped, " "); s != "Destination Schedule" {
+		t.Errorf("skipped: have %v, want Destination Schedule", s)
+	}
+}
+
+func TestMIFValues(t *testing.T) {
+	m := readSP4(t)
+
+	x, err := m.Block("Oxs_BoxAtlas").Floats("xrange")
+	if err != nil || len(x) != 2 || x[1] != 500e-9 {
+		t.Errorf("xrange: have %v, %v", x, err)
+	}
+	if v := m.Block("Oxs_RectangularMesh").Values["atlas"]; v != ":atlas" {
+		t.Errorf("atlas reference: have %q", v)
+	}
+
+	// set, [expr] and $var substitution inside [subst {...}]
+	mult, err := m.Block("Oxs_UZeeman").Float("multiplier", 0)
+	if want := 0.001 / (4 * math.Pi * 1e-7); err != nil || math.Abs(mult-want) > 1e-9*want {
+		t.Errorf("multiplier: have %v, %v, want %v", mult, err, want)
+	}
+	stop, err := m.Block("Oxs_TimeDriver").Float("stopping_dm_dt", 0)
+	if err != nil || stop != 0.1 {
+		t.Errorf("Parameter substitution: have %v, %v", stop, err)
+	}
+
+	// nested list with a line continuation, flattened
+	h, err := m.Block("Oxs_UZeeman").Floats("Hrange")
+	want := []float64{0, 0, 0, 50, 50, 0, 1, 50, 50, 0, -24.6, 4.3, 0, 2}
+	if err != nil || len(h) != len(want) {
+		t.Fatalf("Hrange: have %v, %v", h, err)
+	}
+	for i := range h {
+		if h[i] != want[i] {
+			t.Errorf("Hrange[%v]: have %v, want %v", i, h[i], want[i])
+		}
+	}
+
+	ev := m.Block("Oxs_RungeKuttaEvolve")
+	if g, err := ev.Float("gamma_G", 0); err != nil || g != 2.211e5 {
+		t.Errorf("gamma_G: have %v, %v", g, err)
+	}
+	if ev.Has("gamma_LL") {
+		t.Error("has absent gamma_LL")
+	}
+	if g, err := ev.Float("gamma_LL", 1); err != nil || g != 1 {
+		t.Errorf("default: have %v, %v", g, err)
+	}
+	if _, err := m.Block("Oxs_TimeDriver").Float("m0", 0); err == nil {
+		t.Error("Float of a list: expected error")
+	}
+	if _, err := m.Block("Oxs_TimeDriver").Floats("basename"); err == nil {
+		t.Error("Floats of a name: expected error")
+	}
+	if _, err := ev.Floats("beta"); err == nil {
+		t.Error("missing key: expected error")
+	}
+}
+
+func TestMIFErrors(t *testing.T) {
+	bad := map[string]string{
+		"header":        "# MIF 1.1\nSpecify Oxs_Demag {}\n",
+		"undefined var": "# MIF 2.1\nSpecify Oxs_UZeeman [subst {multiplier $mu0}]\n",
+		"odd block":     "# MIF 2.1\nSpecify Oxs_UniformExchange { A }\n",
+		"close-brace":   "# MIF 2.1\nSpecify Oxs_Demag {\n",
+		"set":           "# MIF 2.1\nset pi\n",
+	}
+	for name, src := range bad {
+		if _, err := ReadMIF(strings.NewReader(src)); err == nil {
+			t.Errorf("%v: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package oommf
+
+import (
+	"os"
+	"testing"
+)
+
+// mumax2 OMF output is read in the usual XYZ order
+// and holds the same field as ../dump/testdata/mumax2.dump.
+func TestReadMumax2OMF(t *testing.T) {
+	f, err := os.Open("testdata/mumax2.omf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s, info, err := Read(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Size() != [3]int{3, 2, 1} || info.CellSize != [3]float64{5e-9, 4e-9, 3e-9} {
+		t.Fatalf("have size %v, cell %v", s.Size(), info.CellSize)
+	}
+	m := s.Host()
+	for i := 0; i < s.Len(); i++ {
+		want := [3]float32{0.1 * float32(i+1), 0.2, -0.05 * float32(i)}
+		for c := range want {
+			if d := m[c][i] - want[c]; d > 1e-6 || d < -1e-6 {
+				t.Errorf("cell %v component %v: have %v, want %v", i, c, m[c][i], want[c])
+			}
+		}
+	}
+}
@@ -42,7 +42,7 @@ func Read(in io.Reader) (s *data.Slice, meta data.Meta, err error) {
 		readOVF2DataBinary8(in, data_)
 	}
 
-	return data_, data.Meta{Name: info.Title, Time: info.TotalTime, Unit: info.ValueUnit, CellSize: info.StepSize}, nil
+	return data_, data.Meta{Name: info.Title, Time: info.TotalTime, Unit: info.ValueUnit, CellSize: info.StepSize, MeshUnit: info.MeshUnit, Origin: info.Origin}, nil
 }
 
 func ReadFile(fname string) (*data.Slice, data.Meta, error) {
@@ -77,6 +77,7 @@ type Info struct {
 	SizeofFloat     int // 4/8
 	StepSize        [3]float64
 	MeshUnit        string
+	Origin          [3]float64 // lower corner of the mesh (xmin, ymin, zmin)
 }
 
 // Parses the header part of the OVF1/OVF2 file
@@ -103,12 +104,15 @@ func readHeader(in io.Reader) *Info {
 		default:
 			panic("Unknown key: " + key)
 			// ignored
-		case "oommf", "segment count", "begin", "meshtype", "xbase", "ybase", "zbase", "xmin", "ymin", "zmin", "xmax", "ymax", "zmax", "valuerangeminmag", "valuerangemaxmag", "end": // ignored (OVF1)
+		case "oommf", "segment count", "begin", "meshtype", "xbase", "ybase", "zbase", "xmax", "ymax", "zmax", "valuerangeminmag", "valuerangemaxmag", "end": // ignored (OVF1)
 		case "", "valuelabels": // ignored (OVF2)
 		case "title":
 			info.Title = value
-		case "valueunits":
+		case "valueunits", "valueunit":
 			info.ValueUnit = strings.Split(value, " ")[0] // take unit of first component, we don't support per-component units
+			if info.ValueUnit == "1" {
+				info.ValueUnit = "" // dimensionless
+			}
 		case "valuedim":
 			info.NComp = atoi(value)
 		case "xnodes":
@@ -123,9 +127,15 @@ func readHeader(in io.Reader) *Info {
 			info.StepSize[Y] = atof(value)
 		case "zstepsize":
 			info.StepSize[Z] = atof(value)
+		case "xmin":
+			info.Origin[X] = atof(value)
+		case "ymin":
+			info.Origin[Y] = atof(value)
+		case "zmin":
+			info.Origin[Z] = atof(value)
 		case "valuemultiplier":
-		case "valueunit":
 		case "meshunit":
+			info.MeshUnit = value
 		// desc tags: parse further and add to metadata table
 		case "desc":
 			strs := strings.SplitN(value, ":", 2)
@@ -241,6 +251,14 @@ func hdr(out io.Writer, key string, value ...interface{}) {
 	util.FatalErr(err)
 }
 
+// unit of the mesh coordinates, m unless specified otherwise
+func meshUnit(meta data.Meta) string {
+	if meta.MeshUnit == "" {
+		return "m"
+	}
+	return meta.MeshUnit
+}
+
 func dsc(out io.Writer, k, v interface{}) {
 	hdr(out, "Desc", k, ": ", v)
 }
@@ -0,0 +1,64 @@
+package oommf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mumax/3/data"
+)
+
+// Writing and reading back keeps the units, the origin and the component labels.
+func TestRoundTrip(t *testing.T) {
+	s := data.NewSlice(3, [3]int{3, 2, 1})
+	for c := range s.Host() {
+		for i := range s.Host()[c] {
+			s.Host()[c][i] = float32(10*c + i)
+		}
+	}
+	meta := data.Meta{Name: "m", Unit: "T", Time: 1e-9, CellSize: [3]float64{1e-9, 2e-9, 3e-9},
+		MeshUnit: "m", Origin: [3]float64{-5e-9, 1e-9, 2e-9}}
+
+	tests := []struct {
+		name   string
+		write  func(*bytes.Buffer)
+		labels string
+	}{
+		{"OVF1 text", func(b *bytes.Buffer) { WriteOVF1(b, s, meta, "text") }, "Value labels :  m_x m_y m_z"},
+		{"OVF2 text", func(b *bytes.Buffer) { WriteOVF2(b, s, meta, "text") }, "valuelabels: m_x m_y m_z"},
+		{"OVF2 binary", func(b *bytes.Buffer) { WriteOVF2(b, s, meta, "binary 4") }, "valuelabels: m_x m_y m_z"},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		test.write(&buf)
+		if !strings.Contains(buf.String(), test.labels) {
+			t.Errorf("%v: no component labels %q in header", test.name, test.labels)
+		}
+
+		s2, meta2, err := Read(&buf)
+		if err != nil {
+			t.Fatal(test.name, err)
+		}
+		if meta2.Name != meta.Name || meta2.Unit != meta.Unit || meta2.MeshUnit != meta.MeshUnit ||
+			meta2.Time != meta.Time || meta2.CellSize != meta.CellSize || meta2.Origin != meta.Origin {
+			t.Errorf("%v: meta: have %+v, want %+v", test.name, meta2, meta)
+		}
+		if s2.Size() != s.Size() || s2.Host()[2][5] != s.Host()[2][5] {
+			t.Errorf("%v: data: have %v", test.name, s2.Host())
+		}
+	}
+}
+
+// A dimensionless quantity is written with unit "1" and read back without unit.
+func TestDimensionless(t *testing.T) {
+	s := data.NewSlice(1, [3]int{2, 1, 1})
+	var buf bytes.Buffer
+	WriteOVF2(&buf, s, data.Meta{Name: "regions", CellSize: [3]float64{1, 1, 1}}, "text")
+	if !strings.Contains(buf.String(), "valueunits: 1\n") {
+		t.Error("no valueunits 1 in header")
+	}
+	_, meta, err := Read(&buf)
+	if err != nil || meta.Unit != "" || meta.MeshUnit != "m" {
+		t.Errorf("have unit %q, mesh unit %q, %v", meta.Unit, meta.MeshUnit, err)
+	}
+}
@@ -45,21 +45,23 @@ func writeOVF1Header(out io.Writer, q *data.Slice, meta data.Meta) {
 	hdr(out, "Begin", "Header")
 
 	dsc(out, "Time (s)", meta.Time)
+	dsc(out, "Value labels", strings.Join(meta.Labels(q.NComp()), " "))
 	hdr(out, "Title", meta.Name)
 	hdr(out, "meshtype", "rectangular")
-	hdr(out, "meshunit", "m")
-	hdr(out, "xbase", cellsize[X]/2)
-	hdr(out, "ybase", cellsize[Y]/2)
-	hdr(out, "zbase", cellsize[Z]/2)
+	hdr(out, "meshunit", meshUnit(meta))
+	origin := meta.Origin
+	hdr(out, "xbase", origin[X]+cellsize[X]/2)
+	hdr(out, "ybase", origin[Y]+cellsize[Y]/2)
+	hdr(out, "zbase", origin[Z]+cellsize[Z]/2)
 	hdr(out, "xstepsize", cellsize[X])
 	hdr(out, "ystepsize", cellsize[Y])
 	hdr(out, "zstepsize", cellsize[Z])
-	hdr(out, "xmin", 0)
-	hdr(out, "ymin", 0)
-	hdr(out, "zmin", 0)
-	hdr(out, "xmax", cellsize[X]*float64(gridsize[X]))
-	hdr(out, "ymax", cellsize[Y]*float64(gridsize[Y]))
-	hdr(out, "zmax", cellsize[Z]*float64(gridsize[Z]))
+	hdr(out, "xmin", origin[X])
+	hdr(out, "ymin", origin[Y])
+	hdr(out, "zmin", origin[Z])
+	hdr(out, "xmax", origin[X]+cellsize[X]*float64(gridsize[X]))
+	hdr(out, "ymax", origin[Y]+cellsize[Y]*float64(gridsize[Y]))
+	hdr(out, "zmax", origin[Z]+cellsize[Z]*float64(gridsize[Z]))
 	hdr(out, "xnodes", gridsize[X])
 	hdr(out, "ynodes", gridsize[Y])
 	hdr(out, "znodes", gridsize[Z])
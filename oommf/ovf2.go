@@ -26,44 +26,40 @@ func writeOVF2Header(out io.Writer, q *data.Slice, meta data.Meta) {
 
 	hdr(out, "Title", meta.Name)
 	hdr(out, "meshtype", "rectangular")
-	hdr(out, "meshunit", "m")
+	hdr(out, "meshunit", meshUnit(meta))
 
-	hdr(out, "xmin", 0)
-	hdr(out, "ymin", 0)
-	hdr(out, "zmin", 0)
+	origin := meta.Origin
+	hdr(out, "xmin", origin[X])
+	hdr(out, "ymin", origin[Y])
+	hdr(out, "zmin", origin[Z])
 
-	hdr(out, "xmax", cellsize[X]*float64(gridsize[X]))
-	hdr(out, "ymax", cellsize[Y]*float64(gridsize[Y]))
-	hdr(out, "zmax", cellsize[Z]*float64(gridsize[Z]))
+	hdr(out, "xmax", origin[X]+cellsize[X]*float64(gridsize[X]))
+	hdr(out, "ymax", origin[Y]+cellsize[Y]*float64(gridsize[Y]))
+	hdr(out, "zmax", origin[Z]+cellsize[Z]*float64(gridsize[Z]))
 
-	name := meta.Name
 	var labels []interface{}
-	if q.NComp() == 1 {
-		labels = []interface{}{name}
-	} else {
-		for i := 0; i < q.NComp(); i++ {
-			labels = append(labels, name+"_"+string('x'+i))
-		}
+	for _, l := range meta.Labels(q.NComp()) {
+		labels = append(labels, l)
 	}
 	hdr(out, "valuedim", q.NComp())
-	hdr(out, "valuelabels", labels...) // TODO
+	hdr(out, "valuelabels", labels...)
 	unit := meta.Unit
 	if unit == "" {
 		unit = "1"
 	}
-	if q.NComp() == 1 {
-		hdr(out, "valueunits", unit)
-	} else {
-		hdr(out, "valueunits", unit, unit, unit)
+	var units []interface{}
+	for range labels {
+		units = append(units, unit)
 	}
+	hdr(out, "valueunits", units...)
 
 	// We don't really have stages
 	//fmt.Fprintln(out, "# Desc: Stage simulation time: ", meta.TimeStep, " s") // TODO
 	hdr(out, "Desc", "Total simulation time: ", meta.Time, " s")
 
-	hdr(out, "xbase", cellsize[X]/2)
-	hdr(out, "ybase", cellsize[Y]/2)
-	hdr(out, "zbase", cellsize[Z]/2)
+	hdr(out, "xbase", origin[X]+cellsize[X]/2)
+	hdr(out, "ybase", origin[Y]+cellsize[Y]/2)
+	hdr(out, "zbase", origin[Z]+cellsize[Z]/2)
 	hdr(out, "xnodes", gridsize[X])
 	hdr(out, "ynodes", gridsize[Y])
 	hdr(out, "znodes", gridsize[Z])
@@ -0,0 +1,65 @@
+//+build ignore
+
+/*
+Analysis plugin running concurrently on saved frames, writing its own table.
+*/
+
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mumax/3/data"
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+// average mx of each frame
+type mxAverage struct{}
+
+func (mxAverage) Columns() []string { return []string{"mx ()"} }
+
+func (mxAverage) Analyze(s *data.Slice, info data.Meta) []float64 {
+	sum := 0.0
+	for _, v := range s.Host()[X] {
+		sum += float64(v)
+	}
+	return []float64{sum / float64(s.Len())}
+}
+
+func main() {
+
+	defer InitAndClose()()
+
+	AddAnalysis("mxavg", "m", mxAverage{})
+	OutputFiles = false
+
+	Eval(`
+		SetGridSize(32, 32, 1)
+		SetCellSize(4e-9, 4e-9, 4e-9)
+		Msat = 800e3
+		Aex = 13e-12
+		Alpha = 0.5
+		M = Uniform(1, 1, 0)
+		AutoSave(m, 10e-12)
+		Run(50e-12)
+		Save(m)
+		Flush()
+	`)
+
+	in, err := httpfs.Read(OD() + "mxavg.txt")
+	util.FatalErr(err)
+	lines := strings.Split(strings.TrimSpace(string(in)), "\n")
+	if len(lines) < 6 {
+		util.Fatal("expected at least 5 rows, have ", len(lines)-1)
+	}
+	last := strings.Fields(lines[len(lines)-1])
+	mx, err := strconv.ParseFloat(last[1], 64)
+	util.FatalErr(err)
+	if d := math.Abs(mx - M.Average()[X]); d > 1e-5 {
+		util.Fatal("last row mx differs from m: ", d)
+	}
+}
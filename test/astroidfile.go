@@ -0,0 +1,55 @@
+//+build ignore
+
+/*
+Astroid of a Stoner-Wohlfarth particle: also the end points, where the field
+is along the easy and hard axis, give a finite switching field.
+*/
+
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	Eval(`
+		SetGridSize(1, 1, 1)
+		SetCellSize(5e-9, 5e-9, 5e-9)
+		Msat  = 1e6
+		Aex   = 10e-12
+		Ku1   = 1e5
+		anisU = vector(1, 0, 0)
+		m     = uniform(-1, 0, 0)
+		Astroid(vector(1, 0, 0), vector(0, 1, 0), 0.3, 3, 300)
+	`)
+
+	in, err := httpfs.Read(OD() + "astroid.txt")
+	util.FatalErr(err)
+	lines := strings.Split(strings.TrimSpace(string(in)), "\n")[1:]
+	if len(lines) != 3 {
+		util.Fatal("expected 3 angles, have ", len(lines))
+	}
+	for _, l := range lines {
+		f := strings.Fields(l)
+		theta, err := strconv.ParseFloat(f[0], 64)
+		util.FatalErr(err)
+		Bsw, err := strconv.ParseFloat(f[1], 64)
+		util.FatalErr(err)
+		if math.IsNaN(Bsw) {
+			util.Fatal("no switching at ", theta, " deg")
+		}
+		// Bk = 0.2 T
+		s, c := math.Sincos(theta * math.Pi / 180)
+		want := 0.2 / math.Pow(math.Pow(c, 2./3.)+math.Pow(s, 2./3.), 1.5)
+		Expect("Bsw", Bsw, want, 2e-3)
+	}
+}
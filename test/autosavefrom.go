@@ -0,0 +1,54 @@
+//+build ignore
+
+/*
+AutoSaveFrom with a phase offset, and ExactOutput saving exactly on time.
+*/
+
+package main
+
+import (
+	"math"
+
+	"github.com/mumax/3/data"
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/util"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	times := make(map[string][]float64) // save times per quantity
+	AddOutputConsumer(func(fname string, s *data.Slice, info data.Meta) {
+		times[info.Name] = append(times[info.Name], info.Time)
+	})
+	OutputFiles = false
+
+	Eval(`
+		SetGridSize(32, 32, 1)
+		SetCellSize(4e-9, 4e-9, 4e-9)
+		Msat = 800e3
+		Aex = 13e-12
+		Alpha = 0.02
+		M = Uniform(1, 1, 0)
+		ExactOutput = true
+		AutoSave(m, 10e-12)
+		AutoSaveFrom(B_demag, 20e-12, 5e-12)
+		Run(50e-12)
+	`)
+	Eval("Flush()")
+
+	check := func(name string, want ...float64) {
+		have := times[name]
+		if len(have) != len(want) {
+			util.Fatal(name, ": expected saves at ", want, ", have ", have)
+		}
+		for i := range want {
+			if math.Abs(have[i]-want[i]) > 1e-20 {
+				util.Fatal(name, ": expected saves at ", want, ", have ", have)
+			}
+		}
+	}
+	check("m", 0, 10e-12, 20e-12, 30e-12, 40e-12, 50e-12)
+	check("B_demag", 5e-12, 25e-12, 45e-12)
+}
@@ -0,0 +1,59 @@
+//+build ignore
+
+/*
+Bloch point detection on a hedgehog (index +1) and an anti-hedgehog (index -1).
+*/
+
+package main
+
+import (
+	"github.com/mumax/3/data"
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/util"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	Eval(`
+		SetGridSize(8, 8, 8)
+		SetCellSize(1e-9, 1e-9, 1e-9)
+		Msat = 800e3
+		Aex = 13e-12
+	`)
+
+	for _, sign := range []float64{1, -1} {
+		setHedgehog(sign)
+		if n := Ext_NBlochPoints.Get(); n != 1 {
+			util.Fatal("found ", n, " Bloch points, expected 1")
+		}
+		if n := BlochPoints(); n != 1 {
+			util.Fatal("BlochPoints: ", n, ", expected 1")
+		}
+	}
+
+	Eval(`m = uniform(1, 0, 0)`)
+	if n := Ext_NBlochPoints.Get(); n != 0 {
+		util.Fatal("found ", n, " Bloch points in uniform state, expected 0")
+	}
+}
+
+// m = ±r/|r| around the center of the mesh
+func setHedgehog(sign float64) {
+	n := Mesh().Size()
+	m := data.NewSlice(3, n)
+	v := m.Vectors()
+	for iz := 0; iz < n[Z]; iz++ {
+		for iy := 0; iy < n[Y]; iy++ {
+			for ix := 0; ix < n[X]; ix++ {
+				r := Index2Coord(ix, iy, iz)
+				r = r.Mul(sign / r.Len())
+				for c := 0; c < 3; c++ {
+					v[c][iz][iy][ix] = float32(r[c])
+				}
+			}
+		}
+	}
+	M.SetArray(m)
+}
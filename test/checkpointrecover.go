@@ -0,0 +1,42 @@
+//+build ignore
+
+/*
+-recover sets m and t from the checkpoint in the script's first Run, Steps or RunWhile,
+not in a Relax before it.
+*/
+
+package main
+
+import (
+	. "github.com/mumax/3/engine"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	Eval(`
+		SetGridSize(64, 32, 1)
+		SetCellSize(4e-9, 4e-9, 4e-9)
+		Msat  = 800e3
+		Aex   = 13e-12
+		alpha = 1
+		m     = uniform(1, 0.1, 0)
+		AutoCheckpoint(10e-12)
+		run(55e-12)
+		Flush()
+	`)
+
+	// as after -failover: the script runs again from the start
+	*Flag_recover = CheckpointFile()
+	Eval(`
+		t = 0
+		m = uniform(1, 0.1, 0)
+		relax()
+	`)
+	Expect("t after relax", Time, 0, 0)
+
+	// last checkpoint between 50 and 55 ps
+	Eval(`steps(1)`)
+	Expect("t recovered by steps", Time, 52.5e-12, 3e-12)
+}
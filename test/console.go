@@ -0,0 +1,87 @@
+//+build ignore
+
+/*
+TCP script console: rejects connections without the token, evaluates code with it.
+*/
+
+package main
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	Eval(`
+		SetGridSize(16, 16, 1)
+		SetCellSize(4e-9, 4e-9, 4e-9)
+		Msat  = 800e3
+		Aex   = 13e-12
+		alpha = 1
+		m     = uniform(1, 0, 0)
+	`)
+
+	GoServeConsole(":0")
+	addr, err := httpfs.Read(OD() + "console")
+	util.FatalErr(err)
+	token, err := httpfs.Read(OD() + "console_token")
+	util.FatalErr(err)
+	if fi, err := os.Stat(OD() + "console_token"); err != nil || fi.Mode().Perm() != 0600 {
+		util.Fatal("console_token should be readable only by the user: ", fi.Mode(), err)
+	}
+	if host, _, _ := net.SplitHostPort(string(addr)); !net.ParseIP(host).IsLoopback() {
+		util.Fatal("console not on localhost: ", string(addr))
+	}
+
+	done := make(chan string)
+	go func() {
+		// wrong token
+		conn, err := net.Dial("tcp", string(addr))
+		util.FatalErr(err)
+		in := bufio.NewReader(conn)
+		conn.Write([]byte("wrong\n"))
+		in.ReadString(' ') // token prompt
+		reply, _ := in.ReadString('\n')
+		conn.Close()
+		if strings.TrimSpace(reply) != "invalid token" {
+			util.Fatal("wrong token accepted: ", reply)
+		}
+
+		// right token
+		conn, err = net.Dial("tcp", string(addr))
+		util.FatalErr(err)
+		defer conn.Close()
+		in = bufio.NewReader(conn)
+		conn.Write(token)
+		conn.Write([]byte("Msat = 600e3\nMsat.average()\nexit\n"))
+		in.ReadString(' ') // token prompt
+		in.ReadString(' ') // prompt
+		in.ReadString(' ') // prompt
+		reply, _ = in.ReadString('\n')
+		done <- strings.TrimSpace(reply)
+	}()
+
+	var reply string
+	RunWhile(func() bool {
+		select {
+		case reply = <-done:
+			return false
+		default:
+			return true
+		}
+	})
+
+	Msat, err := strconv.ParseFloat(reply, 64)
+	util.FatalErr(err)
+	Expect("Msat via console", Msat, 600e3, 0)
+}
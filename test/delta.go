@@ -0,0 +1,62 @@
+//+build ignore
+
+/*
+Delta-encoded output: the frames read back equal the saved magnetization.
+*/
+
+package main
+
+import (
+	"io"
+
+	"github.com/mumax/3/delta"
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	Eval(`
+		SetGridSize(32, 32, 1)
+		SetCellSize(4e-9, 4e-9, 4e-9)
+		Msat = 800e3
+		Aex = 13e-12
+		Alpha = 0.5
+		M = Uniform(1, 1, 0)
+		OutputFormat = DELTA
+		DeltaKeyframe = 3
+		AutoSave(m, 10e-12)
+		Run(50e-12)
+		Save(m)
+		Flush()
+	`)
+
+	in, err := httpfs.Open(OD() + "m.delta")
+	util.FatalErr(err)
+	defer in.Close()
+	r, err := delta.NewReader(in)
+	util.FatalErr(err)
+	n := 0
+	var last [3]float32
+	for {
+		s, info, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		util.FatalErr(err)
+		if info.Name != "m" {
+			util.Fatal("bad name: ", info.Name)
+		}
+		last = [3]float32{float32(s.Get(X, 7, 5, 0)), float32(s.Get(Y, 7, 5, 0)), float32(s.Get(Z, 7, 5, 0))}
+		n++
+	}
+	if n < 6 {
+		util.Fatal("expected at least 6 frames, have ", n)
+	}
+	if have := M.GetCell(7, 5, 0); float32(have[X]) != last[X] || float32(have[Y]) != last[Y] || float32(have[Z]) != last[Z] {
+		util.Fatal("last frame ", last, " differs from m ", have)
+	}
+}
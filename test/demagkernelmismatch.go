@@ -0,0 +1,58 @@
+//+build ignore
+
+/*
+LoadDemagKernel refuses a kernel made for another cell size or PBC,
+and keeps using the current kernel.
+*/
+
+package main
+
+import (
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/util"
+	"strings"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	Eval(`
+		SetGridSize(32, 16, 2)
+		SetCellSize(4e-9, 4e-9, 2e-9)
+		Msat = 800e3
+		m    = uniform(1, 0.3, 0.1)
+		SaveDemagKernel("kernel_")
+	`)
+	E0 := Eval1Line(`E_demag.get()`).(float64)
+	prefix := OD() + "kernel_"
+
+	expectErr(func() { Eval(`SetCellSize(4e-9, 4e-9, 3e-9)`); LoadDemagKernel(prefix) }, "cell size")
+	expectErr(func() { Eval(`SetCellSize(4e-9, 4e-9, 2e-9); SetPBC(1, 0, 0)`); LoadDemagKernel(prefix) }, "PBC")
+
+	// matching mesh again: the kernel is accepted
+	Eval(`SetPBC(0, 0, 0)`)
+	LoadDemagKernel(prefix)
+	Expect("E", Eval1Line(`E_demag.get()`).(float64), E0, 1e-6*abs(E0))
+}
+
+// f should raise a UserErr mentioning what
+func expectErr(f func(), what string) {
+	defer func() {
+		err, ok := recover().(UserErr)
+		if !ok {
+			util.Fatal("LoadDemagKernel: expected UserErr for mismatched ", what, ", got ", err)
+		}
+		if !strings.Contains(err.Error(), what) {
+			util.Fatal("LoadDemagKernel: error should mention ", what, ": ", err)
+		}
+	}()
+	f()
+}
+
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,49 @@
+//+build ignore
+
+/*
+The multigrid demag field agrees cell by cell with the FFT convolution,
+also for a geometry with vacuum cells and several layers.
+*/
+
+package main
+
+import (
+	"math"
+
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/mag"
+	"github.com/mumax/3/util"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	const (
+		Ms  = 800e3
+		tol = 0.02 // max field error relative to µ0Msat
+	)
+
+	Eval(`
+		SetGridSize(24, 16, 2)
+		SetCellSize(2e-9, 2e-9, 2e-9)
+		SetGeom(ellipse(40e-9, 28e-9))
+		Msat = 800e3
+		Aex  = 13e-12
+		m    = vortex(1, 1)
+	`)
+	Bfft := B_demag.HostCopy().Host()
+	Eval(`DemagMultigrid = true`)
+	Bmg := B_demag.HostCopy().Host()
+
+	maxErr := 0.0
+	for c := range Bfft {
+		for i := range Bfft[c] {
+			maxErr = math.Max(maxErr, math.Abs(float64(Bmg[c][i]-Bfft[c][i])))
+		}
+	}
+	if maxErr/(mag.Mu0*Ms) > tol {
+		util.Fatal("multigrid field differs from FFT by ", maxErr/(mag.Mu0*Ms), " µ0Msat, want < ", tol)
+	}
+	LogOut("multigrid field error: ", maxErr/(mag.Mu0*Ms), " µ0Msat")
+}
@@ -0,0 +1,66 @@
+//+build ignore
+
+/*
+ECC media switching fields are reported per grain, and exchange-decoupled
+grains with a soft layer switch below the Stoner-Wohlfarth field 2Ku1/Msat
+of their hard layer.
+*/
+
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	Eval(`
+		SetGridSize(32, 32, 2)
+		SetCellSize(2e-9, 2e-9, 3e-9)
+		N := 10
+		ECCMedia(16e-9, N, 1, 3e-9, 0.2, 0)
+		Aex = 10e-12
+		for i:=0; i<N; i++{
+			Msat.SetRegion(i, 500e3)
+			Ku1.SetRegion(i, 4e5)
+			Msat.SetRegion(N+i, 800e3)
+			Ku1.SetRegion(N+i, 1e4)
+		}
+		AnisU = vector(0, 0, 1)
+		m = uniform(0, 0, 1)
+		ECCSwitchingFields(vector(0.01, 0, -1), 2, 20)
+	`)
+
+	const (
+		BSW  = 2 * 4e5 / 500e3 // Stoner-Wohlfarth field of the hard layer (T)
+		step = 2. / 20
+	)
+
+	in, err := httpfs.Read(OD() + "ecc_switching.txt")
+	util.FatalErr(err)
+	lines := strings.Split(strings.TrimSpace(string(in)), "\n")[1:]
+	regions := make(map[string]bool)
+	for _, l := range lines {
+		f := strings.Fields(l)
+		if len(f) != 5 {
+			util.Fatal("bad line: ", l)
+		}
+		regions[f[3]] = true
+		Bsw, err := strconv.ParseFloat(f[4], 64)
+		util.FatalErr(err)
+		if math.IsNaN(Bsw) || Bsw <= 0 || Bsw > BSW+step {
+			util.Fatal("grain ", f[0], ": switching field ", Bsw, " T, want in (0, ", BSW+step, "]")
+		}
+	}
+	if len(lines) <= len(regions) {
+		util.Fatal("expected more grains than regions, have ", len(lines), " grains in ", len(regions), " regions")
+	}
+}
@@ -0,0 +1,48 @@
+//+build ignore
+
+/*
+GetAsArray, GetVector and GetScalar return host copies indexed as [comp][z][y][x].
+*/
+
+package main
+
+import (
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/util"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	Eval(`
+		SetGridSize(8, 4, 2)
+		SetCellSize(1e-9, 1e-9, 1e-9)
+		Msat = 800e3
+		DefRegion(1, xrange(0, inf))
+		Msat.SetRegion(1, 600e3)
+		m = uniform(0, 0, 1)
+		m.SetCell(5, 2, 1, vector(1, 0, 0))
+		B_ext = vector(0.1, 0, -0.2)
+	`)
+
+	m := GetVector("m")
+	Expect("mx", float64(m[0][1][2][5]), 1, 0)
+	Expect("mz", float64(m[2][1][2][5]), 0, 0)
+	Expect("mz", float64(m[2][0][2][5]), 1, 0)
+
+	Ms := GetScalar("Msat")
+	Expect("Msat", float64(Ms[0][0][0]), 800e3, 0)
+	Expect("Msat", float64(Ms[1][3][7]), 600e3, 0)
+
+	B := GetAsArray("B_ext")
+	if len(B) != 3 || len(B[0]) != 2 || len(B[0][0]) != 4 || len(B[0][0][0]) != 8 {
+		util.Fatal("GetAsArray: bad size: ", len(B), len(B[0]), len(B[0][0]), len(B[0][0][0]))
+	}
+	Expect("Bx", float64(B[0][1][3][7]), 0.1, 1e-7)
+	Expect("Bz", float64(B[2][0][0][0]), -0.2, 1e-7)
+
+	regions := GetScalar("regions")
+	Expect("region", float64(regions[0][0][0]), 0, 0)
+	Expect("region", float64(regions[0][0][4]), 1, 0)
+}
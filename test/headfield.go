@@ -0,0 +1,55 @@
+//+build ignore
+
+/*
+Moving write head field from a field map.
+*/
+
+package main
+
+import (
+	"math"
+
+	"github.com/mumax/3/data"
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/oommf"
+	"github.com/mumax/3/util"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	// 8 x 8 cell map of 1 T along z
+	head := data.NewSlice(3, [3]int{8, 8, 1})
+	for i := range head.Host()[Z] {
+		head.Host()[Z][i] = 1
+	}
+	f, err := httpfs.Create(OD() + "head.ovf")
+	util.FatalErr(err)
+	oommf.WriteOVF2(f, head, data.Meta{Unit: "T", CellSize: [3]float64{4e-9, 4e-9, 4e-9}}, "binary 4")
+	f.Close()
+
+	Eval(`
+		SetGridSize(64, 8, 1)
+		SetCellSize(4e-9, 4e-9, 4e-9)
+	`)
+	h := HeadField(OD()+"head.ovf", data.Vector{-100e-9, 0, 0}, data.Vector{1000, 0, 0})
+
+	// map covers 8 of the 64 cells along x
+	if B := h.Average()[Z]; math.Abs(B-0.125) > 0.02 {
+		util.Fatal("head field average ", B, ", expected 0.125")
+	}
+
+	// translated on the GPU: same average over the center of the mesh
+	Time = 1e-10
+	if B := h.Average()[Z]; math.Abs(B-0.125) > 0.02 {
+		util.Fatal("head field average ", B, " at ", Time, " s, expected 0.125")
+	}
+
+	// after 1 ns, the head has moved off the mesh
+	Time = 1e-9
+	if B := h.Average()[Z]; B != 0 {
+		util.Fatal("head field average ", B, ", expected 0")
+	}
+}
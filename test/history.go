@@ -0,0 +1,69 @@
+//+build ignore
+
+/*
+Ring buffer of the last steps of m, dumped on demand and when a trigger fires.
+*/
+
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	Eval(`
+		SetGridSize(8, 8, 1)
+		SetCellSize(4e-9, 4e-9, 2e-9)
+		Msat = 800e3
+		Aex = 13e-12
+		alpha = 0.1
+		m = uniform(1, 0, 0.1)
+		B_ext = vector(-0.1, 0, 0)
+
+		RecordHistory(10)
+		Steps(4)
+		DumpHistory()
+		Steps(20)
+		DumpHistory()
+	`)
+	checkFrames(OD()+"history0.txt", 4)
+	checkFrames(OD()+"history1.txt", 10)
+
+	// only one cell, dumped when mx changes sign under the reversed field
+	Eval(`
+		HistoryCell(3, 4, 0)
+		HistoryTrigger(m.comp(0), 0)
+		Run(3e-9)
+	`)
+	if m := M.Average()[X]; m > 0 {
+		util.Fatal("mx did not change sign: ", m)
+	}
+	checkFrames(OD()+"history2.txt", 10)
+}
+
+// checks that the history table has n frames with increasing times and steps
+func checkFrames(fname string, n int) {
+	in, err := httpfs.Read(fname)
+	util.FatalErr(err)
+	lines := strings.Split(strings.TrimSpace(string(in)), "\n")[1:]
+	if len(lines) != n {
+		util.Fatal(fname, ": have ", len(lines), " frames, expected ", n)
+	}
+	prev := -1
+	for _, l := range lines {
+		step, err := strconv.Atoi(strings.Fields(l)[2])
+		util.FatalErr(err)
+		if step <= prev {
+			util.Fatal(fname, ": steps not increasing: ", prev, ", ", step)
+		}
+		prev = step
+	}
+}
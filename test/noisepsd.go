@@ -0,0 +1,42 @@
+//+build ignore
+
+/*
+Random waveforms with a given power spectral density.
+*/
+
+package main
+
+import (
+	"math"
+
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	// white noise: variance S/2dt
+	const S, dt = 2e-12, 1e-11
+	w := PowerLawNoise(S, 0, dt, 1e-7, 1)
+	v2 := 0.
+	const n = 10000
+	for i := 0; i < n; i++ {
+		v2 += math.Pow(w.At(float64(i)*dt), 2)
+	}
+	v2 /= n
+	if want := S / (2 * dt); math.Abs(v2-want) > 0.05*want {
+		util.Fatal("white noise variance ", v2, ", expected ", want)
+	}
+
+	// same spectrum from a file, same seed: same waveform
+	util.FatalErr(httpfs.Put(OD()+"psd.csv", []byte("0, 2e-12\n1e12, 2e-12\n")))
+	w2 := NoiseFromPSD(OD()+"psd.csv", dt, 1e-7, 1)
+	for _, t := range []float64{0, 1e-9, 3.3e-8} {
+		if a, b := w.At(t), w2.At(t); math.Abs(a-b) > 1e-6*math.Abs(a) {
+			util.Fatal("noise from file at t=", t, ": ", b, ", expected ", a)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+//+build ignore
+
+/*
+Receive saved data in memory with an output consumer,
+without writing output files.
+*/
+
+package main
+
+import (
+	"math"
+
+	"github.com/mumax/3/data"
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/util"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	var (
+		frames []float64 // average mx of each saved frame
+		times  []float64
+	)
+	AddOutputConsumer(func(fname string, s *data.Slice, info data.Meta) {
+		if info.Name != "m" {
+			return
+		}
+		mx := s.Host()[X]
+		sum := 0.0
+		for _, v := range mx {
+			sum += float64(v)
+		}
+		frames = append(frames, sum/float64(len(mx)))
+		times = append(times, info.Time)
+	})
+	OutputFiles = false
+
+	Eval(`
+		SetGridSize(32, 32, 1)
+		SetCellSize(4e-9, 4e-9, 4e-9)
+		Msat = 800e3
+		Aex = 13e-12
+		Alpha = 0.5
+		M = Uniform(1, 1, 0)
+		AutoSave(m, 10e-12)
+		Run(50e-12)
+	`)
+	Eval("Flush()")
+	if len(frames) < 5 {
+		util.Fatal("expected at least 5 frames, have ", len(frames))
+	}
+
+	Eval("Save(m)")
+	Eval("Flush()")
+	if d := math.Abs(frames[len(frames)-1] - M.Average()[X]); d > 1e-5 {
+		util.Fatal("last frame mx differs from m: ", d)
+	}
+	if math.Abs(times[1]-10e-12) > 1e-15 {
+		util.Fatal("bad time of second frame: ", times[1])
+	}
+}
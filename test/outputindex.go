@@ -0,0 +1,58 @@
+//+build ignore
+
+/*
+The output index lists every saved file with its time and step.
+*/
+
+package main
+
+import (
+	"encoding/csv"
+	"path"
+	"strconv"
+
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	Eval(`
+		OutputIndex = true
+		SetGridSize(32, 32, 1)
+		SetCellSize(4e-9, 4e-9, 4e-9)
+		Msat = 800e3
+		Aex = 13e-12
+		Alpha = 0.5
+		M = Uniform(1, 1, 0)
+		AutoSave(m, 10e-12)
+		Run(30e-12)
+	`)
+	Eval("Snapshot(m)")
+	Eval("Flush()")
+
+	in, err := httpfs.Open(OD() + "index.csv")
+	util.FatalErr(err)
+	rows, err := csv.NewReader(in).ReadAll()
+	util.FatalErr(err)
+	// header, autosaves at 0, 10, 20 (and maybe 30) ps and the snapshot
+	if len(rows) < 5 {
+		util.Fatal("expected at least 5 rows in index.csv, have ", len(rows), ": ", rows)
+	}
+	if rows[2][0] != "m000001.ovf" || rows[2][3] != "m" {
+		util.Fatal("bad index entry: ", rows[2])
+	}
+	t, _ := strconv.ParseFloat(rows[2][1], 64)
+	if t < 9.99e-12 || t > 10.01e-12 {
+		util.Fatal("bad time in index: ", rows[2])
+	}
+	if step, _ := strconv.Atoi(rows[2][2]); step <= 0 {
+		util.Fatal("bad step in index: ", rows[2])
+	}
+	if last := rows[len(rows)-1]; path.Ext(last[0]) != ".jpg" {
+		util.Fatal("bad snapshot entry: ", last)
+	}
+}
@@ -0,0 +1,57 @@
+//+build ignore
+
+/*
+Reciprocity readback of a single transition in perpendicular media
+peaks at the transition.
+*/
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"strings"
+
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	Eval(`
+		SetGridSize(128, 16, 2)
+		SetCellSize(2e-9, 2e-9, 5e-9)
+		Msat = 500e3
+		m = uniform(0, 0, 1)
+		m.SetInShape(xrange(0, inf), uniform(0, 0, -1))
+		ReadbackKarlqvist(10e-9, 20e-9, 5e-9)
+	`)
+
+	in, err := httpfs.Open(OD() + "readback.txt")
+	util.FatalErr(err)
+	var xpeak, Vpeak, Vmid float64 // Vmid: halfway to the end of the medium, away from the transition
+	sc := bufio.NewScanner(in)
+	for sc.Scan() {
+		if strings.HasPrefix(sc.Text(), "#") {
+			continue
+		}
+		var x, V float64
+		fmt.Sscan(sc.Text(), &x, &V)
+		if math.Abs(V) > math.Abs(Vpeak) {
+			xpeak, Vpeak = x, V
+		}
+		if x > 60e-9 && Vmid == 0 {
+			Vmid = V
+		}
+	}
+	if math.Abs(xpeak) > 4e-9 {
+		util.Fatal("readback peak at ", xpeak, ", expected at the transition")
+	}
+	if math.Abs(Vmid) > 0.1*math.Abs(Vpeak) {
+		util.Fatal("readback away from the transition too large: ", Vmid, " peak: ", Vpeak)
+	}
+}
@@ -0,0 +1,67 @@
+//+build ignore
+
+/*
+A request to /save on the GUI port saves m, while running and while paused.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	Eval(`
+		SetGridSize(16, 16, 1)
+		SetCellSize(4e-9, 4e-9, 4e-9)
+		Msat  = 800e3
+		Aex   = 13e-12
+		alpha = 1
+		m     = uniform(1, 1, 0)
+	`)
+	addr, err := httpfs.Read(OD() + "gui")
+	util.FatalErr(err)
+
+	var done int32
+	reply := make(chan string, 1)
+	request := func() {
+		resp, err := http.Get("http://" + string(addr) + "/save")
+		util.FatalErr(err)
+		body, err := ioutil.ReadAll(resp.Body)
+		util.FatalErr(err)
+		resp.Body.Close()
+		atomic.StoreInt32(&done, 1)
+		reply <- string(body)
+	}
+
+	// paused: the save is run by the loop serving Inject, like RunInteractive
+	go request()
+	(<-Inject)()
+	if r := <-reply; !strings.HasPrefix(r, "saved at t = 0 s") {
+		util.Fatal("paused: bad reply: ", r)
+	}
+	Eval("Flush()")
+	if _, err := httpfs.Read(OD() + "m000000.ovf"); err != nil {
+		util.Fatal("paused: not saved: ", err)
+	}
+
+	// running: saved between time steps
+	atomic.StoreInt32(&done, 0)
+	go request()
+	RunWhile(func() bool { return atomic.LoadInt32(&done) == 0 })
+	<-reply
+	Eval("Flush()")
+	if _, err := httpfs.Read(OD() + "m000001.ovf"); err != nil {
+		util.Fatal("running: not saved: ", err)
+	}
+}
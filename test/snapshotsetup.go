@@ -0,0 +1,64 @@
+//+build ignore
+
+/*
+SnapshotSetup writes images of the geometry, the regions, the exchange coupling
+and of the non-uniform parameters and excitations only.
+*/
+
+package main
+
+import (
+	"bytes"
+	"image/png"
+
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	Eval(`
+		setgridsize(64, 32, 1)
+		setcellsize(4e-9, 4e-9, 3e-9)
+
+		setgeom(ellipse(256e-9, 128e-9))
+		defregion(1, xrange(0, inf))
+		Msat = 800e3
+		Msat.SetRegion(1, 600e3)
+		Aex = 13e-12
+		ext_ScaleExchange(0, 1, 0.5)
+		B_ext.Add(NewSlice(3, 64, 32, 1), sin(1e9*t))
+
+		snapshotformat = "png"
+		SnapshotSetup()
+		Flush()
+	`)
+
+	for _, name := range []string{"geom", "regions", "ExchCoupling", "Msat", "B_ext"} {
+		if _, err := httpfs.Read(OD() + "setup_" + name + ".png"); err != nil {
+			util.Fatal("no image of ", name, ": ", err)
+		}
+	}
+	for _, name := range []string{"Aex", "alpha", "DindCoupling"} { // uniform or unset
+		if _, err := httpfs.Read(OD() + "setup_" + name + ".png"); err == nil {
+			util.Fatal("unexpected image of ", name)
+		}
+	}
+
+	// region 0 on the left is black, region 1 on the right white
+	f, err := httpfs.Read(OD() + "setup_regions.png")
+	util.FatalErr(err)
+	img, err := png.Decode(bytes.NewReader(f))
+	util.FatalErr(err)
+	if b := img.Bounds().Size(); b.X != 64 || b.Y != 32 {
+		util.Fatal("image size ", b, ", want 64x32")
+	}
+	left, _, _, _ := img.At(16, 16).RGBA()
+	right, _, _, _ := img.At(48, 16).RGBA()
+	if left != 0 || right != 0xffff {
+		util.Fatal("regions: have red ", left, " left and ", right, " right, want 0 and 0xffff")
+	}
+}
@@ -0,0 +1,69 @@
+//+build ignore
+
+/*
+Run summary: content of summary.md and well-formed SVG plots,
+also for a table column with XML special characters in its name.
+*/
+
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	Eval(`
+		setgridsize(16, 16, 1)
+		setcellsize(4e-9, 4e-9, 2e-9)
+		Msat  = 800e3
+		Aex   = 13e-12
+		alpha = 0.1
+		m = uniform(1, 1, 0)
+
+		TableAdd(E_total)
+		TableAddVar(t*1e9, "t<1&2>", "ns")
+		TableAutoSave(1e-11)
+		run(1e-10)
+		WriteSummary()
+		flush()
+	`)
+
+	md, err := httpfs.Read(OD() + "summary.md")
+	util.FatalErr(err)
+	for _, want := range []string{
+		"- grid: 16 x 16 x 1 cells of 4e-09 x 4e-09 x 2e-09 m\n",
+		"| Msat | A/m | 800000 | 0 |",
+		"![E_total (J)](summary_04.svg)",
+		"![m](summary_m.png)",
+	} {
+		if !strings.Contains(string(md), want) {
+			util.Fatal("summary.md does not contain ", want)
+		}
+	}
+
+	// every plot must be valid XML, the special characters in the label escaped
+	for i := 1; i <= 5; i++ {
+		svg, err := httpfs.Read(OD() + "summary_0" + string('0'+i) + ".svg")
+		util.FatalErr(err)
+		d := xml.NewDecoder(strings.NewReader(string(svg)))
+		for {
+			_, err := d.Token()
+			if err == io.EOF {
+				break
+			}
+			util.FatalErr(err)
+		}
+	}
+
+	_, err = httpfs.Read(OD() + "summary_m.png")
+	util.FatalErr(err)
+}
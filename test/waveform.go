@@ -0,0 +1,37 @@
+//+build ignore
+
+/*
+Sampled waveform from a CSV file as time dependence of B_ext.
+*/
+
+package main
+
+import (
+	"math"
+
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	// triangular pulse of 1 ns
+	util.FatalErr(httpfs.Put(OD()+"pulse.csv", []byte("t (s), value\n0, 0\n0.5e-9, 1\n1e-9, 0\n")))
+
+	Eval(`
+		SetGridSize(4, 4, 1)
+		SetCellSize(4e-9, 4e-9, 4e-9)
+		pulse := LoadWaveform("` + OD() + `pulse.csv")
+		B_ext = vector(0, 0, 0.1*pulse.At(t))
+	`)
+
+	for _, c := range []struct{ t, B float64 }{{-1e-9, 0}, {0.25e-9, 0.05}, {0.5e-9, 0.1}, {0.8e-9, 0.04}, {2e-9, 0}} {
+		Time = c.t
+		if B := B_ext.Average()[Z]; math.Abs(B-c.B) > 1e-6 {
+			util.Fatal("B_ext at t=", c.t, ": ", B, ", expected ", c.B)
+		}
+	}
+}
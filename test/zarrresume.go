@@ -0,0 +1,71 @@
+//+build ignore
+
+/*
+Zarr output with -resume continues an existing store: the time axis and the
+chunks already written are kept, the new time step is appended.
+*/
+
+package main
+
+import (
+	"bytes"
+
+	. "github.com/mumax/3/engine"
+	"github.com/mumax/3/httpfs"
+	"github.com/mumax/3/util"
+	"github.com/mumax/3/zarr"
+)
+
+func main() {
+
+	defer InitAndClose()()
+
+	// store of an earlier run with 2 time steps of m on a 8x4x1 mesh
+	dir := OD() + "m.zarr/"
+	put := func(file string, p []byte) { util.FatalErr(httpfs.Put(dir+file, p)) }
+	first := zarr.Chunk32(make([]float32, 8*4), true)
+	for t := 0; t < 2; t++ {
+		for c := 0; c < 3; c++ {
+			put("m/"+zarr.ChunkName(t, c, 0, 0, 0), first)
+		}
+	}
+	put("m/.zarray", zarr.Array([]int{2, 3, 1, 4, 8}, []int{1, 1, 1, 4, 8}, "<f4", true))
+	times := make([]float64, 1024)
+	times[0], times[1] = -2e-9, -1e-9
+	put("time/0", zarr.Chunk64(times, false))
+	put("time/.zarray", zarr.Array([]int{2}, []int{1024}, "<f8", false))
+
+	*Flag_resume = true
+	Eval(`
+		SetGridSize(8, 4, 1)
+		SetCellSize(4e-9, 4e-9, 4e-9)
+		Msat = 800e3
+		Aex = 13e-12
+		M = Uniform(1, 0, 0)
+		OutputFormat = ZARR
+		Save(m)
+		Flush()
+	`)
+
+	read := func(file string) []byte {
+		p, err := httpfs.Read(dir + file)
+		util.FatalErr(err)
+		return p
+	}
+	shape, err := zarr.Shape(read("m/.zarray"))
+	util.FatalErr(err)
+	if shape[0] != 3 {
+		util.Fatal("have ", shape[0], " time steps, want 3")
+	}
+	if !bytes.Equal(read("m/"+zarr.ChunkName(1, 0, 0, 0, 0)), first) {
+		util.Fatal("earlier chunk overwritten")
+	}
+	if bytes.Equal(read("m/"+zarr.ChunkName(2, 0, 0, 0, 0)), first) {
+		util.Fatal("new chunk not written")
+	}
+	t, err := zarr.ReadChunk64(read("time/0"), false)
+	util.FatalErr(err)
+	if t[0] != -2e-9 || t[1] != -1e-9 || t[2] != 0 {
+		util.Fatal("bad time values: ", t[:3])
+	}
+}
@@ -0,0 +1,134 @@
+// package zarr encodes arrays in the Zarr (version 2) storage format,
+// for lazy, chunked analysis with e.g. zarr-python, xarray and dask.
+//
+// A Zarr store is a directory (or object store prefix) holding a .zgroup file
+// and one subdirectory per array with its metadata (.zarray), attributes (.zattrs)
+// and chunks, one file per chunk named by its chunk indices, e.g. "3.0.0.0.0".
+// Attributes include xarray's _ARRAY_DIMENSIONS, so arrays sharing dimension names
+// are recognized as coordinates.
+package zarr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strings"
+)
+
+// Group metadata, stored as .zgroup in the root of the store.
+func Group() []byte {
+	return marshal(map[string]interface{}{"zarr_format": 2})
+}
+
+// Array metadata, stored as .zarray.
+// dtype is "<f4" or "<f8", chunks are compressed with zlib if compress is set.
+func Array(shape, chunks []int, dtype string, compress bool) []byte {
+	var compressor interface{}
+	if compress {
+		compressor = map[string]interface{}{"id": "zlib", "level": 1}
+	}
+	return marshal(map[string]interface{}{
+		"zarr_format": 2,
+		"shape":       shape,
+		"chunks":      chunks,
+		"dtype":       dtype,
+		"compressor":  compressor,
+		"fill_value":  "NaN",
+		"order":       "C",
+		"filters":     nil,
+	})
+}
+
+// Shape of an array, from its metadata (.zarray).
+func Shape(zarray []byte) ([]int, error) {
+	var meta struct{ Shape []int }
+	if err := json.Unmarshal(zarray, &meta); err != nil {
+		return nil, fmt.Errorf("zarr: bad array metadata: %v", err)
+	}
+	return meta.Shape, nil
+}
+
+// Attributes, stored as .zattrs, with the dimension names used by xarray (if any).
+func Attrs(dims []string, attrs map[string]interface{}) []byte {
+	a := map[string]interface{}{}
+	if dims != nil {
+		a["_ARRAY_DIMENSIONS"] = dims
+	}
+	for k, v := range attrs {
+		a[k] = v
+	}
+	return marshal(a)
+}
+
+// Name of the chunk with given chunk indices, e.g. "3.0.0.0.0".
+func ChunkName(index ...int) string {
+	s := make([]string, len(index))
+	for i, x := range index {
+		s[i] = fmt.Sprint(x)
+	}
+	return strings.Join(s, ".")
+}
+
+// Chunk32 encodes float32 data as a little-endian ("<f4") chunk.
+// The data must fill the whole chunk.
+func Chunk32(data []float32, compress bool) []byte {
+	p := make([]byte, 4*len(data))
+	for i, v := range data {
+		binary.LittleEndian.PutUint32(p[4*i:], math.Float32bits(v))
+	}
+	return encode(p, compress)
+}
+
+// Chunk64 encodes float64 data as a little-endian ("<f8") chunk.
+// The data must fill the whole chunk.
+func Chunk64(data []float64, compress bool) []byte {
+	p := make([]byte, 8*len(data))
+	for i, v := range data {
+		binary.LittleEndian.PutUint64(p[8*i:], math.Float64bits(v))
+	}
+	return encode(p, compress)
+}
+
+// ReadChunk64 decodes a chunk written by Chunk64.
+func ReadChunk64(p []byte, compress bool) ([]float64, error) {
+	if compress {
+		r, err := zlib.NewReader(bytes.NewReader(p))
+		if err != nil {
+			return nil, err
+		}
+		if p, err = ioutil.ReadAll(r); err != nil {
+			return nil, err
+		}
+	}
+	if len(p)%8 != 0 {
+		return nil, fmt.Errorf("zarr: chunk of %v bytes is not float64", len(p))
+	}
+	data := make([]float64, len(p)/8)
+	for i := range data {
+		data[i] = math.Float64frombits(binary.LittleEndian.Uint64(p[8*i:]))
+	}
+	return data, nil
+}
+
+func encode(p []byte, compress bool) []byte {
+	if !compress {
+		return p
+	}
+	var b bytes.Buffer
+	w, _ := zlib.NewWriterLevel(&b, 1)
+	w.Write(p)
+	w.Close()
+	return b.Bytes()
+}
+
+func marshal(v interface{}) []byte {
+	b, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		panic(err)
+	}
+	return append(b, '\n')
+}
@@ -0,0 +1,58 @@
+package zarr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+func TestChunk(t *testing.T) {
+	c := Chunk32([]float32{1, 2}, true)
+	r, err := zlib.NewReader(bytes.NewReader(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0, 0, 0x80, 0x3f, 0, 0, 0, 0x40} // little-endian 1, 2
+	if !bytes.Equal(p, want) {
+		t.Errorf("chunk: have %x, want %x", p, want)
+	}
+	if n := ChunkName(3, 0, 1); n != "3.0.1" {
+		t.Errorf("chunk name: have %q, want 3.0.1", n)
+	}
+}
+
+func TestArray(t *testing.T) {
+	var meta struct {
+		Shape, Chunks []int
+		Dtype         string
+		Compressor    struct{ ID string }
+	}
+	if err := json.Unmarshal(Array([]int{2, 3}, []int{1, 3}, "<f4", true), &meta); err != nil {
+		t.Fatal(err)
+	}
+	if meta.Shape[0] != 2 || meta.Chunks[1] != 3 || meta.Dtype != "<f4" || meta.Compressor.ID != "zlib" {
+		t.Errorf("bad metadata: %+v", meta)
+	}
+}
+
+func TestReadBack(t *testing.T) {
+	shape, err := Shape(Array([]int{5, 3, 1, 2, 4}, []int{1, 1, 1, 2, 4}, "<f4", true))
+	if err != nil || len(shape) != 5 || shape[0] != 5 || shape[4] != 4 {
+		t.Errorf("shape: have %v, %v", shape, err)
+	}
+	for _, compress := range []bool{false, true} {
+		data, err := ReadChunk64(Chunk64([]float64{1e-12, 2e-12}, compress), compress)
+		if err != nil || len(data) != 2 || data[1] != 2e-12 {
+			t.Errorf("compress %v: have %v, %v", compress, data, err)
+		}
+	}
+	if _, err := Shape([]byte("{")); err == nil {
+		t.Error("expected error")
+	}
+}